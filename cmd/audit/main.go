@@ -0,0 +1,103 @@
+// Command audit exports the moderator audit log as JSON Lines, newest first,
+// e.g.
+//
+//	go run ./cmd/audit -actor alice -since 2024-01-01T00:00:00Z > audit.jsonl
+//
+// It reads the postgres or sqlite storage selected by STORAGE. The in-memory
+// log belongs to the running server and cannot be exported.
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"time"
+
+	"github.com/erknas/forum/graph/model"
+	"github.com/erknas/forum/internal/config"
+	"github.com/erknas/forum/internal/storage"
+)
+
+const batchSize = 500
+
+func main() {
+	var (
+		actor  = flag.String("actor", "", "only entries by this moderator")
+		action = flag.String("action", "", "only entries with this action, e.g. REJECT_CONTENT")
+		since  = flag.String("since", "", "only entries at or after this RFC 3339 time")
+		until  = flag.String("until", "", "only entries before this RFC 3339 time")
+	)
+
+	flag.Parse()
+
+	filter := model.CustomAuditFilter{Actor: *actor, Action: model.AuditAction(*action)}
+
+	if filter.Action != "" && !filter.Action.IsValid() {
+		log.Fatalf("unknown action %q", *action)
+	}
+
+	var err error
+
+	if filter.Since, err = parseTime(*since); err != nil {
+		log.Fatalf("invalid -since: %s", err)
+	}
+	if filter.Until, err = parseTime(*until); err != nil {
+		log.Fatalf("invalid -until: %s", err)
+	}
+	if filter.Until.IsZero() {
+		// Entries written during the export would shift the pages.
+		filter.Until = time.Now()
+	}
+
+	var (
+		ctx   = context.Background()
+		cfg   = config.Load()
+		store storage.Storer
+	)
+
+	switch cfg.Storage {
+	case config.StoragePostgres:
+		store, err = storage.NewPostgresPool(ctx, cfg)
+	case config.StorageSQLite:
+		store, err = storage.NewSQLiteStorage(ctx, cfg)
+	default:
+		log.Fatalf("cannot export the audit log of %q storage", cfg.Storage)
+	}
+	if err != nil {
+		log.Fatalf("failed to open storage: %s", err)
+	}
+
+	out := bufio.NewWriter(os.Stdout)
+	enc := json.NewEncoder(out)
+
+	for offset := 0; ; offset += batchSize {
+		entries, err := store.GetAuditLog(ctx, filter, offset, batchSize)
+		if err != nil {
+			log.Fatalf("failed to read audit log: %s", err)
+		}
+
+		for _, entry := range entries {
+			if err := enc.Encode(entry); err != nil {
+				log.Fatalf("failed to write entry: %s", err)
+			}
+		}
+
+		if len(entries) < batchSize {
+			break
+		}
+	}
+
+	if err := out.Flush(); err != nil {
+		log.Fatalf("failed to write entries: %s", err)
+	}
+}
+
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
@@ -4,30 +4,42 @@ import (
 	"context"
 	"log"
 	"net/http"
-	"os"
 
 	"github.com/99designs/gqlgen/graphql/handler"
 	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
 	"github.com/99designs/gqlgen/graphql/handler/transport"
 	"github.com/99designs/gqlgen/graphql/playground"
 	"github.com/erknas/forum/graph"
+	"github.com/erknas/forum/graph/model"
+	"github.com/erknas/forum/internal/auth"
+	"github.com/erknas/forum/internal/clientip"
 	"github.com/erknas/forum/internal/config"
+	"github.com/erknas/forum/internal/feed"
+	"github.com/erknas/forum/internal/filter"
+	"github.com/erknas/forum/internal/loader"
+	"github.com/erknas/forum/internal/outbox"
+	"github.com/erknas/forum/internal/safelist"
+	"github.com/erknas/forum/internal/scheduler"
 	"github.com/erknas/forum/internal/service"
 	"github.com/erknas/forum/internal/storage"
 	"github.com/erknas/forum/internal/subscription"
 	"github.com/erknas/forum/migrations/migrator"
+	"github.com/erknas/forum/pkg/ratelimit"
 )
 
 func main() {
 	var (
-		ctx      = context.Background()
-		cfg      = config.Load()
-		svc      *service.Service
-		sub      = subscription.New()
-		inmemory = os.Getenv("IN_MEMORY")
+		ctx   = context.Background()
+		cfg   = config.Load()
+		store storage.Storer
+		sub   = subscription.New()
+		posts = subscription.NewHub[*model.Post]()
+		polls = subscription.NewHub[*model.Poll]()
 	)
 
-	if inmemory == "false" {
+	switch cfg.Storage {
+	case config.StoragePostgres:
 		if err := migrator.New(cfg); err != nil {
 			log.Fatalf("failed to migrate: %s", err)
 		}
@@ -37,28 +49,208 @@ func main() {
 			log.Fatalf("failed to connect to postgres: %s", err)
 		}
 
-		svc = service.New(postgres, sub)
+		store = postgres
 
 		log.Println("using postgres storage")
-	} else {
-		inmemory := storage.NewInMemoryStorage()
-		svc = service.New(inmemory, sub)
+	case config.StorageSQLite:
+		if err := migrator.NewSQLite(cfg); err != nil {
+			log.Fatalf("failed to migrate: %s", err)
+		}
+
+		sqlite, err := storage.NewSQLiteStorage(ctx, cfg)
+		if err != nil {
+			log.Fatalf("failed to open sqlite: %s", err)
+		}
+
+		store = sqlite
+
+		log.Println("using sqlite storage")
+	case config.StorageMemory:
+		if cfg.MemoryConfig.DataDir == "" {
+			store = storage.NewInMemoryStorage()
+
+			log.Println("using in-memory storage")
+			break
+		}
+
+		inmemory, err := storage.NewDurableInMemoryStorage(ctx, cfg)
+		if err != nil {
+			log.Fatalf("failed to restore in-memory storage: %s", err)
+		}
+
+		store = inmemory
 
-		log.Println("using in-memory storage")
+		log.Printf("using in-memory storage persisted to %s", cfg.MemoryConfig.DataDir)
+	default:
+		log.Fatalf("unknown storage %q", cfg.Storage)
 	}
 
-	srv := handler.New(graph.NewExecutableSchema(graph.Config{Resolvers: &graph.Resolver{Svc: svc, Sub: sub}}))
+	filters := []filter.Filter{
+		filter.NewBannedWords(cfg.BannedWords),
+		filter.NewLinks(store, cfg.NewAccountAge, cfg.NewAccountMaxLinks),
+	}
+	if cfg.DuplicateWindow > 0 {
+		filters = append(filters, filter.NewDuplicates(cfg.DuplicateWindow))
+	}
+	filters = append(filters, filter.NewSpam(filter.NewClassifier(), cfg.SpamThreshold, cfg.SpamMinSamples))
+
+	var blobs storage.BlobStore
+
+	switch {
+	case cfg.AttachmentConfig.MaxCount == 0:
+		log.Println("attachments are disabled")
+	case cfg.S3Endpoint != "":
+		s3, err := storage.NewS3BlobStore(cfg)
+		if err != nil {
+			log.Fatalf("failed to configure s3 blob store: %s", err)
+		}
+
+		blobs = s3
+
+		log.Printf("keeping attachments in bucket %s at %s", cfg.S3Bucket, cfg.S3Endpoint)
+	default:
+		local, err := storage.NewLocalBlobStore(cfg.BlobConfig.Dir)
+		if err != nil {
+			log.Fatalf("failed to open blob directory: %s", err)
+		}
+
+		blobs = local
+
+		log.Printf("keeping attachments in %s", cfg.BlobConfig.Dir)
+	}
+
+	svc := service.New(store, service.Options{
+		Filters:           filters,
+		ReportThreshold:   cfg.HideThreshold,
+		SignInWhileBanned: cfg.SignInWhileBanned,
+		Attachments: service.AttachmentOptions{
+			Blobs:         blobs,
+			MaxCount:      cfg.AttachmentConfig.MaxCount,
+			MaxSize:       cfg.AttachmentConfig.MaxSize,
+			Types:         cfg.AttachmentConfig.Types,
+			ThumbnailSize: cfg.ThumbnailSize,
+			URL:           cfg.AttachmentConfig.URL,
+		},
+	})
+
+	if err := svc.TrainFilters(ctx); err != nil {
+		log.Printf("failed to train filters on the audit log: %s", err)
+	}
+
+	sinks := []outbox.Sink{outbox.NewSubscriberSink(sub, posts, polls, store)}
+	for _, url := range cfg.WebhookURLs {
+		sinks = append(sinks, outbox.NewWebhookSink(url))
+	}
+
+	go outbox.NewRelay(store, cfg.PollInterval, sinks...).Run(ctx)
+	go scheduler.New(store, cfg.SchedulerInterval).Run(ctx)
+
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers:  &graph.Resolver{Svc: svc, Sub: sub, Posts: posts, Polls: polls},
+		Complexity: graph.NewComplexity(),
+	}))
+
+	var signer *auth.Signer
+	if cfg.Secret != "" {
+		signer = auth.NewSigner(cfg.Secret)
+	} else {
+		log.Println("AUTH_SECRET is not set, all requests are anonymous")
+	}
 
 	srv.AddTransport(transport.Options{})
 	srv.AddTransport(transport.GET{})
 	srv.AddTransport(transport.POST{})
-	srv.AddTransport(transport.Websocket{})
+	srv.AddTransport(transport.Websocket{InitFunc: auth.WebsocketInit(signer)})
+	if blobs != nil {
+		srv.AddTransport(transport.MultipartForm{
+			// Leaves room for the operations and map parts of the request.
+			MaxUploadSize: int64(cfg.AttachmentConfig.MaxCount)*cfg.AttachmentConfig.MaxSize + 1<<20,
+			MaxMemory:     32 << 20,
+		})
+	}
+
+	srv.SetErrorPresenter(graph.ErrorPresenter)
+	srv.SetRecoverFunc(graph.Recover)
+
+	if cfg.ComplexityLimit > 0 {
+		srv.Use(&graph.ComplexityLimit{Limit: cfg.ComplexityLimit})
+	}
+
+	if cfg.MaxDepth > 0 {
+		srv.Use(graph.DepthLimit{MaxDepth: cfg.MaxDepth})
+	}
+
+	if cfg.Introspection {
+		srv.Use(extension.Introspection{})
+	} else {
+		srv.Use(graph.NoIntrospection{})
+	}
+
+	rateLimit := graph.RateLimit{Key: clientKey}
+	if cfg.PostBurst > 0 {
+		rateLimit.Posts = ratelimit.New(cfg.PostInterval, cfg.PostBurst)
+	}
+	if cfg.CommentBurst > 0 {
+		rateLimit.Comments = ratelimit.New(cfg.CommentInterval, cfg.CommentBurst)
+	}
+	if cfg.MaxSubscriptions > 0 {
+		rateLimit.Subscriptions = ratelimit.NewConcurrency(cfg.MaxSubscriptions)
+	}
+
+	srv.Use(rateLimit)
+
+	switch {
+	case cfg.SafelistPath != "":
+		list, err := safelist.Load(cfg.SafelistPath)
+		if err != nil {
+			log.Fatalf("failed to load safelist: %s", err)
+		}
+
+		go list.ReloadOnSignal(ctx)
 
-	srv.Use(extension.Introspection{})
+		srv.Use(safelist.Extension{List: list, Strict: cfg.SafelistOnly})
+
+		log.Printf("loaded %d safelisted operations from %s", list.Len(), cfg.SafelistPath)
+	case cfg.SafelistOnly:
+		log.Fatal("SAFELIST_ONLY requires SAFELIST_PATH")
+	}
+
+	if cfg.APQCacheSize > 0 {
+		srv.Use(extension.AutomaticPersistedQuery{Cache: lru.New[string](cfg.APQCacheSize)})
+	}
 
 	http.Handle("/", playground.Handler("GraphQL playground", "/query"))
-	http.Handle("/query", srv)
+	http.Handle("/query", clientip.Middleware(cfg.TrustProxy, auth.Middleware(signer, loader.Middleware(store, srv))))
+
+	// Feeds are public: they are built for anonymous viewers whatever the
+	// request carries.
+	feeds := feed.New(svc, feed.Options{BaseURL: cfg.FeedConfig.BaseURL, Entries: cfg.FeedConfig.Entries})
+	feedHandler := func(h http.HandlerFunc) http.Handler {
+		return clientip.Middleware(cfg.TrustProxy, loader.Middleware(store, h))
+	}
+	if cfg.FeedConfig.Burst > 0 {
+		limiter := ratelimit.New(cfg.FeedConfig.Interval, cfg.FeedConfig.Burst)
+		feedKey := func(r *http.Request) string { return clientKey(r.Context()) }
+		feedHandler = func(h http.HandlerFunc) http.Handler {
+			return clientip.Middleware(cfg.TrustProxy, ratelimit.Middleware(limiter, feedKey, loader.Middleware(store, h)))
+		}
+	}
+	http.Handle("GET /feed.atom", feedHandler(feeds.Posts))
+	http.Handle("GET /posts/{id}/comments.atom", feedHandler(feeds.Comments))
+
+	if blobs != nil {
+		http.Handle("/attachments/", http.StripPrefix("/attachments/", storage.ServeBlobs(blobs)))
+	}
 
 	log.Printf("starting server on [http://localhost:%s/]", cfg.Addr)
 	log.Fatal(http.ListenAndServe(":"+cfg.Addr, nil))
 }
+
+// clientKey identifies signed-in users by name and everyone else by address.
+func clientKey(ctx context.Context) string {
+	if viewer := auth.From(ctx); viewer != nil {
+		return "user:" + viewer.Name
+	}
+
+	return "ip:" + clientip.From(ctx)
+}
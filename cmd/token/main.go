@@ -0,0 +1,36 @@
+// Command token issues a bearer token signed with AUTH_SECRET, e.g.
+//
+//	go run ./cmd/token -name alice -role moderator
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/erknas/forum/internal/auth"
+	"github.com/erknas/forum/internal/config"
+)
+
+func main() {
+	var (
+		name = flag.String("name", "", "author name of the user")
+		role = flag.String("role", string(auth.RoleUser), "user, moderator or admin")
+		ttl  = flag.Duration("ttl", 24*time.Hour, "how long the token is valid")
+	)
+
+	flag.Parse()
+
+	cfg := config.Load()
+	if cfg.Secret == "" {
+		log.Fatal("AUTH_SECRET is not set")
+	}
+
+	token, err := auth.NewSigner(cfg.Secret).Issue(auth.Viewer{Name: *name, Role: auth.Role(*role)}, *ttl)
+	if err != nil {
+		log.Fatalf("failed to issue token: %s", err)
+	}
+
+	fmt.Println(token)
+}
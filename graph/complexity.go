@@ -0,0 +1,142 @@
+package graph
+
+import (
+	"github.com/99designs/gqlgen/complexity"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/erknas/forum/graph/model"
+	"github.com/erknas/forum/pkg/pagination"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// unboundedListSize is the assumed length of lists that are not paginated.
+const unboundedListSize = 10
+
+// NewComplexity returns cost functions that multiply the cost of list items
+// by the number of items the arguments allow.
+func NewComplexity() ComplexityRoot {
+	c := ComplexityRoot{}
+
+	c.Query.GetPosts = func(childComplexity int) int {
+		return 1 + childComplexity*unboundedListSize
+	}
+	c.Query.Nodes = func(childComplexity int, ids []string) int {
+		return 1 + childComplexity*len(ids)
+	}
+
+	c.Post.Comments = func(childComplexity int, page *int32, pageSize *int32) int {
+		limit, _ := pagination.New(page, pageSize)
+		return 1 + childComplexity*limit
+	}
+	c.Comment.Replies = func(childComplexity int) int {
+		return 1 + childComplexity*unboundedListSize
+	}
+	c.Post.Revisions = func(childComplexity int) int {
+		return 1 + childComplexity*unboundedListSize
+	}
+	c.Comment.Revisions = func(childComplexity int) int {
+		return 1 + childComplexity*unboundedListSize
+	}
+	c.Post.Attachments = func(childComplexity int) int {
+		return 1 + childComplexity*unboundedListSize
+	}
+	c.Comment.Attachments = func(childComplexity int) int {
+		return 1 + childComplexity*unboundedListSize
+	}
+
+	c.Author.Posts = func(childComplexity int, first *int32, _ *string) int {
+		return 1 + childComplexity*connectionSize(first)
+	}
+	c.Author.Comments = func(childComplexity int, first *int32, _ *string) int {
+		return 1 + childComplexity*connectionSize(first)
+	}
+	c.Query.ModerationQueue = func(childComplexity int, first *int32, _ *string) int {
+		return 1 + childComplexity*connectionSize(first)
+	}
+	c.Query.Reports = func(childComplexity int, first *int32, _ *string) int {
+		return 1 + childComplexity*connectionSize(first)
+	}
+	c.Query.AuditLog = func(childComplexity int, _ *model.AuditLogFilter, first *int32, _ *string) int {
+		return 1 + childComplexity*connectionSize(first)
+	}
+	c.Query.Bans = func(childComplexity int, first *int32, _ *string) int {
+		return 1 + childComplexity*connectionSize(first)
+	}
+	c.Query.MyDrafts = func(childComplexity int, first *int32, _ *string) int {
+		return 1 + childComplexity*connectionSize(first)
+	}
+
+	return c
+}
+
+func connectionSize(first *int32) int {
+	limit, _, _ := pagination.FromCursor(first, nil)
+	return limit
+}
+
+// Complexity returns the cost of op. The comments of a post requested by
+// GetPostByID without arguments of their own take its deprecated page and
+// pageSize, as legacyCommentsPage does, so they are costed by them.
+func Complexity(es graphql.ExecutableSchema, op *ast.OperationDefinition, vars map[string]any) int {
+	cost := complexity.Calculate(es, op, vars)
+
+	for _, field := range selectedFields(op.SelectionSet) {
+		if field.Name != "GetPostByID" {
+			continue
+		}
+
+		args := field.ArgumentMap(vars)
+		page, pageSize := int32Arg(args, "page"), int32Arg(args, "pageSize")
+		if page == nil && pageSize == nil {
+			continue
+		}
+
+		legacyLimit, _ := pagination.New(page, pageSize)
+		defaultLimit, _ := pagination.New(nil, nil)
+
+		for _, comments := range selectedFields(field.SelectionSet) {
+			args := comments.ArgumentMap(vars)
+			if comments.Name != "comments" || args["page"] != nil || args["pageSize"] != nil {
+				continue
+			}
+
+			childComplexity := complexity.Calculate(es, &ast.OperationDefinition{SelectionSet: comments.SelectionSet}, vars)
+			cost += childComplexity * (legacyLimit - defaultLimit)
+		}
+	}
+
+	return cost
+}
+
+// selectedFields returns the fields of selectionSet, including those selected
+// by fragments.
+func selectedFields(selectionSet ast.SelectionSet) []*ast.Field {
+	var fields []*ast.Field
+
+	for _, selection := range selectionSet {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			fields = append(fields, selection)
+		case *ast.InlineFragment:
+			fields = append(fields, selectedFields(selection.SelectionSet)...)
+		case *ast.FragmentSpread:
+			if selection.Definition != nil {
+				fields = append(fields, selectedFields(selection.Definition.SelectionSet)...)
+			}
+		}
+	}
+
+	return fields
+}
+
+func int32Arg(args map[string]any, name string) *int32 {
+	if args[name] == nil {
+		return nil
+	}
+
+	n, err := graphql.UnmarshalInt32(args[name])
+	if err != nil {
+		return nil
+	}
+
+	return &n
+}
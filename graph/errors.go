@@ -0,0 +1,71 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/erknas/forum/pkg/apperr"
+	"github.com/erknas/forum/pkg/sl"
+	"github.com/google/uuid"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+const internalErrorMessage = "internal server error"
+
+// ErrorPresenter sets extensions.code on every error returned to clients.
+// Errors that are neither *apperr.Error nor produced by gqlgen itself are not
+// meant for clients: their message is replaced and the original error is
+// logged with a correlation ID that is returned instead.
+func ErrorPresenter(ctx context.Context, err error) *gqlerror.Error {
+	gqlErr := graphql.DefaultErrorPresenter(ctx, err)
+
+	var appErr *apperr.Error
+	if errors.As(err, &appErr) {
+		gqlErr.Message = appErr.Message
+		gqlErr.Extensions = extensions(appErr.Code, appErr.Fields)
+		return gqlErr
+	}
+
+	// gqlgen reports rejected operations, e.g. ones that fail to parse or
+	// validate, as *gqlerror.Error values that do not wrap another error.
+	var ownErr *gqlerror.Error
+	if errors.As(err, &ownErr) && ownErr.Unwrap() == nil {
+		if _, ok := gqlErr.Extensions["code"]; !ok {
+			gqlErr.Extensions = extensions(apperr.CodeValidation, gqlErr.Extensions)
+		}
+		return gqlErr
+	}
+
+	correlationID := uuid.NewString()
+
+	slog.Error("internal error", sl.Err(err), "correlation_id", correlationID, "path", gqlErr.Path.String())
+
+	gqlErr.Message = internalErrorMessage
+	gqlErr.Extensions = map[string]interface{}{
+		"code":          apperr.CodeInternal,
+		"correlationId": correlationID,
+	}
+
+	return gqlErr
+}
+
+func extensions(code apperr.Code, fields map[string]interface{}) map[string]interface{} {
+	ext := make(map[string]interface{}, len(fields)+1)
+	for k, v := range fields {
+		ext[k] = v
+	}
+
+	ext["code"] = code
+
+	return ext
+}
+
+// Recover turns a panic in a resolver into an error that ErrorPresenter masks
+// and logs.
+func Recover(_ context.Context, p any) error {
+	return fmt.Errorf("panic: %v\n%s", p, debug.Stack())
+}
@@ -0,0 +1,100 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/erknas/forum/pkg/apperr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+func TestErrorPresenter(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		wantMessage string
+		wantCode    apperr.Code
+		wantFields  map[string]interface{}
+	}{
+		{
+			name:        "Not found",
+			err:         apperr.ErrPostNotFound,
+			wantMessage: "post not found",
+			wantCode:    apperr.CodeNotFound,
+		},
+		{
+			name:        "Wrapped comments disabled",
+			err:         fmt.Errorf("create comment: %w", apperr.ErrCommentsDisabled),
+			wantMessage: "comments not allowed",
+			wantCode:    apperr.CodeCommentsDisabled,
+		},
+		{
+			name:        "Validation with fields",
+			err:         apperr.Validation("invalid request data", map[string]interface{}{"title": "title length cannot be zero"}),
+			wantMessage: "invalid request data",
+			wantCode:    apperr.CodeValidation,
+			wantFields:  map[string]interface{}{"title": "title length cannot be zero"},
+		},
+		{
+			name:        "Forbidden",
+			err:         apperr.Forbidden("not allowed"),
+			wantMessage: "not allowed",
+			wantCode:    apperr.CodeForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := graphql.WithResponseContext(context.Background(), graphql.DefaultErrorPresenter, graphql.DefaultRecover)
+
+			gqlErr := ErrorPresenter(ctx, tt.err)
+
+			assert.Equal(t, tt.wantMessage, gqlErr.Message)
+			assert.Equal(t, tt.wantCode, gqlErr.Extensions["code"])
+			for field, message := range tt.wantFields {
+				assert.Equal(t, message, gqlErr.Extensions[field])
+			}
+		})
+	}
+}
+
+func TestErrorPresenter_MasksInternalErrors(t *testing.T) {
+	ctx := graphql.WithResponseContext(context.Background(), graphql.DefaultErrorPresenter, graphql.DefaultRecover)
+
+	gqlErr := ErrorPresenter(ctx, errors.New(`ERROR: relation "post" does not exist (SQLSTATE 42P01)`))
+
+	assert.Equal(t, internalErrorMessage, gqlErr.Message)
+	assert.Equal(t, apperr.CodeInternal, gqlErr.Extensions["code"])
+	require.IsType(t, "", gqlErr.Extensions["correlationId"])
+	assert.NotEmpty(t, gqlErr.Extensions["correlationId"])
+}
+
+func TestErrorPresenter_KeepsGqlgenErrors(t *testing.T) {
+	ctx := graphql.WithResponseContext(context.Background(), graphql.DefaultErrorPresenter, graphql.DefaultRecover)
+
+	validationErr := gqlerror.Errorf(`Cannot query field "GetPots" on type "Query".`)
+	errcode.Set(validationErr, errcode.ValidationFailed)
+
+	gqlErr := ErrorPresenter(ctx, validationErr)
+	assert.Equal(t, `Cannot query field "GetPots" on type "Query".`, gqlErr.Message)
+	assert.Equal(t, errcode.ValidationFailed, gqlErr.Extensions["code"])
+
+	gqlErr = ErrorPresenter(ctx, gqlerror.Errorf("no operation provided"))
+	assert.Equal(t, "no operation provided", gqlErr.Message)
+	assert.Equal(t, apperr.CodeValidation, gqlErr.Extensions["code"])
+}
+
+func TestErrorPresenter_MasksPanics(t *testing.T) {
+	ctx := graphql.WithResponseContext(context.Background(), graphql.DefaultErrorPresenter, graphql.DefaultRecover)
+
+	gqlErr := ErrorPresenter(ctx, Recover(ctx, "nil map"))
+
+	assert.Equal(t, internalErrorMessage, gqlErr.Message)
+	assert.Equal(t, apperr.CodeInternal, gqlErr.Extensions["code"])
+}
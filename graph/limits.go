@@ -0,0 +1,156 @@
+package graph
+
+import (
+	"context"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/erknas/forum/pkg/apperr"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+const (
+	errDepthLimit      = "DEPTH_LIMIT_EXCEEDED"
+	errComplexityLimit = "COMPLEXITY_LIMIT_EXCEEDED"
+)
+
+// DepthLimit rejects operations that nest fields deeper than MaxDepth.
+// Introspection fields are not counted.
+type DepthLimit struct {
+	MaxDepth int
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationContextMutator
+} = DepthLimit{}
+
+func (DepthLimit) ExtensionName() string {
+	return "DepthLimit"
+}
+
+func (DepthLimit) Validate(graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (d DepthLimit) MutateOperationContext(_ context.Context, opCtx *graphql.OperationContext) *gqlerror.Error {
+	if opCtx.Operation == nil {
+		return nil
+	}
+
+	if depth := selectionDepth(opCtx.Operation.SelectionSet); depth > d.MaxDepth {
+		err := gqlerror.Errorf("operation has depth %d, which exceeds the limit of %d", depth, d.MaxDepth)
+		errcode.Set(err, errDepthLimit)
+		return err
+	}
+
+	return nil
+}
+
+func selectionDepth(selectionSet ast.SelectionSet) int {
+	depth := 0
+
+	for _, selection := range selectionSet {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(selection.Name, "__") {
+				continue
+			}
+			depth = max(depth, 1+selectionDepth(selection.SelectionSet))
+		case *ast.InlineFragment:
+			depth = max(depth, selectionDepth(selection.SelectionSet))
+		case *ast.FragmentSpread:
+			if selection.Definition != nil {
+				depth = max(depth, selectionDepth(selection.Definition.SelectionSet))
+			}
+		}
+	}
+
+	return depth
+}
+
+// ComplexityLimit rejects operations whose Complexity exceeds Limit.
+type ComplexityLimit struct {
+	Limit int
+
+	es graphql.ExecutableSchema
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationContextMutator
+} = &ComplexityLimit{}
+
+func (*ComplexityLimit) ExtensionName() string {
+	return "ComplexityLimit"
+}
+
+func (c *ComplexityLimit) Validate(es graphql.ExecutableSchema) error {
+	c.es = es
+	return nil
+}
+
+func (c *ComplexityLimit) MutateOperationContext(_ context.Context, opCtx *graphql.OperationContext) *gqlerror.Error {
+	if opCtx.Operation == nil {
+		return nil
+	}
+
+	if cost := Complexity(c.es, opCtx.Operation, opCtx.Variables); cost > c.Limit {
+		err := gqlerror.Errorf("operation has complexity %d, which exceeds the limit of %d", cost, c.Limit)
+		errcode.Set(err, errComplexityLimit)
+		return err
+	}
+
+	return nil
+}
+
+// NoIntrospection rejects operations that query __schema or __type. Without
+// it, such fields fail one by one with an error that is not meant for clients.
+type NoIntrospection struct{}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationContextMutator
+} = NoIntrospection{}
+
+func (NoIntrospection) ExtensionName() string {
+	return "NoIntrospection"
+}
+
+func (NoIntrospection) Validate(graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (NoIntrospection) MutateOperationContext(_ context.Context, opCtx *graphql.OperationContext) *gqlerror.Error {
+	if opCtx.Operation == nil || !selectsSchema(opCtx.Operation.SelectionSet) {
+		return nil
+	}
+
+	err := gqlerror.Errorf("introspection is disabled")
+	errcode.Set(err, string(apperr.CodeForbidden))
+
+	return err
+}
+
+func selectsSchema(selectionSet ast.SelectionSet) bool {
+	for _, selection := range selectionSet {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			if selection.Name == "__schema" || selection.Name == "__type" {
+				return true
+			}
+		case *ast.InlineFragment:
+			if selectsSchema(selection.SelectionSet) {
+				return true
+			}
+		case *ast.FragmentSpread:
+			if selection.Definition != nil && selectsSchema(selection.Definition.SelectionSet) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
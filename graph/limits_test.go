@@ -0,0 +1,143 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+func parseOperation(t *testing.T, query string) *ast.OperationDefinition {
+	t.Helper()
+
+	es := NewExecutableSchema(Config{Complexity: NewComplexity()})
+
+	doc, errs := gqlparser.LoadQuery(es.Schema(), query)
+	require.Nil(t, errs)
+	require.Len(t, doc.Operations, 1)
+
+	return doc.Operations[0]
+}
+
+func TestComplexity(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  int
+	}{
+		{
+			name:  "Post comments with page size",
+			query: `{ GetPostByID(id: "1") { id comments(pageSize: 5) { id } } }`,
+			want:  1 + (1 + (1 + 5*1)),
+		},
+		{
+			name:  "Post comments with default page size",
+			query: `{ GetPostByID(id: "1") { comments { id } } }`,
+			want:  1 + (1 + 10*1),
+		},
+		{
+			name:  "Deprecated page size without comments",
+			query: `{ GetPostByID(id: "1", pageSize: 100) { id } }`,
+			want:  1 + 1,
+		},
+		{
+			name:  "Deprecated page size",
+			query: `{ GetPostByID(id: "1", pageSize: 100) { id comments { id } } }`,
+			want:  1 + (1 + (1 + 100*1)),
+		},
+		{
+			name:  "Deprecated page size in a fragment",
+			query: `query ($size: Int) { GetPostByID(id: "1", pageSize: $size) { ...comments } } fragment comments on Post { comments { replies { id } } }`,
+			want:  1 + (1 + 50*(1+10*1)),
+		},
+		{
+			name:  "Page size of the comments wins",
+			query: `{ GetPostByID(id: "1", pageSize: 100) { comments(pageSize: 5) { id } } }`,
+			want:  1 + (1 + 5*1),
+		},
+		{
+			name:  "Author connection with first",
+			query: `{ Author(name: "Bob") { posts(first: 3) { edges { node { id } } } } }`,
+			want:  1 + (1 + 3*(1+(1+1))),
+		},
+		{
+			name:  "Author connection above maximum",
+			query: `{ Author(name: "Bob") { posts(first: 1000) { totalCount } } }`,
+			want:  1 + (1 + 100*1),
+		},
+		{
+			name:  "Nested replies",
+			query: `{ GetPosts { comments { replies { replies { id } } } } }`,
+			want:  1 + 10*(1+10*(1+10*(1+10*1))),
+		},
+		{
+			name:  "Nodes",
+			query: `{ nodes(ids: ["a", "b", "c"]) { id } }`,
+			want:  1 + 3*1,
+		},
+	}
+
+	es := NewExecutableSchema(Config{Complexity: NewComplexity()})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Complexity(es, parseOperation(t, tt.query), map[string]any{"size": int64(50)}))
+		})
+	}
+}
+
+func TestSelectionDepth(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  int
+	}{
+		{
+			name:  "Flat",
+			query: `{ GetPosts { id } }`,
+			want:  2,
+		},
+		{
+			name:  "Nested replies",
+			query: `{ GetPostByID(id: "1") { comments { replies { replies { id } } } } }`,
+			want:  5,
+		},
+		{
+			name:  "Fragments",
+			query: `query { node(id: "1") { ... on Post { comments { ...reply } } } } fragment reply on Comment { replies { id } }`,
+			want:  4,
+		},
+		{
+			name:  "Introspection is not counted",
+			query: `{ __schema { types { fields { type { ofType { ofType { name } } } } } } GetPosts { id } }`,
+			want:  2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, selectionDepth(parseOperation(t, tt.query).SelectionSet))
+		})
+	}
+}
+
+func TestSelectsSchema(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  bool
+	}{
+		{name: "Schema", query: `{ __schema { types { name } } }`, want: true},
+		{name: "Type", query: `{ __type(name: "Post") { name } }`, want: true},
+		{name: "Fragment", query: `query { ...schema } fragment schema on Query { __schema { queryType { name } } }`, want: true},
+		{name: "Typename only", query: `{ __typename GetPosts { __typename id } }`, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, selectsSchema(parseOperation(t, tt.query).SelectionSet))
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package model
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshalDateTime(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*60*60)
+
+	var buf bytes.Buffer
+	MarshalDateTime(time.Date(2024, 1, 31, 12, 15, 30, 0, moscow)).MarshalGQL(&buf)
+
+	assert.Equal(t, `"2024-01-31T09:15:30Z"`, buf.String())
+}
+
+func TestUnmarshalDateTime(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   any
+		want    time.Time
+		wantErr string
+	}{
+		{
+			name:  "UTC",
+			input: "2024-01-31T09:15:30Z",
+			want:  time.Date(2024, 1, 31, 9, 15, 30, 0, time.UTC),
+		},
+		{
+			name:  "Offset",
+			input: "2024-01-31T12:15:30+03:00",
+			want:  time.Date(2024, 1, 31, 9, 15, 30, 0, time.UTC),
+		},
+		{
+			name:    "Legacy format",
+			input:   "31.01.2024 09:15",
+			wantErr: "invalid DateTime 31.01.2024 09:15",
+		},
+		{
+			name:    "Not a string",
+			input:   1706692530,
+			wantErr: "DateTime must be a string, got int",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := UnmarshalDateTime(tt.input)
+
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.True(t, tt.want.Equal(got))
+		})
+	}
+}
@@ -2,45 +2,623 @@
 
 package model
 
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+type Content interface {
+	IsContent()
+}
+
+// An object with an opaque ID that is unique across all types.
+type Node interface {
+	IsNode()
+	GetID() string
+}
+
+// A file attached to a post or comment.
+type Attachment struct {
+	ID       string `json:"id"`
+	Filename string `json:"filename"`
+	// The type of the content, detected from the content itself.
+	ContentType string `json:"contentType"`
+	// Size in bytes.
+	Size int32  `json:"size"`
+	URL  string `json:"url"`
+	// A JPEG preview of an image, at most ATTACHMENT_THUMBNAIL_SIZE pixels wide
+	// and high. Null for other files.
+	ThumbnailURL *string   `json:"thumbnailUrl,omitempty"`
+	Width        *int32    `json:"width,omitempty"`
+	Height       *int32    `json:"height,omitempty"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+type AuditEntry struct {
+	ID string `json:"id"`
+	// Name of the moderator or admin who acted.
+	Actor  string      `json:"actor"`
+	Action AuditAction `json:"action"`
+	// ID of the post, comment or ban the action changed.
+	TargetID string `json:"targetId"`
+	// JSON snapshot of what the action changed, before and after it.
+	Before    *string   `json:"before,omitempty"`
+	After     *string   `json:"after,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type AuditEntryConnection struct {
+	Edges    []*AuditEntryEdge `json:"edges"`
+	PageInfo *PageInfo         `json:"pageInfo"`
+}
+
+type AuditEntryEdge struct {
+	Cursor string      `json:"cursor"`
+	Node   *AuditEntry `json:"node"`
+}
+
+// Entries match every field that is set. since is inclusive, until is not.
+type AuditLogFilter struct {
+	Actor    *string      `json:"actor,omitempty"`
+	Action   *AuditAction `json:"action,omitempty"`
+	TargetID *string      `json:"targetId,omitempty"`
+	Since    *time.Time   `json:"since,omitempty"`
+	Until    *time.Time   `json:"until,omitempty"`
+}
+
+type Author struct {
+	ID             string             `json:"id"`
+	Name           string             `json:"name"`
+	JoinedAt       time.Time          `json:"joinedAt"`
+	JoinedAtString string             `json:"joinedAtString"`
+	PostCount      int32              `json:"postCount"`
+	CommentCount   int32              `json:"commentCount"`
+	Posts          *PostConnection    `json:"posts"`
+	Comments       *CommentConnection `json:"comments"`
+}
+
+func (Author) IsNode()            {}
+func (this Author) GetID() string { return this.ID }
+
+type Ban struct {
+	ID string `json:"id"`
+	// Name of the banned user.
+	Author    string    `json:"author"`
+	Kind      BanKind   `json:"kind"`
+	Reason    string    `json:"reason"`
+	Moderator string    `json:"moderator"`
+	CreatedAt time.Time `json:"createdAt"`
+	// When the ban ends by itself. Permanent bans have no expiry.
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	LiftedBy  *string    `json:"liftedBy,omitempty"`
+	LiftedAt  *time.Time `json:"liftedAt,omitempty"`
+}
+
+type BanConnection struct {
+	Edges    []*BanEdge `json:"edges"`
+	PageInfo *PageInfo  `json:"pageInfo"`
+}
+
+type BanEdge struct {
+	Cursor string `json:"cursor"`
+	Node   *Ban   `json:"node"`
+}
+
 type Comment struct {
-	ID        string     `json:"id"`
-	Author    string     `json:"author"`
-	Content   string     `json:"content"`
-	CreatedAt string     `json:"createdAt"`
-	PostID    string     `json:"postID"`
-	ParentID  *string    `json:"parentID,omitempty"`
-	Replies   []*Comment `json:"replies,omitempty"`
+	ID              string        `json:"id"`
+	Author          *Author       `json:"author"`
+	Content         string        `json:"content"`
+	CreatedAt       time.Time     `json:"createdAt"`
+	CreatedAtString string        `json:"createdAtString"`
+	PostID          string        `json:"postID"`
+	ParentID        *string       `json:"parentID,omitempty"`
+	Status          ContentStatus `json:"status"`
+	// Why the comment is pending or was rejected.
+	StatusReason *string    `json:"statusReason,omitempty"`
+	Edited       bool       `json:"edited"`
+	EditedAt     *time.Time `json:"editedAt,omitempty"`
+	// Every version of the comment, oldest first.
+	Revisions   []*Revision   `json:"revisions"`
+	Attachments []*Attachment `json:"attachments"`
+	Post        *Post         `json:"post"`
+	Replies     []*Comment    `json:"replies,omitempty"`
+}
+
+func (Comment) IsNode()            {}
+func (this Comment) GetID() string { return this.ID }
+
+func (Comment) IsContent() {}
+
+type CommentConnection struct {
+	Edges      []*CommentEdge `json:"edges"`
+	PageInfo   *PageInfo      `json:"pageInfo"`
+	TotalCount int32          `json:"totalCount"`
+}
+
+type CommentEdge struct {
+	Cursor string   `json:"cursor"`
+	Node   *Comment `json:"node"`
+}
+
+type CommentEditInput struct {
+	ID      string `json:"id"`
+	Content string `json:"content"`
 }
 
 type CommentInput struct {
-	PostID   string  `json:"postID"`
-	Author   string  `json:"author"`
-	Content  string  `json:"content"`
-	ParentID *string `json:"parentID,omitempty"`
+	PostID      string            `json:"postID"`
+	Author      string            `json:"author"`
+	Content     string            `json:"content"`
+	ParentID    *string           `json:"parentID,omitempty"`
+	Attachments []*graphql.Upload `json:"attachments,omitempty"`
+}
+
+type DiffLine struct {
+	Op   DiffOp `json:"op"`
+	Text string `json:"text"`
+}
+
+type ModerationConnection struct {
+	Edges    []*ModerationEdge `json:"edges"`
+	PageInfo *PageInfo         `json:"pageInfo"`
+}
+
+type ModerationEdge struct {
+	Cursor string  `json:"cursor"`
+	Node   Content `json:"node"`
 }
 
 type Mutation struct {
 }
 
+type PageInfo struct {
+	HasNextPage bool    `json:"hasNextPage"`
+	EndCursor   *string `json:"endCursor,omitempty"`
+}
+
+// A poll attached to a post. Voters can change their vote until it closes.
+type Poll struct {
+	ID       string `json:"id"`
+	Question string `json:"question"`
+	// Whether a voter can choose more than one option.
+	MultipleChoice bool `json:"multipleChoice"`
+	// Votes of an anonymous poll are counted without naming their voters.
+	Anonymous bool       `json:"anonymous"`
+	ClosesAt  *time.Time `json:"closesAt,omitempty"`
+	Closed    bool       `json:"closed"`
+	// Number of users who voted, whatever the number of options they chose.
+	TotalVoters int32 `json:"totalVoters"`
+	// Options in the order they were given.
+	Options []*PollOption `json:"options"`
+}
+
+// A poll has 2 to 10 distinct options. It stays open until closesAt, which
+// must be in the future, or for good.
+type PollInput struct {
+	Question       string     `json:"question"`
+	Options        []string   `json:"options"`
+	MultipleChoice *bool      `json:"multipleChoice,omitempty"`
+	Anonymous      *bool      `json:"anonymous,omitempty"`
+	ClosesAt       *time.Time `json:"closesAt,omitempty"`
+}
+
+type PollOption struct {
+	ID    string `json:"id"`
+	Text  string `json:"text"`
+	Votes int32  `json:"votes"`
+	// Names of the users who chose the option. Null for anonymous polls.
+	Voters []string `json:"voters,omitempty"`
+}
+
 type Post struct {
-	ID              string     `json:"id"`
-	Title           string     `json:"title"`
-	Author          string     `json:"author"`
-	Content         string     `json:"content"`
-	CreatedAt       string     `json:"createdAt"`
-	CommentsAllowed bool       `json:"commentsAllowed"`
-	Comments        []*Comment `json:"comments,omitempty"`
+	ID              string        `json:"id"`
+	Title           string        `json:"title"`
+	Author          *Author       `json:"author"`
+	Content         string        `json:"content"`
+	CreatedAt       time.Time     `json:"createdAt"`
+	CreatedAtString string        `json:"createdAtString"`
+	CommentsAllowed bool          `json:"commentsAllowed"`
+	Status          ContentStatus `json:"status"`
+	// Why the post is pending or was rejected.
+	StatusReason *string `json:"statusReason,omitempty"`
+	// When a scheduled post is published, or when a draft was published.
+	PublishAt *time.Time `json:"publishAt,omitempty"`
+	// Pinned posts come first in GetPosts.
+	Pinned bool `json:"pinned"`
+	// When the pin ends by itself. Set only while the post is pinned.
+	PinnedUntil *time.Time `json:"pinnedUntil,omitempty"`
+	Edited      bool       `json:"edited"`
+	EditedAt    *time.Time `json:"editedAt,omitempty"`
+	// Every version of the post, oldest first.
+	Revisions   []*Revision   `json:"revisions"`
+	Poll        *Poll         `json:"poll,omitempty"`
+	Attachments []*Attachment `json:"attachments"`
+	// Top-level comments of the post. Defaults to the page requested in
+	// GetPostByID, or to the first 10 comments.
+	Comments []*Comment `json:"comments,omitempty"`
+}
+
+func (Post) IsNode()            {}
+func (this Post) GetID() string { return this.ID }
+
+func (Post) IsContent() {}
+
+type PostConnection struct {
+	Edges      []*PostEdge `json:"edges"`
+	PageInfo   *PageInfo   `json:"pageInfo"`
+	TotalCount int32       `json:"totalCount"`
+}
+
+type PostEdge struct {
+	Cursor string `json:"cursor"`
+	Node   *Post  `json:"node"`
+}
+
+type PostEditInput struct {
+	ID      string `json:"id"`
+	Title   string `json:"title"`
+	Content string `json:"content"`
 }
 
+// A post saved as a draft or scheduled by publishAt is only visible to its
+// author, who must be signed in.
 type PostInput struct {
 	Title           string `json:"title"`
 	Author          string `json:"author"`
 	Content         string `json:"content"`
 	CommentsAllowed bool   `json:"commentsAllowed"`
+	Draft           *bool  `json:"draft,omitempty"`
+	// Schedules the post to be published at this time, which must be in the
+	// future.
+	PublishAt   *time.Time        `json:"publishAt,omitempty"`
+	Poll        *PollInput        `json:"poll,omitempty"`
+	Attachments []*graphql.Upload `json:"attachments,omitempty"`
 }
 
 type Query struct {
 }
 
+type Report struct {
+	ID string `json:"id"`
+	// Name of the user who reported the content.
+	Reporter   string        `json:"reporter"`
+	Reason     ReportReason  `json:"reason"`
+	Details    *string       `json:"details,omitempty"`
+	CreatedAt  time.Time     `json:"createdAt"`
+	ResolvedBy *string       `json:"resolvedBy,omitempty"`
+	ResolvedAt *time.Time    `json:"resolvedAt,omitempty"`
+	Action     *ReportAction `json:"action,omitempty"`
+}
+
+// Open reports on a single post or comment.
+type ReportGroup struct {
+	Target Content `json:"target"`
+	Count  int32   `json:"count"`
+	// The reasons given, most common first.
+	Reasons []*ReportReasonCount `json:"reasons"`
+	Reports []*Report            `json:"reports"`
+}
+
+type ReportGroupConnection struct {
+	Edges    []*ReportGroupEdge `json:"edges"`
+	PageInfo *PageInfo          `json:"pageInfo"`
+}
+
+type ReportGroupEdge struct {
+	Cursor string       `json:"cursor"`
+	Node   *ReportGroup `json:"node"`
+}
+
+type ReportReasonCount struct {
+	Reason ReportReason `json:"reason"`
+	Count  int32        `json:"count"`
+}
+
+// A version of a post or comment.
+type Revision struct {
+	// 1 for the original, counting up with every edit.
+	Version int32 `json:"version"`
+	// Name of the user who wrote this version.
+	Editor    string    `json:"editor"`
+	CreatedAt time.Time `json:"createdAt"`
+	// Always empty for comments.
+	Title   string `json:"title"`
+	Content string `json:"content"`
+	// Line-level changes from the previous version. The first version has none.
+	TitleDiff   []*DiffLine `json:"titleDiff"`
+	ContentDiff []*DiffLine `json:"contentDiff"`
+}
+
 type Subscription struct {
 }
+
+// A moderator or admin action recorded in the audit log.
+type AuditAction string
+
+const (
+	AuditActionApproveContent AuditAction = "APPROVE_CONTENT"
+	AuditActionRejectContent  AuditAction = "REJECT_CONTENT"
+	// Published content was sent back to the moderation queue.
+	AuditActionHideContent    AuditAction = "HIDE_CONTENT"
+	AuditActionResolveReports AuditAction = "RESOLVE_REPORTS"
+	AuditActionBanUser        AuditAction = "BAN_USER"
+	AuditActionUnbanUser      AuditAction = "UNBAN_USER"
+	// A post or comment was edited by someone other than its author.
+	AuditActionEditContent AuditAction = "EDIT_CONTENT"
+	AuditActionPinPost     AuditAction = "PIN_POST"
+	AuditActionUnpinPost   AuditAction = "UNPIN_POST"
+)
+
+var AllAuditAction = []AuditAction{
+	AuditActionApproveContent,
+	AuditActionRejectContent,
+	AuditActionHideContent,
+	AuditActionResolveReports,
+	AuditActionBanUser,
+	AuditActionUnbanUser,
+	AuditActionEditContent,
+	AuditActionPinPost,
+	AuditActionUnpinPost,
+}
+
+func (e AuditAction) IsValid() bool {
+	switch e {
+	case AuditActionApproveContent, AuditActionRejectContent, AuditActionHideContent, AuditActionResolveReports, AuditActionBanUser, AuditActionUnbanUser, AuditActionEditContent, AuditActionPinPost, AuditActionUnpinPost:
+		return true
+	}
+	return false
+}
+
+func (e AuditAction) String() string {
+	return string(e)
+}
+
+func (e *AuditAction) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = AuditAction(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid AuditAction", str)
+	}
+	return nil
+}
+
+func (e AuditAction) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type BanKind string
+
+const (
+	// The user cannot post or comment, or subscribe to comments.
+	BanKindBan BanKind = "BAN"
+	// The user can still post and comment, but only they and moderators see it.
+	BanKindShadowban BanKind = "SHADOWBAN"
+)
+
+var AllBanKind = []BanKind{
+	BanKindBan,
+	BanKindShadowban,
+}
+
+func (e BanKind) IsValid() bool {
+	switch e {
+	case BanKindBan, BanKindShadowban:
+		return true
+	}
+	return false
+}
+
+func (e BanKind) String() string {
+	return string(e)
+}
+
+func (e *BanKind) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = BanKind(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid BanKind", str)
+	}
+	return nil
+}
+
+func (e BanKind) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+// Content held by filters or moderators is only visible to moderators and to
+// its author.
+type ContentStatus string
+
+const (
+	ContentStatusPublished ContentStatus = "PUBLISHED"
+	ContentStatusPending   ContentStatus = "PENDING"
+	ContentStatusRejected  ContentStatus = "REJECTED"
+	// Written by a shadowbanned user. Its author sees it as published.
+	ContentStatusShadowed ContentStatus = "SHADOWED"
+	// A post saved by its author to publish later. Only the author sees it.
+	ContentStatusDraft ContentStatus = "DRAFT"
+	// A draft that is published at its publishAt time. Only the author sees it.
+	ContentStatusScheduled ContentStatus = "SCHEDULED"
+)
+
+var AllContentStatus = []ContentStatus{
+	ContentStatusPublished,
+	ContentStatusPending,
+	ContentStatusRejected,
+	ContentStatusShadowed,
+	ContentStatusDraft,
+	ContentStatusScheduled,
+}
+
+func (e ContentStatus) IsValid() bool {
+	switch e {
+	case ContentStatusPublished, ContentStatusPending, ContentStatusRejected, ContentStatusShadowed, ContentStatusDraft, ContentStatusScheduled:
+		return true
+	}
+	return false
+}
+
+func (e ContentStatus) String() string {
+	return string(e)
+}
+
+func (e *ContentStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ContentStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ContentStatus", str)
+	}
+	return nil
+}
+
+func (e ContentStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type DiffOp string
+
+const (
+	DiffOpEqual  DiffOp = "EQUAL"
+	DiffOpInsert DiffOp = "INSERT"
+	DiffOpDelete DiffOp = "DELETE"
+)
+
+var AllDiffOp = []DiffOp{
+	DiffOpEqual,
+	DiffOpInsert,
+	DiffOpDelete,
+}
+
+func (e DiffOp) IsValid() bool {
+	switch e {
+	case DiffOpEqual, DiffOpInsert, DiffOpDelete:
+		return true
+	}
+	return false
+}
+
+func (e DiffOp) String() string {
+	return string(e)
+}
+
+func (e *DiffOp) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = DiffOp(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid DiffOp", str)
+	}
+	return nil
+}
+
+func (e DiffOp) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+// What a moderator did about the reports on a post or comment.
+type ReportAction string
+
+const (
+	// The reports were unfounded. Content hidden by reports is published again.
+	ReportActionDismissed ReportAction = "DISMISSED"
+	// The content was rejected.
+	ReportActionRejected ReportAction = "REJECTED"
+)
+
+var AllReportAction = []ReportAction{
+	ReportActionDismissed,
+	ReportActionRejected,
+}
+
+func (e ReportAction) IsValid() bool {
+	switch e {
+	case ReportActionDismissed, ReportActionRejected:
+		return true
+	}
+	return false
+}
+
+func (e ReportAction) String() string {
+	return string(e)
+}
+
+func (e *ReportAction) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ReportAction(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ReportAction", str)
+	}
+	return nil
+}
+
+func (e ReportAction) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type ReportReason string
+
+const (
+	ReportReasonSpam     ReportReason = "SPAM"
+	ReportReasonAbuse    ReportReason = "ABUSE"
+	ReportReasonOffTopic ReportReason = "OFF_TOPIC"
+	ReportReasonOther    ReportReason = "OTHER"
+)
+
+var AllReportReason = []ReportReason{
+	ReportReasonSpam,
+	ReportReasonAbuse,
+	ReportReasonOffTopic,
+	ReportReasonOther,
+}
+
+func (e ReportReason) IsValid() bool {
+	switch e {
+	case ReportReasonSpam, ReportReasonAbuse, ReportReasonOffTopic, ReportReasonOther:
+		return true
+	}
+	return false
+}
+
+func (e ReportReason) String() string {
+	return string(e)
+}
+
+func (e *ReportReason) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ReportReason(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ReportReason", str)
+	}
+	return nil
+}
+
+func (e ReportReason) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
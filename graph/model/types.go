@@ -1,77 +1,662 @@
 package model
 
 import (
-	"strconv"
+	"encoding/json"
+	"slices"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/erknas/forum/pkg/conv"
+	"github.com/erknas/forum/pkg/diff"
 )
 
+// layout is used by the deprecated string timestamp fields.
 const layout = "02.01.2006 15:04"
 
+type CustomAuthor struct {
+	ID       int       `json:"id"`
+	Name     string    `json:"name"`
+	JoinedAt time.Time `json:"joinedAt"`
+}
+
+type AuthorStats struct {
+	PostCount    int `json:"postCount"`
+	CommentCount int `json:"commentCount"`
+}
+
 type CustomPost struct {
 	ID              int              `json:"id"`
 	Title           string           `json:"title"`
-	Author          string           `json:"author"`
+	Author          CustomAuthor     `json:"author"`
 	Content         string           `json:"content"`
 	CreatedAt       time.Time        `json:"createdAt"`
 	CommentsAllowed bool             `json:"commentsAllowed"`
+	Status          ContentStatus    `json:"status,omitempty"`
+	StatusReason    string           `json:"statusReason,omitempty"`
+	PublishAt       *time.Time       `json:"publishAt,omitempty"`
+	PinnedBy        string           `json:"pinnedBy,omitempty"`
+	PinnedAt        *time.Time       `json:"pinnedAt,omitempty"`
+	PinnedUntil     *time.Time       `json:"pinnedUntil,omitempty"`
+	EditedBy        string           `json:"editedBy,omitempty"`
+	EditedAt        *time.Time       `json:"editedAt,omitempty"`
 	Comments        []*CustomComment `json:"comments,omitempty"`
 }
 
-type CustomComment struct {
-	ID        int       `json:"id"`
-	Author    string    `json:"author"`
-	Content   string    `json:"content"`
+type CustomPostInput struct {
+	Title           string             `json:"title"`
+	Author          string             `json:"author"`
+	Content         string             `json:"content"`
+	CommentsAllowed bool               `json:"commentsAllowed"`
+	Status          ContentStatus      `json:"status,omitempty"`
+	StatusReason    string             `json:"statusReason,omitempty"`
+	PublishAt       *time.Time         `json:"publishAt,omitempty"`
+	Poll            *CustomPollInput   `json:"poll,omitempty"`
+	Attachments     []CustomAttachment `json:"attachments,omitempty"`
+}
+
+type CustomPollInput struct {
+	Question       string     `json:"question"`
+	Options        []string   `json:"options"`
+	MultipleChoice bool       `json:"multipleChoice"`
+	Anonymous      bool       `json:"anonymous"`
+	ClosesAt       *time.Time `json:"closesAt,omitempty"`
+}
+
+// CustomPoll is a poll attached to a post, with its results. Voters are only
+// listed for public polls; Voters counts everyone who voted.
+type CustomPoll struct {
+	ID             int                `json:"id"`
+	PostID         int                `json:"postId"`
+	Question       string             `json:"question"`
+	MultipleChoice bool               `json:"multipleChoice"`
+	Anonymous      bool               `json:"anonymous"`
+	ClosesAt       *time.Time         `json:"closesAt,omitempty"`
+	CreatedAt      time.Time          `json:"createdAt"`
+	Options        []CustomPollOption `json:"options"`
+	Voters         int                `json:"voters"`
+}
+
+type CustomPollOption struct {
+	ID     int      `json:"id"`
+	Text   string   `json:"text"`
+	Votes  int      `json:"votes"`
+	Voters []string `json:"voters,omitempty"`
+}
+
+// CustomBallot is the vote of a user in a poll. A ballot without options
+// withdraws the vote.
+type CustomBallot struct {
+	PollID    int       `json:"pollId"`
+	Voter     string    `json:"voter"`
+	OptionIDs []int     `json:"optionIds"`
 	CreatedAt time.Time `json:"createdAt"`
-	PostID    int       `json:"postId"`
-	ParentID  *int      `json:"parentId,omitempty"`
+}
+
+// Closed reports whether p no longer takes votes at now.
+func (p CustomPoll) Closed(now time.Time) bool {
+	return p.ClosesAt != nil && !p.ClosesAt.After(now)
+}
+
+type CustomComment struct {
+	ID           int           `json:"id"`
+	Author       CustomAuthor  `json:"author"`
+	Content      string        `json:"content"`
+	CreatedAt    time.Time     `json:"createdAt"`
+	PostID       int           `json:"postId"`
+	ParentID     *int          `json:"parentId,omitempty"`
+	Status       ContentStatus `json:"status,omitempty"`
+	StatusReason string        `json:"statusReason,omitempty"`
+	EditedBy     string        `json:"editedBy,omitempty"`
+	EditedAt     *time.Time    `json:"editedAt,omitempty"`
 }
 
 type CustomCommentInput struct {
-	PostID   int    `json:"postId"`
-	Author   string `json:"author"`
-	Content  string `json:"content"`
-	ParentID *int   `json:"parentId,omitempty"`
+	PostID       int                `json:"postId"`
+	Author       string             `json:"author"`
+	Content      string             `json:"content"`
+	ParentID     *int               `json:"parentId,omitempty"`
+	Status       ContentStatus      `json:"status,omitempty"`
+	StatusReason string             `json:"statusReason,omitempty"`
+	Attachments  []CustomAttachment `json:"attachments,omitempty"`
+}
+
+// CustomAttachment is a file attached to a post or comment. Its content, and
+// the thumbnail of an image, are kept in the blob store under Key and
+// ThumbnailKey. Width and Height are zero for files other than images.
+type CustomAttachment struct {
+	ID           int       `json:"id"`
+	TargetType   string    `json:"targetType"`
+	TargetID     int       `json:"targetId"`
+	Key          string    `json:"key"`
+	ThumbnailKey string    `json:"thumbnailKey,omitempty"`
+	Filename     string    `json:"filename"`
+	ContentType  string    `json:"contentType"`
+	Size         int64     `json:"size"`
+	Width        int       `json:"width,omitempty"`
+	Height       int       `json:"height,omitempty"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// CustomEdit replaces the title and content of a post, or the content of a
+// comment. A non-empty Status replaces the status of the content as well.
+type CustomEdit struct {
+	ID           int           `json:"id"`
+	Title        string        `json:"title,omitempty"`
+	Content      string        `json:"content"`
+	Editor       string        `json:"editor"`
+	Status       ContentStatus `json:"status,omitempty"`
+	StatusReason string        `json:"statusReason,omitempty"`
+}
+
+// CustomRevision is a version of a post or comment that an edit replaced.
+// Editor wrote the version at CreatedAt.
+type CustomRevision struct {
+	ID         int       `json:"id"`
+	TargetType string    `json:"targetType"`
+	TargetID   int       `json:"targetId"`
+	Version    int       `json:"version"`
+	Title      string    `json:"title,omitempty"`
+	Content    string    `json:"content"`
+	Editor     string    `json:"editor"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// CustomContent is a post or a comment.
+type CustomContent struct {
+	Post    *CustomPost
+	Comment *CustomComment
+}
+
+// CustomReport is a user report on a post or comment. TargetType is
+// conv.TypePost or conv.TypeComment. ResolvedAt is nil while the report is
+// open.
+type CustomReport struct {
+	ID         int          `json:"id"`
+	TargetType string       `json:"targetType"`
+	TargetID   int          `json:"targetId"`
+	Reporter   string       `json:"reporter"`
+	Reason     ReportReason `json:"reason"`
+	Details    string       `json:"details,omitempty"`
+	CreatedAt  time.Time    `json:"createdAt"`
+	ResolvedBy string       `json:"resolvedBy,omitempty"`
+	ResolvedAt *time.Time   `json:"resolvedAt,omitempty"`
+	Action     ReportAction `json:"action,omitempty"`
+}
+
+// CustomReportInput is a report to create. Once the target has HideAt open
+// reports, Hide is made to its status along with the report; HideAt 0 never
+// hides it.
+type CustomReportInput struct {
+	TargetType string             `json:"targetType"`
+	TargetID   int                `json:"targetId"`
+	Reporter   string             `json:"reporter"`
+	Reason     ReportReason       `json:"reason"`
+	Details    string             `json:"details,omitempty"`
+	HideAt     int                `json:"-"`
+	Hide       CustomStatusChange `json:"-"`
+}
+
+// CustomStatusChange is a status change a storage method makes to content
+// along with its own change. It applies to content with one of the From
+// statuses, or with any status if From is empty, and only to content with
+// FromReason if it is set; a change without a Status never applies.
+type CustomStatusChange struct {
+	Status     ContentStatus
+	Reason     string
+	From       []ContentStatus
+	FromReason string
+}
+
+// AppliesTo reports whether c changes content with status and reason.
+func (c CustomStatusChange) AppliesTo(status ContentStatus, reason string) bool {
+	return c.Status != "" &&
+		(len(c.From) == 0 || slices.Contains(c.From, status.OrPublished())) &&
+		(c.FromReason == "" || c.FromReason == reason)
+}
+
+// CustomReportGroup holds the open reports on a single target, oldest first.
+type CustomReportGroup struct {
+	TargetType string         `json:"targetType"`
+	TargetID   int            `json:"targetId"`
+	Reports    []CustomReport `json:"reports"`
+}
+
+// CustomBan suspends the author with the given name. A ban is in force until
+// it expires or a moderator lifts it; a nil ExpiresAt never expires.
+type CustomBan struct {
+	ID        int        `json:"id"`
+	Author    string     `json:"author"`
+	Kind      BanKind    `json:"kind"`
+	Reason    string     `json:"reason"`
+	Moderator string     `json:"moderator"`
+	CreatedAt time.Time  `json:"createdAt"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	LiftedBy  string     `json:"liftedBy,omitempty"`
+	LiftedAt  *time.Time `json:"liftedAt,omitempty"`
+}
+
+type CustomBanInput struct {
+	Author    string     `json:"author"`
+	Kind      BanKind    `json:"kind"`
+	Reason    string     `json:"reason"`
+	Moderator string     `json:"moderator"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// Active reports whether b is in force at now.
+func (b CustomBan) Active(now time.Time) bool {
+	return b.LiftedAt == nil && (b.ExpiresAt == nil || b.ExpiresAt.After(now))
+}
+
+// CustomAuditEntry is a moderator or admin action. Before and after are JSON
+// snapshots of what it changed.
+type CustomAuditEntry struct {
+	ID         int             `json:"id"`
+	Actor      string          `json:"actor"`
+	Action     AuditAction     `json:"action"`
+	TargetType string          `json:"targetType"`
+	TargetID   int             `json:"targetId"`
+	Before     json.RawMessage `json:"before,omitempty"`
+	After      json.RawMessage `json:"after,omitempty"`
+	CreatedAt  time.Time       `json:"createdAt"`
+}
+
+// CustomAuditFilter selects audit entries that match every field that is not
+// zero. Since is inclusive, Until is not.
+type CustomAuditFilter struct {
+	Actor      string
+	Action     AuditAction
+	TargetType string
+	TargetID   int
+	Since      time.Time
+	Until      time.Time
+}
+
+// Match reports whether entry matches f.
+func (f CustomAuditFilter) Match(entry CustomAuditEntry) bool {
+	switch {
+	case f.Actor != "" && entry.Actor != f.Actor,
+		f.Action != "" && entry.Action != f.Action,
+		f.TargetType != "" && (entry.TargetType != f.TargetType || entry.TargetID != f.TargetID),
+		!f.Since.IsZero() && entry.CreatedAt.Before(f.Since),
+		!f.Until.IsZero() && !entry.CreatedAt.Before(f.Until):
+		return false
+	default:
+		return true
+	}
+}
+
+// AuditAction returns the action of a moderator setting content to e.
+func (e ContentStatus) AuditAction() AuditAction {
+	switch e.OrPublished() {
+	case ContentStatusPublished:
+		return AuditActionApproveContent
+	case ContentStatusPending:
+		return AuditActionHideContent
+	default:
+		return AuditActionRejectContent
+	}
+}
+
+// Draft reports whether e is the status of a post its author has not
+// published yet.
+func (e ContentStatus) Draft() bool {
+	return e == ContentStatusDraft || e == ContentStatusScheduled
+}
+
+// ShadowedReason is the status reason of content shadowed because its author
+// is shadowbanned.
+const ShadowedReason = "shadowbanned"
+
+// ReportedReason is the status reason of content hidden because it reached
+// the report threshold.
+const ReportedReason = "hidden after reports"
+
+// OrPublished returns e, or PUBLISHED if e is empty, as it is for content
+// stored before moderation existed and for inputs that do not set a status.
+func (e ContentStatus) OrPublished() ContentStatus {
+	if e == "" {
+		return ContentStatusPublished
+	}
+	return e
+}
+
+func (a CustomAuthor) Convert() Author {
+	return Author{
+		ID:             conv.GlobalID(conv.TypeAuthor, a.ID),
+		Name:           a.Name,
+		JoinedAt:       a.JoinedAt,
+		JoinedAtString: a.JoinedAt.Format(layout),
+	}
 }
 
 func (p CustomPost) Convert() Post {
-	return Post{
-		ID:              strconv.Itoa(p.ID),
+	author := p.Author.Convert()
+
+	post := Post{
+		ID:              conv.GlobalID(conv.TypePost, p.ID),
 		Title:           p.Title,
-		Author:          p.Author,
+		Author:          &author,
 		Content:         p.Content,
-		CreatedAt:       p.CreatedAt.Format(layout),
+		CreatedAt:       p.CreatedAt,
+		CreatedAtString: p.CreatedAt.Format(layout),
 		CommentsAllowed: p.CommentsAllowed,
+		Status:          p.Status.OrPublished(),
+		StatusReason:    optional(p.StatusReason),
+		PublishAt:       p.PublishAt,
+		Edited:          p.EditedAt != nil,
+		EditedAt:        p.EditedAt,
+	}
+
+	if p.Pinned(time.Now()) {
+		post.Pinned = true
+		post.PinnedUntil = p.PinnedUntil
 	}
+
+	return post
+}
+
+// Pinned reports whether p is pinned at now. A pin past PinnedUntil has
+// expired.
+func (p CustomPost) Pinned(now time.Time) bool {
+	return p.PinnedAt != nil && (p.PinnedUntil == nil || p.PinnedUntil.After(now))
+}
+
+// Revision returns the current version of p, numbered version.
+func (p CustomPost) Revision(version int) CustomRevision {
+	return current(conv.TypePost, p.ID, version, p.Title, p.Content, p.Author.Name, p.CreatedAt, p.EditedBy, p.EditedAt)
 }
 
 func (c CustomComment) Convert() Comment {
+	author := c.Author.Convert()
+
 	if c.ParentID == nil {
 		return Comment{
-			ID:        strconv.Itoa(c.ID),
-			Author:    c.Author,
-			Content:   c.Content,
-			CreatedAt: c.CreatedAt.Format(layout),
-			PostID:    strconv.Itoa(c.PostID),
-			ParentID:  nil,
+			ID:              conv.GlobalID(conv.TypeComment, c.ID),
+			Author:          &author,
+			Content:         c.Content,
+			CreatedAt:       c.CreatedAt,
+			CreatedAtString: c.CreatedAt.Format(layout),
+			PostID:          conv.GlobalID(conv.TypePost, c.PostID),
+			ParentID:        nil,
+			Status:          c.Status.OrPublished(),
+			StatusReason:    optional(c.StatusReason),
+			Edited:          c.EditedAt != nil,
+			EditedAt:        c.EditedAt,
 		}
 	}
 
-	parentID := strconv.Itoa(*c.ParentID)
+	parentID := conv.GlobalID(conv.TypeComment, *c.ParentID)
 
 	return Comment{
-		ID:        strconv.Itoa(c.ID),
-		Author:    c.Author,
-		Content:   c.Content,
-		CreatedAt: c.CreatedAt.Format(layout),
-		PostID:    strconv.Itoa(c.PostID),
-		ParentID:  &parentID,
+		ID:              conv.GlobalID(conv.TypeComment, c.ID),
+		Author:          &author,
+		Content:         c.Content,
+		CreatedAt:       c.CreatedAt,
+		CreatedAtString: c.CreatedAt.Format(layout),
+		PostID:          conv.GlobalID(conv.TypePost, c.PostID),
+		ParentID:        &parentID,
+		Status:          c.Status.OrPublished(),
+		StatusReason:    optional(c.StatusReason),
+		Edited:          c.EditedAt != nil,
+		EditedAt:        c.EditedAt,
+	}
+}
+
+// Revision returns the current version of c, numbered version.
+func (c CustomComment) Revision(version int) CustomRevision {
+	return current(conv.TypeComment, c.ID, version, "", c.Content, c.Author.Name, c.CreatedAt, c.EditedBy, c.EditedAt)
+}
+
+func current(targetType string, targetID int, version int, title, content, author string, createdAt time.Time, editedBy string, editedAt *time.Time) CustomRevision {
+	revision := CustomRevision{
+		TargetType: targetType,
+		TargetID:   targetID,
+		Version:    version,
+		Title:      title,
+		Content:    content,
+		Editor:     author,
+		CreatedAt:  createdAt,
+	}
+
+	if editedAt != nil {
+		revision.Editor, revision.CreatedAt = editedBy, *editedAt
+	}
+
+	return revision
+}
+
+func (c CustomContent) Convert() Content {
+	if c.Post != nil {
+		post := c.Post.Convert()
+		return &post
+	}
+
+	comment := c.Comment.Convert()
+	return &comment
+}
+
+func (r CustomReport) Convert() Report {
+	report := Report{
+		ID:         conv.GlobalID(conv.TypeReport, r.ID),
+		Reporter:   r.Reporter,
+		Reason:     r.Reason,
+		Details:    optional(r.Details),
+		CreatedAt:  r.CreatedAt,
+		ResolvedBy: optional(r.ResolvedBy),
+		ResolvedAt: r.ResolvedAt,
 	}
+
+	if r.Action != "" {
+		action := r.Action
+		report.Action = &action
+	}
+
+	return report
+}
+
+// Convert returns the group of reports on target with reasons counted, most
+// common first.
+func (g CustomReportGroup) Convert(target CustomContent) ReportGroup {
+	group := ReportGroup{
+		Target:  target.Convert(),
+		Count:   int32(len(g.Reports)),
+		Reasons: make([]*ReportReasonCount, 0),
+		Reports: make([]*Report, 0, len(g.Reports)),
+	}
+
+	counts := make(map[ReportReason]int32)
+
+	for _, customReport := range g.Reports {
+		report := customReport.Convert()
+		group.Reports = append(group.Reports, &report)
+
+		if counts[customReport.Reason] == 0 {
+			group.Reasons = append(group.Reasons, &ReportReasonCount{Reason: customReport.Reason})
+		}
+		counts[customReport.Reason]++
+	}
+
+	for _, reason := range group.Reasons {
+		reason.Count = counts[reason.Reason]
+	}
+
+	sort.SliceStable(group.Reasons, func(i, j int) bool {
+		return group.Reasons[i].Count > group.Reasons[j].Count
+	})
+
+	return group
+}
+
+func (p CustomPoll) Convert() Poll {
+	poll := Poll{
+		ID:             conv.GlobalID(conv.TypePoll, p.ID),
+		Question:       p.Question,
+		MultipleChoice: p.MultipleChoice,
+		Anonymous:      p.Anonymous,
+		ClosesAt:       p.ClosesAt,
+		Closed:         p.Closed(time.Now()),
+		TotalVoters:    int32(p.Voters),
+		Options:        make([]*PollOption, 0, len(p.Options)),
+	}
+
+	for _, o := range p.Options {
+		option := &PollOption{
+			ID:    conv.GlobalID(conv.TypePollOption, o.ID),
+			Text:  o.Text,
+			Votes: int32(o.Votes),
+		}
+
+		if !p.Anonymous {
+			option.Voters = append([]string{}, o.Voters...)
+		}
+
+		poll.Options = append(poll.Options, option)
+	}
+
+	return poll
+}
+
+func (b CustomBan) Convert() Ban {
+	return Ban{
+		ID:        conv.GlobalID(conv.TypeBan, b.ID),
+		Author:    b.Author,
+		Kind:      b.Kind,
+		Reason:    b.Reason,
+		Moderator: b.Moderator,
+		CreatedAt: b.CreatedAt,
+		ExpiresAt: b.ExpiresAt,
+		LiftedBy:  optional(b.LiftedBy),
+		LiftedAt:  b.LiftedAt,
+	}
+}
+
+// Convert returns a with URLs of its blobs under baseURL.
+func (a CustomAttachment) Convert(baseURL string) Attachment {
+	attachment := Attachment{
+		ID:          conv.GlobalID(conv.TypeAttachment, a.ID),
+		Filename:    a.Filename,
+		ContentType: a.ContentType,
+		Size:        int32(a.Size),
+		URL:         baseURL + a.Key,
+		CreatedAt:   a.CreatedAt,
+	}
+
+	if a.ThumbnailKey != "" {
+		thumbnailURL := baseURL + a.ThumbnailKey
+		attachment.ThumbnailURL = &thumbnailURL
+	}
+
+	if a.Width > 0 && a.Height > 0 {
+		width, height := int32(a.Width), int32(a.Height)
+		attachment.Width, attachment.Height = &width, &height
+	}
+
+	return attachment
+}
+
+// Convert returns r with the line-level changes from previous, the version it
+// replaced, or with no changes if r is the first version.
+func (r CustomRevision) Convert(previous *CustomRevision) Revision {
+	revision := Revision{
+		Version:     int32(r.Version),
+		Editor:      r.Editor,
+		CreatedAt:   r.CreatedAt,
+		Title:       r.Title,
+		Content:     r.Content,
+		TitleDiff:   make([]*DiffLine, 0),
+		ContentDiff: make([]*DiffLine, 0),
+	}
+
+	if previous != nil {
+		revision.TitleDiff = diffLines(previous.Title, r.Title)
+		revision.ContentDiff = diffLines(previous.Content, r.Content)
+	}
+
+	return revision
+}
+
+// ConvertRevisions returns the versions in revisions, oldest first, each with
+// the changes from the one before.
+func ConvertRevisions(revisions []CustomRevision) []*Revision {
+	result := make([]*Revision, 0, len(revisions))
+
+	for i, customRevision := range revisions {
+		var previous *CustomRevision
+		if i > 0 {
+			previous = &revisions[i-1]
+		}
+
+		revision := customRevision.Convert(previous)
+		result = append(result, &revision)
+	}
+
+	return result
+}
+
+var diffOps = map[diff.Op]DiffOp{
+	diff.Equal:  DiffOpEqual,
+	diff.Insert: DiffOpInsert,
+	diff.Delete: DiffOpDelete,
+}
+
+func diffLines(a, b string) []*DiffLine {
+	lines := diff.Lines(a, b)
+	result := make([]*DiffLine, 0, len(lines))
+
+	for _, line := range lines {
+		result = append(result, &DiffLine{Op: diffOps[line.Op], Text: line.Text})
+	}
+
+	return result
+}
+
+func (e CustomAuditEntry) Convert() AuditEntry {
+	return AuditEntry{
+		ID:        conv.GlobalID(conv.TypeAuditEntry, e.ID),
+		Actor:     e.Actor,
+		Action:    e.Action,
+		TargetID:  conv.GlobalID(e.TargetType, e.TargetID),
+		Before:    optional(string(e.Before)),
+		After:     optional(string(e.After)),
+		CreatedAt: e.CreatedAt,
+	}
+}
+
+func (p PostInput) Convert() CustomPostInput {
+	return CustomPostInput{
+		Title:           p.Title,
+		Author:          p.Author,
+		Content:         p.Content,
+		CommentsAllowed: p.CommentsAllowed,
+		PublishAt:       p.PublishAt,
+		Poll:            p.Poll.Convert(),
+	}
+}
+
+func (p *PollInput) Convert() *CustomPollInput {
+	if p == nil {
+		return nil
+	}
+
+	input := &CustomPollInput{
+		Question:       strings.TrimSpace(p.Question),
+		Options:        make([]string, 0, len(p.Options)),
+		MultipleChoice: p.MultipleChoice != nil && *p.MultipleChoice,
+		Anonymous:      p.Anonymous != nil && *p.Anonymous,
+		ClosesAt:       p.ClosesAt,
+	}
+
+	for _, option := range p.Options {
+		input.Options = append(input.Options, strings.TrimSpace(option))
+	}
+
+	return input
 }
 
 func (c CommentInput) Convert() (CustomCommentInput, error) {
-	postID, err := conv.ID(c.PostID)
+	postID, err := conv.NodeID(conv.TypePost, c.PostID)
 	if err != nil {
 		return CustomCommentInput{}, err
 	}
@@ -94,7 +679,7 @@ func (c CommentInput) Convert() (CustomCommentInput, error) {
 		}, nil
 	}
 
-	parentID, err := conv.ID(*c.ParentID)
+	parentID, err := conv.NodeID(conv.TypeComment, *c.ParentID)
 	if err != nil {
 		return CustomCommentInput{}, err
 	}
@@ -106,3 +691,28 @@ func (c CommentInput) Convert() (CustomCommentInput, error) {
 		ParentID: &parentID,
 	}, nil
 }
+
+func (p PostEditInput) Convert() (CustomEdit, error) {
+	id, err := conv.NodeID(conv.TypePost, p.ID)
+	if err != nil {
+		return CustomEdit{}, err
+	}
+
+	return CustomEdit{ID: id, Title: p.Title, Content: p.Content}, nil
+}
+
+func (c CommentEditInput) Convert() (CustomEdit, error) {
+	id, err := conv.NodeID(conv.TypeComment, c.ID)
+	if err != nil {
+		return CustomEdit{}, err
+	}
+
+	return CustomEdit{ID: id, Content: c.Content}, nil
+}
+
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
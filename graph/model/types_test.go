@@ -1,9 +1,10 @@
 package model
 
 import (
-	"fmt"
 	"testing"
+	"time"
 
+	"github.com/erknas/forum/pkg/apperr"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -84,7 +85,7 @@ func TestConvertToCustomCommentInput_FaileCases(t *testing.T) {
 				Content:  "content1",
 				ParentID: nil,
 			},
-			wantError: fmt.Errorf("invalid ID -1"),
+			wantError: apperr.Validationf("invalid ID -1"),
 		},
 		{
 			name: "Invalid ParentID",
@@ -94,7 +95,7 @@ func TestConvertToCustomCommentInput_FaileCases(t *testing.T) {
 				Content:  "content2",
 				ParentID: stringPtr("0"),
 			},
-			wantError: fmt.Errorf("invalid ID 0"),
+			wantError: apperr.Validationf("invalid ID 0"),
 		},
 		{
 			name: "Stirng PostID",
@@ -104,7 +105,7 @@ func TestConvertToCustomCommentInput_FaileCases(t *testing.T) {
 				Content:  "content3",
 				ParentID: stringPtr("2"),
 			},
-			wantError: fmt.Errorf("invalid ID string_id"),
+			wantError: apperr.Validationf("invalid ID string_id"),
 		},
 		{
 			name: "Stirng ParentID",
@@ -114,7 +115,7 @@ func TestConvertToCustomCommentInput_FaileCases(t *testing.T) {
 				Content:  "content3",
 				ParentID: stringPtr("parentID"),
 			},
-			wantError: fmt.Errorf("invalid ID parentID"),
+			wantError: apperr.Validationf("invalid ID parentID"),
 		},
 	}
 
@@ -129,6 +130,56 @@ func TestConvertToCustomCommentInput_FaileCases(t *testing.T) {
 
 }
 
+func TestConvertRevisions(t *testing.T) {
+	createdAt := time.Date(2024, 1, 31, 9, 15, 0, 0, time.UTC)
+	editedAt := createdAt.Add(time.Hour)
+
+	post := CustomPost{ID: 1, Title: "Title", Content: "one\n2", Author: CustomAuthor{Name: "Mod"}, CreatedAt: createdAt, EditedBy: "Mod", EditedAt: &editedAt}
+	original := CustomRevision{TargetType: "Post", TargetID: 1, Version: 1, Title: "Title", Content: "one\ntwo", Editor: "Alice", CreatedAt: createdAt}
+
+	revisions := ConvertRevisions([]CustomRevision{original, post.Revision(2)})
+
+	assert.Len(t, revisions, 2)
+	assert.Equal(t, &Revision{
+		Version:     1,
+		Editor:      "Alice",
+		CreatedAt:   createdAt,
+		Title:       "Title",
+		Content:     "one\ntwo",
+		TitleDiff:   []*DiffLine{},
+		ContentDiff: []*DiffLine{},
+	}, revisions[0])
+	assert.Equal(t, &Revision{
+		Version:     2,
+		Editor:      "Mod",
+		CreatedAt:   editedAt,
+		Title:       "Title",
+		Content:     "one\n2",
+		TitleDiff:   []*DiffLine{{Op: DiffOpEqual, Text: "Title"}},
+		ContentDiff: []*DiffLine{{Op: DiffOpEqual, Text: "one"}, {Op: DiffOpDelete, Text: "two"}, {Op: DiffOpInsert, Text: "2"}},
+	}, revisions[1])
+
+	assert.True(t, post.Convert().Edited)
+	assert.False(t, CustomComment{ID: 1}.Convert().Edited)
+}
+
+func TestConvertPinnedPost(t *testing.T) {
+	pinnedAt := time.Now().Add(-time.Hour)
+	until := time.Now().Add(time.Hour)
+	expired := time.Now().Add(-time.Minute)
+
+	post := CustomPost{ID: 1, PinnedBy: "Root", PinnedAt: &pinnedAt, PinnedUntil: &until}.Convert()
+	assert.True(t, post.Pinned)
+	assert.Equal(t, &until, post.PinnedUntil)
+
+	post = CustomPost{ID: 1, PinnedBy: "Root", PinnedAt: &pinnedAt, PinnedUntil: &expired}.Convert()
+	assert.False(t, post.Pinned, "expired pins are ignored")
+	assert.Nil(t, post.PinnedUntil)
+
+	assert.True(t, CustomPost{ID: 1, PinnedAt: &pinnedAt}.Convert().Pinned)
+	assert.False(t, CustomPost{ID: 1}.Convert().Pinned)
+}
+
 func stringPtr(s string) *string {
 	return &s
 }
@@ -136,3 +187,22 @@ func stringPtr(s string) *string {
 func intPtr(i int) *int {
 	return &i
 }
+
+func TestStatusChangeAppliesTo(t *testing.T) {
+	publish := CustomStatusChange{Status: ContentStatusPublished, From: []ContentStatus{ContentStatusPending}}
+
+	assert.True(t, publish.AppliesTo(ContentStatusPending, "too many links"))
+	assert.False(t, publish.AppliesTo(ContentStatusRejected, ""))
+
+	hide := CustomStatusChange{Status: ContentStatusPending, From: []ContentStatus{ContentStatusPublished}}
+	assert.True(t, hide.AppliesTo("", ""), "content without a status is published")
+
+	restore := CustomStatusChange{Status: ContentStatusPublished, From: []ContentStatus{ContentStatusPending}, FromReason: ReportedReason}
+	assert.True(t, restore.AppliesTo(ContentStatusPending, ReportedReason))
+	assert.False(t, restore.AppliesTo(ContentStatusPending, "too many links"), "content held by filters stays pending")
+
+	reject := CustomStatusChange{Status: ContentStatusRejected}
+	assert.True(t, reject.AppliesTo(ContentStatusShadowed, ShadowedReason))
+
+	assert.False(t, CustomStatusChange{}.AppliesTo(ContentStatusPublished, ""))
+}
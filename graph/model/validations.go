@@ -1,5 +1,17 @@
 package model
 
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	maxAuthorNameLength = 16
+	minPollOptions      = 2
+	maxPollOptions      = 10
+)
+
 func (p PostInput) ValidatePostInput() map[string]interface{} {
 	errors := make(map[string]interface{})
 
@@ -15,13 +27,60 @@ func (p PostInput) ValidatePostInput() map[string]interface{} {
 		errors["author"] = "author name length cannot be zero"
 	}
 
+	if utf8.RuneCountInString(p.Author) > maxAuthorNameLength {
+		errors["author"] = "author name length cannot be more than 16 symbols"
+	}
+
 	if len(p.Content) == 0 {
 		errors["content"] = "content length cannot be zero"
 	}
 
+	if p.PublishAt != nil && !p.PublishAt.After(time.Now()) {
+		errors["publishAt"] = "publishAt must be in the future"
+	}
+
+	if p.Poll != nil {
+		p.Poll.validate(errors)
+	}
+
 	return errors
 }
 
+func (p PollInput) validate(errors map[string]interface{}) {
+	if len(strings.TrimSpace(p.Question)) == 0 {
+		errors["poll.question"] = "question length cannot be zero"
+	}
+
+	if utf8.RuneCountInString(p.Question) > 200 {
+		errors["poll.question"] = "question length cannot be more than 200 symbols"
+	}
+
+	seen := make(map[string]bool, len(p.Options))
+
+	for _, option := range p.Options {
+		option = strings.TrimSpace(option)
+
+		switch {
+		case len(option) == 0:
+			errors["poll.options"] = "option length cannot be zero"
+		case utf8.RuneCountInString(option) > 100:
+			errors["poll.options"] = "option length cannot be more than 100 symbols"
+		case seen[option]:
+			errors["poll.options"] = "options must be distinct"
+		}
+
+		seen[option] = true
+	}
+
+	if len(p.Options) < minPollOptions || len(p.Options) > maxPollOptions {
+		errors["poll.options"] = "a poll has 2 to 10 options"
+	}
+
+	if p.ClosesAt != nil && !p.ClosesAt.After(time.Now()) {
+		errors["poll.closesAt"] = "closesAt must be in the future"
+	}
+}
+
 func (c CommentInput) ValidateCommentInput() map[string]interface{} {
 	errors := make(map[string]interface{})
 
@@ -33,6 +92,50 @@ func (c CommentInput) ValidateCommentInput() map[string]interface{} {
 		errors["author"] = "author name length cannot be zero"
 	}
 
+	if utf8.RuneCountInString(c.Author) > maxAuthorNameLength {
+		errors["author"] = "author name length cannot be more than 16 symbols"
+	}
+
+	if len(c.Content) == 0 {
+		errors["content"] = "content length cannot be zero"
+	}
+
+	if len(c.Content) > 2000 {
+		errors["content"] = "content length cannot be more than 2000 symbols"
+	}
+
+	return errors
+}
+
+func (p PostEditInput) ValidatePostEditInput() map[string]interface{} {
+	errors := make(map[string]interface{})
+
+	if len(p.ID) == 0 {
+		errors["id"] = "id cannot be empty"
+	}
+
+	if len(p.Title) == 0 {
+		errors["title"] = "title length cannot be zero"
+	}
+
+	if len(p.Title) > 100 {
+		errors["title"] = "title length cannot be more than 100 symbols"
+	}
+
+	if len(p.Content) == 0 {
+		errors["content"] = "content length cannot be zero"
+	}
+
+	return errors
+}
+
+func (c CommentEditInput) ValidateCommentEditInput() map[string]interface{} {
+	errors := make(map[string]interface{})
+
+	if len(c.ID) == 0 {
+		errors["id"] = "id cannot be empty"
+	}
+
 	if len(c.Content) == 0 {
 		errors["content"] = "content length cannot be zero"
 	}
@@ -1,12 +1,16 @@
 package model
 
 import (
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
 
 func TestValidatePostInput(t *testing.T) {
+	past := time.Now().Add(-time.Minute)
+
 	tests := []struct {
 		name           string
 		input          PostInput
@@ -58,6 +62,28 @@ func TestValidatePostInput(t *testing.T) {
 				"author": "author name length cannot be zero",
 			},
 		},
+		{
+			name: "Author Too Long",
+			input: PostInput{
+				Title:           "Valid Title",
+				Author:          "Алексей Степанович",
+				Content:         "This is some content.",
+				CommentsAllowed: true,
+			},
+			expectedErrors: map[string]interface{}{
+				"author": "author name length cannot be more than 16 symbols",
+			},
+		},
+		{
+			name: "Cyrillic Author",
+			input: PostInput{
+				Title:           "Valid Title",
+				Author:          "Алексей Петров",
+				Content:         "This is some content.",
+				CommentsAllowed: true,
+			},
+			expectedErrors: map[string]interface{}{},
+		},
 		{
 			name: "Empty Content",
 			input: PostInput{
@@ -70,6 +96,54 @@ func TestValidatePostInput(t *testing.T) {
 				"content": "content length cannot be zero",
 			},
 		},
+		{
+			name: "Publish In The Past",
+			input: PostInput{
+				Title:     "Valid Title",
+				Author:    "Author Name",
+				Content:   "This is some content.",
+				PublishAt: &past,
+			},
+			expectedErrors: map[string]interface{}{
+				"publishAt": "publishAt must be in the future",
+			},
+		},
+		{
+			name: "Valid Poll",
+			input: PostInput{
+				Title:   "Valid Title",
+				Author:  "Author Name",
+				Content: "This is some content.",
+				Poll:    &PollInput{Question: "When?", Options: []string{"Monday", "Friday"}},
+			},
+			expectedErrors: map[string]interface{}{},
+		},
+		{
+			name: "Invalid Poll",
+			input: PostInput{
+				Title:   "Valid Title",
+				Author:  "Author Name",
+				Content: "This is some content.",
+				Poll:    &PollInput{Question: " ", Options: []string{"Monday", " Monday "}, ClosesAt: &past},
+			},
+			expectedErrors: map[string]interface{}{
+				"poll.question": "question length cannot be zero",
+				"poll.options":  "options must be distinct",
+				"poll.closesAt": "closesAt must be in the future",
+			},
+		},
+		{
+			name: "Poll With One Option",
+			input: PostInput{
+				Title:   "Valid Title",
+				Author:  "Author Name",
+				Content: "This is some content.",
+				Poll:    &PollInput{Question: "When?", Options: []string{"Monday"}},
+			},
+			expectedErrors: map[string]interface{}{
+				"poll.options": "a poll has 2 to 10 options",
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -137,3 +211,17 @@ func TestValidateCommentInput(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateEditInputs(t *testing.T) {
+	assert.Empty(t, PostEditInput{ID: "1", Title: "Title", Content: "Content"}.ValidatePostEditInput())
+	assert.Equal(t, map[string]interface{}{
+		"id":      "id cannot be empty",
+		"title":   "title length cannot be zero",
+		"content": "content length cannot be zero",
+	}, PostEditInput{}.ValidatePostEditInput())
+
+	assert.Empty(t, CommentEditInput{ID: "1", Content: "Content"}.ValidateCommentEditInput())
+	assert.Equal(t, map[string]interface{}{
+		"content": "content length cannot be more than 2000 symbols",
+	}, CommentEditInput{ID: "1", Content: strings.Repeat("a", 2001)}.ValidateCommentEditInput())
+}
@@ -0,0 +1,93 @@
+package graph
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/erknas/forum/pkg/apperr"
+	"github.com/erknas/forum/pkg/ratelimit"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// RateLimit limits how often a client may create posts and comments and how
+// many subscriptions it may keep open. Clients are told apart by Key. A nil
+// limiter does not limit.
+type RateLimit struct {
+	Posts         *ratelimit.Limiter
+	Comments      *ratelimit.Limiter
+	Subscriptions *ratelimit.Concurrency
+	Key           func(ctx context.Context) string
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+} = RateLimit{}
+
+func (RateLimit) ExtensionName() string {
+	return "RateLimit"
+}
+
+func (RateLimit) Validate(graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (r RateLimit) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	opCtx := graphql.GetOperationContext(ctx)
+	key := r.Key(ctx)
+
+	switch opCtx.Operation.Operation {
+	case ast.Mutation:
+		for _, field := range graphql.CollectFields(opCtx, opCtx.Operation.SelectionSet, []string{"Mutation"}) {
+			var (
+				limiter *ratelimit.Limiter
+				what    string
+			)
+
+			switch field.Name {
+			case "CreatePost":
+				limiter, what = r.Posts, "posts"
+			case "CreateComment":
+				limiter, what = r.Comments, "comments"
+			}
+
+			if limiter == nil {
+				continue
+			}
+
+			if ok, retryAfter := limiter.Allow(key); !ok {
+				return rejected(ctx, apperr.RateLimited("too many "+what+", try again later", retryAfter))
+			}
+		}
+	case ast.Subscription:
+		if r.Subscriptions == nil {
+			break
+		}
+
+		release, ok := r.Subscriptions.Acquire(key)
+		if !ok {
+			return rejected(ctx, apperr.RateLimited("too many open subscriptions", 0))
+		}
+
+		// The transport cancels ctx when the client stops the subscription
+		// or disconnects.
+		context.AfterFunc(ctx, release)
+
+		responses := next(ctx)
+
+		return func(ctx context.Context) *graphql.Response {
+			resp := responses(ctx)
+			if resp == nil {
+				release()
+			}
+			return resp
+		}
+	}
+
+	return next(ctx)
+}
+
+func rejected(ctx context.Context, err error) graphql.ResponseHandler {
+	return graphql.OneShot(&graphql.Response{Errors: gqlerror.List{ErrorPresenter(ctx, err)}})
+}
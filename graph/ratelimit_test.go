@@ -0,0 +1,145 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/client"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/erknas/forum/internal/service"
+	"github.com/erknas/forum/internal/storage"
+	"github.com/erknas/forum/pkg/apperr"
+	"github.com/erknas/forum/pkg/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+type clientKey struct{}
+
+func newRateLimitedClient(t *testing.T, rateLimit RateLimit) *client.Client {
+	t.Helper()
+
+	srv := handler.New(NewExecutableSchema(Config{
+		Resolvers: &Resolver{Svc: service.New(storage.NewInMemoryStorage(), service.Options{})},
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.SetErrorPresenter(ErrorPresenter)
+
+	rateLimit.Key = func(ctx context.Context) string {
+		key, _ := ctx.Value(clientKey{}).(string)
+		return key
+	}
+	srv.Use(rateLimit)
+
+	return client.New(srv)
+}
+
+func as(key string) client.Option {
+	return func(bd *client.Request) {
+		bd.HTTP = bd.HTTP.WithContext(context.WithValue(bd.HTTP.Context(), clientKey{}, key))
+	}
+}
+
+type responseErrors []struct {
+	Message    string
+	Extensions map[string]interface{}
+}
+
+func errorsOf(t *testing.T, resp *client.Response) responseErrors {
+	t.Helper()
+
+	var errs responseErrors
+	if resp.Errors != nil {
+		require.NoError(t, json.Unmarshal(resp.Errors, &errs))
+	}
+
+	return errs
+}
+
+func TestRateLimit(t *testing.T) {
+	c := newRateLimitedClient(t, RateLimit{
+		Posts:    ratelimit.New(time.Minute, 2),
+		Comments: ratelimit.New(time.Minute, 1),
+	})
+
+	const createPost = `mutation { CreatePost(input: {title: "title", author: "Bob", content: "content", commentsAllowed: true}) { id } }`
+
+	for range 2 {
+		resp, err := c.RawPost(createPost, as("1.1.1.1"))
+		require.NoError(t, err)
+		require.Empty(t, errorsOf(t, resp))
+	}
+
+	resp, err := c.RawPost(createPost, as("1.1.1.1"))
+	require.NoError(t, err)
+
+	errs := errorsOf(t, resp)
+	require.Len(t, errs, 1)
+	assert.Equal(t, "RATE_LIMITED", errs[0].Extensions["code"])
+	assert.EqualValues(t, 60, errs[0].Extensions["retryAfter"])
+
+	resp, err = c.RawPost(createPost, as("2.2.2.2"))
+	require.NoError(t, err)
+	assert.Empty(t, errorsOf(t, resp), "clients have separate budgets")
+
+	resp, err = c.RawPost(`{ GetPosts { id } }`, as("1.1.1.1"))
+	require.NoError(t, err)
+	assert.Empty(t, errorsOf(t, resp), "queries are not limited")
+
+	resp, err = c.RawPost(`mutation {
+		a: CreateComment(input: {postID: "1", author: "Bob", content: "a"}) { id }
+		b: CreateComment(input: {postID: "1", author: "Bob", content: "b"}) { id }
+	}`, as("1.1.1.1"))
+	require.NoError(t, err)
+
+	errs = errorsOf(t, resp)
+	require.Len(t, errs, 1)
+	assert.Equal(t, "RATE_LIMITED", errs[0].Extensions["code"])
+}
+
+func TestRateLimit_Subscriptions(t *testing.T) {
+	r := RateLimit{
+		Subscriptions: ratelimit.NewConcurrency(1),
+		Key:           func(context.Context) string { return "1.1.1.1" },
+	}
+
+	subscribe := func(ctx context.Context) graphql.ResponseHandler {
+		ctx = graphql.WithOperationContext(ctx, &graphql.OperationContext{
+			Operation: &ast.OperationDefinition{Operation: ast.Subscription},
+		})
+
+		sent := false
+
+		return r.InterceptOperation(ctx, func(context.Context) graphql.ResponseHandler {
+			return func(context.Context) *graphql.Response {
+				if sent {
+					return nil
+				}
+				sent = true
+				return &graphql.Response{Data: json.RawMessage(`{}`)}
+			}
+		})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := subscribe(ctx)
+	require.Empty(t, first(ctx).Errors)
+
+	resp := subscribe(context.Background())(context.Background())
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, apperr.CodeRateLimited, resp.Errors[0].Extensions["code"])
+
+	cancel()
+
+	assert.Eventually(t, func() bool {
+		return len(subscribe(context.Background())(context.Background()).Errors) == 0
+	}, time.Second, 10*time.Millisecond, "stopped subscriptions release their slot")
+
+	second := subscribe(context.Background())
+	assert.Len(t, second(context.Background()).Errors, 1, "the subscription above still holds the slot")
+}
@@ -1,6 +1,9 @@
 package graph
 
 import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
 	"github.com/erknas/forum/internal/service"
 	"github.com/erknas/forum/internal/subscription"
 )
@@ -10,6 +13,22 @@ import (
 // It serves as dependency injection for your app, add any dependencies you require here.
 
 type Resolver struct {
-	Svc service.Servicer
-	Sub subscription.Subscriber
+	Svc   service.Servicer
+	Sub   subscription.Subscriber
+	Posts subscription.PostSubscriber
+	Polls subscription.PollSubscriber
+}
+
+// legacyCommentsPage returns the deprecated page and pageSize arguments of
+// GetPostByID if the post whose comments are resolved was requested by it.
+func legacyCommentsPage(ctx context.Context) (page *int32, pageSize *int32) {
+	fc := graphql.GetFieldContext(ctx)
+	if fc == nil || fc.Parent == nil || fc.Parent.Field.Field == nil || fc.Parent.Field.Name != "GetPostByID" {
+		return nil, nil
+	}
+
+	page, _ = fc.Parent.Args["page"].(*int32)
+	pageSize, _ = fc.Parent.Args["pageSize"].(*int32)
+
+	return page, pageSize
 }
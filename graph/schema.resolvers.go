@@ -6,10 +6,79 @@ package graph
 
 import (
 	"context"
+	"time"
 
 	"github.com/erknas/forum/graph/model"
+	"github.com/erknas/forum/internal/subscription"
+	"github.com/erknas/forum/pkg/apperr"
+	"github.com/erknas/forum/pkg/conv"
 )
 
+// PostCount is the resolver for the postCount field.
+func (r *authorResolver) PostCount(ctx context.Context, obj *model.Author) (int32, error) {
+	stats, err := r.Svc.AuthorStats(ctx, obj.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	return int32(stats.PostCount), nil
+}
+
+// CommentCount is the resolver for the commentCount field.
+func (r *authorResolver) CommentCount(ctx context.Context, obj *model.Author) (int32, error) {
+	stats, err := r.Svc.AuthorStats(ctx, obj.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	return int32(stats.CommentCount), nil
+}
+
+// Posts is the resolver for the posts field.
+func (r *authorResolver) Posts(ctx context.Context, obj *model.Author, first *int32, after *string) (*model.PostConnection, error) {
+	posts, err := r.Svc.PostsByAuthor(ctx, obj.ID, first, after)
+	if err != nil {
+		return nil, err
+	}
+
+	return posts, nil
+}
+
+// Comments is the resolver for the comments field.
+func (r *authorResolver) Comments(ctx context.Context, obj *model.Author, first *int32, after *string) (*model.CommentConnection, error) {
+	comments, err := r.Svc.CommentsByAuthor(ctx, obj.ID, first, after)
+	if err != nil {
+		return nil, err
+	}
+
+	return comments, nil
+}
+
+// Revisions is the resolver for the revisions field.
+func (r *commentResolver) Revisions(ctx context.Context, obj *model.Comment) ([]*model.Revision, error) {
+	return r.Svc.CommentRevisions(ctx, obj.ID)
+}
+
+// Attachments is the resolver for the attachments field.
+func (r *commentResolver) Attachments(ctx context.Context, obj *model.Comment) ([]*model.Attachment, error) {
+	return r.Svc.CommentAttachments(ctx, obj.ID)
+}
+
+// Post is the resolver for the post field.
+func (r *commentResolver) Post(ctx context.Context, obj *model.Comment) (*model.Post, error) {
+	post, err := r.Svc.PostByID(ctx, obj.PostID)
+	if err != nil {
+		return nil, err
+	}
+
+	return post, nil
+}
+
+// Replies is the resolver for the replies field.
+func (r *commentResolver) Replies(ctx context.Context, obj *model.Comment) ([]*model.Comment, error) {
+	return r.Svc.Replies(ctx, obj.ID)
+}
+
 // CreatePost is the resolver for the CreatePost field.
 func (r *mutationResolver) CreatePost(ctx context.Context, input model.PostInput) (*model.Post, error) {
 	post, err := r.Svc.CreatePost(ctx, input)
@@ -30,6 +99,90 @@ func (r *mutationResolver) CreateComment(ctx context.Context, input model.Commen
 	return comment, nil
 }
 
+// EditPost is the resolver for the EditPost field.
+func (r *mutationResolver) EditPost(ctx context.Context, input model.PostEditInput) (*model.Post, error) {
+	return r.Svc.EditPost(ctx, input)
+}
+
+// EditComment is the resolver for the EditComment field.
+func (r *mutationResolver) EditComment(ctx context.Context, input model.CommentEditInput) (*model.Comment, error) {
+	return r.Svc.EditComment(ctx, input)
+}
+
+// PublishPost is the resolver for the PublishPost field.
+func (r *mutationResolver) PublishPost(ctx context.Context, id string, publishAt *time.Time) (*model.Post, error) {
+	return r.Svc.PublishPost(ctx, id, publishAt)
+}
+
+// PinPost is the resolver for the PinPost field.
+func (r *mutationResolver) PinPost(ctx context.Context, id string, until *time.Time) (*model.Post, error) {
+	return r.Svc.PinPost(ctx, id, until)
+}
+
+// UnpinPost is the resolver for the UnpinPost field.
+func (r *mutationResolver) UnpinPost(ctx context.Context, id string) (*model.Post, error) {
+	return r.Svc.UnpinPost(ctx, id)
+}
+
+// VotePoll is the resolver for the VotePoll field.
+func (r *mutationResolver) VotePoll(ctx context.Context, pollID string, optionIds []string) (*model.Poll, error) {
+	return r.Svc.VotePoll(ctx, pollID, optionIds)
+}
+
+// ApproveContent is the resolver for the ApproveContent field.
+func (r *mutationResolver) ApproveContent(ctx context.Context, id string) (model.Content, error) {
+	return r.Svc.ApproveContent(ctx, id)
+}
+
+// RejectContent is the resolver for the RejectContent field.
+func (r *mutationResolver) RejectContent(ctx context.Context, id string, reason string) (model.Content, error) {
+	return r.Svc.RejectContent(ctx, id, reason)
+}
+
+// Report is the resolver for the Report field.
+func (r *mutationResolver) Report(ctx context.Context, targetID string, reason model.ReportReason, details *string) (*model.Report, error) {
+	return r.Svc.Report(ctx, targetID, reason, details)
+}
+
+// ResolveReports is the resolver for the ResolveReports field.
+func (r *mutationResolver) ResolveReports(ctx context.Context, targetID string, action model.ReportAction) (*model.ReportGroup, error) {
+	return r.Svc.ResolveReports(ctx, targetID, action)
+}
+
+// BanUser is the resolver for the BanUser field.
+func (r *mutationResolver) BanUser(ctx context.Context, author string, kind model.BanKind, reason string, expiresAt *time.Time) (*model.Ban, error) {
+	return r.Svc.BanUser(ctx, author, kind, reason, expiresAt)
+}
+
+// UnbanUser is the resolver for the UnbanUser field.
+func (r *mutationResolver) UnbanUser(ctx context.Context, author string) (*model.Ban, error) {
+	return r.Svc.UnbanUser(ctx, author)
+}
+
+// Revisions is the resolver for the revisions field.
+func (r *postResolver) Revisions(ctx context.Context, obj *model.Post) ([]*model.Revision, error) {
+	return r.Svc.PostRevisions(ctx, obj.ID)
+}
+
+// Poll is the resolver for the poll field.
+func (r *postResolver) Poll(ctx context.Context, obj *model.Post) (*model.Poll, error) {
+	return r.Svc.PostPoll(ctx, obj.ID)
+}
+
+// Attachments is the resolver for the attachments field.
+func (r *postResolver) Attachments(ctx context.Context, obj *model.Post) ([]*model.Attachment, error) {
+	return r.Svc.PostAttachments(ctx, obj.ID)
+}
+
+// Comments is the resolver for the comments field.
+func (r *postResolver) Comments(ctx context.Context, obj *model.Post, page *int32, pageSize *int32) ([]*model.Comment, error) {
+	if page == nil && pageSize == nil {
+		page, pageSize = legacyCommentsPage(ctx)
+	}
+
+	return r.Svc.CommentsByPost(ctx, obj.ID, page, pageSize)
+}
+
 // GetPosts is the resolver for the GetPosts field.
 func (r *queryResolver) GetPosts(ctx context.Context) ([]*model.Post, error) {
 	posts, err := r.Svc.Posts(ctx)
@@ -42,42 +195,142 @@ func (r *queryResolver) GetPosts(ctx context.Context) ([]*model.Post, error) {
 
 // GetPostByID is the resolver for the GetPostByID field.
 func (r *queryResolver) GetPostByID(ctx context.Context, id string, page *int32, pageSize *int32) (*model.Post, error) {
-	post, err := r.Svc.PostByID(ctx, id)
+	// page and pageSize are read by the comments resolver of the post.
+	return r.Svc.PostByID(ctx, id)
+}
+
+// Author is the resolver for the Author field.
+func (r *queryResolver) Author(ctx context.Context, id *string, name *string) (*model.Author, error) {
+	switch {
+	case id != nil:
+		return r.Svc.AuthorByID(ctx, *id)
+	case name != nil:
+		return r.Svc.AuthorByName(ctx, *name)
+	default:
+		return nil, apperr.New(apperr.CodeValidation, "either id or name must be provided")
+	}
+}
+
+// Node is the resolver for the node field.
+func (r *queryResolver) Node(ctx context.Context, id string) (model.Node, error) {
+	return r.Svc.Node(ctx, id)
+}
+
+// Nodes is the resolver for the nodes field.
+func (r *queryResolver) Nodes(ctx context.Context, ids []string) ([]model.Node, error) {
+	return r.Svc.Nodes(ctx, ids)
+}
+
+// ModerationQueue is the resolver for the ModerationQueue field.
+func (r *queryResolver) ModerationQueue(ctx context.Context, first *int32, after *string) (*model.ModerationConnection, error) {
+	return r.Svc.ModerationQueue(ctx, first, after)
+}
+
+// Reports is the resolver for the Reports field.
+func (r *queryResolver) Reports(ctx context.Context, first *int32, after *string) (*model.ReportGroupConnection, error) {
+	return r.Svc.Reports(ctx, first, after)
+}
+
+// AuditLog is the resolver for the AuditLog field.
+func (r *queryResolver) AuditLog(ctx context.Context, filter *model.AuditLogFilter, first *int32, after *string) (*model.AuditEntryConnection, error) {
+	return r.Svc.AuditLog(ctx, filter, first, after)
+}
+
+// Bans is the resolver for the Bans field.
+func (r *queryResolver) Bans(ctx context.Context, first *int32, after *string) (*model.BanConnection, error) {
+	return r.Svc.Bans(ctx, first, after)
+}
+
+// MyDrafts is the resolver for the MyDrafts field.
+func (r *queryResolver) MyDrafts(ctx context.Context, first *int32, after *string) (*model.PostConnection, error) {
+	return r.Svc.MyDrafts(ctx, first, after)
+}
+
+// CommentAdded is the resolver for the CommentAdded field.
+func (r *subscriptionResolver) CommentAdded(ctx context.Context, postID string) (<-chan *model.Comment, error) {
+	id, err := conv.NodeID(conv.TypePost, postID)
 	if err != nil {
 		return nil, err
 	}
 
-	comments, err := r.Svc.CommentsByPost(ctx, id, page, pageSize)
-	if err != nil {
+	if err := r.Svc.CheckSubscriber(ctx); err != nil {
 		return nil, err
 	}
 
-	post.Comments = comments
+	// Comments are published under the global ID of their post.
+	topic := conv.GlobalID(conv.TypePost, id)
 
-	return post, nil
+	ch := r.Sub.Subscribe(topic)
+
+	go func() {
+		<-ctx.Done()
+		r.Sub.Unsubscribe(topic, ch)
+	}()
+
+	return ch, nil
 }
 
-// CommentAdded is the resolver for the CommentAdded field.
-func (r *subscriptionResolver) CommentAdded(ctx context.Context, postID string) (<-chan *model.Comment, error) {
-	ch := r.Sub.Subscribe(postID)
+// PostAdded is the resolver for the PostAdded field.
+func (r *subscriptionResolver) PostAdded(ctx context.Context) (<-chan *model.Post, error) {
+	if err := r.Svc.CheckSubscriber(ctx); err != nil {
+		return nil, err
+	}
+
+	ch := r.Posts.Subscribe(subscription.PostsTopic)
+
+	go func() {
+		<-ctx.Done()
+		r.Posts.Unsubscribe(subscription.PostsTopic, ch)
+	}()
+
+	return ch, nil
+}
+
+// PollUpdated is the resolver for the PollUpdated field.
+func (r *subscriptionResolver) PollUpdated(ctx context.Context, pollID string) (<-chan *model.Poll, error) {
+	id, err := conv.NodeID(conv.TypePoll, pollID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.Svc.CheckSubscriber(ctx); err != nil {
+		return nil, err
+	}
+
+	// Results are published under the global ID of the poll.
+	topic := conv.GlobalID(conv.TypePoll, id)
+
+	ch := r.Polls.Subscribe(topic)
 
 	go func() {
 		<-ctx.Done()
-		r.Sub.Unsubscribe(postID, ch)
+		r.Polls.Unsubscribe(topic, ch)
 	}()
 
 	return ch, nil
 }
 
+// Author returns AuthorResolver implementation.
+func (r *Resolver) Author() AuthorResolver { return &authorResolver{r} }
+
+// Comment returns CommentResolver implementation.
+func (r *Resolver) Comment() CommentResolver { return &commentResolver{r} }
+
 // Mutation returns MutationResolver implementation.
 func (r *Resolver) Mutation() MutationResolver { return &mutationResolver{r} }
 
+// Post returns PostResolver implementation.
+func (r *Resolver) Post() PostResolver { return &postResolver{r} }
+
 // Query returns QueryResolver implementation.
 func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
 
 // Subscription returns SubscriptionResolver implementation.
 func (r *Resolver) Subscription() SubscriptionResolver { return &subscriptionResolver{r} }
 
+type authorResolver struct{ *Resolver }
+type commentResolver struct{ *Resolver }
 type mutationResolver struct{ *Resolver }
+type postResolver struct{ *Resolver }
 type queryResolver struct{ *Resolver }
 type subscriptionResolver struct{ *Resolver }
@@ -0,0 +1,183 @@
+// Package auth identifies the user behind a request by a signed bearer token.
+// Requests without a token are anonymous.
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+)
+
+type Role string
+
+const (
+	RoleUser      Role = "user"
+	RoleModerator Role = "moderator"
+	RoleAdmin     Role = "admin"
+)
+
+func (r Role) Valid() bool {
+	switch r {
+	case RoleUser, RoleModerator, RoleAdmin:
+		return true
+	default:
+		return false
+	}
+}
+
+// Viewer is the signed-in user. Name is the author name the user posts as.
+type Viewer struct {
+	Name string `json:"sub"`
+	Role Role   `json:"role"`
+}
+
+func (v *Viewer) IsModerator() bool {
+	return v != nil && (v.Role == RoleModerator || v.Role == RoleAdmin)
+}
+
+func (v *Viewer) IsAdmin() bool {
+	return v != nil && v.Role == RoleAdmin
+}
+
+var ErrInvalidToken = errors.New("invalid token")
+
+type claims struct {
+	Viewer
+	ExpiresAt int64 `json:"exp"`
+}
+
+// Signer issues and verifies tokens of the form payload.signature, where the
+// payload is base64url-encoded JSON and the signature its HMAC-SHA256.
+type Signer struct {
+	secret []byte
+	now    func() time.Time
+}
+
+func NewSigner(secret string) *Signer {
+	return &Signer{
+		secret: []byte(secret),
+		now:    time.Now,
+	}
+}
+
+func (s *Signer) Issue(viewer Viewer, ttl time.Duration) (string, error) {
+	if viewer.Name == "" || !viewer.Role.Valid() {
+		return "", fmt.Errorf("invalid viewer %q with role %q", viewer.Name, viewer.Role)
+	}
+
+	payload, err := json.Marshal(claims{Viewer: viewer, ExpiresAt: s.now().Add(ttl).Unix()})
+	if err != nil {
+		return "", err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+
+	return encoded + "." + s.sign(encoded), nil
+}
+
+func (s *Signer) Verify(token string) (*Viewer, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(s.sign(encoded))) {
+		return nil, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	c := claims{}
+	if err := json.Unmarshal(payload, &c); err != nil || c.Name == "" || !c.Role.Valid() {
+		return nil, ErrInvalidToken
+	}
+
+	if s.now().Unix() >= c.ExpiresAt {
+		return nil, ErrInvalidToken
+	}
+
+	return &c.Viewer, nil
+}
+
+func (s *Signer) sign(encoded string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Middleware attaches the viewer of the bearer token in the Authorization
+// header to the request context. Requests with an invalid token are refused.
+func Middleware(signer *Signer, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		if header == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		viewer, err := verifyHeader(signer, header)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(With(r.Context(), viewer)))
+	})
+}
+
+// WebsocketInit reads the bearer token from the authorization field of the
+// connection_init payload, since browsers cannot set headers on websockets.
+func WebsocketInit(signer *Signer) transport.WebsocketInitFunc {
+	return func(ctx context.Context, payload transport.InitPayload) (context.Context, *transport.InitPayload, error) {
+		header := payload.Authorization()
+		if header == "" {
+			return ctx, nil, nil
+		}
+
+		viewer, err := verifyHeader(signer, header)
+		if err != nil {
+			return ctx, nil, err
+		}
+
+		return With(ctx, viewer), nil, nil
+	}
+}
+
+func verifyHeader(signer *Signer, header string) (*Viewer, error) {
+	token, ok := strings.CutPrefix(header, "Bearer ")
+	if !ok || signer == nil {
+		return nil, ErrInvalidToken
+	}
+
+	return signer.Verify(token)
+}
+
+type ctxKey struct{}
+
+func With(ctx context.Context, viewer *Viewer) context.Context {
+	return context.WithValue(ctx, ctxKey{}, viewer)
+}
+
+// From returns the viewer attached to ctx, or nil for anonymous requests.
+func From(ctx context.Context) *Viewer {
+	viewer, _ := ctx.Value(ctxKey{}).(*Viewer)
+	return viewer
+}
+
+// Name returns the name of the viewer attached to ctx, or "" for anonymous
+// requests.
+func Name(ctx context.Context) string {
+	if viewer := From(ctx); viewer != nil {
+		return viewer.Name
+	}
+	return ""
+}
@@ -0,0 +1,108 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSigner(t *testing.T) {
+	signer := NewSigner("secret")
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	signer.now = func() time.Time { return now }
+
+	token, err := signer.Issue(Viewer{Name: "Bob", Role: RoleModerator}, time.Hour)
+	require.NoError(t, err)
+
+	viewer, err := signer.Verify(token)
+	require.NoError(t, err)
+	assert.Equal(t, &Viewer{Name: "Bob", Role: RoleModerator}, viewer)
+	assert.True(t, viewer.IsModerator())
+	assert.False(t, viewer.IsAdmin())
+
+	_, err = NewSigner("other").Verify(token)
+	assert.ErrorIs(t, err, ErrInvalidToken, "another secret")
+
+	_, err = signer.Verify(token[1:])
+	assert.ErrorIs(t, err, ErrInvalidToken, "tampered payload")
+
+	_, err = signer.Verify("garbage")
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	now = now.Add(time.Hour)
+	_, err = signer.Verify(token)
+	assert.ErrorIs(t, err, ErrInvalidToken, "expired")
+
+	_, err = signer.Issue(Viewer{Name: "Bob", Role: "root"}, time.Hour)
+	assert.Error(t, err)
+}
+
+func TestMiddleware(t *testing.T) {
+	signer := NewSigner("secret")
+
+	token, err := signer.Issue(Viewer{Name: "Bob", Role: RoleUser}, time.Hour)
+	require.NoError(t, err)
+
+	var got *Viewer
+	handler := Middleware(signer, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = From(r.Context())
+	}))
+
+	tests := []struct {
+		name       string
+		header     string
+		wantStatus int
+		want       *Viewer
+	}{
+		{name: "Anonymous", wantStatus: http.StatusOK},
+		{name: "Valid token", header: "Bearer " + token, wantStatus: http.StatusOK, want: &Viewer{Name: "Bob", Role: RoleUser}},
+		{name: "Invalid token", header: "Bearer " + token + "x", wantStatus: http.StatusUnauthorized},
+		{name: "Other scheme", header: "Basic Ym9iOnB3", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got = nil
+
+			r := httptest.NewRequest(http.MethodPost, "/query", nil)
+			if tt.header != "" {
+				r.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, r)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestWebsocketInit(t *testing.T) {
+	signer := NewSigner("secret")
+
+	token, err := signer.Issue(Viewer{Name: "Bob", Role: RoleAdmin}, time.Hour)
+	require.NoError(t, err)
+
+	init := WebsocketInit(signer)
+
+	ctx, _, err := init(context.Background(), transport.InitPayload{"Authorization": "Bearer " + token})
+	require.NoError(t, err)
+	assert.True(t, From(ctx).IsAdmin())
+
+	ctx, _, err = init(context.Background(), transport.InitPayload{})
+	require.NoError(t, err)
+	assert.Nil(t, From(ctx))
+
+	_, _, err = init(context.Background(), transport.InitPayload{"Authorization": "Bearer x.y"})
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	_, _, err = WebsocketInit(nil)(context.Background(), transport.InitPayload{"Authorization": "Bearer " + token})
+	assert.ErrorIs(t, err, ErrInvalidToken, "without a secret")
+}
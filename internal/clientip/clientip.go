@@ -0,0 +1,49 @@
+// Package clientip attaches the address of the client to request contexts.
+package clientip
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+)
+
+type ctxKey struct{}
+
+// Middleware attaches the client IP to the request context. With trustProxy
+// the last address of X-Forwarded-For is used, which is the one the proxy
+// appended: the addresses before it come from the client and can be forged.
+// It must only be enabled behind a single proxy that sets the header.
+func Middleware(trustProxy bool, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(With(r.Context(), FromRequest(r, trustProxy))))
+	})
+}
+
+func FromRequest(r *http.Request, trustProxy bool) string {
+	if trustProxy {
+		if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+			last := forwarded[len(forwarded)-1]
+			if ip := strings.TrimSpace(last[strings.LastIndex(last, ",")+1:]); ip != "" {
+				return ip
+			}
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+func With(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, ip)
+}
+
+// From returns the client IP attached to ctx, or an empty string.
+func From(ctx context.Context) string {
+	ip, _ := ctx.Value(ctxKey{}).(string)
+	return ip
+}
@@ -0,0 +1,93 @@
+package clientip
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromRequest(t *testing.T) {
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		trustProxy bool
+		want       string
+	}{
+		{
+			name:       "Remote address",
+			remoteAddr: "10.0.0.1:5432",
+			want:       "10.0.0.1",
+		},
+		{
+			name:       "Forwarded header is ignored without a trusted proxy",
+			remoteAddr: "10.0.0.1:5432",
+			forwarded:  "203.0.113.7",
+			want:       "10.0.0.1",
+		},
+		{
+			name:       "Forwarded address behind a trusted proxy",
+			remoteAddr: "10.0.0.1:5432",
+			forwarded:  "203.0.113.7",
+			trustProxy: true,
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "Spoofed forwarded addresses are ignored",
+			remoteAddr: "10.0.0.1:5432",
+			forwarded:  "198.51.100.1, 198.51.100.2, 203.0.113.7",
+			trustProxy: true,
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "Empty forwarded address",
+			remoteAddr: "10.0.0.1:5432",
+			forwarded:  "203.0.113.7, ",
+			trustProxy: true,
+			want:       "10.0.0.1",
+		},
+		{
+			name:       "IPv6 remote address",
+			remoteAddr: "[::1]:5432",
+			trustProxy: true,
+			want:       "::1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/query", nil)
+			r.RemoteAddr = tt.remoteAddr
+			if tt.forwarded != "" {
+				r.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+
+			assert.Equal(t, tt.want, FromRequest(r, tt.trustProxy))
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	var got string
+
+	h := Middleware(false, http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		got = From(r.Context())
+	}))
+
+	r := httptest.NewRequest(http.MethodPost, "/query", nil)
+	r.RemoteAddr = "10.0.0.1:5432"
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	assert.Equal(t, "10.0.0.1", got)
+}
+
+func TestFromRequest_SeveralHeaders(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/query", nil)
+	r.RemoteAddr = "10.0.0.1:5432"
+	r.Header.Add("X-Forwarded-For", "203.0.113.7")
+	r.Header.Add("X-Forwarded-For", "198.51.100.1, 198.51.100.2")
+
+	assert.Equal(t, "198.51.100.2", FromRequest(r, true), "the proxy appends to the last header")
+}
@@ -2,14 +2,36 @@ package config
 
 import (
 	"log"
+	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
 	"github.com/joho/godotenv"
 )
 
+const (
+	StorageMemory   = "memory"
+	StoragePostgres = "postgres"
+	StorageSQLite   = "sqlite"
+)
+
 type Config struct {
-	Addr string `env:"ADDR"`
+	Addr    string `env:"ADDR"`
+	Storage string `env:"STORAGE" env-default:"memory"`
 	PostgresConfig
+	SQLiteConfig
+	MemoryConfig
+	OutboxConfig
+	SchedulerConfig
+	GraphQLConfig
+	PersistedQueryConfig
+	RateLimitConfig
+	FilterConfig
+	AuthConfig
+	ReportConfig
+	BanConfig
+	BlobConfig
+	AttachmentConfig
+	FeedConfig
 }
 
 type PostgresConfig struct {
@@ -22,6 +44,127 @@ type PostgresConfig struct {
 	MigrationPath string `env:"MIGRATIONS_PATH"`
 }
 
+type SQLiteConfig struct {
+	Path          string `env:"SQLITE_PATH" env-default:"forum.db"`
+	MigrationPath string `env:"SQLITE_MIGRATIONS_PATH" env-default:"file://migrations/sqlite"`
+}
+
+// MemoryConfig enables persistence of the in-memory storage when DataDir is
+// set. Fsync is one of always, interval or never.
+type MemoryConfig struct {
+	DataDir          string        `env:"MEMORY_DATA_DIR"`
+	Fsync            string        `env:"MEMORY_FSYNC" env-default:"always"`
+	FsyncInterval    time.Duration `env:"MEMORY_FSYNC_INTERVAL" env-default:"1s"`
+	SnapshotInterval time.Duration `env:"MEMORY_SNAPSHOT_INTERVAL" env-default:"5m"`
+}
+
+type OutboxConfig struct {
+	PollInterval time.Duration `env:"OUTBOX_POLL_INTERVAL" env-default:"1s"`
+	WebhookURLs  []string      `env:"WEBHOOK_URLS" env-separator:","`
+}
+
+// SchedulerConfig sets how often scheduled posts that are due are published.
+type SchedulerConfig struct {
+	SchedulerInterval time.Duration `env:"SCHEDULER_INTERVAL" env-default:"10s"`
+}
+
+// GraphQLConfig limits what a single operation may request. A zero limit is
+// not enforced.
+type GraphQLConfig struct {
+	ComplexityLimit int  `env:"GRAPHQL_COMPLEXITY_LIMIT" env-default:"5000"`
+	MaxDepth        int  `env:"GRAPHQL_MAX_DEPTH" env-default:"10"`
+	Introspection   bool `env:"GRAPHQL_INTROSPECTION" env-default:"true"`
+}
+
+// PersistedQueryConfig configures automatic persisted queries and the
+// safelist. A zero APQCacheSize disables automatic persisted queries. With
+// SafelistOnly only operations from the safelist at SafelistPath are run.
+type PersistedQueryConfig struct {
+	APQCacheSize int    `env:"APQ_CACHE_SIZE" env-default:"1000"`
+	SafelistPath string `env:"SAFELIST_PATH"`
+	SafelistOnly bool   `env:"SAFELIST_ONLY" env-default:"false"`
+}
+
+// RateLimitConfig sets the budgets of a single client. Its bucket of posts or
+// comments holds up to Burst tokens and gets a new one every Interval. A zero
+// burst or MaxSubscriptions is not enforced.
+type RateLimitConfig struct {
+	PostBurst        int           `env:"RATE_LIMIT_POST_BURST" env-default:"5"`
+	PostInterval     time.Duration `env:"RATE_LIMIT_POST_INTERVAL" env-default:"1m"`
+	CommentBurst     int           `env:"RATE_LIMIT_COMMENT_BURST" env-default:"10"`
+	CommentInterval  time.Duration `env:"RATE_LIMIT_COMMENT_INTERVAL" env-default:"10s"`
+	MaxSubscriptions int           `env:"RATE_LIMIT_SUBSCRIPTIONS" env-default:"10"`
+	TrustProxy       bool          `env:"RATE_LIMIT_TRUST_PROXY" env-default:"false"`
+}
+
+// FilterConfig configures the checks of new posts and comments. Content with
+// more than NewAccountMaxLinks links from an account younger than
+// NewAccountAge is held for moderators, as is content the spam classifier
+// rates at least SpamThreshold once it has SpamMinSamples examples of spam and
+// of regular content. A zero DuplicateWindow disables duplicate detection.
+type FilterConfig struct {
+	BannedWords        []string      `env:"FILTER_BANNED_WORDS" env-separator:","`
+	NewAccountAge      time.Duration `env:"FILTER_NEW_ACCOUNT_AGE" env-default:"24h"`
+	NewAccountMaxLinks int           `env:"FILTER_NEW_ACCOUNT_MAX_LINKS" env-default:"1"`
+	DuplicateWindow    time.Duration `env:"FILTER_DUPLICATE_WINDOW" env-default:"10m"`
+	SpamThreshold      float64       `env:"FILTER_SPAM_THRESHOLD" env-default:"0.9"`
+	SpamMinSamples     int           `env:"FILTER_SPAM_MIN_SAMPLES" env-default:"20"`
+}
+
+// AuthConfig holds the secret bearer tokens are signed with. Without it every
+// request is anonymous and moderation is unavailable.
+type AuthConfig struct {
+	Secret string `env:"AUTH_SECRET"`
+}
+
+// ReportConfig sets how many open reports hide published content until a
+// moderator resolves them. Zero never hides content.
+type ReportConfig struct {
+	HideThreshold int `env:"REPORT_HIDE_THRESHOLD" env-default:"3"`
+}
+
+// BanConfig sets whether anonymous posts and comments are rejected while any
+// ban is in force. Bans only apply to the name they were issued for, so a
+// banned user can otherwise keep posting anonymously under another name.
+type BanConfig struct {
+	SignInWhileBanned bool `env:"BAN_REQUIRE_SIGN_IN" env-default:"false"`
+}
+
+// BlobConfig sets where attachments are kept: in Dir, or in an S3-compatible
+// bucket addressed by path when S3Endpoint is set.
+type BlobConfig struct {
+	Dir         string `env:"BLOB_DIR" env-default:"attachments"`
+	S3Endpoint  string `env:"S3_ENDPOINT"`
+	S3Bucket    string `env:"S3_BUCKET"`
+	S3Region    string `env:"S3_REGION" env-default:"us-east-1"`
+	S3AccessKey string `env:"S3_ACCESS_KEY"`
+	S3SecretKey string `env:"S3_SECRET_KEY"`
+}
+
+// AttachmentConfig limits the files attached to a post or comment to MaxCount
+// files of at most MaxSize bytes each, whose type detected from their content
+// is one of Types. Thumbnails of images fit in ThumbnailSize pixels. Blobs
+// are linked under URL. A zero MaxCount disables attachments.
+type AttachmentConfig struct {
+	MaxCount      int      `env:"ATTACHMENT_MAX_COUNT" env-default:"5"`
+	MaxSize       int64    `env:"ATTACHMENT_MAX_SIZE" env-default:"10485760"`
+	Types         []string `env:"ATTACHMENT_TYPES" env-separator:"," env-default:"image/png,image/jpeg,image/gif,application/pdf,text/plain"`
+	ThumbnailSize int      `env:"ATTACHMENT_THUMBNAIL_SIZE" env-default:"320"`
+	URL           string   `env:"ATTACHMENT_URL" env-default:"/attachments/"`
+}
+
+// FeedConfig sets the number of Entries in Atom feeds, and the BaseURL of the
+// links in them. Without BaseURL links point at the host the feed was
+// requested from, which is wrong behind a proxy that rewrites it. A client
+// may fetch Burst feeds at once and one more every Interval; a zero Burst is
+// not enforced.
+type FeedConfig struct {
+	BaseURL  string        `env:"FEED_BASE_URL"`
+	Entries  int           `env:"FEED_ENTRIES" env-default:"50"`
+	Burst    int           `env:"FEED_RATE_LIMIT_BURST" env-default:"30"`
+	Interval time.Duration `env:"FEED_RATE_LIMIT_INTERVAL" env-default:"2s"`
+}
+
 func Load() *Config {
 	if err := godotenv.Load(); err != nil {
 		log.Fatalf("failed to load .env file: %s", err)
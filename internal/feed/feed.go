@@ -0,0 +1,483 @@
+// Package feed serves Atom feeds of the latest posts and of the comments of a
+// post, for feed readers.
+package feed
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"hash"
+	"html"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/erknas/forum/graph/model"
+	"github.com/erknas/forum/internal/service"
+	"github.com/erknas/forum/pkg/apperr"
+	"github.com/erknas/forum/pkg/conv"
+	"github.com/erknas/forum/pkg/sl"
+)
+
+const contentType = "application/atom+xml; charset=utf-8"
+
+// Options sets the number of Entries in a feed, and the BaseURL of its links.
+// Without BaseURL links point at the host the feed was requested from.
+type Options struct {
+	BaseURL string
+	Entries int
+}
+
+type Feeds struct {
+	svc     service.Servicer
+	baseURL string
+	entries int
+}
+
+func New(svc service.Servicer, opts Options) *Feeds {
+	return &Feeds{
+		svc:     svc,
+		baseURL: strings.TrimSuffix(opts.BaseURL, "/"),
+		entries: opts.Entries,
+	}
+}
+
+// Posts serves the feed of the latest published posts, newest first.
+func (f *Feeds) Posts(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	base := f.base(r)
+
+	posts, err := f.svc.LatestPosts(ctx, f.entries)
+	if err != nil {
+		f.fail(w, err)
+		return
+	}
+
+	feed := atomFeed{
+		ID:    base + "/feed.atom",
+		Title: "Forum",
+		Links: []atomLink{{Rel: "self", Type: "application/atom+xml", Href: base + "/feed.atom"}},
+	}
+
+	ids := make([]string, 0, len(posts))
+	tag := newVersion(feed.ID, feed.Title)
+
+	for _, post := range posts {
+		ids = append(ids, post.ID)
+		feed.Updated = latest(feed.Updated, updated(post.CreatedAt, post.EditedAt))
+		tag.add(post.ID, updated(post.CreatedAt, post.EditedAt))
+	}
+
+	etag := tag.etag()
+	if notModified(w, r, etag, feed.Updated) {
+		return
+	}
+
+	attachments, err := loadAttachments(ctx, ids, f.svc.PostAttachments)
+	if err != nil {
+		f.fail(w, err)
+		return
+	}
+
+	for i, post := range posts {
+		_, id, err := conv.FromGlobalID(post.ID)
+		if err != nil {
+			f.fail(w, err)
+			return
+		}
+
+		entry := newEntry(base+"/posts/"+strconv.Itoa(id), post.Title, post.Author, post.Content, post.CreatedAt, post.EditedAt, attachments[i], base)
+		entry.Links = []atomLink{{Rel: "replies", Type: "application/atom+xml", Href: commentsURL(base, id)}}
+
+		feed.add(entry)
+	}
+
+	f.serve(w, r, feed, etag)
+}
+
+// Comments serves the feed of the comments of a post, replies included,
+// newest first.
+func (f *Feeds) Comments(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	base := f.base(r)
+
+	post, err := f.svc.PostByID(ctx, r.PathValue("id"))
+	if err != nil {
+		f.fail(w, err)
+		return
+	}
+
+	_, postID, err := conv.FromGlobalID(post.ID)
+	if err != nil {
+		f.fail(w, err)
+		return
+	}
+
+	comments, err := f.svc.LatestComments(ctx, post.ID, f.entries)
+	if err != nil {
+		f.fail(w, err)
+		return
+	}
+
+	postEntryID := base + "/posts/" + strconv.Itoa(postID)
+
+	feed := atomFeed{
+		ID:      commentsURL(base, postID),
+		Title:   "Comments on " + post.Title,
+		Updated: updated(post.CreatedAt, post.EditedAt),
+		Links:   []atomLink{{Rel: "self", Type: "application/atom+xml", Href: commentsURL(base, postID)}},
+	}
+
+	ids := make([]string, 0, len(comments))
+	tag := newVersion(feed.ID, feed.Title)
+	tag.add(post.ID, feed.Updated)
+
+	for _, comment := range comments {
+		ids = append(ids, comment.ID)
+		feed.Updated = latest(feed.Updated, updated(comment.CreatedAt, comment.EditedAt))
+		tag.add(comment.ID, updated(comment.CreatedAt, comment.EditedAt))
+	}
+
+	etag := tag.etag()
+	if notModified(w, r, etag, feed.Updated) {
+		return
+	}
+
+	attachments, err := loadAttachments(ctx, ids, f.svc.CommentAttachments)
+	if err != nil {
+		f.fail(w, err)
+		return
+	}
+
+	for i, comment := range comments {
+		_, id, err := conv.FromGlobalID(comment.ID)
+		if err != nil {
+			f.fail(w, err)
+			return
+		}
+
+		title := "Comment by "
+		if comment.ParentID != nil {
+			title = "Reply by "
+		}
+
+		entry := newEntry(postEntryID+"/comments/"+strconv.Itoa(id), title+authorName(comment.Author), comment.Author, comment.Content, comment.CreatedAt, comment.EditedAt, attachments[i], base)
+
+		entry.InReplyTo = &atomInReplyTo{Ref: postEntryID}
+		if comment.ParentID != nil {
+			if _, parentID, err := conv.FromGlobalID(*comment.ParentID); err == nil {
+				entry.InReplyTo.Ref = postEntryID + "/comments/" + strconv.Itoa(parentID)
+			}
+		}
+
+		feed.add(entry)
+	}
+
+	f.serve(w, r, feed, etag)
+}
+
+// loadAttachments loads the attachments of every entry at once, so that the
+// loaders of the request fetch them in a single batch.
+func loadAttachments(ctx context.Context, ids []string, load func(context.Context, string) ([]*model.Attachment, error)) ([][]*model.Attachment, error) {
+	var (
+		attachments = make([][]*model.Attachment, len(ids))
+		errs        = make([]error, len(ids))
+		wg          sync.WaitGroup
+	)
+
+	for i, id := range ids {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			attachments[i], errs[i] = load(ctx, id)
+		}()
+	}
+
+	wg.Wait()
+
+	return attachments, errors.Join(errs...)
+}
+
+// base returns the URL that links in feeds start with.
+func (f *Feeds) base(r *http.Request) string {
+	if f.baseURL != "" {
+		return f.baseURL
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+
+	return scheme + "://" + r.Host
+}
+
+// notModified answers a conditional request that matches the ETag of a feed,
+// or its updated time, with 304 Not Modified before the feed is rendered. It
+// reports whether it answered.
+func notModified(w http.ResponseWriter, r *http.Request, etag string, updated time.Time) bool {
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		if !etagMatches(match, etag) {
+			return false
+		}
+	} else {
+		since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+		if err != nil || atomTime(updated).Truncate(time.Second).After(since) {
+			return false
+		}
+	}
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusNotModified)
+
+	return true
+}
+
+// etagMatches reports whether an If-None-Match header lists etag, which it
+// compares weakly as the RFC requires.
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// serve writes the feed with etag and its updated time as Last-Modified.
+func (f *Feeds) serve(w http.ResponseWriter, r *http.Request, feed atomFeed, etag string) {
+	feed.Updated = atomTime(feed.Updated)
+
+	var buf bytes.Buffer
+
+	buf.WriteString(xml.Header)
+	if err := xml.NewEncoder(&buf).Encode(feed); err != nil {
+		f.fail(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+
+	http.ServeContent(w, r, "", feed.Updated, bytes.NewReader(buf.Bytes()))
+}
+
+// atomTime returns t, or the Unix epoch for a zero t, as Atom requires a time
+// even for a feed without entries.
+func atomTime(t time.Time) time.Time {
+	if t.IsZero() {
+		return time.Unix(0, 0).UTC()
+	}
+
+	return t
+}
+
+// version hashes what a feed is built from: its ID and title, and the ID and
+// updated time of the content in it. Content cannot change without its
+// updated time, and attachments do not change at all, so the hash changes
+// with the feed without the feed being rendered.
+type version struct {
+	hash hash.Hash
+}
+
+func newVersion(feedID, title string) version {
+	v := version{hash: sha256.New()}
+	v.add(feedID, time.Time{})
+	v.add(title, time.Time{})
+	return v
+}
+
+func (v version) add(id string, updated time.Time) {
+	v.hash.Write([]byte(id + "\x00" + updated.UTC().Format(time.RFC3339Nano) + "\x00"))
+}
+
+func (v version) etag() string {
+	return `"` + hex.EncodeToString(v.hash.Sum(nil)[:16]) + `"`
+}
+
+func (f *Feeds) fail(w http.ResponseWriter, err error) {
+	switch apperr.CodeOf(err) {
+	case apperr.CodeNotFound:
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+	case apperr.CodeValidation:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		slog.Error("failed to serve feed", sl.Err(err))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	}
+}
+
+func newEntry(id, title string, author *model.Author, content string, createdAt time.Time, editedAt *time.Time, attachments []*model.Attachment, base string) atomEntry {
+	return atomEntry{
+		ID:        id,
+		Title:     title,
+		Author:    atomAuthor{Name: authorName(author)},
+		Published: createdAt.UTC(),
+		Updated:   updated(createdAt, editedAt),
+		Content:   atomContent{Type: "html", Body: renderHTML(content, attachments, base)},
+	}
+}
+
+// latest returns the later of two times.
+func latest(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
+
+// updated returns when content was last changed.
+func updated(createdAt time.Time, editedAt *time.Time) time.Time {
+	if editedAt != nil && editedAt.After(createdAt) {
+		return editedAt.UTC()
+	}
+
+	return createdAt.UTC()
+}
+
+func authorName(author *model.Author) string {
+	if author == nil {
+		return "unknown"
+	}
+
+	return cmp.Or(author.Name, "unknown")
+}
+
+func commentsURL(base string, postID int) string {
+	return base + "/posts/" + strconv.Itoa(postID) + "/comments.atom"
+}
+
+// renderHTML renders plain text content as HTML, with blank lines separating
+// paragraphs, followed by links to the attachments. All text is escaped, so
+// content cannot add markup of its own.
+func renderHTML(content string, attachments []*model.Attachment, base string) string {
+	var b strings.Builder
+
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+
+	for _, paragraph := range strings.Split(content, "\n\n") {
+		paragraph = strings.Trim(paragraph, "\n")
+		if strings.TrimSpace(paragraph) == "" {
+			continue
+		}
+
+		b.WriteString("<p>")
+		b.WriteString(strings.ReplaceAll(html.EscapeString(paragraph), "\n", "<br>"))
+		b.WriteString("</p>")
+	}
+
+	for _, attachment := range attachments {
+		href, ok := absoluteURL(base, attachment.URL)
+		if !ok {
+			continue
+		}
+
+		b.WriteString(`<p><a href="` + html.EscapeString(href) + `">`)
+
+		if src, ok := absoluteURL(base, deref(attachment.ThumbnailURL)); ok {
+			b.WriteString(`<img src="` + html.EscapeString(src) + `" alt="` + html.EscapeString(attachment.Filename) + `">`)
+		} else {
+			b.WriteString(html.EscapeString(attachment.Filename))
+		}
+
+		b.WriteString("</a></p>")
+	}
+
+	return b.String()
+}
+
+// absoluteURL resolves ref against base, and reports whether the result is a
+// web URL a feed reader can follow.
+func absoluteURL(base, ref string) (string, bool) {
+	if ref == "" {
+		return "", false
+	}
+
+	baseURL, err := url.Parse(base + "/")
+	if err != nil {
+		return "", false
+	}
+
+	refURL, err := url.Parse(ref)
+	if err != nil {
+		return "", false
+	}
+
+	resolved := baseURL.ResolveReference(refURL)
+	if resolved.Scheme != "http" && resolved.Scheme != "https" {
+		return "", false
+	}
+
+	return resolved.String(), true
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+
+	return *s
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated time.Time   `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+// add appends entry to the feed, which is updated whenever any of its
+// entries is.
+func (f *atomFeed) add(entry atomEntry) {
+	if entry.Updated.After(f.Updated) {
+		f.Updated = entry.Updated
+	}
+
+	f.Entries = append(f.Entries, entry)
+}
+
+type atomEntry struct {
+	ID        string         `xml:"id"`
+	Title     string         `xml:"title"`
+	Author    atomAuthor     `xml:"author"`
+	Published time.Time      `xml:"published"`
+	Updated   time.Time      `xml:"updated"`
+	Links     []atomLink     `xml:"link"`
+	InReplyTo *atomInReplyTo `xml:"http://purl.org/syndication/thread/1.0 in-reply-to"`
+	Content   atomContent    `xml:"content"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+// atomInReplyTo points a comment at the post or comment it answers, as
+// defined by the Atom threading extensions (RFC 4685).
+type atomInReplyTo struct {
+	Ref string `xml:"ref,attr"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
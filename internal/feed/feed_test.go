@@ -0,0 +1,198 @@
+package feed
+
+import (
+	"context"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/erknas/forum/graph/model"
+	"github.com/erknas/forum/internal/loader"
+	"github.com/erknas/forum/internal/service"
+	"github.com/erknas/forum/internal/storage"
+	"github.com/erknas/forum/pkg/conv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestFeeds(t *testing.T) (*service.Service, http.Handler) {
+	t.Helper()
+
+	return newTestFeedsWith(t, storage.NewInMemoryStorage())
+}
+
+func newTestFeedsWith(t *testing.T, store storage.Storer) (*service.Service, http.Handler) {
+	t.Helper()
+
+	svc := service.New(store, service.Options{})
+	feeds := New(svc, Options{BaseURL: "https://forum.example.com/", Entries: 2})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /feed.atom", feeds.Posts)
+	mux.HandleFunc("GET /posts/{id}/comments.atom", feeds.Comments)
+
+	return svc, loader.Middleware(store, mux)
+}
+
+// attachmentStore counts the attachment lookups of a storage.
+type attachmentStore struct {
+	storage.Storer
+	lookups atomic.Int32
+}
+
+func (s *attachmentStore) GetAttachments(ctx context.Context, targetType string, targetIDs []int) (map[int][]model.CustomAttachment, error) {
+	s.lookups.Add(1)
+	return s.Storer.GetAttachments(ctx, targetType, targetIDs)
+}
+
+func get(t *testing.T, h http.Handler, target string, header http.Header) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	for name, values := range header {
+		req.Header[name] = values
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func decode(t *testing.T, rec *httptest.ResponseRecorder) atomFeed {
+	t.Helper()
+
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, contentType, rec.Header().Get("Content-Type"))
+
+	var feed atomFeed
+	require.NoError(t, xml.Unmarshal(rec.Body.Bytes(), &feed))
+
+	return feed
+}
+
+func TestPosts(t *testing.T) {
+	svc, h := newTestFeeds(t)
+	ctx := context.Background()
+
+	for _, content := range []string{"First", "Second <script>alert(1)</script>", "Third\n\nparagraph"} {
+		_, err := svc.CreatePost(ctx, model.PostInput{Title: "Title", Author: "Bob", Content: content, CommentsAllowed: true})
+		require.NoError(t, err)
+	}
+
+	rec := get(t, h, "/feed.atom", nil)
+	feed := decode(t, rec)
+
+	assert.Equal(t, "https://forum.example.com/feed.atom", feed.ID)
+	require.Len(t, feed.Entries, 2, "the feed is limited to the latest entries")
+
+	third, second := feed.Entries[0], feed.Entries[1]
+
+	assert.Equal(t, "https://forum.example.com/posts/3", third.ID)
+	assert.Equal(t, "<p>Third</p><p>paragraph</p>", third.Content.Body)
+	assert.Equal(t, "https://forum.example.com/posts/3/comments.atom", third.Links[0].Href)
+	assert.Equal(t, "<p>Second &lt;script&gt;alert(1)&lt;/script&gt;</p>", second.Content.Body)
+	assert.Equal(t, "Bob", second.Author.Name)
+	assert.True(t, feed.Updated.Equal(third.Updated))
+
+	etag := rec.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	rec = get(t, h, "/feed.atom", http.Header{"If-None-Match": {etag}})
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+
+	rec = get(t, h, "/feed.atom", http.Header{"If-Modified-Since": {feed.Updated.Add(time.Second).Format(http.TimeFormat)}})
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+
+	_, err := svc.CreatePost(ctx, model.PostInput{Title: "Title", Author: "Bob", Content: "Fourth", CommentsAllowed: true})
+	require.NoError(t, err)
+
+	rec = get(t, h, "/feed.atom", http.Header{"If-None-Match": {etag}})
+	assert.Equal(t, http.StatusOK, rec.Code, "a new post changes the feed")
+}
+
+func TestPosts_Attachments(t *testing.T) {
+	store := &attachmentStore{Storer: storage.NewInMemoryStorage()}
+	svc, h := newTestFeedsWith(t, store)
+	ctx := context.Background()
+
+	for _, content := range []string{"First", "Second", "Third"} {
+		_, err := svc.CreatePost(ctx, model.PostInput{Title: "Title", Author: "Bob", Content: content, CommentsAllowed: true})
+		require.NoError(t, err)
+	}
+
+	rec := get(t, h, "/feed.atom", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.EqualValues(t, 1, store.lookups.Load(), "the attachments of all entries are loaded at once")
+
+	rec = get(t, h, "/feed.atom", http.Header{"If-None-Match": {"W/" + rec.Header().Get("ETag")}})
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.EqualValues(t, 1, store.lookups.Load(), "an unchanged feed is not built again")
+}
+
+func TestPosts_Empty(t *testing.T) {
+	_, h := newTestFeeds(t)
+
+	feed := decode(t, get(t, h, "/feed.atom", nil))
+
+	assert.Empty(t, feed.Entries)
+	assert.True(t, feed.Updated.Equal(time.Unix(0, 0)))
+}
+
+func TestComments(t *testing.T) {
+	svc, h := newTestFeeds(t)
+	ctx := context.Background()
+
+	post, err := svc.CreatePost(ctx, model.PostInput{Title: "Title", Author: "Bob", Content: "Content", CommentsAllowed: true})
+	require.NoError(t, err)
+
+	parent, err := svc.CreateComment(ctx, model.CommentInput{Author: "Alice", Content: "Parent", PostID: post.ID})
+	require.NoError(t, err)
+
+	_, err = svc.CreateComment(ctx, model.CommentInput{Author: "Bob", Content: "Reply", PostID: post.ID, ParentID: &parent.ID})
+	require.NoError(t, err)
+
+	feed := decode(t, get(t, h, "/posts/1/comments.atom", nil))
+
+	assert.Equal(t, "Comments on Title", feed.Title)
+	require.Len(t, feed.Entries, 2)
+
+	reply, comment := feed.Entries[0], feed.Entries[1]
+
+	assert.Equal(t, "Reply by Bob", reply.Title)
+	assert.Equal(t, "https://forum.example.com/posts/1/comments/1", reply.InReplyTo.Ref)
+	assert.Equal(t, "Comment by Alice", comment.Title)
+	assert.Equal(t, "https://forum.example.com/posts/1", comment.InReplyTo.Ref)
+
+	global := decode(t, get(t, h, "/posts/"+conv.GlobalID(conv.TypePost, 1)+"/comments.atom", nil))
+	assert.Equal(t, feed.ID, global.ID, "global IDs of posts are accepted")
+}
+
+func TestComments_NotFound(t *testing.T) {
+	_, h := newTestFeeds(t)
+
+	assert.Equal(t, http.StatusNotFound, get(t, h, "/posts/1/comments.atom", nil).Code)
+	assert.Equal(t, http.StatusBadRequest, get(t, h, "/posts/abc/comments.atom", nil).Code)
+}
+
+func TestRenderHTML(t *testing.T) {
+	thumbnail := "/attachments/a-thumb.jpg"
+	script := "javascript:alert(1)"
+
+	got := renderHTML("a & b\r\nc\n\n\n\nd", []*model.Attachment{
+		{Filename: `cat".png`, URL: "/attachments/a.png", ThumbnailURL: &thumbnail},
+		{Filename: "notes.txt", URL: "https://cdn.example.com/b.txt"},
+		{Filename: "evil", URL: script},
+	}, "https://forum.example.com")
+
+	assert.Equal(t, strings.Join([]string{
+		"<p>a &amp; b<br>c</p>",
+		"<p>d</p>",
+		`<p><a href="https://forum.example.com/attachments/a.png"><img src="https://forum.example.com/attachments/a-thumb.jpg" alt="cat&#34;.png"></a></p>`,
+		`<p><a href="https://cdn.example.com/b.txt">notes.txt</a></p>`,
+	}, ""), got)
+}
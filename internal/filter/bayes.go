@@ -0,0 +1,119 @@
+package filter
+
+import (
+	"context"
+	"math"
+	"sync"
+)
+
+// Classifier is a naive Bayes spam classifier trained on moderator decisions.
+type Classifier struct {
+	mu     sync.RWMutex
+	counts [2]map[string]int
+	words  [2]int
+	docs   [2]int
+}
+
+const (
+	ham = iota
+	spam
+)
+
+func NewClassifier() *Classifier {
+	return &Classifier{
+		counts: [2]map[string]int{make(map[string]int), make(map[string]int)},
+	}
+}
+
+// Train adds a text that moderators decided is spam or not.
+func (c *Classifier) Train(text string, isSpam bool) {
+	class := ham
+	if isSpam {
+		class = spam
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, word := range words(text) {
+		c.counts[class][word]++
+		c.words[class]++
+	}
+
+	c.docs[class]++
+}
+
+// Samples returns the number of trained texts that are not spam and that are.
+func (c *Classifier) Samples() (hamDocs, spamDocs int) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.docs[ham], c.docs[spam]
+}
+
+// SpamProbability returns the probability that text is spam.
+func (c *Classifier) SpamProbability(text string) float64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.docs[ham] == 0 || c.docs[spam] == 0 {
+		return 0.5
+	}
+
+	vocabulary := len(c.counts[ham])
+	for word := range c.counts[spam] {
+		if _, ok := c.counts[ham][word]; !ok {
+			vocabulary++
+		}
+	}
+
+	// Log-odds of spam with Laplace smoothing, so words seen in only one
+	// class do not decide alone.
+	logOdds := math.Log(float64(c.docs[spam])) - math.Log(float64(c.docs[ham]))
+
+	for _, word := range words(text) {
+		pSpam := float64(c.counts[spam][word]+1) / float64(c.words[spam]+vocabulary)
+		pHam := float64(c.counts[ham][word]+1) / float64(c.words[ham]+vocabulary)
+		logOdds += math.Log(pSpam) - math.Log(pHam)
+	}
+
+	return 1 / (1 + math.Exp(-logOdds))
+}
+
+// Spam holds content the classifier considers spam with at least threshold
+// probability. Until it is trained on minSamples texts of both kinds it allows
+// everything.
+type Spam struct {
+	classifier *Classifier
+	threshold  float64
+	minSamples int
+}
+
+func NewSpam(classifier *Classifier, threshold float64, minSamples int) *Spam {
+	return &Spam{
+		classifier: classifier,
+		threshold:  threshold,
+		minSamples: minSamples,
+	}
+}
+
+func (*Spam) Name() string {
+	return "spam"
+}
+
+func (s *Spam) Check(_ context.Context, content Content) (Verdict, error) {
+	if hamDocs, spamDocs := s.classifier.Samples(); hamDocs < s.minSamples || spamDocs < s.minSamples {
+		return allow()
+	}
+
+	if s.classifier.SpamProbability(content.Title+"\n"+content.Text) < s.threshold {
+		return allow()
+	}
+
+	return Verdict{Action: Hold, Reason: "content looks like spam"}, nil
+}
+
+// Learn trains the classifier on approved and rejected content.
+func (s *Spam) Learn(content Content, spam bool) {
+	s.classifier.Train(content.Title+"\n"+content.Text, spam)
+}
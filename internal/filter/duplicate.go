@@ -0,0 +1,68 @@
+package filter
+
+import (
+	"context"
+	"crypto/sha256"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Duplicates rejects content that its author already posted within window.
+// Only recorded content counts as posted. Texts are compared after
+// normalization, so changing case, punctuation or letters to look-alikes does
+// not make a duplicate new.
+type Duplicates struct {
+	window time.Duration
+	now    func() time.Time
+
+	mu   sync.Mutex
+	seen map[[sha256.Size]byte]time.Time
+}
+
+func NewDuplicates(window time.Duration) *Duplicates {
+	return &Duplicates{
+		window: window,
+		now:    time.Now,
+		seen:   make(map[[sha256.Size]byte]time.Time),
+	}
+}
+
+func (*Duplicates) Name() string {
+	return "duplicates"
+}
+
+func (d *Duplicates) Check(_ context.Context, content Content) (Verdict, error) {
+	key := duplicateKey(content)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if at, ok := d.seen[key]; ok && d.now().Sub(at) < d.window {
+		return Verdict{Action: Reject, Reason: "duplicate content"}, nil
+	}
+
+	return allow()
+}
+
+// Record notes content as posted now.
+func (d *Duplicates) Record(content Content) {
+	key := duplicateKey(content)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+
+	for k, at := range d.seen {
+		if now.Sub(at) >= d.window {
+			delete(d.seen, k)
+		}
+	}
+
+	d.seen[key] = now
+}
+
+func duplicateKey(content Content) [sha256.Size]byte {
+	return sha256.Sum256([]byte(strings.ToLower(content.Author) + "\x00" + normalize(content.Title) + "\x00" + normalize(content.Text)))
+}
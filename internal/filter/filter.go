@@ -0,0 +1,106 @@
+// Package filter checks new posts and comments for spam and abuse before they
+// are stored.
+package filter
+
+import (
+	"context"
+)
+
+type Action int
+
+const (
+	Allow Action = iota
+	// Hold sends the content to moderators.
+	Hold
+	Reject
+)
+
+func (a Action) String() string {
+	switch a {
+	case Hold:
+		return "hold"
+	case Reject:
+		return "reject"
+	default:
+		return "allow"
+	}
+}
+
+// Content is a new post or comment. Title is empty for comments.
+type Content struct {
+	Author string
+	Title  string
+	Text   string
+}
+
+type Verdict struct {
+	Action Action
+	// Filter is the name of the filter that decided, Reason is shown to the
+	// author.
+	Filter string
+	Reason string
+}
+
+type Filter interface {
+	Name() string
+	Check(ctx context.Context, content Content) (Verdict, error)
+}
+
+// Chain runs every filter and returns the strictest verdict. Filters after
+// one that rejects the content are not run.
+type Chain []Filter
+
+func (c Chain) Check(ctx context.Context, content Content) (Verdict, error) {
+	result := Verdict{Action: Allow}
+
+	for _, f := range c {
+		verdict, err := f.Check(ctx, content)
+		if err != nil {
+			return Verdict{}, err
+		}
+
+		if verdict.Action > result.Action {
+			verdict.Filter = f.Name()
+			result = verdict
+		}
+
+		if result.Action == Reject {
+			break
+		}
+	}
+
+	return result, nil
+}
+
+// Learner is a filter that learns from moderator decisions.
+type Learner interface {
+	Learn(content Content, spam bool)
+}
+
+// Learn passes a moderator decision to every filter in c that learns.
+func (c Chain) Learn(content Content, spam bool) {
+	for _, f := range c {
+		if learner, ok := f.(Learner); ok {
+			learner.Learn(content, spam)
+		}
+	}
+}
+
+// Recorder is a filter that needs to know which content was stored, as the
+// content it checks may still be rejected by another filter or fail to store.
+type Recorder interface {
+	Record(content Content)
+}
+
+// Record passes stored content to every filter in c that records it.
+func (c Chain) Record(content Content) {
+	for _, f := range c {
+		if recorder, ok := f.(Recorder); ok {
+			recorder.Record(content)
+		}
+	}
+}
+
+func allow() (Verdict, error) {
+	return Verdict{Action: Allow}, nil
+}
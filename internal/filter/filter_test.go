@@ -0,0 +1,250 @@
+package filter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/erknas/forum/graph/model"
+	"github.com/erknas/forum/pkg/apperr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fixed struct {
+	name   string
+	action Action
+	called *int
+}
+
+func (f fixed) Name() string {
+	return f.name
+}
+
+func (f fixed) Check(context.Context, Content) (Verdict, error) {
+	if f.called != nil {
+		*f.called++
+	}
+	return Verdict{Action: f.action, Reason: f.name}, nil
+}
+
+func TestChain(t *testing.T) {
+	var called int
+
+	tests := []struct {
+		name  string
+		chain Chain
+		want  Verdict
+	}{
+		{
+			name: "Empty",
+			want: Verdict{Action: Allow},
+		},
+		{
+			name:  "Strictest verdict",
+			chain: Chain{fixed{name: "a", action: Allow}, fixed{name: "b", action: Hold}, fixed{name: "c", action: Allow}},
+			want:  Verdict{Action: Hold, Filter: "b", Reason: "b"},
+		},
+		{
+			name:  "First hold wins",
+			chain: Chain{fixed{name: "a", action: Hold}, fixed{name: "b", action: Hold}},
+			want:  Verdict{Action: Hold, Filter: "a", Reason: "a"},
+		},
+		{
+			name:  "Reject stops the chain",
+			chain: Chain{fixed{name: "a", action: Hold}, fixed{name: "b", action: Reject}, fixed{name: "c", action: Allow, called: &called}},
+			want:  Verdict{Action: Reject, Filter: "b", Reason: "b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verdict, err := tt.chain.Check(context.Background(), Content{})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, verdict)
+		})
+	}
+
+	assert.Zero(t, called)
+}
+
+func TestBannedWords(t *testing.T) {
+	f := NewBannedWords([]string{"casino", "buy followers", "спам", " "})
+
+	tests := []struct {
+		name string
+		text string
+		want Action
+	}{
+		{name: "Clean", text: "Let's meet on Friday", want: Allow},
+		{name: "Banned word", text: "Best CASINO in town!", want: Reject},
+		{name: "Cyrillic look-alikes", text: "best саsinо", want: Reject},
+		{name: "Digits for letters", text: "c4s1n0 bonus", want: Reject},
+		{name: "Phrase", text: "Buy   followers, cheap", want: Reject},
+		{name: "Phrase words apart", text: "buy more followers", want: Allow},
+		{name: "Part of a word", text: "casinos", want: Allow},
+		{name: "Cyrillic word with Latin letters", text: "это cпaм", want: Reject},
+		{name: "Cyrillic digits for letters", text: "сп4м", want: Reject},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verdict, err := f.Check(context.Background(), Content{Text: tt.text})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, verdict.Action)
+		})
+	}
+}
+
+type authors map[string]model.CustomAuthor
+
+func (a authors) GetAuthorByName(_ context.Context, name string) (model.CustomAuthor, error) {
+	if name == "broken" {
+		return model.CustomAuthor{}, errors.New("connection refused")
+	}
+
+	author, ok := a[name]
+	if !ok {
+		return model.CustomAuthor{}, apperr.ErrAuthorNotFound
+	}
+
+	return author, nil
+}
+
+func TestLinks(t *testing.T) {
+	now := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	f := NewLinks(authors{
+		"old": {Name: "old", JoinedAt: now.Add(-48 * time.Hour)},
+		"new": {Name: "new", JoinedAt: now.Add(-time.Hour)},
+	}, 24*time.Hour, 1)
+	f.now = func() time.Time { return now }
+
+	const twoLinks = "see https://example.com and www.example.org"
+
+	tests := []struct {
+		name    string
+		content Content
+		want    Action
+	}{
+		{name: "One link from a new account", content: Content{Author: "new", Text: "see https://example.com"}, want: Allow},
+		{name: "Links from an old account", content: Content{Author: "old", Text: twoLinks}, want: Allow},
+		{name: "Links from a new account", content: Content{Author: "new", Text: twoLinks}, want: Hold},
+		{name: "Links from a first-time author", content: Content{Author: "first", Title: "http://a.example", Text: "http://b.example"}, want: Hold},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verdict, err := f.Check(context.Background(), tt.content)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, verdict.Action)
+		})
+	}
+
+	_, err := f.Check(context.Background(), Content{Author: "broken", Text: twoLinks})
+	assert.Error(t, err)
+}
+
+func TestDuplicates(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	f := NewDuplicates(10 * time.Minute)
+	f.now = func() time.Time { return now }
+
+	check := func(content Content) Action {
+		verdict, err := f.Check(context.Background(), content)
+		require.NoError(t, err)
+		return verdict.Action
+	}
+
+	assert.Equal(t, Allow, check(Content{Author: "Bob", Text: "Hello, world!"}))
+	assert.Equal(t, Allow, check(Content{Author: "Bob", Text: "Hello, world!"}), "checked content is not recorded")
+
+	f.Record(Content{Author: "Bob", Text: "Hello, world!"})
+
+	assert.Equal(t, Reject, check(Content{Author: "Bob", Text: "hello world"}))
+	assert.Equal(t, Reject, check(Content{Author: "Bob", Text: "hеllо world"}), "Cyrillic look-alikes")
+	assert.Equal(t, Allow, check(Content{Author: "Alice", Text: "Hello, world!"}), "other author")
+	assert.Equal(t, Allow, check(Content{Author: "Bob", Title: "Greeting", Text: "Hello, world!"}), "other title")
+
+	now = now.Add(10 * time.Minute)
+	assert.Equal(t, Allow, check(Content{Author: "Bob", Text: "Hello, world!"}), "after the window")
+}
+
+func TestSpam(t *testing.T) {
+	classifier := NewClassifier()
+	f := NewSpam(classifier, 0.9, 2)
+
+	const ad = "cheap pills discount buy now"
+
+	check := func(text string) Action {
+		verdict, err := f.Check(context.Background(), Content{Text: text})
+		require.NoError(t, err)
+		return verdict.Action
+	}
+
+	classifier.Train("buy cheap pills now", true)
+	classifier.Train("meeting notes for friday", false)
+	assert.Equal(t, Allow, check(ad), "not enough samples")
+
+	classifier.Train("discount pills, buy now", true)
+	classifier.Train("which date works for the offsite", false)
+
+	assert.Greater(t, classifier.SpamProbability(ad), 0.9)
+	assert.Less(t, classifier.SpamProbability("notes from the friday meeting"), 0.5)
+
+	assert.Equal(t, Hold, check(ad))
+	assert.Equal(t, Allow, check("friday offsite notes"))
+}
+
+func TestChain_Record(t *testing.T) {
+	duplicates := NewDuplicates(time.Minute)
+	chain := Chain{fixed{name: "a"}, duplicates}
+
+	chain.Record(Content{Author: "Bob", Text: "Hello"})
+
+	verdict, err := chain.Check(context.Background(), Content{Author: "Bob", Text: "Hello"})
+	require.NoError(t, err)
+	assert.Equal(t, Reject, verdict.Action)
+	assert.Equal(t, "duplicates", verdict.Filter)
+}
+
+func TestChain_Learn(t *testing.T) {
+	classifier := NewClassifier()
+	chain := Chain{fixed{name: "a"}, NewSpam(classifier, 0.9, 1)}
+
+	chain.Learn(Content{Title: "Deal", Text: "buy cheap pills"}, true)
+	chain.Learn(Content{Text: "see you on friday"}, false)
+
+	hamDocs, spamDocs := classifier.Samples()
+	assert.Equal(t, 1, hamDocs)
+	assert.Equal(t, 1, spamDocs)
+	assert.Greater(t, classifier.SpamProbability("cheap deal"), 0.5)
+}
+
+func TestBannedWords_SingleScript(t *testing.T) {
+	f := NewBannedWords([]string{"cop"})
+
+	tests := []struct {
+		name string
+		text string
+		want Action
+	}{
+		{name: "Latin word", text: "call a COP", want: Reject},
+		{name: "Mixed scripts", text: "call a сoр", want: Reject},
+		{name: "Cyrillic word", text: "в углу сор", want: Allow},
+		{name: "Digits", text: "7 0 3", want: Allow},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verdict, err := f.Check(context.Background(), Content{Text: tt.text})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, verdict.Action)
+		})
+	}
+
+	assert.Equal(t, " в углу сор ", normalize("В углу сор"), "words in a single script are not folded")
+	assert.Equal(t, " cop ", normalize("сoр"))
+}
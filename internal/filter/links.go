@@ -0,0 +1,58 @@
+package filter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/erknas/forum/graph/model"
+	"github.com/erknas/forum/pkg/apperr"
+)
+
+var linkRe = regexp.MustCompile(`(?i)\b(?:https?://|www\.)\S+`)
+
+type Authors interface {
+	GetAuthorByName(ctx context.Context, name string) (model.CustomAuthor, error)
+}
+
+// Links holds content with more than MaxLinks links from authors who joined
+// less than NewAccountAge ago. Authors without any posts or comments yet are
+// new.
+type Links struct {
+	authors       Authors
+	newAccountAge time.Duration
+	maxLinks      int
+	now           func() time.Time
+}
+
+func NewLinks(authors Authors, newAccountAge time.Duration, maxLinks int) *Links {
+	return &Links{
+		authors:       authors,
+		newAccountAge: newAccountAge,
+		maxLinks:      maxLinks,
+		now:           time.Now,
+	}
+}
+
+func (*Links) Name() string {
+	return "links"
+}
+
+func (l *Links) Check(ctx context.Context, content Content) (Verdict, error) {
+	if len(linkRe.FindAllString(content.Title+"\n"+content.Text, -1)) <= l.maxLinks {
+		return allow()
+	}
+
+	author, err := l.authors.GetAuthorByName(ctx, content.Author)
+	if err != nil && !errors.Is(err, apperr.ErrAuthorNotFound) {
+		return Verdict{}, fmt.Errorf("get author %q: %w", content.Author, err)
+	}
+
+	if err == nil && l.now().Sub(author.JoinedAt) >= l.newAccountAge {
+		return allow()
+	}
+
+	return Verdict{Action: Hold, Reason: "too many links for a new account"}, nil
+}
@@ -0,0 +1,74 @@
+package filter
+
+import (
+	"strings"
+	"unicode"
+)
+
+// homoglyphs maps Cyrillic letters and digits that look like Latin letters to
+// those letters, so "сlаss" written with Cyrillic с and а and "cl4ss" fold to
+// "class". Only words that mix scripts are folded: a word written entirely
+// in Cyrillic, such as "сор", is a word of its own and not a disguised "cop".
+var homoglyphs = map[rune]rune{
+	'а': 'a', 'в': 'b', 'е': 'e', 'ё': 'e', 'і': 'i', 'ј': 'j',
+	'к': 'k', 'м': 'm', 'н': 'h', 'о': 'o', 'р': 'p', 'с': 'c', 'т': 't',
+	'у': 'y', 'х': 'x', 'ѕ': 's', 'ԁ': 'd', 'ԛ': 'q', 'ԝ': 'w',
+	'0': 'o', '1': 'i', '3': 'e', '4': 'a', '5': 's', '7': 't', '@': 'a', '$': 's',
+}
+
+// words splits text into lower-case words.
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		_, glyph := homoglyphs[r]
+		return !glyph && !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// mixesScripts reports whether word has letters of more than one script, or
+// letters along with digits or symbols that stand for letters.
+func mixesScripts(word string) bool {
+	var latin, cyrillic, symbol bool
+
+	for _, r := range word {
+		switch {
+		case unicode.Is(unicode.Latin, r):
+			latin = true
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic = true
+		default:
+			_, symbol = homoglyphs[r]
+		}
+	}
+
+	return latin && (cyrillic || symbol) || cyrillic && symbol
+}
+
+// fold replaces the homoglyphs in word.
+func fold(word string) string {
+	return strings.Map(func(r rune) rune {
+		if latin, ok := homoglyphs[r]; ok {
+			return latin
+		}
+		return r
+	}, word)
+}
+
+// sameWord reports whether word is banned, either as written or, if word
+// mixes scripts, once the homoglyphs of both are replaced.
+func sameWord(word, banned string) bool {
+	return word == banned || mixesScripts(word) && fold(word) == fold(banned)
+}
+
+// normalize returns the words of text, folded if they mix scripts, separated
+// and surrounded by single spaces.
+func normalize(text string) string {
+	fields := words(text)
+
+	for i, field := range fields {
+		if mixesScripts(field) {
+			fields[i] = fold(field)
+		}
+	}
+
+	return " " + strings.Join(fields, " ") + " "
+}
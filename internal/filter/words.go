@@ -0,0 +1,42 @@
+package filter
+
+import (
+	"context"
+	"slices"
+)
+
+// BannedWords rejects content that contains any of the banned words or
+// phrases, also when they are written with look-alike letters.
+type BannedWords struct {
+	phrases [][]string
+}
+
+func NewBannedWords(phrases []string) *BannedWords {
+	b := &BannedWords{}
+
+	for _, phrase := range phrases {
+		if words := words(phrase); len(words) > 0 {
+			b.phrases = append(b.phrases, words)
+		}
+	}
+
+	return b
+}
+
+func (*BannedWords) Name() string {
+	return "banned_words"
+}
+
+func (b *BannedWords) Check(_ context.Context, content Content) (Verdict, error) {
+	text := words(content.Title + "\n" + content.Text)
+
+	for i := range text {
+		for _, phrase := range b.phrases {
+			if len(text)-i >= len(phrase) && slices.EqualFunc(text[i:i+len(phrase)], phrase, sameWord) {
+				return Verdict{Action: Reject, Reason: "content contains banned words"}, nil
+			}
+		}
+	}
+
+	return allow()
+}
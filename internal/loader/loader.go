@@ -0,0 +1,269 @@
+// Package loader provides per-request loaders that batch and dedupe storage
+// lookups made by field resolvers.
+package loader
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/erknas/forum/graph/model"
+	"github.com/erknas/forum/internal/auth"
+	"github.com/erknas/forum/internal/storage"
+	"github.com/erknas/forum/pkg/apperr"
+	"github.com/erknas/forum/pkg/dataloader"
+)
+
+const (
+	wait     = 2 * time.Millisecond
+	maxBatch = 100
+)
+
+type ctxKey struct{}
+
+// CommentsKey identifies a page of top-level comments of a post.
+type CommentsKey struct {
+	PostID int
+	Offset int
+	Limit  int
+}
+
+// RevisionsKey identifies the revisions of a post or comment.
+type RevisionsKey struct {
+	TargetType string
+	TargetID   int
+}
+
+// AttachmentsKey identifies the attachments of a post or comment.
+type AttachmentsKey struct {
+	TargetType string
+	TargetID   int
+}
+
+type pageKey struct {
+	offset int
+	limit  int
+}
+
+type Loaders struct {
+	Post        *dataloader.Loader[int, model.CustomPost]
+	Comments    *dataloader.Loader[CommentsKey, []model.CustomComment]
+	Replies     *dataloader.Loader[int, []model.CustomComment]
+	AuthorStats *dataloader.Loader[int, model.AuthorStats]
+	Revisions   *dataloader.Loader[RevisionsKey, []model.CustomRevision]
+	// Polls loads the poll of a post by post ID, or nil if it has none.
+	Polls       *dataloader.Loader[int, *model.CustomPoll]
+	Attachments *dataloader.Loader[AttachmentsKey, []model.CustomAttachment]
+}
+
+// New returns loaders for a request made by viewer, who is "" for anonymous
+// requests.
+func New(store storage.Storer, viewer string) *Loaders {
+	return &Loaders{
+		Post:        dataloader.New(posts(store), wait, maxBatch),
+		Comments:    dataloader.New(comments(store, viewer), wait, maxBatch),
+		Replies:     dataloader.New(replies(store, viewer), wait, maxBatch),
+		AuthorStats: dataloader.New(authorStats(store, viewer), wait, maxBatch),
+		Revisions:   dataloader.New(revisions(store), wait, maxBatch),
+		Polls:       dataloader.New(polls(store), wait, maxBatch),
+		Attachments: dataloader.New(attachments(store), wait, maxBatch),
+	}
+}
+
+// Middleware attaches new loaders to the context of every request, for the
+// viewer attached to it by auth.Middleware.
+func Middleware(store storage.Storer, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A websocket connection lives as long as its subscriptions, so values
+		// cached for it would go stale.
+		if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(With(r.Context(), New(store, auth.Name(r.Context())))))
+	})
+}
+
+func With(ctx context.Context, loaders *Loaders) context.Context {
+	return context.WithValue(ctx, ctxKey{}, loaders)
+}
+
+// For returns the loaders attached to ctx, or nil if there are none.
+func For(ctx context.Context) *Loaders {
+	loaders, _ := ctx.Value(ctxKey{}).(*Loaders)
+	return loaders
+}
+
+func posts(store storage.Storer) dataloader.BatchFunc[int, model.CustomPost] {
+	return func(ctx context.Context, ids []int) ([]model.CustomPost, []error) {
+		customPosts, err := store.GetPostsByIDs(ctx, ids)
+		if err != nil {
+			return nil, []error{err}
+		}
+
+		byID := make(map[int]model.CustomPost, len(customPosts))
+		for _, post := range customPosts {
+			byID[post.ID] = post
+		}
+
+		result := make([]model.CustomPost, len(ids))
+		errs := make([]error, len(ids))
+
+		for i, id := range ids {
+			post, ok := byID[id]
+			if !ok {
+				errs[i] = apperr.ErrPostNotFound
+				continue
+			}
+			result[i] = post
+		}
+
+		return result, errs
+	}
+}
+
+func comments(store storage.Storer, viewer string) dataloader.BatchFunc[CommentsKey, []model.CustomComment] {
+	return func(ctx context.Context, keys []CommentsKey) ([][]model.CustomComment, []error) {
+		postIDs := make(map[pageKey][]int)
+		for _, key := range keys {
+			page := pageKey{offset: key.Offset, limit: key.Limit}
+			postIDs[page] = append(postIDs[page], key.PostID)
+		}
+
+		byKey := make(map[CommentsKey][]model.CustomComment, len(keys))
+
+		for page, ids := range postIDs {
+			customComments, err := store.GetCommentsByPostIDs(ctx, ids, page.offset, page.limit, viewer)
+			if err != nil {
+				return nil, []error{err}
+			}
+
+			for _, comment := range customComments {
+				key := CommentsKey{PostID: comment.PostID, Offset: page.offset, Limit: page.limit}
+				byKey[key] = append(byKey[key], comment)
+			}
+		}
+
+		result := make([][]model.CustomComment, len(keys))
+		for i, key := range keys {
+			result[i] = byKey[key]
+		}
+
+		return result, nil
+	}
+}
+
+func replies(store storage.Storer, viewer string) dataloader.BatchFunc[int, []model.CustomComment] {
+	return func(ctx context.Context, parentIDs []int) ([][]model.CustomComment, []error) {
+		customComments, err := store.GetRepliesByParentIDs(ctx, parentIDs, viewer)
+		if err != nil {
+			return nil, []error{err}
+		}
+
+		byParent := make(map[int][]model.CustomComment, len(parentIDs))
+		for _, comment := range customComments {
+			byParent[*comment.ParentID] = append(byParent[*comment.ParentID], comment)
+		}
+
+		result := make([][]model.CustomComment, len(parentIDs))
+		for i, id := range parentIDs {
+			result[i] = byParent[id]
+		}
+
+		return result, nil
+	}
+}
+
+func authorStats(store storage.Storer, viewer string) dataloader.BatchFunc[int, model.AuthorStats] {
+	return func(ctx context.Context, ids []int) ([]model.AuthorStats, []error) {
+		stats, err := store.GetAuthorStatsByIDs(ctx, ids, viewer)
+		if err != nil {
+			return nil, []error{err}
+		}
+
+		result := make([]model.AuthorStats, len(ids))
+		for i, id := range ids {
+			result[i] = stats[id]
+		}
+
+		return result, nil
+	}
+}
+
+func revisions(store storage.Storer) dataloader.BatchFunc[RevisionsKey, []model.CustomRevision] {
+	return func(ctx context.Context, keys []RevisionsKey) ([][]model.CustomRevision, []error) {
+		targetIDs := make(map[string][]int)
+		for _, key := range keys {
+			targetIDs[key.TargetType] = append(targetIDs[key.TargetType], key.TargetID)
+		}
+
+		byKey := make(map[RevisionsKey][]model.CustomRevision, len(keys))
+
+		for targetType, ids := range targetIDs {
+			byTarget, err := store.GetRevisions(ctx, targetType, ids)
+			if err != nil {
+				return nil, []error{err}
+			}
+
+			for id, revisions := range byTarget {
+				byKey[RevisionsKey{TargetType: targetType, TargetID: id}] = revisions
+			}
+		}
+
+		result := make([][]model.CustomRevision, len(keys))
+		for i, key := range keys {
+			result[i] = byKey[key]
+		}
+
+		return result, nil
+	}
+}
+
+func polls(store storage.Storer) dataloader.BatchFunc[int, *model.CustomPoll] {
+	return func(ctx context.Context, postIDs []int) ([]*model.CustomPoll, []error) {
+		byPost, err := store.GetPollsByPostIDs(ctx, postIDs)
+		if err != nil {
+			return nil, []error{err}
+		}
+
+		result := make([]*model.CustomPoll, len(postIDs))
+		for i, id := range postIDs {
+			if poll, ok := byPost[id]; ok {
+				result[i] = &poll
+			}
+		}
+
+		return result, nil
+	}
+}
+
+func attachments(store storage.Storer) dataloader.BatchFunc[AttachmentsKey, []model.CustomAttachment] {
+	return func(ctx context.Context, keys []AttachmentsKey) ([][]model.CustomAttachment, []error) {
+		targetIDs := make(map[string][]int)
+		for _, key := range keys {
+			targetIDs[key.TargetType] = append(targetIDs[key.TargetType], key.TargetID)
+		}
+
+		byKey := make(map[AttachmentsKey][]model.CustomAttachment, len(keys))
+
+		for targetType, ids := range targetIDs {
+			byTarget, err := store.GetAttachments(ctx, targetType, ids)
+			if err != nil {
+				return nil, []error{err}
+			}
+
+			for id, attachments := range byTarget {
+				byKey[AttachmentsKey{TargetType: targetType, TargetID: id}] = attachments
+			}
+		}
+
+		result := make([][]model.CustomAttachment, len(keys))
+		for i, key := range keys {
+			result[i] = byKey[key]
+		}
+
+		return result, nil
+	}
+}
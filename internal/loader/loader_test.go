@@ -0,0 +1,178 @@
+package loader
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/erknas/forum/graph/model"
+	"github.com/erknas/forum/internal/storage/mocks"
+	"github.com/erknas/forum/pkg/apperr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostLoader(t *testing.T) {
+	storerMock := mocks.NewStorer(t)
+
+	storerMock.On("GetPostsByIDs", mock.Anything, mock.MatchedBy(func(ids []int) bool {
+		return assert.ElementsMatch(t, []int{1, 2, 3}, ids)
+	})).Return([]model.CustomPost{{ID: 1, Title: "First"}, {ID: 3, Title: "Third"}}, nil).Once()
+
+	loaders := New(storerMock, "")
+
+	var (
+		wg     sync.WaitGroup
+		titles = make([]string, 4)
+		errs   = make([]error, 4)
+	)
+
+	for i, id := range []int{1, 2, 3, 1} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			post, err := loaders.Post.Load(context.Background(), id)
+			titles[i], errs[i] = post.Title, err
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, []string{"First", "", "Third", "First"}, titles)
+	assert.NoError(t, errs[0])
+	assert.ErrorIs(t, errs[1], apperr.ErrPostNotFound)
+}
+
+func TestCommentsLoader(t *testing.T) {
+	storerMock := mocks.NewStorer(t)
+
+	storerMock.On("GetCommentsByPostIDs", mock.Anything, []int{1}, 0, 10, "").Return([]model.CustomComment{{ID: 1, PostID: 1}}, nil).Once()
+	storerMock.On("GetCommentsByPostIDs", mock.Anything, []int{1}, 10, 10, "").Return([]model.CustomComment{{ID: 11, PostID: 1}}, nil).Once()
+
+	loaders := New(storerMock, "")
+
+	var wg sync.WaitGroup
+
+	pages := make([][]model.CustomComment, 2)
+	for i := range pages {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			comments, err := loaders.Comments.Load(context.Background(), CommentsKey{PostID: 1, Offset: i * 10, Limit: 10})
+			assert.NoError(t, err)
+			pages[i] = comments
+		}()
+	}
+
+	wg.Wait()
+
+	require.Len(t, pages[0], 1)
+	require.Len(t, pages[1], 1)
+	assert.Equal(t, 1, pages[0][0].ID)
+	assert.Equal(t, 11, pages[1][0].ID)
+}
+
+func TestAuthorStatsLoader_MissingAuthor(t *testing.T) {
+	storerMock := mocks.NewStorer(t)
+
+	storerMock.On("GetAuthorStatsByIDs", mock.Anything, []int{42}, "").Return(map[int]model.AuthorStats{}, nil).Once()
+
+	stats, err := New(storerMock, "").AuthorStats.Load(context.Background(), 42)
+	require.NoError(t, err)
+	assert.Equal(t, model.AuthorStats{}, stats)
+}
+
+func TestRevisionsLoader(t *testing.T) {
+	storerMock := mocks.NewStorer(t)
+
+	storerMock.On("GetRevisions", mock.Anything, "Post", []int{1}).Return(map[int][]model.CustomRevision{
+		1: {{TargetType: "Post", TargetID: 1, Version: 1}},
+	}, nil).Once()
+	storerMock.On("GetRevisions", mock.Anything, "Comment", []int{1}).Return(map[int][]model.CustomRevision{}, nil).Once()
+
+	loaders := New(storerMock, "")
+
+	var (
+		wg      sync.WaitGroup
+		results = make([][]model.CustomRevision, 2)
+	)
+
+	for i, targetType := range []string{"Post", "Comment"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			revisions, err := loaders.Revisions.Load(context.Background(), RevisionsKey{TargetType: targetType, TargetID: 1})
+			assert.NoError(t, err)
+			results[i] = revisions
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Len(t, results[0], 1)
+	assert.Empty(t, results[1])
+}
+
+func TestPollsLoader(t *testing.T) {
+	storerMock := mocks.NewStorer(t)
+
+	storerMock.On("GetPollsByPostIDs", mock.Anything, mock.MatchedBy(func(ids []int) bool {
+		return assert.ElementsMatch(t, []int{1, 2}, ids)
+	})).Return(map[int]model.CustomPoll{1: {ID: 7, PostID: 1}}, nil).Once()
+
+	loaders := New(storerMock, "")
+
+	var (
+		wg      sync.WaitGroup
+		results = make([]*model.CustomPoll, 2)
+	)
+
+	for i, postID := range []int{1, 2} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			poll, err := loaders.Polls.Load(context.Background(), postID)
+			assert.NoError(t, err)
+			results[i] = poll
+		}()
+	}
+
+	wg.Wait()
+
+	require.NotNil(t, results[0])
+	assert.Equal(t, 7, results[0].ID)
+	assert.Nil(t, results[1], "posts without a poll have none")
+}
+
+func TestAttachmentsLoader(t *testing.T) {
+	storerMock := mocks.NewStorer(t)
+
+	storerMock.On("GetAttachments", mock.Anything, "Post", []int{1}).Return(map[int][]model.CustomAttachment{
+		1: {{ID: 3, TargetType: "Post", TargetID: 1}},
+	}, nil).Once()
+	storerMock.On("GetAttachments", mock.Anything, "Comment", []int{1}).Return(map[int][]model.CustomAttachment{}, nil).Once()
+
+	loaders := New(storerMock, "")
+
+	var (
+		wg      sync.WaitGroup
+		results = make([][]model.CustomAttachment, 2)
+	)
+
+	for i, targetType := range []string{"Post", "Comment"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			attachments, err := loaders.Attachments.Load(context.Background(), AttachmentsKey{TargetType: targetType, TargetID: 1})
+			assert.NoError(t, err)
+			results[i] = attachments
+		}()
+	}
+
+	wg.Wait()
+
+	require.Len(t, results[0], 1)
+	assert.Equal(t, 3, results[0][0].ID)
+	assert.Empty(t, results[1])
+}
@@ -0,0 +1,70 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type EventType string
+
+const (
+	PostCreated    EventType = "post_created"
+	CommentCreated EventType = "comment_created"
+	// PollUpdated carries the results of a poll after a vote, under the ID of
+	// the poll.
+	PollUpdated EventType = "poll_updated"
+)
+
+// Event is a stored change. Attempts counts the failed attempts to deliver it
+// of the sink it was returned for.
+type Event struct {
+	ID        int64           `json:"-"`
+	EventID   string          `json:"id"`
+	Type      EventType       `json:"type"`
+	Topic     string          `json:"topic"`
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt time.Time       `json:"createdAt"`
+	Attempts  int             `json:"-"`
+}
+
+// Failure is a failed attempt of a sink to deliver the event with ID. The
+// event is retried at RetryAt, or never again if it is Dead.
+type Failure struct {
+	ID       int64     `json:"id"`
+	Attempts int       `json:"attempts"`
+	RetryAt  time.Time `json:"retryAt"`
+	Dead     bool      `json:"dead"`
+}
+
+// Store is implemented by storages which write outbox rows in the same
+// transaction as the change they describe. Deliveries are recorded per sink.
+// PendingEvents returns up to limit events, oldest first, that sink has
+// neither delivered nor given up on and that are due for a retry at now.
+type Store interface {
+	PendingEvents(ctx context.Context, sink string, now time.Time, limit int) ([]Event, error)
+	MarkDelivered(ctx context.Context, sink string, ids []int64) error
+	MarkFailed(ctx context.Context, sink string, failure Failure) error
+}
+
+type Sink interface {
+	Name() string
+	Deliver(context.Context, Event) error
+}
+
+func NewEvent(eventType EventType, topic string, payload any) (Event, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return Event{}, err
+	}
+
+	return Event{
+		EventID:   uuid.NewString(),
+		Type:      eventType,
+		Topic:     topic,
+		Payload:   data,
+		CreatedAt: time.Now(),
+	}, nil
+}
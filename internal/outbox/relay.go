@@ -0,0 +1,132 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/erknas/forum/pkg/sl"
+)
+
+const (
+	defaultBatchSize = 100
+	// maxAttempts is how many times a sink tries to deliver an event before
+	// it gives up on it.
+	maxAttempts   = 10
+	minRetryDelay = time.Second
+	maxRetryDelay = time.Hour
+)
+
+// Relay delivers pending outbox events to every sink at least once. Sinks
+// are flushed independently: an event a sink fails to deliver is retried with
+// exponential backoff and given up on after maxAttempts, while the other
+// sinks go on with newer events. Flush is not safe for concurrent use.
+type Relay struct {
+	store    Store
+	sinks    []Sink
+	interval time.Duration
+	batch    int
+	now      func() time.Time
+}
+
+func NewRelay(store Store, interval time.Duration, sinks ...Sink) *Relay {
+	return &Relay{
+		store:    store,
+		sinks:    sinks,
+		interval: interval,
+		batch:    defaultBatchSize,
+		now:      time.Now,
+	}
+}
+
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.Flush(ctx); err != nil {
+			slog.Error("failed to relay outbox events", sl.Err(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Relay) Flush(ctx context.Context) error {
+	var (
+		now  = r.now()
+		errs = make([]error, len(r.sinks))
+		wg   sync.WaitGroup
+	)
+
+	for i, sink := range r.sinks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = r.flush(ctx, sink, now)
+		}()
+	}
+
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// flush delivers the pending events of sink until one fails, as the sink is
+// likely down then. The failed event waits for its retry, so the next flush
+// goes on with the events after it.
+func (r *Relay) flush(ctx context.Context, sink Sink, now time.Time) error {
+	events, err := r.store.PendingEvents(ctx, sink.Name(), now, r.batch)
+	if err != nil {
+		return err
+	}
+
+	var (
+		ids  = make([]int64, 0, len(events))
+		errs []error
+	)
+
+	for _, event := range events {
+		if err := sink.Deliver(ctx, event); err != nil {
+			errs = append(errs, r.fail(ctx, sink, event, err, now))
+			break
+		}
+
+		ids = append(ids, event.ID)
+	}
+
+	if len(ids) > 0 {
+		errs = append(errs, r.store.MarkDelivered(ctx, sink.Name(), ids))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (r *Relay) fail(ctx context.Context, sink Sink, event Event, err error, now time.Time) error {
+	failure := Failure{ID: event.ID, Attempts: event.Attempts + 1}
+
+	if failure.Attempts >= maxAttempts {
+		failure.Dead = true
+		slog.Error("gave up on outbox event", sl.Err(err), "event_id", event.EventID, "sink", sink.Name(), "attempts", failure.Attempts)
+	} else {
+		failure.RetryAt = now.Add(retryDelay(failure.Attempts))
+		slog.Error("failed to deliver outbox event", sl.Err(err), "event_id", event.EventID, "sink", sink.Name(), "retry_at", failure.RetryAt)
+	}
+
+	return r.store.MarkFailed(ctx, sink.Name(), failure)
+}
+
+// retryDelay doubles the delay before each retry, up to maxRetryDelay.
+func retryDelay(attempts int) time.Duration {
+	if attempts > 20 {
+		return maxRetryDelay
+	}
+
+	return min(minRetryDelay<<(attempts-1), maxRetryDelay)
+}
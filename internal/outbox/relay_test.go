@@ -0,0 +1,194 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type deliveryKey struct {
+	sink string
+	id   int64
+}
+
+type memoryStore struct {
+	mu        sync.Mutex
+	events    []Event
+	delivered map[deliveryKey]bool
+	failures  map[deliveryKey]Failure
+}
+
+func newMemoryStore(events ...Event) *memoryStore {
+	return &memoryStore{events: events, delivered: make(map[deliveryKey]bool), failures: make(map[deliveryKey]Failure)}
+}
+
+func (m *memoryStore) PendingEvents(_ context.Context, sink string, now time.Time, limit int) ([]Event, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var pending []Event
+
+	for _, event := range m.events {
+		key := deliveryKey{sink, event.ID}
+		failure := m.failures[key]
+		if m.delivered[key] || failure.Dead || failure.RetryAt.After(now) || len(pending) == limit {
+			continue
+		}
+
+		event.Attempts = failure.Attempts
+		pending = append(pending, event)
+	}
+
+	return pending, nil
+}
+
+func (m *memoryStore) MarkDelivered(_ context.Context, sink string, ids []int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, id := range ids {
+		m.delivered[deliveryKey{sink, id}] = true
+	}
+	return nil
+}
+
+func (m *memoryStore) MarkFailed(_ context.Context, sink string, failure Failure) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.failures[deliveryKey{sink, failure.ID}] = failure
+	return nil
+}
+
+func (m *memoryStore) isDelivered(sink string, id int64) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.delivered[deliveryKey{sink, id}]
+}
+
+type recordingSink struct {
+	name     string
+	fail     bool
+	received []string
+}
+
+func (r *recordingSink) Name() string {
+	return r.name
+}
+
+func (r *recordingSink) Deliver(_ context.Context, event Event) error {
+	if r.fail {
+		return errors.New("unavailable")
+	}
+	r.received = append(r.received, event.EventID)
+	return nil
+}
+
+func newEvent(t *testing.T, id int64) Event {
+	t.Helper()
+
+	event, err := NewEvent(CommentCreated, "1", map[string]int64{"id": id})
+	require.NoError(t, err)
+
+	event.ID = id
+
+	return event
+}
+
+func TestRelayFlush(t *testing.T) {
+	store := newMemoryStore(newEvent(t, 1), newEvent(t, 2))
+	sink := &recordingSink{name: "sink"}
+
+	relay := NewRelay(store, 0, sink)
+
+	require.NoError(t, relay.Flush(context.Background()))
+	require.NoError(t, relay.Flush(context.Background()))
+
+	assert.True(t, store.isDelivered("sink", 1))
+	assert.True(t, store.isDelivered("sink", 2))
+	assert.Equal(t, []string{store.events[0].EventID, store.events[1].EventID}, sink.received)
+}
+
+func TestRelayFlush_RetriesFailedSinkOnly(t *testing.T) {
+	store := newMemoryStore(newEvent(t, 1))
+	healthy := &recordingSink{name: "healthy"}
+	failing := &recordingSink{name: "failing", fail: true}
+
+	now := time.Now()
+	relay := NewRelay(store, 0, healthy, failing)
+	relay.now = func() time.Time { return now }
+
+	require.NoError(t, relay.Flush(context.Background()))
+	assert.True(t, store.isDelivered("healthy", 1))
+	assert.False(t, store.isDelivered("failing", 1))
+
+	failing.fail = false
+
+	require.NoError(t, relay.Flush(context.Background()))
+	assert.Empty(t, failing.received, "the event waits for its retry")
+
+	now = now.Add(minRetryDelay)
+
+	require.NoError(t, relay.Flush(context.Background()))
+	assert.True(t, store.isDelivered("failing", 1))
+	assert.Len(t, healthy.received, 1)
+	assert.Len(t, failing.received, 1)
+}
+
+func TestRelayFlush_FailingSinkDoesNotHoldBackOthers(t *testing.T) {
+	store := newMemoryStore(newEvent(t, 1))
+	healthy := &recordingSink{name: "healthy"}
+	failing := &recordingSink{name: "failing", fail: true}
+
+	now := time.Now()
+	relay := NewRelay(store, 0, healthy, failing)
+	relay.now = func() time.Time { return now }
+
+	for i := int64(2); i <= maxAttempts+1; i++ {
+		store.mu.Lock()
+		store.events = append(store.events, newEvent(t, i))
+		store.mu.Unlock()
+
+		require.NoError(t, relay.Flush(context.Background()))
+		now = now.Add(maxRetryDelay)
+	}
+
+	assert.Len(t, healthy.received, maxAttempts+1, "newer events reach the healthy sink")
+
+	for i := int64(1); i <= maxAttempts+1; i++ {
+		assert.False(t, store.isDelivered("failing", i))
+	}
+
+	failure := store.failures[deliveryKey{"failing", 1}]
+	assert.True(t, failure.Dead, "the failing sink gives up after %d attempts", maxAttempts)
+	assert.Equal(t, maxAttempts, failure.Attempts)
+
+	failing.fail = false
+
+	require.NoError(t, relay.Flush(context.Background()))
+	assert.NotContains(t, failing.received, store.events[0].EventID, "dead events are not retried")
+	assert.Contains(t, failing.received, store.events[1].EventID)
+}
+
+func TestRetryDelay(t *testing.T) {
+	assert.Equal(t, minRetryDelay, retryDelay(1))
+	assert.Equal(t, 2*minRetryDelay, retryDelay(2))
+	assert.Equal(t, maxRetryDelay, retryDelay(30))
+}
+
+func TestNewEvent(t *testing.T) {
+	first, err := NewEvent(PostCreated, "posts", struct{}{})
+	require.NoError(t, err)
+
+	second, err := NewEvent(PostCreated, "posts", struct{}{})
+	require.NoError(t, err)
+
+	assert.NotEqual(t, first.EventID, second.EventID)
+	assert.JSONEq(t, "{}", string(first.Payload))
+}
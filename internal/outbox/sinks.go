@@ -0,0 +1,138 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/erknas/forum/graph/model"
+	"github.com/erknas/forum/internal/subscription"
+	"github.com/erknas/forum/pkg/apperr"
+)
+
+const webhookTimeout time.Duration = time.Second * 5
+
+// BanStore tells whether an author has a ban in force.
+type BanStore interface {
+	GetActiveBan(ctx context.Context, author string) (model.CustomBan, error)
+}
+
+// SubscriberSink publishes new posts and comments to subscribers, except
+// those by authors who are banned by the time they are delivered, and the
+// results of polls.
+type SubscriberSink struct {
+	comments subscription.Subscriber
+	posts    subscription.PostSubscriber
+	polls    subscription.PollSubscriber
+	bans     BanStore
+}
+
+func NewSubscriberSink(comments subscription.Subscriber, posts subscription.PostSubscriber, polls subscription.PollSubscriber, bans BanStore) *SubscriberSink {
+	return &SubscriberSink{comments: comments, posts: posts, polls: polls, bans: bans}
+}
+
+func (s *SubscriberSink) Name() string {
+	return "subscriber"
+}
+
+func (s *SubscriberSink) Deliver(ctx context.Context, event Event) error {
+	switch event.Type {
+	case PostCreated:
+		var customPost model.CustomPost
+		if err := json.Unmarshal(event.Payload, &customPost); err != nil {
+			return err
+		}
+
+		if banned, err := s.banned(ctx, customPost.Author.Name); banned || err != nil {
+			return err
+		}
+
+		post := customPost.Convert()
+
+		s.posts.Publish(subscription.PostsTopic, &post)
+	case CommentCreated:
+		var customComment model.CustomComment
+		if err := json.Unmarshal(event.Payload, &customComment); err != nil {
+			return err
+		}
+
+		if banned, err := s.banned(ctx, customComment.Author.Name); banned || err != nil {
+			return err
+		}
+
+		comment := customComment.Convert()
+
+		s.comments.Publish(comment.PostID, &comment)
+	case PollUpdated:
+		var customPoll model.CustomPoll
+		if err := json.Unmarshal(event.Payload, &customPoll); err != nil {
+			return err
+		}
+
+		poll := customPoll.Convert()
+
+		s.polls.Publish(poll.ID, &poll)
+	}
+
+	return nil
+}
+
+func (s *SubscriberSink) banned(ctx context.Context, author string) (bool, error) {
+	_, err := s.bans.GetActiveBan(ctx, author)
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, apperr.ErrNoActiveBan):
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+type WebhookSink struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookSink(url string) *WebhookSink {
+	return &WebhookSink{
+		url:    url,
+		client: &http.Client{Timeout: webhookTimeout},
+	}
+}
+
+func (s *WebhookSink) Name() string {
+	return "webhook " + s.url
+}
+
+func (s *WebhookSink) Deliver(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-ID", event.EventID)
+	req.Header.Set("X-Event-Type", string(event.Type))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/erknas/forum/graph/model"
+	"github.com/erknas/forum/internal/subscription"
+	"github.com/erknas/forum/internal/subscription/mocks"
+	"github.com/erknas/forum/pkg/apperr"
+	"github.com/erknas/forum/pkg/conv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type banStore map[string]error
+
+func (b banStore) GetActiveBan(_ context.Context, author string) (model.CustomBan, error) {
+	if err, ok := b[author]; ok {
+		return model.CustomBan{}, err
+	}
+	return model.CustomBan{Author: author}, nil
+}
+
+func TestSubscriberSink_SkipsBannedAuthors(t *testing.T) {
+	sub := mocks.NewSubscriber(t)
+	sink := NewSubscriberSink(sub, mocks.NewPostSubscriber(t), mocks.NewPollSubscriber(t), banStore{"Alice": apperr.ErrNoActiveBan, "Carol": errors.New("unavailable")})
+
+	comment := func(author string) Event {
+		event, err := NewEvent(CommentCreated, "1", model.CustomComment{ID: 1, PostID: 1, Author: model.CustomAuthor{Name: author}})
+		require.NoError(t, err)
+		return event
+	}
+
+	sub.On("Publish", conv.GlobalID(conv.TypePost, 1), mock.MatchedBy(func(c *model.Comment) bool {
+		return c.Author.Name == "Alice"
+	})).Once()
+
+	require.NoError(t, sink.Deliver(context.Background(), comment("Alice")))
+	require.NoError(t, sink.Deliver(context.Background(), comment("Bob")), "banned authors are skipped")
+	assert.Error(t, sink.Deliver(context.Background(), comment("Carol")), "the relay retries when bans cannot be checked")
+}
+
+func TestSubscriberSink_Posts(t *testing.T) {
+	posts := mocks.NewPostSubscriber(t)
+	sink := NewSubscriberSink(mocks.NewSubscriber(t), posts, mocks.NewPollSubscriber(t), banStore{"Alice": apperr.ErrNoActiveBan})
+
+	post := func(author string) Event {
+		event, err := NewEvent(PostCreated, "posts", model.CustomPost{ID: 1, Author: model.CustomAuthor{Name: author}})
+		require.NoError(t, err)
+		return event
+	}
+
+	posts.On("Publish", subscription.PostsTopic, mock.MatchedBy(func(p *model.Post) bool {
+		return p.ID == conv.GlobalID(conv.TypePost, 1) && p.Author.Name == "Alice"
+	})).Once()
+
+	require.NoError(t, sink.Deliver(context.Background(), post("Alice")))
+	require.NoError(t, sink.Deliver(context.Background(), post("Bob")), "banned authors are skipped")
+}
+
+func TestSubscriberSink_Polls(t *testing.T) {
+	polls := mocks.NewPollSubscriber(t)
+	sink := NewSubscriberSink(mocks.NewSubscriber(t), mocks.NewPostSubscriber(t), polls, banStore{})
+
+	event, err := NewEvent(PollUpdated, "7", model.CustomPoll{ID: 7, Anonymous: true, Voters: 1, Options: []model.CustomPollOption{{ID: 1, Text: "Monday", Votes: 1}}})
+	require.NoError(t, err)
+
+	polls.On("Publish", conv.GlobalID(conv.TypePoll, 7), mock.MatchedBy(func(p *model.Poll) bool {
+		return p.TotalVoters == 1 && p.Options[0].Votes == 1 && p.Options[0].Voters == nil
+	})).Once()
+
+	require.NoError(t, sink.Deliver(context.Background(), event))
+}
@@ -0,0 +1,179 @@
+// Package safelist keeps the operations a client is allowed to run, loaded
+// from an Apollo persisted query manifest.
+package safelist
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/erknas/forum/pkg/apperr"
+	"github.com/erknas/forum/pkg/sl"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+const manifestFormat = "apollo-persisted-query-manifest"
+
+// manifest is the file generated by Apollo's generate-persisted-query-manifest.
+// Operation IDs are SHA-256 hashes of the operation bodies, the same hashes
+// clients send for automatic persisted queries.
+type manifest struct {
+	Format     string `json:"format"`
+	Version    int    `json:"version"`
+	Operations []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+		Body string `json:"body"`
+	} `json:"operations"`
+}
+
+type Safelist struct {
+	path string
+
+	mu      sync.RWMutex
+	queries map[string]string
+}
+
+func Load(path string) (*Safelist, error) {
+	s := &Safelist{path: path}
+
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Reload replaces the operations with the current content of the file. On
+// error the operations loaded before are kept.
+func (s *Safelist) Reload() error {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return err
+	}
+
+	m := manifest{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return fmt.Errorf("parse %s: %w", s.path, err)
+	}
+
+	if m.Format != manifestFormat || m.Version != 1 {
+		return fmt.Errorf("%s: unsupported manifest format %q version %d", s.path, m.Format, m.Version)
+	}
+
+	queries := make(map[string]string, len(m.Operations))
+
+	for _, op := range m.Operations {
+		if hash(op.Body) != op.ID {
+			return fmt.Errorf("%s: id of operation %q is not the SHA-256 hash of its body", s.path, op.Name)
+		}
+		queries[op.ID] = op.Body
+	}
+
+	s.mu.Lock()
+	s.queries = queries
+	s.mu.Unlock()
+
+	return nil
+}
+
+// ReloadOnSignal reloads the safelist on every SIGHUP until ctx is done.
+func (s *Safelist) ReloadOnSignal(ctx context.Context) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGHUP)
+	defer signal.Stop(ch)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ch:
+			if err := s.Reload(); err != nil {
+				slog.Error("failed to reload safelist", sl.Err(err), "path", s.path)
+				continue
+			}
+			slog.Info("safelist reloaded", "path", s.path, "operations", s.Len())
+		}
+	}
+}
+
+func (s *Safelist) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.queries)
+}
+
+// Query returns the operation with the given SHA-256 hash.
+func (s *Safelist) Query(hash string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	query, ok := s.queries[hash]
+	return query, ok
+}
+
+// Contains reports whether the operation text is in the safelist.
+func (s *Safelist) Contains(query string) bool {
+	_, ok := s.Query(hash(query))
+	return ok
+}
+
+func hash(query string) string {
+	sum := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(sum[:])
+}
+
+// Extension runs operations from the safelist when a client sends only the
+// hash of a safelisted operation. In strict mode any other operation is
+// rejected. It must be added before extension.AutomaticPersistedQuery.
+type Extension struct {
+	List   *Safelist
+	Strict bool
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationParameterMutator
+} = Extension{}
+
+func (Extension) ExtensionName() string {
+	return "Safelist"
+}
+
+func (e Extension) Validate(graphql.ExecutableSchema) error {
+	if e.List == nil {
+		return fmt.Errorf("safelist extension requires a safelist")
+	}
+	return nil
+}
+
+func (e Extension) MutateOperationParameters(_ context.Context, rawParams *graphql.RawParams) *gqlerror.Error {
+	if rawParams.Query == "" {
+		if persisted, ok := rawParams.Extensions["persistedQuery"].(map[string]any); ok {
+			if sha256Hash, ok := persisted["sha256Hash"].(string); ok {
+				if query, ok := e.List.Query(sha256Hash); ok {
+					rawParams.Query = query
+				}
+			}
+		}
+	}
+
+	if !e.Strict || (rawParams.Query != "" && e.List.Contains(rawParams.Query)) {
+		return nil
+	}
+
+	err := gqlerror.Errorf("operation is not in the safelist")
+	errcode.Set(err, string(apperr.CodeForbidden))
+
+	return err
+}
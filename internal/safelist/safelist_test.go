@@ -0,0 +1,181 @@
+package safelist
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	postsQuery  = `query Posts { GetPosts { id title } }`
+	authorQuery = `query Author { Author(name: "Bob") { id } }`
+)
+
+func writeManifest(t *testing.T, path string, queries ...string) {
+	t.Helper()
+
+	m := map[string]any{
+		"format":  manifestFormat,
+		"version": 1,
+	}
+
+	var ops []map[string]string
+	for _, query := range queries {
+		ops = append(ops, map[string]string{"id": hash(query), "name": "op", "type": "query", "body": query})
+	}
+	m["operations"] = ops
+
+	data, err := json.Marshal(m)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+}
+
+func newSafelist(t *testing.T, queries ...string) *Safelist {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "manifest.json")
+	writeManifest(t, path, queries...)
+
+	s, err := Load(path)
+	require.NoError(t, err)
+
+	return s
+}
+
+func TestLoad(t *testing.T) {
+	s := newSafelist(t, postsQuery)
+
+	assert.Equal(t, 1, s.Len())
+	assert.True(t, s.Contains(postsQuery))
+	assert.False(t, s.Contains(authorQuery))
+
+	query, ok := s.Query(hash(postsQuery))
+	assert.True(t, ok)
+	assert.Equal(t, postsQuery, query)
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+	}{
+		{
+			name:     "Not JSON",
+			manifest: `not json`,
+		},
+		{
+			name:     "Unknown format",
+			manifest: `{"format":"other","version":1,"operations":[]}`,
+		},
+		{
+			name:     "ID is not the hash of the body",
+			manifest: `{"format":"apollo-persisted-query-manifest","version":1,"operations":[{"id":"abc","name":"Posts","body":"{ GetPosts { id } }"}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "manifest.json")
+			require.NoError(t, os.WriteFile(path, []byte(tt.manifest), 0o600))
+
+			_, err := Load(path)
+			assert.Error(t, err)
+		})
+	}
+
+	_, err := Load(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
+
+func TestReload(t *testing.T) {
+	s := newSafelist(t, postsQuery)
+
+	writeManifest(t, s.path, postsQuery, authorQuery)
+	require.NoError(t, s.Reload())
+	assert.Equal(t, 2, s.Len())
+	assert.True(t, s.Contains(authorQuery))
+
+	require.NoError(t, os.WriteFile(s.path, []byte(`broken`), 0o600))
+	assert.Error(t, s.Reload())
+	assert.Equal(t, 2, s.Len())
+}
+
+func TestExtension(t *testing.T) {
+	s := newSafelist(t, postsQuery)
+
+	persisted := func(query string) map[string]any {
+		return map[string]any{
+			"persistedQuery": map[string]any{"version": 1, "sha256Hash": hash(query)},
+		}
+	}
+
+	tests := []struct {
+		name      string
+		strict    bool
+		params    graphql.RawParams
+		wantQuery string
+		wantErr   bool
+	}{
+		{
+			name:      "Hash of a safelisted query",
+			params:    graphql.RawParams{Extensions: persisted(postsQuery)},
+			wantQuery: postsQuery,
+		},
+		{
+			name:      "Unknown hash is left to APQ",
+			params:    graphql.RawParams{Extensions: persisted(authorQuery)},
+			wantQuery: "",
+		},
+		{
+			name:      "Query outside of the safelist",
+			params:    graphql.RawParams{Query: authorQuery},
+			wantQuery: authorQuery,
+		},
+		{
+			name:      "Strict hash of a safelisted query",
+			strict:    true,
+			params:    graphql.RawParams{Extensions: persisted(postsQuery)},
+			wantQuery: postsQuery,
+		},
+		{
+			name:      "Strict safelisted query",
+			strict:    true,
+			params:    graphql.RawParams{Query: postsQuery},
+			wantQuery: postsQuery,
+		},
+		{
+			name:    "Strict unknown hash",
+			strict:  true,
+			params:  graphql.RawParams{Extensions: persisted(authorQuery)},
+			wantErr: true,
+		},
+		{
+			name:    "Strict query outside of the safelist",
+			strict:  true,
+			params:  graphql.RawParams{Query: authorQuery},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := tt.params
+
+			err := Extension{List: s, Strict: tt.strict}.MutateOperationParameters(context.Background(), &params)
+			if tt.wantErr {
+				require.NotNil(t, err)
+				assert.Equal(t, "FORBIDDEN", err.Extensions["code"])
+				return
+			}
+
+			require.Nil(t, err)
+			assert.Equal(t, tt.wantQuery, params.Query)
+		})
+	}
+}
@@ -0,0 +1,59 @@
+// Package scheduler publishes scheduled posts when they are due.
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/erknas/forum/graph/model"
+	"github.com/erknas/forum/pkg/sl"
+)
+
+// Store publishes the scheduled posts due at now. Storages store the events
+// of the published posts, which the outbox relay delivers to subscribers.
+type Store interface {
+	PublishDuePosts(ctx context.Context, now time.Time) ([]model.CustomPost, error)
+}
+
+type Scheduler struct {
+	store    Store
+	interval time.Duration
+	now      func() time.Time
+}
+
+func New(store Store, interval time.Duration) *Scheduler {
+	return &Scheduler{
+		store:    store,
+		interval: interval,
+		now:      time.Now,
+	}
+}
+
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.Publish(ctx); err != nil {
+			slog.Error("failed to publish scheduled posts", sl.Err(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Publish publishes the posts that are due and returns how many there were.
+func (s *Scheduler) Publish(ctx context.Context) (int, error) {
+	posts, err := s.store.PublishDuePosts(ctx, s.now())
+
+	for _, post := range posts {
+		slog.Info("scheduled post published", "post_id", post.ID, "publish_at", post.PublishAt)
+	}
+
+	return len(posts), err
+}
@@ -0,0 +1,82 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/erknas/forum/graph/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type scheduledStore struct {
+	posts []model.CustomPost
+	err   error
+}
+
+func (s *scheduledStore) PublishDuePosts(_ context.Context, now time.Time) ([]model.CustomPost, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	var due, rest []model.CustomPost
+
+	for _, post := range s.posts {
+		if post.PublishAt.After(now) {
+			rest = append(rest, post)
+		} else {
+			post.Status = model.ContentStatusPublished
+			due = append(due, post)
+		}
+	}
+
+	s.posts = rest
+
+	return due, nil
+}
+
+func TestScheduler_Publish(t *testing.T) {
+	var (
+		now    = time.Now()
+		past   = now.Add(-time.Minute)
+		future = now.Add(time.Minute)
+		store  = &scheduledStore{posts: []model.CustomPost{{ID: 1, PublishAt: &past}, {ID: 2, PublishAt: &future}}}
+	)
+
+	s := New(store, time.Second)
+	s.now = func() time.Time { return now }
+
+	published, err := s.Publish(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, published)
+
+	published, err = s.Publish(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, published, "posts are published once")
+
+	s.now = func() time.Time { return future }
+
+	published, err = s.Publish(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, published)
+
+	store.err = errors.New("unavailable")
+
+	_, err = s.Publish(context.Background())
+	assert.Error(t, err)
+}
+
+func TestScheduler_Run(t *testing.T) {
+	past := time.Now().Add(-time.Minute)
+	store := &scheduledStore{posts: []model.CustomPost{{ID: 1, PublishAt: &past}}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Run publishes once before waiting for the first tick.
+	New(store, time.Hour).Run(ctx)
+
+	assert.Empty(t, store.posts)
+}
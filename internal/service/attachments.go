@@ -0,0 +1,331 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/erknas/forum/graph/model"
+	"github.com/erknas/forum/internal/loader"
+	"github.com/erknas/forum/internal/storage"
+	"github.com/erknas/forum/pkg/apperr"
+	"github.com/erknas/forum/pkg/conv"
+	"github.com/erknas/forum/pkg/sl"
+	"github.com/erknas/forum/pkg/thumbnail"
+)
+
+const (
+	maxFilenameLength = 255
+	// maxImagePixels bounds the images that are decoded for a thumbnail, so a
+	// small file cannot expand into a huge bitmap.
+	maxImagePixels = 25_000_000
+)
+
+var errInvalidImage = errors.New("invalid image")
+
+// extensions are the file extensions of the blob keys of known types.
+var extensions = map[string]string{
+	"image/png":       ".png",
+	"image/jpeg":      ".jpg",
+	"image/gif":       ".gif",
+	"image/webp":      ".webp",
+	"application/pdf": ".pdf",
+	"text/plain":      ".txt",
+}
+
+// AttachmentOptions mirrors config.AttachmentConfig, with Blobs to keep the
+// files in. Uploads are refused when Blobs is nil or MaxCount is zero.
+type AttachmentOptions struct {
+	Blobs         storage.BlobStore
+	MaxCount      int
+	MaxSize       int64
+	Types         []string
+	ThumbnailSize int
+	URL           string
+}
+
+// upload is an uploaded file that passed validation.
+type upload struct {
+	filename    string
+	contentType string
+	data        []byte
+	width       int
+	height      int
+}
+
+// PostAttachments returns the attachments of a post.
+func (s *Service) PostAttachments(ctx context.Context, strID string) ([]*model.Attachment, error) {
+	id, err := conv.NodeID(conv.TypePost, strID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.getAttachments(ctx, conv.TypePost, id)
+}
+
+// CommentAttachments returns the attachments of a comment.
+func (s *Service) CommentAttachments(ctx context.Context, strID string) ([]*model.Attachment, error) {
+	id, err := conv.NodeID(conv.TypeComment, strID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.getAttachments(ctx, conv.TypeComment, id)
+}
+
+func (s *Service) getAttachments(ctx context.Context, targetType string, id int) ([]*model.Attachment, error) {
+	var (
+		customAttachments []model.CustomAttachment
+		err               error
+	)
+
+	if loaders := loader.For(ctx); loaders != nil {
+		customAttachments, err = loaders.Attachments.Load(ctx, loader.AttachmentsKey{TargetType: targetType, TargetID: id})
+	} else {
+		var byTarget map[int][]model.CustomAttachment
+		byTarget, err = s.store.GetAttachments(ctx, targetType, []int{id})
+		customAttachments = byTarget[id]
+	}
+	if err != nil {
+		slog.Error("failed to get attachments", sl.Err(err), "target_type", targetType, "target_id", id)
+		return nil, err
+	}
+
+	attachments := make([]*model.Attachment, 0, len(customAttachments))
+
+	for _, customAttachment := range customAttachments {
+		attachment := customAttachment.Convert(s.attachments.URL)
+		attachments = append(attachments, &attachment)
+	}
+
+	return attachments, nil
+}
+
+// readUploads reads and validates uploaded files. The type of a file is
+// detected from its content, whatever the client claims it is.
+func (s *Service) readUploads(files []*graphql.Upload) ([]upload, error) {
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	if s.attachments.Blobs == nil || s.attachments.MaxCount == 0 {
+		return nil, invalidAttachments("attachments are disabled")
+	}
+
+	if len(files) > s.attachments.MaxCount {
+		return nil, invalidAttachments(fmt.Sprintf("at most %d files can be attached", s.attachments.MaxCount))
+	}
+
+	uploads := make([]upload, 0, len(files))
+
+	for _, file := range files {
+		if file == nil {
+			continue
+		}
+
+		data, err := io.ReadAll(io.LimitReader(file.File, s.attachments.MaxSize+1))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read upload %q: %w", file.Filename, err)
+		}
+
+		if int64(len(data)) > s.attachments.MaxSize {
+			return nil, invalidAttachments(fmt.Sprintf("%s is larger than %d bytes", sanitizeFilename(file.Filename), s.attachments.MaxSize))
+		}
+
+		if len(data) == 0 {
+			return nil, invalidAttachments(fmt.Sprintf("%s is empty", sanitizeFilename(file.Filename)))
+		}
+
+		contentType := http.DetectContentType(data)
+
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil || !slices.Contains(s.attachments.Types, mediaType) {
+			return nil, invalidAttachments(fmt.Sprintf("%s has a type that is not allowed: %s", sanitizeFilename(file.Filename), contentType))
+		}
+
+		u := upload{filename: sanitizeFilename(file.Filename), contentType: contentType, data: data}
+
+		if strings.HasPrefix(mediaType, "image/") {
+			if err := u.decodeConfig(); err != nil {
+				return nil, invalidAttachments(fmt.Sprintf("%s: %s", u.filename, err))
+			}
+		}
+
+		uploads = append(uploads, u)
+	}
+
+	return uploads, nil
+}
+
+// decodeConfig reads the dimensions of an image. Images of types the image
+// package cannot decode have none, nor a thumbnail.
+func (u *upload) decodeConfig() error {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(u.data))
+	if errors.Is(err, image.ErrFormat) {
+		return nil
+	}
+	if err != nil {
+		return errInvalidImage
+	}
+
+	if cfg.Width*cfg.Height > maxImagePixels {
+		return fmt.Errorf("image is larger than %d pixels", maxImagePixels)
+	}
+
+	u.width, u.height = cfg.Width, cfg.Height
+
+	return nil
+}
+
+// putUploads puts the uploads and their thumbnails in the blob store. Blobs
+// put before a failure are deleted again.
+func (s *Service) putUploads(ctx context.Context, uploads []upload) ([]model.CustomAttachment, error) {
+	if len(uploads) == 0 {
+		return nil, nil
+	}
+
+	attachments := make([]model.CustomAttachment, 0, len(uploads))
+
+	for _, u := range uploads {
+		attachment, err := s.putUpload(ctx, u)
+		if err != nil {
+			s.deleteBlobs(ctx, attachments)
+			return nil, err
+		}
+
+		attachments = append(attachments, attachment)
+	}
+
+	return attachments, nil
+}
+
+func (s *Service) putUpload(ctx context.Context, u upload) (model.CustomAttachment, error) {
+	name, err := blobName()
+	if err != nil {
+		return model.CustomAttachment{}, err
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(u.contentType)
+
+	attachment := model.CustomAttachment{
+		Key:         name + extensions[mediaType],
+		Filename:    u.filename,
+		ContentType: u.contentType,
+		Size:        int64(len(u.data)),
+		Width:       u.width,
+		Height:      u.height,
+	}
+
+	if err := s.attachments.Blobs.Put(ctx, attachment.Key, u.contentType, bytes.NewReader(u.data), attachment.Size); err != nil {
+		slog.Error("failed to put blob", sl.Err(err), "key", attachment.Key)
+		return model.CustomAttachment{}, err
+	}
+
+	if u.width == 0 {
+		return attachment, nil
+	}
+
+	// Images are decoded one at a time, as each of them can take up to
+	// maxImagePixels*4 bytes.
+	img, _, err := image.Decode(bytes.NewReader(u.data))
+	if err != nil {
+		s.deleteBlobs(ctx, []model.CustomAttachment{attachment})
+		return model.CustomAttachment{}, invalidAttachments(fmt.Sprintf("%s: %s", u.filename, errInvalidImage))
+	}
+
+	var thumb bytes.Buffer
+
+	if err := thumbnail.Encode(&thumb, thumbnail.Scale(img, s.attachments.ThumbnailSize)); err != nil {
+		slog.Error("failed to encode thumbnail", sl.Err(err), "key", attachment.Key)
+		s.deleteBlobs(ctx, []model.CustomAttachment{attachment})
+		return model.CustomAttachment{}, err
+	}
+
+	thumbnailKey := name + "-thumb.jpg"
+
+	if err := s.attachments.Blobs.Put(ctx, thumbnailKey, "image/jpeg", &thumb, int64(thumb.Len())); err != nil {
+		slog.Error("failed to put blob", sl.Err(err), "key", thumbnailKey)
+		s.deleteBlobs(ctx, []model.CustomAttachment{attachment})
+		return model.CustomAttachment{}, err
+	}
+
+	attachment.ThumbnailKey = thumbnailKey
+
+	return attachment, nil
+}
+
+// deleteBlobs deletes the blobs of attachments that were not stored. It is
+// best-effort: blobs it fails to delete are only logged.
+func (s *Service) deleteBlobs(ctx context.Context, attachments []model.CustomAttachment) {
+	// The blobs are deleted even if the request that put them was canceled.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancel()
+
+	for _, attachment := range attachments {
+		for _, key := range []string{attachment.Key, attachment.ThumbnailKey} {
+			if key == "" {
+				continue
+			}
+
+			if err := s.attachments.Blobs.Delete(ctx, key); err != nil {
+				slog.Error("failed to delete blob", sl.Err(err), "key", key)
+			}
+		}
+	}
+}
+
+// blobName returns a random name for a new blob.
+func blobName() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate blob name: %w", err)
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// sanitizeFilename drops the directories and control characters from the
+// name a client gave a file, and shortens it to maxFilenameLength runes.
+func sanitizeFilename(filename string) string {
+	filename = filepath.Base(strings.ReplaceAll(filename, `\`, "/"))
+
+	filename = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || r == utf8.RuneError {
+			return -1
+		}
+		return r
+	}, filename)
+
+	filename = strings.TrimSpace(filename)
+
+	if runes := []rune(filename); len(runes) > maxFilenameLength {
+		filename = string(runes[:maxFilenameLength])
+	}
+
+	if filename == "" || filename == "." || filename == ".." || filename == "/" {
+		return "file"
+	}
+
+	return filename
+}
+
+func invalidAttachments(message string) error {
+	return apperr.Validation("invalid request data", map[string]interface{}{"attachments": message})
+}
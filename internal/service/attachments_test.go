@@ -0,0 +1,235 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/erknas/forum/graph/model"
+	"github.com/erknas/forum/internal/storage/mocks"
+	"github.com/erknas/forum/pkg/apperr"
+	"github.com/erknas/forum/pkg/conv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func testAttachmentOptions(blobs *mocks.BlobStore) AttachmentOptions {
+	return AttachmentOptions{
+		Blobs:         blobs,
+		MaxCount:      2,
+		MaxSize:       1 << 16,
+		Types:         []string{"image/png", "image/gif", "text/plain"},
+		ThumbnailSize: 32,
+		URL:           "/attachments/",
+	}
+}
+
+func testUpload(filename string, data []byte) *graphql.Upload {
+	return &graphql.Upload{File: bytes.NewReader(data), Filename: filename, Size: int64(len(data))}
+}
+
+func testPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height))))
+
+	return buf.Bytes()
+}
+
+func TestCreatePost_Attachments(t *testing.T) {
+	var (
+		storerMock = mocks.NewStorer(t)
+		blobsMock  = mocks.NewBlobStore(t)
+		s          = New(storerMock, Options{Attachments: testAttachmentOptions(blobsMock)})
+		keys       []string
+	)
+
+	notBanned(storerMock)
+
+	blobsMock.On("Put", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { keys = append(keys, args.String(1)) }).
+		Return(nil).Times(3)
+
+	storerMock.On("CreatePost", mock.Anything, mock.MatchedBy(func(input model.CustomPostInput) bool {
+		if len(input.Attachments) != 2 {
+			return false
+		}
+
+		img, text := input.Attachments[0], input.Attachments[1]
+
+		return img.Filename == "cat.png" && img.ContentType == "image/png" && img.Width == 64 && img.Height == 16 &&
+			strings.HasSuffix(img.Key, ".png") && strings.HasSuffix(img.ThumbnailKey, "-thumb.jpg") &&
+			text.Filename == "notes.txt" && text.ContentType == "text/plain; charset=utf-8" && text.Size == 5 &&
+			strings.HasSuffix(text.Key, ".txt") && text.ThumbnailKey == ""
+	})).Return(model.CustomPost{ID: 1}, nil).Once()
+
+	_, err := s.CreatePost(context.Background(), model.PostInput{
+		Title:   "Title",
+		Author:  "Bob",
+		Content: "Content",
+		Attachments: []*graphql.Upload{
+			testUpload(`C:\photos\cat.png`, testPNG(t, 64, 16)),
+			testUpload("notes.txt", []byte("hello")),
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Len(t, keys, 3)
+}
+
+func TestCreatePost_AttachmentsNotStored(t *testing.T) {
+	var (
+		storerMock = mocks.NewStorer(t)
+		blobsMock  = mocks.NewBlobStore(t)
+		s          = New(storerMock, Options{Attachments: testAttachmentOptions(blobsMock)})
+		put        []string
+		deleted    []string
+	)
+
+	notBanned(storerMock)
+
+	blobsMock.On("Put", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { put = append(put, args.String(1)) }).
+		Return(nil)
+	blobsMock.On("Delete", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { deleted = append(deleted, args.String(1)) }).
+		Return(nil)
+
+	storerMock.On("CreatePost", mock.Anything, mock.Anything).Return(model.CustomPost{}, errors.New("db is down")).Once()
+
+	_, err := s.CreatePost(context.Background(), model.PostInput{
+		Title:       "Title",
+		Author:      "Bob",
+		Content:     "Content",
+		Attachments: []*graphql.Upload{testUpload("cat.png", testPNG(t, 8, 8))},
+	})
+	require.Error(t, err)
+
+	assert.Len(t, put, 2)
+	assert.ElementsMatch(t, put, deleted, "blobs of posts that were not stored are deleted")
+}
+
+func TestCreateComment_AttachmentsBanned(t *testing.T) {
+	var (
+		storerMock = mocks.NewStorer(t)
+		blobsMock  = mocks.NewBlobStore(t)
+		s          = New(storerMock, Options{Attachments: testAttachmentOptions(blobsMock)})
+	)
+
+	storerMock.On("GetActiveBan", mock.Anything, "Bob").Return(model.CustomBan{Kind: model.BanKindBan}, nil)
+
+	_, err := s.CreateComment(context.Background(), model.CommentInput{
+		Author:      "Bob",
+		Content:     "Content",
+		PostID:      "1",
+		Attachments: []*graphql.Upload{testUpload("notes.txt", []byte("hello"))},
+	})
+	require.Error(t, err)
+
+	blobsMock.AssertNotCalled(t, "Put", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestReadUploads_Invalid(t *testing.T) {
+	// The header of a GIF whose screen is 10000x10000 pixels.
+	hugeGIF := []byte("GIF89a\x10\x27\x10\x27\x00\x00\x00")
+
+	tests := []struct {
+		name    string
+		opts    func(*AttachmentOptions)
+		files   []*graphql.Upload
+		message string
+	}{
+		{
+			name:    "disabled",
+			opts:    func(o *AttachmentOptions) { o.Blobs = nil },
+			files:   []*graphql.Upload{testUpload("a.txt", []byte("a"))},
+			message: "attachments are disabled",
+		},
+		{
+			name:    "too many",
+			files:   []*graphql.Upload{testUpload("a.txt", []byte("a")), testUpload("b.txt", []byte("b")), testUpload("c.txt", []byte("c"))},
+			message: "at most 2 files can be attached",
+		},
+		{
+			name:    "too large",
+			opts:    func(o *AttachmentOptions) { o.MaxSize = 4 },
+			files:   []*graphql.Upload{testUpload("a.txt", []byte("hello"))},
+			message: "a.txt is larger than 4 bytes",
+		},
+		{
+			name:    "empty",
+			files:   []*graphql.Upload{testUpload("a.txt", nil)},
+			message: "a.txt is empty",
+		},
+		{
+			name:    "type not allowed",
+			files:   []*graphql.Upload{testUpload("cat.png", []byte("<html><script>alert(1)</script>"))},
+			message: "cat.png has a type that is not allowed: text/html; charset=utf-8",
+		},
+		{
+			name:    "too many pixels",
+			files:   []*graphql.Upload{testUpload("huge.gif", hugeGIF)},
+			message: "huge.gif: image is larger than 25000000 pixels",
+		},
+		{
+			name:    "broken image",
+			files:   []*graphql.Upload{testUpload("broken.png", []byte("\x89PNG\r\n\x1a\nbroken"))},
+			message: "broken.png: invalid image",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := testAttachmentOptions(mocks.NewBlobStore(t))
+			if tt.opts != nil {
+				tt.opts(&opts)
+			}
+
+			s := New(mocks.NewStorer(t), Options{Attachments: opts})
+
+			_, err := s.readUploads(tt.files)
+			assert.Equal(t, apperr.Validation("invalid request data", map[string]interface{}{"attachments": tt.message}), err)
+		})
+	}
+}
+
+func TestPostAttachments(t *testing.T) {
+	storerMock := mocks.NewStorer(t)
+	s := New(storerMock, Options{Attachments: AttachmentOptions{URL: "https://cdn.example.com/"}})
+
+	storerMock.On("GetAttachments", mock.Anything, conv.TypePost, []int{1}).Return(map[int][]model.CustomAttachment{
+		1: {{ID: 3, Key: "a.png", ThumbnailKey: "a-thumb.jpg", Filename: "cat.png", ContentType: "image/png", Size: 10, Width: 4, Height: 2}},
+	}, nil).Once()
+
+	attachments, err := s.PostAttachments(context.Background(), conv.GlobalID(conv.TypePost, 1))
+	require.NoError(t, err)
+	require.Len(t, attachments, 1)
+
+	assert.Equal(t, conv.GlobalID(conv.TypeAttachment, 3), attachments[0].ID)
+	assert.Equal(t, "https://cdn.example.com/a.png", attachments[0].URL)
+	require.NotNil(t, attachments[0].ThumbnailURL)
+	assert.Equal(t, "https://cdn.example.com/a-thumb.jpg", *attachments[0].ThumbnailURL)
+}
+
+func TestSanitizeFilename(t *testing.T) {
+	tests := map[string]string{
+		"cat.png":                "cat.png",
+		"../../etc/passwd":       "passwd",
+		`C:\Users\bob\cat.png`:   "cat.png",
+		"  a\x00b\nc.txt ":       "abc.txt",
+		"":                       "file",
+		"..":                     "file",
+		strings.Repeat("й", 300): strings.Repeat("й", maxFilenameLength),
+	}
+
+	for filename, want := range tests {
+		assert.Equal(t, want, sanitizeFilename(filename), filename)
+	}
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+
+	"github.com/erknas/forum/graph/model"
+	"github.com/erknas/forum/internal/auth"
+	"github.com/erknas/forum/internal/filter"
+	"github.com/erknas/forum/pkg/apperr"
+	"github.com/erknas/forum/pkg/conv"
+	"github.com/erknas/forum/pkg/pagination"
+	"github.com/erknas/forum/pkg/sl"
+)
+
+var errAdminOnly = apperr.Forbidden("admin role required")
+
+const trainBatchSize = 500
+
+// AuditLog returns the moderator actions that match filter, newest first.
+func (s *Service) AuditLog(ctx context.Context, filter *model.AuditLogFilter, first *int32, after *string) (*model.AuditEntryConnection, error) {
+	if !auth.From(ctx).IsAdmin() {
+		return nil, errAdminOnly
+	}
+
+	customFilter, err := auditFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	limit, offset, err := pagination.FromCursor(first, after)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := s.store.GetAuditLog(ctx, customFilter, offset, limit+1)
+	if err != nil {
+		slog.Error("failed to get audit log", sl.Err(err))
+		return nil, err
+	}
+
+	connection := new(model.AuditEntryConnection)
+	connection.Edges, connection.PageInfo = pagination.Edges(entries, offset, limit, func(entry model.CustomAuditEntry, cursor string) *model.AuditEntryEdge {
+		node := entry.Convert()
+		return &model.AuditEntryEdge{Cursor: cursor, Node: &node}
+	})
+
+	slog.Info("AuditLog OK", "offset", offset, "limit", limit)
+
+	return connection, nil
+}
+
+// TrainFilters teaches the filters the moderator decisions in the audit log,
+// so that what they learned survives a restart: approved content is not
+// spam, rejected content is. Content rejected by resolving its reports is
+// replayed from the REJECT_CONTENT entry the resolution writes along with its
+// RESOLVE_REPORTS entry.
+func (s *Service) TrainFilters(ctx context.Context) error {
+	var samples int
+
+	for _, action := range []model.AuditAction{model.AuditActionApproveContent, model.AuditActionRejectContent} {
+		for offset := 0; ; offset += trainBatchSize {
+			entries, err := s.store.GetAuditLog(ctx, model.CustomAuditFilter{Action: action}, offset, trainBatchSize)
+			if err != nil {
+				slog.Error("failed to get audit log", sl.Err(err), "action", action)
+				return err
+			}
+
+			for _, entry := range entries {
+				content, err := decided(entry)
+				if err != nil {
+					slog.Error("failed to decode audit entry", sl.Err(err), "id", entry.ID)
+					return err
+				}
+
+				s.filters.Learn(content, action == model.AuditActionRejectContent)
+				samples++
+			}
+
+			if len(entries) < trainBatchSize {
+				break
+			}
+		}
+	}
+
+	slog.Info("TrainFilters OK", "samples", samples)
+
+	return nil
+}
+
+// decided returns the content a moderator decision in the audit log was made
+// on.
+func decided(entry model.CustomAuditEntry) (filter.Content, error) {
+	if entry.TargetType == conv.TypePost {
+		var post model.CustomPost
+		if err := json.Unmarshal(entry.After, &post); err != nil {
+			return filter.Content{}, err
+		}
+		return filter.Content{Author: post.Author.Name, Title: post.Title, Text: post.Content}, nil
+	}
+
+	var comment model.CustomComment
+	if err := json.Unmarshal(entry.After, &comment); err != nil {
+		return filter.Content{}, err
+	}
+	return filter.Content{Author: comment.Author.Name, Text: comment.Content}, nil
+}
+
+func auditFilter(filter *model.AuditLogFilter) (model.CustomAuditFilter, error) {
+	customFilter := model.CustomAuditFilter{}
+
+	if filter == nil {
+		return customFilter, nil
+	}
+
+	if filter.Actor != nil {
+		customFilter.Actor = *filter.Actor
+	}
+	if filter.Action != nil {
+		customFilter.Action = *filter.Action
+	}
+	if filter.TargetID != nil {
+		typ, id, err := conv.FromGlobalID(*filter.TargetID)
+		if err != nil {
+			return customFilter, err
+		}
+		customFilter.TargetType, customFilter.TargetID = typ, id
+	}
+	if filter.Since != nil {
+		customFilter.Since = *filter.Since
+	}
+	if filter.Until != nil {
+		customFilter.Until = *filter.Until
+	}
+
+	return customFilter, nil
+}
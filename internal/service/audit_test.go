@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/erknas/forum/graph/model"
+	"github.com/erknas/forum/internal/auth"
+	"github.com/erknas/forum/internal/filter"
+	"github.com/erknas/forum/internal/storage/mocks"
+	"github.com/erknas/forum/pkg/apperr"
+	"github.com/erknas/forum/pkg/conv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditLog(t *testing.T) {
+	storerMock := mocks.NewStorer(t)
+	s := New(storerMock, Options{})
+
+	_, err := s.AuditLog(auth.With(context.Background(), moderator), nil, nil, nil)
+	assert.Equal(t, apperr.CodeForbidden, apperr.CodeOf(err))
+
+	var (
+		ctx    = auth.With(context.Background(), &auth.Viewer{Name: "Root", Role: auth.RoleAdmin})
+		since  = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		actor  = "Mod"
+		action = model.AuditActionRejectContent
+		target = conv.GlobalID(conv.TypeComment, 2)
+		first  = int32(1)
+	)
+
+	filter := model.CustomAuditFilter{Actor: actor, Action: action, TargetType: conv.TypeComment, TargetID: 2, Since: since}
+	entries := []model.CustomAuditEntry{
+		{ID: 7, Actor: actor, Action: action, TargetType: conv.TypeComment, TargetID: 2, Before: json.RawMessage(`{"status":"PUBLISHED"}`), After: json.RawMessage(`{"status":"REJECTED"}`)},
+		{ID: 3, Actor: actor, Action: action, TargetType: conv.TypeComment, TargetID: 2},
+	}
+
+	storerMock.On("GetAuditLog", mock.Anything, filter, 0, 2).Return(entries, nil)
+
+	connection, err := s.AuditLog(ctx, &model.AuditLogFilter{Actor: &actor, Action: &action, TargetID: &target, Since: &since}, &first, nil)
+	require.NoError(t, err)
+	require.Len(t, connection.Edges, 1)
+	assert.True(t, connection.PageInfo.HasNextPage)
+
+	entry := connection.Edges[0].Node
+	assert.Equal(t, conv.GlobalID(conv.TypeAuditEntry, 7), entry.ID)
+	assert.Equal(t, target, entry.TargetID)
+	require.NotNil(t, entry.Before)
+	assert.JSONEq(t, `{"status":"PUBLISHED"}`, *entry.Before)
+
+	invalid := "nonsense"
+	_, err = s.AuditLog(ctx, &model.AuditLogFilter{TargetID: &invalid}, nil, nil)
+	assert.Error(t, err)
+}
+
+func TestTrainFilters(t *testing.T) {
+	storerMock := mocks.NewStorer(t)
+	classifier := filter.NewClassifier()
+	s := New(storerMock, Options{Filters: []filter.Filter{filter.NewSpam(classifier, 0.9, 1)}})
+
+	approved := []model.CustomAuditEntry{
+		{ID: 2, Action: model.AuditActionApproveContent, TargetType: conv.TypeComment, After: json.RawMessage(`{"content":"see you on friday","author":{"name":"Bob"}}`)},
+	}
+	rejected := []model.CustomAuditEntry{
+		{ID: 3, Action: model.AuditActionRejectContent, TargetType: conv.TypePost, After: json.RawMessage(`{"title":"Deal","content":"buy cheap pills","author":{"name":"Eve"}}`)},
+		{ID: 1, Action: model.AuditActionRejectContent, TargetType: conv.TypeComment, After: json.RawMessage(`{"content":"cheap pills here","author":{"name":"Eve"}}`)},
+	}
+
+	storerMock.On("GetAuditLog", mock.Anything, model.CustomAuditFilter{Action: model.AuditActionApproveContent}, 0, trainBatchSize).Return(approved, nil)
+	storerMock.On("GetAuditLog", mock.Anything, model.CustomAuditFilter{Action: model.AuditActionRejectContent}, 0, trainBatchSize).Return(rejected, nil)
+
+	require.NoError(t, s.TrainFilters(context.Background()))
+
+	hamDocs, spamDocs := classifier.Samples()
+	assert.Equal(t, 1, hamDocs)
+	assert.Equal(t, 2, spamDocs)
+	assert.Greater(t, classifier.SpamProbability("cheap deal"), 0.5)
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/erknas/forum/graph/model"
+	"github.com/erknas/forum/internal/auth"
+	"github.com/erknas/forum/pkg/apperr"
+	"github.com/erknas/forum/pkg/pagination"
+	"github.com/erknas/forum/pkg/sl"
+)
+
+// BanUser bans or shadowbans author until expiresAt, or for good if it is
+// nil. The new ban replaces the one in force.
+func (s *Service) BanUser(ctx context.Context, author string, kind model.BanKind, reason string, expiresAt *time.Time) (*model.Ban, error) {
+	viewer := auth.From(ctx)
+	if !viewer.IsModerator() {
+		return nil, errModeratorOnly
+	}
+
+	input := model.CustomBanInput{
+		Author:    strings.TrimSpace(author),
+		Kind:      kind,
+		Reason:    strings.TrimSpace(reason),
+		Moderator: viewer.Name,
+		ExpiresAt: expiresAt,
+	}
+
+	fields := make(map[string]interface{})
+	if input.Author == "" {
+		fields["author"] = "author is required"
+	}
+	if input.Reason == "" {
+		fields["reason"] = "reason is required"
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		fields["expiresAt"] = "expiresAt must be in the future"
+	}
+	if len(fields) > 0 {
+		return nil, apperr.Validation("invalid request data", fields)
+	}
+
+	customBan, err := s.store.CreateBan(ctx, input)
+	if err != nil {
+		slog.Error("failed to ban user", sl.Err(err), "author", input.Author)
+		return nil, err
+	}
+
+	ban := customBan.Convert()
+
+	slog.Info("BanUser OK", "author", ban.Author, "kind", ban.Kind, "moderator", viewer.Name)
+
+	return &ban, nil
+}
+
+// UnbanUser lifts the ban in force on author.
+func (s *Service) UnbanUser(ctx context.Context, author string) (*model.Ban, error) {
+	viewer := auth.From(ctx)
+	if !viewer.IsModerator() {
+		return nil, errModeratorOnly
+	}
+
+	customBan, err := s.store.LiftBan(ctx, strings.TrimSpace(author), viewer.Name)
+	if err != nil {
+		slog.Error("failed to unban user", sl.Err(err), "author", author)
+		return nil, err
+	}
+
+	ban := customBan.Convert()
+
+	slog.Info("UnbanUser OK", "author", ban.Author, "moderator", viewer.Name)
+
+	return &ban, nil
+}
+
+// Bans returns the bans in force, newest first.
+func (s *Service) Bans(ctx context.Context, first *int32, after *string) (*model.BanConnection, error) {
+	if !auth.From(ctx).IsModerator() {
+		return nil, errModeratorOnly
+	}
+
+	limit, offset, err := pagination.FromCursor(first, after)
+	if err != nil {
+		return nil, err
+	}
+
+	bans, err := s.store.GetActiveBans(ctx, offset, limit+1)
+	if err != nil {
+		slog.Error("failed to get bans", sl.Err(err))
+		return nil, err
+	}
+
+	connection := new(model.BanConnection)
+	connection.Edges, connection.PageInfo = pagination.Edges(bans, offset, limit, func(customBan model.CustomBan, cursor string) *model.BanEdge {
+		ban := customBan.Convert()
+		return &model.BanEdge{Cursor: cursor, Node: &ban}
+	})
+
+	slog.Info("Bans OK", "offset", offset, "limit", limit)
+
+	return connection, nil
+}
+
+// CheckSubscriber returns an error if the viewer is banned from opening
+// subscriptions.
+func (s *Service) CheckSubscriber(ctx context.Context) error {
+	viewer := auth.From(ctx)
+	if viewer == nil {
+		return nil
+	}
+
+	_, err := s.checkBan(ctx, viewer.Name)
+
+	return err
+}
+
+// checkBan returns an error if author is banned, and whether their content is
+// to be shadowed.
+func (s *Service) checkBan(ctx context.Context, author string) (shadowed bool, err error) {
+	ban, err := s.store.GetActiveBan(ctx, author)
+	if errors.Is(err, apperr.ErrNoActiveBan) {
+		return false, nil
+	}
+	if err != nil {
+		slog.Error("failed to check ban", sl.Err(err), "author", author)
+		return false, err
+	}
+
+	if ban.Kind == model.BanKindBan {
+		slog.Info("banned user rejected", "author", author)
+		return false, apperr.Banned(ban.Reason, ban.ExpiresAt)
+	}
+
+	return true, nil
+}
+
+// checkAnonymous returns an error if any ban is in force.
+func (s *Service) checkAnonymous(ctx context.Context) error {
+	bans, err := s.store.GetActiveBans(ctx, 0, 1)
+	if err != nil {
+		slog.Error("failed to get bans", sl.Err(err))
+		return err
+	}
+
+	if len(bans) > 0 {
+		return errSignInRequired
+	}
+
+	return nil
+}
+
+// disguise shows shadowed content as published to everyone but moderators,
+// so that its author cannot tell they are shadowbanned.
+func disguise(ctx context.Context, status *model.ContentStatus, reason *string) {
+	if *status == model.ContentStatusShadowed && !auth.From(ctx).IsModerator() {
+		*status, *reason = model.ContentStatusPublished, ""
+	}
+}
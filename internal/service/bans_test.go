@@ -0,0 +1,200 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/erknas/forum/graph/model"
+	"github.com/erknas/forum/internal/auth"
+	"github.com/erknas/forum/internal/filter"
+	"github.com/erknas/forum/internal/storage/mocks"
+	"github.com/erknas/forum/pkg/apperr"
+	"github.com/erknas/forum/pkg/conv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func notBanned(storerMock *mocks.Storer) {
+	storerMock.On("GetActiveBan", mock.Anything, mock.Anything).Return(model.CustomBan{}, apperr.ErrNoActiveBan).Maybe()
+}
+
+func TestBanUser(t *testing.T) {
+	storerMock := mocks.NewStorer(t)
+	s := New(storerMock, Options{})
+
+	expiresAt := time.Now().Add(time.Hour)
+
+	_, err := s.BanUser(auth.With(context.Background(), alice), "Bob", model.BanKindBan, "spam", nil)
+	assert.Equal(t, apperr.CodeForbidden, apperr.CodeOf(err))
+
+	ctx := auth.With(context.Background(), moderator)
+
+	past := time.Now().Add(-time.Hour)
+	_, err = s.BanUser(ctx, " ", model.BanKindBan, " ", &past)
+	assert.Equal(t, apperr.Validation("invalid request data", map[string]interface{}{
+		"author":    "author is required",
+		"reason":    "reason is required",
+		"expiresAt": "expiresAt must be in the future",
+	}), err)
+
+	input := model.CustomBanInput{Author: "Bob", Kind: model.BanKindShadowban, Reason: "spam", Moderator: "Mod", ExpiresAt: &expiresAt}
+	storerMock.On("CreateBan", mock.Anything, input).Return(model.CustomBan{ID: 3, Author: "Bob", Kind: model.BanKindShadowban, Moderator: "Mod", ExpiresAt: &expiresAt}, nil)
+
+	ban, err := s.BanUser(ctx, " Bob ", model.BanKindShadowban, "spam", &expiresAt)
+	require.NoError(t, err)
+	assert.Equal(t, conv.GlobalID(conv.TypeBan, 3), ban.ID)
+	assert.Equal(t, &expiresAt, ban.ExpiresAt)
+}
+
+func TestUnbanUser(t *testing.T) {
+	storerMock := mocks.NewStorer(t)
+	s := New(storerMock, Options{})
+
+	_, err := s.UnbanUser(context.Background(), "Bob")
+	assert.Equal(t, apperr.CodeForbidden, apperr.CodeOf(err))
+
+	liftedAt := time.Now()
+	storerMock.On("LiftBan", mock.Anything, "Bob", "Mod").Return(model.CustomBan{ID: 3, Author: "Bob", LiftedBy: "Mod", LiftedAt: &liftedAt}, nil).Once()
+
+	ban, err := s.UnbanUser(auth.With(context.Background(), moderator), "Bob")
+	require.NoError(t, err)
+	require.NotNil(t, ban.LiftedBy)
+	assert.Equal(t, "Mod", *ban.LiftedBy)
+
+	storerMock.On("LiftBan", mock.Anything, "Bob", "Mod").Return(model.CustomBan{}, apperr.ErrNoActiveBan).Once()
+
+	_, err = s.UnbanUser(auth.With(context.Background(), moderator), "Bob")
+	assert.Equal(t, apperr.ErrNoActiveBan, err)
+}
+
+func TestBans(t *testing.T) {
+	storerMock := mocks.NewStorer(t)
+	s := New(storerMock, Options{})
+
+	_, err := s.Bans(auth.With(context.Background(), alice), nil, nil)
+	assert.Equal(t, apperr.CodeForbidden, apperr.CodeOf(err))
+
+	storerMock.On("GetActiveBans", mock.Anything, 0, 2).Return([]model.CustomBan{{ID: 2, Author: "Bob"}, {ID: 1, Author: "Carol"}}, nil)
+
+	first := int32(1)
+
+	connection, err := s.Bans(auth.With(context.Background(), moderator), &first, nil)
+	require.NoError(t, err)
+	require.Len(t, connection.Edges, 1)
+	assert.Equal(t, "Bob", connection.Edges[0].Node.Author)
+	assert.True(t, connection.PageInfo.HasNextPage)
+}
+
+func TestCreatePost_Banned(t *testing.T) {
+	storerMock := mocks.NewStorer(t)
+	s := New(storerMock, Options{})
+
+	expiresAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	storerMock.On("GetActiveBan", mock.Anything, "Bob").Return(model.CustomBan{Kind: model.BanKindBan, Reason: "spam", ExpiresAt: &expiresAt}, nil)
+
+	_, err := s.CreatePost(context.Background(), model.PostInput{Title: "Title", Author: "Bob", Content: "Content"})
+	assert.Equal(t, apperr.Banned("spam", &expiresAt), err)
+
+	_, err = s.CreateComment(context.Background(), model.CommentInput{PostID: "1", Author: "Bob", Content: "Content"})
+	assert.Equal(t, apperr.CodeBanned, apperr.CodeOf(err))
+
+	storerMock.AssertNotCalled(t, "CreatePost", mock.Anything, mock.Anything)
+	storerMock.AssertNotCalled(t, "CreateComment", mock.Anything, mock.Anything)
+}
+
+func TestCreatePost_SignInRequired(t *testing.T) {
+	storerMock := mocks.NewStorer(t)
+	s := New(storerMock, Options{SignInWhileBanned: true})
+
+	storerMock.On("GetActiveBan", mock.Anything, mock.Anything).Return(model.CustomBan{}, apperr.ErrNoActiveBan)
+	storerMock.On("GetActiveBans", mock.Anything, 0, 1).Return([]model.CustomBan{{Author: "Carol", Kind: model.BanKindBan}}, nil)
+
+	_, err := s.CreatePost(context.Background(), model.PostInput{Title: "Title", Author: "Carol2", Content: "Content"})
+	assert.Equal(t, errSignInRequired, err, "a banned user cannot post anonymously under a new name")
+
+	_, err = s.CreateComment(context.Background(), model.CommentInput{PostID: "1", Author: "Carol2", Content: "Content"})
+	assert.Equal(t, errSignInRequired, err)
+
+	storerMock.AssertNotCalled(t, "CreatePost", mock.Anything, mock.Anything)
+	storerMock.AssertNotCalled(t, "CreateComment", mock.Anything, mock.Anything)
+
+	storerMock.On("CreatePost", mock.Anything, mock.Anything).Return(model.CustomPost{ID: 1, Author: model.CustomAuthor{Name: "Dave"}}, nil).Once()
+
+	_, err = s.CreatePost(auth.With(context.Background(), &auth.Viewer{Name: "Dave", Role: auth.RoleUser}), model.PostInput{Title: "Title", Author: "Dave", Content: "Content"})
+	require.NoError(t, err, "signed-in users are not affected")
+}
+
+func TestCreatePost_AnonymousWhileBanned(t *testing.T) {
+	storerMock := mocks.NewStorer(t)
+	s := New(storerMock, Options{})
+
+	storerMock.On("GetActiveBan", mock.Anything, "Carol2").Return(model.CustomBan{}, apperr.ErrNoActiveBan)
+	storerMock.On("CreatePost", mock.Anything, mock.Anything).Return(model.CustomPost{ID: 1, Author: model.CustomAuthor{Name: "Carol2"}}, nil)
+
+	_, err := s.CreatePost(context.Background(), model.PostInput{Title: "Title", Author: "Carol2", Content: "Content"})
+	require.NoError(t, err, "bans of other users do not affect anonymous posts by default")
+
+	storerMock.AssertNotCalled(t, "GetActiveBans", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCreatePost_Shadowbanned(t *testing.T) {
+	storerMock := mocks.NewStorer(t)
+	s := New(storerMock, Options{Filters: []filter.Filter{holdAll{}}})
+
+	storerMock.On("GetActiveBan", mock.Anything, "Bob").Return(model.CustomBan{Kind: model.BanKindShadowban}, nil)
+
+	input := model.PostInput{Title: "Title", Author: "Bob", Content: "Content"}
+	customInput := input.Convert()
+	customInput.Status, customInput.StatusReason = model.ContentStatusShadowed, model.ShadowedReason
+
+	shadowed := model.CustomPost{ID: 1, Author: model.CustomAuthor{Name: "Bob"}, Status: model.ContentStatusShadowed, StatusReason: model.ShadowedReason}
+	storerMock.On("CreatePost", mock.Anything, customInput).Return(shadowed, nil)
+	storerMock.On("GetPostByID", mock.Anything, 1).Return(shadowed, nil)
+
+	bob := auth.With(context.Background(), &auth.Viewer{Name: "Bob", Role: auth.RoleUser})
+
+	post, err := s.CreatePost(bob, input)
+	require.NoError(t, err)
+	assert.Equal(t, model.ContentStatusPublished, post.Status, "the author cannot tell")
+	assert.Nil(t, post.StatusReason)
+
+	post, err = s.PostByID(bob, conv.GlobalID(conv.TypePost, 1))
+	require.NoError(t, err)
+	assert.Equal(t, model.ContentStatusPublished, post.Status)
+
+	_, err = s.PostByID(auth.With(context.Background(), alice), conv.GlobalID(conv.TypePost, 1))
+	assert.Equal(t, apperr.ErrPostNotFound, err)
+
+	post, err = s.PostByID(auth.With(context.Background(), moderator), conv.GlobalID(conv.TypePost, 1))
+	require.NoError(t, err)
+	assert.Equal(t, model.ContentStatusShadowed, post.Status)
+
+	storerMock.On("GetPosts", mock.Anything, "Bob").Return([]model.CustomPost{shadowed}, nil).Once()
+
+	posts, err := s.Posts(bob)
+	require.NoError(t, err)
+	require.Len(t, posts, 1, "the author sees their shadowed posts listed")
+	assert.Equal(t, model.ContentStatusPublished, posts[0].Status)
+
+	storerMock.On("GetCommentReplies", mock.Anything, 2, "Bob").Return([]model.CustomComment{{ID: 3, PostID: 1, Author: model.CustomAuthor{Name: "Bob"}, Status: model.ContentStatusShadowed}}, nil).Once()
+
+	replies, err := s.Replies(bob, conv.GlobalID(conv.TypeComment, 2))
+	require.NoError(t, err)
+	require.Len(t, replies, 1)
+	assert.Equal(t, model.ContentStatusPublished, replies[0].Status)
+}
+
+func TestCheckSubscriber(t *testing.T) {
+	storerMock := mocks.NewStorer(t)
+	s := New(storerMock, Options{})
+
+	require.NoError(t, s.CheckSubscriber(context.Background()))
+
+	storerMock.On("GetActiveBan", mock.Anything, "Alice").Return(model.CustomBan{Kind: model.BanKindBan, Reason: "abuse"}, nil).Once()
+	assert.Equal(t, apperr.CodeBanned, apperr.CodeOf(s.CheckSubscriber(auth.With(context.Background(), alice))))
+
+	storerMock.On("GetActiveBan", mock.Anything, "Alice").Return(model.CustomBan{Kind: model.BanKindShadowban}, nil).Once()
+	assert.NoError(t, s.CheckSubscriber(auth.With(context.Background(), alice)), "shadowbanned users notice nothing")
+}
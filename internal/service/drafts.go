@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/erknas/forum/graph/model"
+	"github.com/erknas/forum/internal/auth"
+	"github.com/erknas/forum/internal/filter"
+	"github.com/erknas/forum/pkg/apperr"
+	"github.com/erknas/forum/pkg/conv"
+	"github.com/erknas/forum/pkg/pagination"
+	"github.com/erknas/forum/pkg/sl"
+)
+
+var (
+	errDraftForbidden   = apperr.Forbidden("drafts are only available to their signed-in author")
+	errPublishForbidden = apperr.Forbidden("only the author can publish a post")
+)
+
+// PublishPost publishes a draft or scheduled post now, or schedules it for
+// publishAt. The post is checked like a new one when it is published.
+func (s *Service) PublishPost(ctx context.Context, strID string, publishAt *time.Time) (*model.Post, error) {
+	if publishAt != nil && !publishAt.After(time.Now()) {
+		return nil, apperr.Validation("invalid request data", map[string]interface{}{"publishAt": "publishAt must be in the future"})
+	}
+
+	id, err := conv.NodeID(conv.TypePost, strID)
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := s.store.GetPostByID(ctx, id)
+	if err != nil {
+		slog.Error("failed to get post", sl.Err(err), "id", id)
+		return nil, err
+	}
+
+	if viewer := auth.From(ctx); viewer == nil || viewer.Name != stored.Author.Name {
+		if !visible(ctx, stored.Status, stored.Author.Name) {
+			return nil, apperr.ErrPostNotFound
+		}
+		return nil, errPublishForbidden
+	}
+
+	if !stored.Status.Draft() {
+		return nil, apperr.ErrNotDraft
+	}
+
+	content := filter.Content{Author: stored.Author.Name, Title: stored.Title, Text: stored.Content}
+
+	status, reason, err := s.publishStatus(ctx, content, publishAt)
+	if err != nil {
+		return nil, err
+	}
+
+	at := time.Now()
+	if publishAt != nil {
+		at = *publishAt
+	}
+
+	customPost, err := s.store.PublishPost(ctx, id, status, reason, at)
+	if err != nil {
+		slog.Error("failed to publish post", sl.Err(err), "id", id)
+		return nil, err
+	}
+
+	s.record(content, customPost.Status)
+
+	disguise(ctx, &customPost.Status, &customPost.StatusReason)
+	post := customPost.Convert()
+
+	slog.Info("PublishPost OK", "post_id", id, "status", status, "publish_at", at)
+
+	return &post, nil
+}
+
+// MyDrafts returns the drafts and scheduled posts of the viewer, newest first.
+func (s *Service) MyDrafts(ctx context.Context, first *int32, after *string) (*model.PostConnection, error) {
+	viewer := auth.From(ctx)
+	if viewer == nil {
+		return nil, errDraftForbidden
+	}
+
+	limit, offset, err := pagination.FromCursor(first, after)
+	if err != nil {
+		return nil, err
+	}
+
+	customPosts, total, err := s.store.GetDrafts(ctx, viewer.Name, offset, limit+1)
+	if err != nil {
+		slog.Error("failed to get drafts", sl.Err(err), "author", viewer.Name)
+		return nil, err
+	}
+
+	connection := &model.PostConnection{TotalCount: int32(total)}
+	connection.Edges, connection.PageInfo = pagination.Edges(customPosts, offset, limit, func(customPost model.CustomPost, cursor string) *model.PostEdge {
+		post := customPost.Convert()
+		return &model.PostEdge{Cursor: cursor, Node: &post}
+	})
+
+	slog.Info("MyDrafts OK", "author", viewer.Name, "offset", offset, "limit", limit)
+
+	return connection, nil
+}
+
+// draftStatus returns the status a new post by author is created with when it
+// is saved as a draft or scheduled. Drafts are only checked when they are
+// published, but their author must not be banned.
+func (s *Service) draftStatus(ctx context.Context, input model.PostInput) (model.ContentStatus, string, error) {
+	if viewer := auth.From(ctx); viewer == nil || viewer.Name != input.Author {
+		return "", "", errDraftForbidden
+	}
+
+	if input.PublishAt != nil {
+		return s.publishStatus(ctx, filter.Content{Author: input.Author, Title: input.Title, Text: input.Content}, input.PublishAt)
+	}
+
+	if _, err := s.checkBan(ctx, input.Author); err != nil {
+		return "", "", err
+	}
+
+	return model.ContentStatusDraft, "", nil
+}
+
+// publishStatus checks a post that is published at publishAt, or now if it
+// is nil. A post that would be published is scheduled instead; held and
+// shadowed posts take their status right away.
+func (s *Service) publishStatus(ctx context.Context, content filter.Content, publishAt *time.Time) (model.ContentStatus, string, error) {
+	status, reason, err := s.checkAuthorAndContent(ctx, content)
+	if err != nil {
+		return "", "", err
+	}
+
+	if publishAt != nil && status == model.ContentStatusPublished {
+		status = model.ContentStatusScheduled
+	}
+
+	return status, reason, nil
+}
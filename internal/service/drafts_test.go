@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/erknas/forum/graph/model"
+	"github.com/erknas/forum/internal/auth"
+	"github.com/erknas/forum/internal/filter"
+	"github.com/erknas/forum/internal/storage/mocks"
+	"github.com/erknas/forum/pkg/apperr"
+	"github.com/erknas/forum/pkg/conv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreatePost_Draft(t *testing.T) {
+	storerMock := mocks.NewStorer(t)
+	notBanned(storerMock)
+	s := New(storerMock, Options{Filters: []filter.Filter{holdAll{}}})
+
+	draft := true
+	input := model.PostInput{Title: "Title", Author: "Alice", Content: "Content", Draft: &draft}
+
+	_, err := s.CreatePost(context.Background(), input)
+	assert.Equal(t, errDraftForbidden, err, "anonymous")
+
+	_, err = s.CreatePost(auth.With(context.Background(), moderator), input)
+	assert.Equal(t, errDraftForbidden, err, "someone else")
+
+	storerMock.On("CreatePost", mock.Anything, model.CustomPostInput{Title: "Title", Author: "Alice", Content: "Content", Status: model.ContentStatusDraft}).
+		Return(model.CustomPost{ID: 1, Title: "Title", Content: "Content", Author: model.CustomAuthor{Name: "Alice"}, Status: model.ContentStatusDraft}, nil).Once()
+
+	post, err := s.CreatePost(auth.With(context.Background(), alice), input)
+	require.NoError(t, err, "drafts are not filtered until they are published")
+	assert.Equal(t, model.ContentStatusDraft, post.Status)
+}
+
+func TestCreatePost_Scheduled(t *testing.T) {
+	storerMock := mocks.NewStorer(t)
+	notBanned(storerMock)
+	s := New(storerMock, Options{Filters: []filter.Filter{filter.NewBannedWords([]string{"casino"})}})
+
+	ctx := auth.With(context.Background(), alice)
+	publishAt := time.Now().Add(time.Hour)
+
+	_, err := s.CreatePost(ctx, model.PostInput{Title: "Title", Author: "Alice", Content: "Visit my casino", PublishAt: &publishAt})
+	assert.Equal(t, apperr.CodeContentRejected, apperr.CodeOf(err), "scheduled posts are checked right away")
+
+	past := time.Now().Add(-time.Hour)
+
+	_, err = s.CreatePost(ctx, model.PostInput{Title: "Title", Author: "Alice", Content: "Content", PublishAt: &past})
+	assert.Equal(t, apperr.CodeValidation, apperr.CodeOf(err))
+
+	storerMock.On("CreatePost", mock.Anything, model.CustomPostInput{Title: "Title", Author: "Alice", Content: "Content", Status: model.ContentStatusScheduled, PublishAt: &publishAt}).
+		Return(model.CustomPost{ID: 1, Author: model.CustomAuthor{Name: "Alice"}, Status: model.ContentStatusScheduled, PublishAt: &publishAt}, nil).Once()
+
+	post, err := s.CreatePost(ctx, model.PostInput{Title: "Title", Author: "Alice", Content: "Content", PublishAt: &publishAt})
+	require.NoError(t, err)
+	assert.Equal(t, model.ContentStatusScheduled, post.Status)
+	assert.Equal(t, &publishAt, post.PublishAt)
+}
+
+func TestPublishPost(t *testing.T) {
+	storerMock := mocks.NewStorer(t)
+	notBanned(storerMock)
+	s := New(storerMock, Options{})
+
+	id := conv.GlobalID(conv.TypePost, 1)
+	draft := model.CustomPost{ID: 1, Title: "Title", Content: "Content", Author: model.CustomAuthor{Name: "Alice"}, Status: model.ContentStatusDraft}
+
+	storerMock.On("GetPostByID", mock.Anything, 1).Return(draft, nil)
+
+	_, err := s.PublishPost(auth.With(context.Background(), moderator), id, nil)
+	assert.ErrorIs(t, err, apperr.ErrPostNotFound, "drafts are hidden from moderators")
+
+	ctx := auth.With(context.Background(), alice)
+
+	storerMock.On("PublishPost", mock.Anything, 1, model.ContentStatusPublished, "", mock.AnythingOfType("time.Time")).
+		Return(model.CustomPost{ID: 1, Author: draft.Author, Status: model.ContentStatusPublished}, nil).Once()
+
+	post, err := s.PublishPost(ctx, id, nil)
+	require.NoError(t, err)
+	assert.Equal(t, model.ContentStatusPublished, post.Status)
+
+	publishAt := time.Now().Add(time.Hour)
+
+	storerMock.On("PublishPost", mock.Anything, 1, model.ContentStatusScheduled, "", publishAt).
+		Return(model.CustomPost{ID: 1, Author: draft.Author, Status: model.ContentStatusScheduled, PublishAt: &publishAt}, nil).Once()
+
+	post, err = s.PublishPost(ctx, id, &publishAt)
+	require.NoError(t, err)
+	assert.Equal(t, model.ContentStatusScheduled, post.Status)
+}
+
+func TestPublishPost_Published(t *testing.T) {
+	storerMock := mocks.NewStorer(t)
+	s := New(storerMock, Options{})
+
+	storerMock.On("GetPostByID", mock.Anything, 1).
+		Return(model.CustomPost{ID: 1, Author: model.CustomAuthor{Name: "Alice"}, Status: model.ContentStatusPublished}, nil)
+
+	_, err := s.PublishPost(auth.With(context.Background(), alice), conv.GlobalID(conv.TypePost, 1), nil)
+	assert.ErrorIs(t, err, apperr.ErrNotDraft)
+
+	_, err = s.PublishPost(auth.With(context.Background(), moderator), conv.GlobalID(conv.TypePost, 1), nil)
+	assert.Equal(t, errPublishForbidden, err)
+}
+
+func TestMyDrafts(t *testing.T) {
+	storerMock := mocks.NewStorer(t)
+	s := New(storerMock, Options{})
+
+	_, err := s.MyDrafts(context.Background(), nil, nil)
+	assert.Equal(t, errDraftForbidden, err)
+
+	first := int32(1)
+
+	storerMock.On("GetDrafts", mock.Anything, "Alice", 0, 2).Return([]model.CustomPost{
+		{ID: 3, Author: model.CustomAuthor{Name: "Alice"}, Status: model.ContentStatusDraft},
+		{ID: 2, Author: model.CustomAuthor{Name: "Alice"}, Status: model.ContentStatusScheduled},
+	}, 2, nil)
+
+	connection, err := s.MyDrafts(auth.With(context.Background(), alice), &first, nil)
+	require.NoError(t, err)
+	require.Len(t, connection.Edges, 1)
+	assert.Equal(t, conv.GlobalID(conv.TypePost, 3), connection.Edges[0].Node.ID)
+	assert.True(t, connection.PageInfo.HasNextPage)
+	assert.Equal(t, int32(2), connection.TotalCount)
+}
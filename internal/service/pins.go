@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/erknas/forum/graph/model"
+	"github.com/erknas/forum/internal/auth"
+	"github.com/erknas/forum/pkg/apperr"
+	"github.com/erknas/forum/pkg/conv"
+	"github.com/erknas/forum/pkg/sl"
+)
+
+var errPinUnpublished = apperr.New(apperr.CodeValidation, "only published posts can be pinned")
+
+// PinPost pins a published post to the top of GetPosts until the given time,
+// or until it is unpinned.
+func (s *Service) PinPost(ctx context.Context, strID string, until *time.Time) (*model.Post, error) {
+	viewer := auth.From(ctx)
+	if !viewer.IsAdmin() {
+		return nil, errAdminOnly
+	}
+
+	if until != nil && !until.After(time.Now()) {
+		return nil, apperr.Validation("invalid request data", map[string]interface{}{"until": "until must be in the future"})
+	}
+
+	id, err := conv.NodeID(conv.TypePost, strID)
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := s.store.GetPostByID(ctx, id)
+	if err != nil {
+		slog.Error("failed to get post", sl.Err(err), "id", id)
+		return nil, err
+	}
+
+	if stored.Status.Draft() {
+		return nil, apperr.ErrPostNotFound
+	}
+
+	if stored.Status.OrPublished() != model.ContentStatusPublished {
+		return nil, errPinUnpublished
+	}
+
+	customPost, err := s.store.PinPost(ctx, id, until, viewer.Name)
+	if err != nil {
+		slog.Error("failed to pin post", sl.Err(err), "id", id)
+		return nil, err
+	}
+
+	post := customPost.Convert()
+
+	slog.Info("PinPost OK", "post_id", id, "until", until, "admin", viewer.Name)
+
+	return &post, nil
+}
+
+// UnpinPost unpins a pinned post.
+func (s *Service) UnpinPost(ctx context.Context, strID string) (*model.Post, error) {
+	viewer := auth.From(ctx)
+	if !viewer.IsAdmin() {
+		return nil, errAdminOnly
+	}
+
+	id, err := conv.NodeID(conv.TypePost, strID)
+	if err != nil {
+		return nil, err
+	}
+
+	customPost, err := s.store.UnpinPost(ctx, id, viewer.Name)
+	if err != nil {
+		slog.Error("failed to unpin post", sl.Err(err), "id", id)
+		return nil, err
+	}
+
+	post := customPost.Convert()
+
+	slog.Info("UnpinPost OK", "post_id", id, "admin", viewer.Name)
+
+	return &post, nil
+}
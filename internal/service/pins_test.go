@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/erknas/forum/graph/model"
+	"github.com/erknas/forum/internal/auth"
+	"github.com/erknas/forum/internal/storage/mocks"
+	"github.com/erknas/forum/pkg/apperr"
+	"github.com/erknas/forum/pkg/conv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var admin = &auth.Viewer{Name: "Root", Role: auth.RoleAdmin}
+
+func TestPinPost(t *testing.T) {
+	storerMock := mocks.NewStorer(t)
+	s := New(storerMock, Options{})
+
+	id := conv.GlobalID(conv.TypePost, 1)
+	until := time.Now().Add(time.Hour)
+
+	_, err := s.PinPost(auth.With(context.Background(), moderator), id, nil)
+	assert.Equal(t, errAdminOnly, err)
+
+	ctx := auth.With(context.Background(), admin)
+
+	past := time.Now().Add(-time.Hour)
+
+	_, err = s.PinPost(ctx, id, &past)
+	assert.Equal(t, apperr.CodeValidation, apperr.CodeOf(err))
+
+	storerMock.On("GetPostByID", mock.Anything, 1).Return(model.CustomPost{ID: 1, Status: model.ContentStatusPublished}, nil)
+
+	pinnedAt := time.Now()
+
+	storerMock.On("PinPost", mock.Anything, 1, &until, "Root").
+		Return(model.CustomPost{ID: 1, Status: model.ContentStatusPublished, PinnedBy: "Root", PinnedAt: &pinnedAt, PinnedUntil: &until}, nil).Once()
+
+	post, err := s.PinPost(ctx, id, &until)
+	require.NoError(t, err)
+	assert.True(t, post.Pinned)
+	assert.Equal(t, &until, post.PinnedUntil)
+}
+
+func TestPinPost_Unpublished(t *testing.T) {
+	storerMock := mocks.NewStorer(t)
+	s := New(storerMock, Options{})
+
+	ctx := auth.With(context.Background(), admin)
+
+	storerMock.On("GetPostByID", mock.Anything, 1).Return(model.CustomPost{ID: 1, Status: model.ContentStatusPending}, nil)
+	storerMock.On("GetPostByID", mock.Anything, 2).Return(model.CustomPost{ID: 2, Status: model.ContentStatusDraft}, nil)
+
+	_, err := s.PinPost(ctx, conv.GlobalID(conv.TypePost, 1), nil)
+	assert.Equal(t, errPinUnpublished, err)
+
+	_, err = s.PinPost(ctx, conv.GlobalID(conv.TypePost, 2), nil)
+	assert.ErrorIs(t, err, apperr.ErrPostNotFound, "drafts are hidden from admins")
+}
+
+func TestUnpinPost(t *testing.T) {
+	storerMock := mocks.NewStorer(t)
+	s := New(storerMock, Options{})
+
+	id := conv.GlobalID(conv.TypePost, 1)
+
+	_, err := s.UnpinPost(auth.With(context.Background(), alice), id)
+	assert.Equal(t, errAdminOnly, err)
+
+	ctx := auth.With(context.Background(), admin)
+
+	storerMock.On("UnpinPost", mock.Anything, 1, "Root").Return(model.CustomPost{ID: 1, Status: model.ContentStatusPublished}, nil).Once()
+
+	post, err := s.UnpinPost(ctx, id)
+	require.NoError(t, err)
+	assert.False(t, post.Pinned)
+
+	storerMock.On("UnpinPost", mock.Anything, 1, "Root").Return(model.CustomPost{}, apperr.ErrNotPinned).Once()
+
+	_, err = s.UnpinPost(ctx, id)
+	assert.ErrorIs(t, err, apperr.ErrNotPinned)
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+
+	"github.com/erknas/forum/graph/model"
+	"github.com/erknas/forum/internal/auth"
+	"github.com/erknas/forum/internal/loader"
+	"github.com/erknas/forum/pkg/apperr"
+	"github.com/erknas/forum/pkg/conv"
+	"github.com/erknas/forum/pkg/sl"
+)
+
+var (
+	errVoteForbidden = apperr.Forbidden("voting requires being signed in")
+	errSingleChoice  = apperr.New(apperr.CodeValidation, "the poll allows a single option")
+)
+
+// PostPoll returns the poll of a post, or nil if it has none.
+func (s *Service) PostPoll(ctx context.Context, strID string) (*model.Poll, error) {
+	id, err := conv.NodeID(conv.TypePost, strID)
+	if err != nil {
+		return nil, err
+	}
+
+	var customPoll *model.CustomPoll
+
+	if loaders := loader.For(ctx); loaders != nil {
+		customPoll, err = loaders.Polls.Load(ctx, id)
+	} else {
+		var byPost map[int]model.CustomPoll
+		byPost, err = s.store.GetPollsByPostIDs(ctx, []int{id})
+		if poll, ok := byPost[id]; ok {
+			customPoll = &poll
+		}
+	}
+	if err != nil {
+		slog.Error("failed to get poll", sl.Err(err), "post_id", id)
+		return nil, err
+	}
+
+	if customPoll == nil {
+		return nil, nil
+	}
+
+	poll := customPoll.Convert()
+
+	return &poll, nil
+}
+
+// VotePoll replaces the vote of the viewer in a poll with optionIDs, or
+// withdraws it if there are none. Votes of shadowbanned users are not
+// counted.
+func (s *Service) VotePoll(ctx context.Context, strID string, optionIDs []string) (*model.Poll, error) {
+	viewer := auth.From(ctx)
+	if viewer == nil {
+		return nil, errVoteForbidden
+	}
+
+	id, err := conv.NodeID(conv.TypePoll, strID)
+	if err != nil {
+		return nil, err
+	}
+
+	ballot := model.CustomBallot{PollID: id, Voter: viewer.Name, OptionIDs: make([]int, 0, len(optionIDs))}
+
+	for _, strOptionID := range optionIDs {
+		optionID, err := conv.NodeID(conv.TypePollOption, strOptionID)
+		if err != nil {
+			return nil, err
+		}
+
+		if !slices.Contains(ballot.OptionIDs, optionID) {
+			ballot.OptionIDs = append(ballot.OptionIDs, optionID)
+		}
+	}
+
+	shadowed, err := s.checkBan(ctx, viewer.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	customPoll, err := s.store.GetPoll(ctx, id)
+	if err != nil {
+		slog.Error("failed to get poll", sl.Err(err), "id", id)
+		return nil, err
+	}
+
+	// Polls of posts the viewer cannot see, or which are not published, do
+	// not take votes.
+	post, err := s.getPost(ctx, customPoll.PostID)
+	if errors.Is(err, apperr.ErrPostNotFound) || (err == nil && post.Status != model.ContentStatusPublished) {
+		return nil, apperr.ErrPollNotFound
+	}
+	if err != nil {
+		slog.Error("failed to get post", sl.Err(err), "id", customPoll.PostID)
+		return nil, err
+	}
+
+	if !customPoll.MultipleChoice && len(ballot.OptionIDs) > 1 {
+		return nil, errSingleChoice
+	}
+
+	if !shadowed {
+		customPoll, err = s.store.Vote(ctx, ballot)
+		if err != nil {
+			slog.Error("failed to vote", sl.Err(err), "poll_id", id)
+			return nil, err
+		}
+	}
+
+	poll := customPoll.Convert()
+
+	slog.Info("VotePoll OK", "poll_id", id, "voter", viewer.Name, "options", len(ballot.OptionIDs), "shadowed", shadowed)
+
+	return &poll, nil
+}
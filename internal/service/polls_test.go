@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/erknas/forum/graph/model"
+	"github.com/erknas/forum/internal/auth"
+	"github.com/erknas/forum/internal/storage/mocks"
+	"github.com/erknas/forum/pkg/apperr"
+	"github.com/erknas/forum/pkg/conv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func testPoll(multipleChoice bool) model.CustomPoll {
+	return model.CustomPoll{ID: 7, PostID: 1, Question: "When?", MultipleChoice: multipleChoice, Options: []model.CustomPollOption{
+		{ID: 1, Text: "Monday"},
+		{ID: 2, Text: "Friday"},
+	}}
+}
+
+func TestVotePoll(t *testing.T) {
+	storerMock := mocks.NewStorer(t)
+	s := New(storerMock, Options{})
+	notBanned(storerMock)
+
+	id := conv.GlobalID(conv.TypePoll, 7)
+	monday, friday := conv.GlobalID(conv.TypePollOption, 1), conv.GlobalID(conv.TypePollOption, 2)
+
+	_, err := s.VotePoll(context.Background(), id, []string{monday})
+	assert.Equal(t, errVoteForbidden, err)
+
+	ctx := auth.With(context.Background(), alice)
+
+	storerMock.On("GetPoll", mock.Anything, 7).Return(testPoll(false), nil)
+	storerMock.On("GetPostByID", mock.Anything, 1).Return(model.CustomPost{ID: 1, Status: model.ContentStatusPublished}, nil)
+
+	_, err = s.VotePoll(ctx, id, []string{monday, friday})
+	assert.Equal(t, errSingleChoice, err)
+
+	voted := testPoll(false)
+	voted.Voters, voted.Options[0].Votes, voted.Options[0].Voters = 1, 1, []string{"Alice"}
+
+	storerMock.On("Vote", mock.Anything, mock.MatchedBy(func(b model.CustomBallot) bool {
+		return b.PollID == 7 && b.Voter == "Alice" && assert.ObjectsAreEqual([]int{1}, b.OptionIDs)
+	})).Return(voted, nil).Once()
+
+	poll, err := s.VotePoll(ctx, id, []string{monday, monday})
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), poll.TotalVoters)
+	assert.Equal(t, []string{"Alice"}, poll.Options[0].Voters)
+}
+
+func TestVotePoll_UnpublishedPost(t *testing.T) {
+	storerMock := mocks.NewStorer(t)
+	s := New(storerMock, Options{})
+	notBanned(storerMock)
+
+	storerMock.On("GetPoll", mock.Anything, 7).Return(testPoll(true), nil)
+	storerMock.On("GetPostByID", mock.Anything, 1).Return(model.CustomPost{ID: 1, Status: model.ContentStatusPending}, nil)
+
+	_, err := s.VotePoll(auth.With(context.Background(), alice), conv.GlobalID(conv.TypePoll, 7), nil)
+	assert.ErrorIs(t, err, apperr.ErrPollNotFound)
+}
+
+func TestVotePoll_Shadowbanned(t *testing.T) {
+	storerMock := mocks.NewStorer(t)
+	s := New(storerMock, Options{})
+
+	storerMock.On("GetActiveBan", mock.Anything, "Alice").Return(model.CustomBan{Kind: model.BanKindShadowban}, nil)
+	storerMock.On("GetPoll", mock.Anything, 7).Return(testPoll(true), nil)
+	storerMock.On("GetPostByID", mock.Anything, 1).Return(model.CustomPost{ID: 1, Status: model.ContentStatusPublished}, nil)
+
+	poll, err := s.VotePoll(auth.With(context.Background(), alice), conv.GlobalID(conv.TypePoll, 7), []string{conv.GlobalID(conv.TypePollOption, 1)})
+	require.NoError(t, err)
+	assert.Zero(t, poll.TotalVoters, "votes of shadowbanned users are not stored")
+}
+
+func TestPostPoll(t *testing.T) {
+	storerMock := mocks.NewStorer(t)
+	s := New(storerMock, Options{})
+
+	storerMock.On("GetPollsByPostIDs", mock.Anything, []int{1}).Return(map[int]model.CustomPoll{1: testPoll(false)}, nil)
+	storerMock.On("GetPollsByPostIDs", mock.Anything, []int{2}).Return(map[int]model.CustomPoll{}, nil)
+
+	poll, err := s.PostPoll(context.Background(), conv.GlobalID(conv.TypePost, 1))
+	require.NoError(t, err)
+	require.NotNil(t, poll)
+	assert.Equal(t, conv.GlobalID(conv.TypePoll, 7), poll.ID)
+	assert.Len(t, poll.Options, 2)
+
+	poll, err = s.PostPoll(context.Background(), conv.GlobalID(conv.TypePost, 2))
+	require.NoError(t, err)
+	assert.Nil(t, poll)
+}
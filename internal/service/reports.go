@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"unicode/utf8"
+
+	"github.com/erknas/forum/graph/model"
+	"github.com/erknas/forum/internal/auth"
+	"github.com/erknas/forum/pkg/apperr"
+	"github.com/erknas/forum/pkg/conv"
+	"github.com/erknas/forum/pkg/pagination"
+	"github.com/erknas/forum/pkg/sl"
+)
+
+const maxReportDetails = 1000
+
+// Report records the viewer's report on a post or comment, and hides the
+// content once it reaches the report threshold.
+func (s *Service) Report(ctx context.Context, targetID string, reason model.ReportReason, details *string) (*model.Report, error) {
+	viewer := auth.From(ctx)
+	if viewer == nil {
+		return nil, apperr.Forbidden("sign in to report content")
+	}
+
+	input := model.CustomReportInput{Reporter: viewer.Name, Reason: reason}
+
+	if details != nil {
+		if utf8.RuneCountInString(*details) > maxReportDetails {
+			return nil, apperr.Validation("invalid request data", map[string]interface{}{"details": fmt.Sprintf("details cannot be more than %d symbols", maxReportDetails)})
+		}
+		input.Details = *details
+	}
+
+	typ, id, err := conv.FromGlobalID(targetID)
+	if err != nil {
+		return nil, err
+	}
+
+	var target model.CustomContent
+
+	switch typ {
+	case conv.TypePost:
+		var post model.CustomPost
+		if post, err = s.getPost(ctx, id); err == nil {
+			target.Post = &post
+		}
+	case conv.TypeComment:
+		var comment model.CustomComment
+		if comment, err = s.getComment(ctx, id); err == nil {
+			target.Comment = &comment
+		}
+	default:
+		return nil, apperr.Validationf("invalid ID %s", targetID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	input.TargetType, input.TargetID = typ, id
+	input.HideAt = s.reportThreshold
+	input.Hide = model.CustomStatusChange{
+		Status: model.ContentStatusPending,
+		Reason: model.ReportedReason,
+		From:   []model.ContentStatus{model.ContentStatusPublished},
+	}
+
+	customReport, open, err := s.store.CreateReport(ctx, input)
+	if err != nil {
+		slog.Error("failed to create report", sl.Err(err), "type", typ, "id", id)
+		return nil, err
+	}
+
+	if s.reportThreshold > 0 && open >= s.reportThreshold && published(target) {
+		slog.Info("reported content hidden", "type", typ, "id", id, "reports", open)
+	}
+
+	report := customReport.Convert()
+
+	slog.Info("Report OK", "type", typ, "id", id, "reporter", viewer.Name)
+
+	return &report, nil
+}
+
+// Reports returns posts and comments with open reports, most reported first.
+func (s *Service) Reports(ctx context.Context, first *int32, after *string) (*model.ReportGroupConnection, error) {
+	if !auth.From(ctx).IsModerator() {
+		return nil, errModeratorOnly
+	}
+
+	limit, offset, err := pagination.FromCursor(first, after)
+	if err != nil {
+		return nil, err
+	}
+
+	groups, err := s.store.GetReportGroups(ctx, offset, limit+1)
+	if err != nil {
+		slog.Error("failed to get reports", sl.Err(err))
+		return nil, err
+	}
+
+	nodes := make([]*model.ReportGroup, 0, limit)
+	for _, customGroup := range groups[:min(len(groups), limit)] {
+		group, err := s.reportGroup(ctx, customGroup)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, group)
+	}
+
+	connection := new(model.ReportGroupConnection)
+	connection.Edges, connection.PageInfo = pagination.Edges(nodes, offset, limit, func(group *model.ReportGroup, cursor string) *model.ReportGroupEdge {
+		return &model.ReportGroupEdge{Cursor: cursor, Node: group}
+	})
+	connection.PageInfo.HasNextPage = len(groups) > limit
+
+	slog.Info("Reports OK", "offset", offset, "limit", limit)
+
+	return connection, nil
+}
+
+// ResolveReports closes the open reports on a post or comment. Rejected
+// content is hidden and teaches the filters that it is spam; dismissing the
+// reports publishes content that the reports hid and is still pending, but not
+// content held for moderators otherwise. The status changes in the same
+// transaction as the reports.
+func (s *Service) ResolveReports(ctx context.Context, targetID string, action model.ReportAction) (*model.ReportGroup, error) {
+	viewer := auth.From(ctx)
+	if !viewer.IsModerator() {
+		return nil, errModeratorOnly
+	}
+
+	typ, id, err := conv.FromGlobalID(targetID)
+	if err != nil {
+		return nil, err
+	}
+
+	if typ != conv.TypePost && typ != conv.TypeComment {
+		return nil, apperr.Validationf("invalid ID %s", targetID)
+	}
+
+	change := model.CustomStatusChange{
+		Status:     model.ContentStatusPublished,
+		From:       []model.ContentStatus{model.ContentStatusPending},
+		FromReason: model.ReportedReason,
+	}
+	if action == model.ReportActionRejected {
+		change = model.CustomStatusChange{Status: model.ContentStatusRejected, Reason: "removed after reports"}
+	}
+
+	reports, err := s.store.ResolveReports(ctx, typ, id, viewer.Name, action, change)
+	if err != nil {
+		slog.Error("failed to resolve reports", sl.Err(err), "type", typ, "id", id)
+		return nil, err
+	}
+
+	target, err := s.reportTarget(ctx, typ, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if action == model.ReportActionRejected {
+		s.learn(target, true)
+	}
+
+	group := model.CustomReportGroup{TargetType: typ, TargetID: id, Reports: reports}.Convert(target)
+
+	slog.Info("ResolveReports OK", "type", typ, "id", id, "action", action, "moderator", viewer.Name)
+
+	return &group, nil
+}
+
+func (s *Service) reportGroup(ctx context.Context, customGroup model.CustomReportGroup) (*model.ReportGroup, error) {
+	target, err := s.reportTarget(ctx, customGroup.TargetType, customGroup.TargetID)
+	if err != nil {
+		return nil, err
+	}
+
+	group := customGroup.Convert(target)
+
+	return &group, nil
+}
+
+// reportTarget returns the reported post or comment whatever its status.
+func (s *Service) reportTarget(ctx context.Context, typ string, id int) (model.CustomContent, error) {
+	if typ == conv.TypePost {
+		post, err := s.store.GetPostByID(ctx, id)
+		if err != nil {
+			slog.Error("failed to get reported post", sl.Err(err), "id", id)
+			return model.CustomContent{}, err
+		}
+		return model.CustomContent{Post: &post}, nil
+	}
+
+	comment, err := s.store.GetCommentByID(ctx, id)
+	if err != nil {
+		slog.Error("failed to get reported comment", sl.Err(err), "id", id)
+		return model.CustomContent{}, err
+	}
+	return model.CustomContent{Comment: &comment}, nil
+}
+
+func statusOf(content model.CustomContent) model.ContentStatus {
+	if content.Post != nil {
+		return content.Post.Status.OrPublished()
+	}
+	return content.Comment.Status.OrPublished()
+}
+
+func published(content model.CustomContent) bool {
+	return statusOf(content) == model.ContentStatusPublished
+}
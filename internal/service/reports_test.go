@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/erknas/forum/graph/model"
+	"github.com/erknas/forum/internal/auth"
+	"github.com/erknas/forum/internal/filter"
+	"github.com/erknas/forum/internal/storage/mocks"
+	"github.com/erknas/forum/pkg/apperr"
+	"github.com/erknas/forum/pkg/conv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	alice     = &auth.Viewer{Name: "Alice", Role: auth.RoleUser}
+	moderator = &auth.Viewer{Name: "Mod", Role: auth.RoleModerator}
+)
+
+func TestReport(t *testing.T) {
+	storerMock := mocks.NewStorer(t)
+	s := New(storerMock, Options{ReportThreshold: 2})
+
+	postID := conv.GlobalID(conv.TypePost, 1)
+	post := model.CustomPost{ID: 1, Author: model.CustomAuthor{ID: 1, Name: "Bob"}, Status: model.ContentStatusPublished}
+	input := model.CustomReportInput{
+		TargetType: conv.TypePost,
+		TargetID:   1,
+		Reporter:   "Alice",
+		Reason:     model.ReportReasonSpam,
+		Details:    "ads",
+		HideAt:     2,
+		Hide: model.CustomStatusChange{
+			Status: model.ContentStatusPending,
+			Reason: model.ReportedReason,
+			From:   []model.ContentStatus{model.ContentStatusPublished},
+		},
+	}
+	details := "ads"
+
+	_, err := s.Report(context.Background(), postID, model.ReportReasonSpam, nil)
+	assert.Equal(t, apperr.CodeForbidden, apperr.CodeOf(err))
+
+	storerMock.On("GetPostByID", mock.Anything, 1).Return(post, nil)
+	storerMock.On("CreateReport", mock.Anything, input).Return(model.CustomReport{ID: 5, Reporter: "Alice", Reason: model.ReportReasonSpam}, 1, nil).Once()
+
+	report, err := s.Report(auth.With(context.Background(), alice), postID, model.ReportReasonSpam, &details)
+	require.NoError(t, err)
+	assert.Equal(t, conv.GlobalID(conv.TypeReport, 5), report.ID)
+	assert.Equal(t, "Alice", report.Reporter)
+
+	storerMock.On("CreateReport", mock.Anything, input).Return(model.CustomReport{ID: 6}, 2, nil).Once()
+
+	_, err = s.Report(auth.With(context.Background(), alice), postID, model.ReportReasonSpam, &details)
+	require.NoError(t, err)
+
+	_, err = s.Report(auth.With(context.Background(), alice), conv.GlobalID(conv.TypeAuthor, 1), model.ReportReasonSpam, nil)
+	assert.Equal(t, apperr.CodeValidation, apperr.CodeOf(err))
+}
+
+func TestReport_HiddenTarget(t *testing.T) {
+	storerMock := mocks.NewStorer(t)
+	s := New(storerMock, Options{ReportThreshold: 1})
+
+	storerMock.On("GetCommentByID", mock.Anything, 2).Return(model.CustomComment{ID: 2, Author: model.CustomAuthor{Name: "Bob"}, Status: model.ContentStatusPending}, nil)
+
+	_, err := s.Report(auth.With(context.Background(), alice), conv.GlobalID(conv.TypeComment, 2), model.ReportReasonAbuse, nil)
+	assert.Equal(t, apperr.ErrCommentNotFound, err)
+
+	storerMock.AssertNotCalled(t, "CreateReport", mock.Anything, mock.Anything)
+}
+
+func TestReports(t *testing.T) {
+	storerMock := mocks.NewStorer(t)
+	s := New(storerMock, Options{})
+
+	_, err := s.Reports(auth.With(context.Background(), alice), nil, nil)
+	assert.Equal(t, apperr.CodeForbidden, apperr.CodeOf(err))
+
+	groups := []model.CustomReportGroup{
+		{
+			TargetType: conv.TypeComment,
+			TargetID:   2,
+			Reports: []model.CustomReport{
+				{ID: 1, Reason: model.ReportReasonAbuse},
+				{ID: 2, Reason: model.ReportReasonSpam},
+				{ID: 3, Reason: model.ReportReasonSpam},
+			},
+		},
+		{TargetType: conv.TypePost, TargetID: 1, Reports: []model.CustomReport{{ID: 4, Reason: model.ReportReasonOther}}},
+	}
+
+	storerMock.On("GetReportGroups", mock.Anything, 0, 2).Return(groups, nil)
+	storerMock.On("GetCommentByID", mock.Anything, 2).Return(model.CustomComment{ID: 2, PostID: 1}, nil)
+
+	first := int32(1)
+
+	connection, err := s.Reports(auth.With(context.Background(), moderator), &first, nil)
+	require.NoError(t, err)
+	require.Len(t, connection.Edges, 1)
+	assert.True(t, connection.PageInfo.HasNextPage)
+
+	group := connection.Edges[0].Node
+	assert.Equal(t, int32(3), group.Count)
+	assert.Len(t, group.Reports, 3)
+	assert.Equal(t, []*model.ReportReasonCount{
+		{Reason: model.ReportReasonSpam, Count: 2},
+		{Reason: model.ReportReasonAbuse, Count: 1},
+	}, group.Reasons)
+
+	comment, ok := group.Target.(*model.Comment)
+	require.True(t, ok)
+	assert.Equal(t, conv.GlobalID(conv.TypeComment, 2), comment.ID)
+}
+
+func TestResolveReports(t *testing.T) {
+	resolved := []model.CustomReport{{ID: 1, ResolvedBy: "Mod", Action: model.ReportActionDismissed, Reason: model.ReportReasonSpam}}
+	dismiss := model.CustomStatusChange{Status: model.ContentStatusPublished, From: []model.ContentStatus{model.ContentStatusPending}, FromReason: model.ReportedReason}
+	reject := model.CustomStatusChange{Status: model.ContentStatusRejected, Reason: "removed after reports"}
+
+	t.Run("Forbidden", func(t *testing.T) {
+		s := New(mocks.NewStorer(t), Options{})
+
+		_, err := s.ResolveReports(auth.With(context.Background(), alice), conv.GlobalID(conv.TypePost, 1), model.ReportActionDismissed)
+		assert.Equal(t, apperr.CodeForbidden, apperr.CodeOf(err))
+	})
+
+	t.Run("Dismiss", func(t *testing.T) {
+		storerMock := mocks.NewStorer(t)
+		s := New(storerMock, Options{})
+
+		storerMock.On("ResolveReports", mock.Anything, conv.TypePost, 1, "Mod", model.ReportActionDismissed, dismiss).Return(resolved, nil)
+		storerMock.On("GetPostByID", mock.Anything, 1).Return(model.CustomPost{ID: 1, Status: model.ContentStatusPublished}, nil)
+
+		group, err := s.ResolveReports(auth.With(context.Background(), moderator), conv.GlobalID(conv.TypePost, 1), model.ReportActionDismissed)
+		require.NoError(t, err)
+
+		post, ok := group.Target.(*model.Post)
+		require.True(t, ok)
+		assert.Equal(t, model.ContentStatusPublished, post.Status)
+		require.Len(t, group.Reports, 1)
+		assert.Equal(t, "Mod", *group.Reports[0].ResolvedBy)
+		assert.Equal(t, model.ReportActionDismissed, *group.Reports[0].Action)
+	})
+
+	t.Run("Reject", func(t *testing.T) {
+		storerMock := mocks.NewStorer(t)
+		classifier := filter.NewClassifier()
+		s := New(storerMock, Options{Filters: []filter.Filter{filter.NewSpam(classifier, 0.9, 1)}})
+
+		storerMock.On("ResolveReports", mock.Anything, conv.TypeComment, 2, "Mod", model.ReportActionRejected, reject).Return(resolved, nil)
+		storerMock.On("GetCommentByID", mock.Anything, 2).Return(model.CustomComment{ID: 2, Status: model.ContentStatusRejected, StatusReason: "removed after reports"}, nil)
+
+		group, err := s.ResolveReports(auth.With(context.Background(), moderator), conv.GlobalID(conv.TypeComment, 2), model.ReportActionRejected)
+		require.NoError(t, err)
+
+		comment, ok := group.Target.(*model.Comment)
+		require.True(t, ok)
+		assert.Equal(t, model.ContentStatusRejected, comment.Status)
+
+		_, spamDocs := classifier.Samples()
+		assert.Equal(t, 1, spamDocs, "rejected content is learned as spam")
+	})
+
+	t.Run("No open reports", func(t *testing.T) {
+		storerMock := mocks.NewStorer(t)
+		s := New(storerMock, Options{})
+
+		storerMock.On("ResolveReports", mock.Anything, conv.TypePost, 1, "Mod", model.ReportActionRejected, reject).Return(nil, apperr.ErrNoOpenReports)
+
+		_, err := s.ResolveReports(auth.With(context.Background(), moderator), conv.GlobalID(conv.TypePost, 1), model.ReportActionRejected)
+		assert.Equal(t, apperr.ErrNoOpenReports, err)
+	})
+}
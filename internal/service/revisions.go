@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+
+	"github.com/erknas/forum/graph/model"
+	"github.com/erknas/forum/internal/auth"
+	"github.com/erknas/forum/internal/filter"
+	"github.com/erknas/forum/internal/loader"
+	"github.com/erknas/forum/pkg/apperr"
+	"github.com/erknas/forum/pkg/conv"
+	"github.com/erknas/forum/pkg/sl"
+)
+
+var errEditForbidden = apperr.Forbidden("only the author or a moderator can edit")
+
+// EditPost replaces the title and content of a post, keeping the old version
+// as a revision.
+func (s *Service) EditPost(ctx context.Context, input model.PostEditInput) (*model.Post, error) {
+	if errors := input.ValidatePostEditInput(); len(errors) > 0 {
+		return nil, apperr.Validation("invalid request data", errors)
+	}
+
+	edit, err := input.Convert()
+	if err != nil {
+		return nil, err
+	}
+
+	// The post is read past the loaders, which would keep serving the version
+	// the edit replaces.
+	stored, err := s.store.GetPostByID(ctx, edit.ID)
+	if err != nil {
+		slog.Error("failed to get post", sl.Err(err), "id", edit.ID)
+		return nil, err
+	}
+
+	if !visible(ctx, stored.Status, stored.Author.Name) {
+		return nil, apperr.ErrPostNotFound
+	}
+
+	content := filter.Content{Author: stored.Author.Name, Title: edit.Title, Text: edit.Content}
+
+	actor, err := s.checkEdit(ctx, &edit, stored.Author.Name, stored.Status, content)
+	if err != nil {
+		return nil, err
+	}
+
+	customPost, err := s.store.EditPost(ctx, edit, actor)
+	if err != nil {
+		slog.Error("failed to edit post", sl.Err(err), "id", edit.ID)
+		return nil, err
+	}
+
+	s.record(content, customPost.Status)
+
+	disguise(ctx, &customPost.Status, &customPost.StatusReason)
+	post := customPost.Convert()
+
+	slog.Info("EditPost OK", "post_id", edit.ID, "editor", edit.Editor)
+
+	return &post, nil
+}
+
+// EditComment replaces the content of a comment, keeping the old version as a
+// revision.
+func (s *Service) EditComment(ctx context.Context, input model.CommentEditInput) (*model.Comment, error) {
+	if errors := input.ValidateCommentEditInput(); len(errors) > 0 {
+		return nil, apperr.Validation("invalid request data", errors)
+	}
+
+	edit, err := input.Convert()
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := s.store.GetCommentByID(ctx, edit.ID)
+	if err != nil {
+		slog.Error("failed to get comment", sl.Err(err), "id", edit.ID)
+		return nil, err
+	}
+
+	if !visible(ctx, stored.Status, stored.Author.Name) {
+		return nil, apperr.ErrCommentNotFound
+	}
+
+	content := filter.Content{Author: stored.Author.Name, Text: edit.Content}
+
+	actor, err := s.checkEdit(ctx, &edit, stored.Author.Name, stored.Status, content)
+	if err != nil {
+		return nil, err
+	}
+
+	customComment, err := s.store.EditComment(ctx, edit, actor)
+	if err != nil {
+		slog.Error("failed to edit comment", sl.Err(err), "id", edit.ID)
+		return nil, err
+	}
+
+	s.record(content, customComment.Status)
+
+	disguise(ctx, &customComment.Status, &customComment.StatusReason)
+	comment := customComment.Convert()
+
+	slog.Info("EditComment OK", "comment_id", edit.ID, "editor", edit.Editor)
+
+	return &comment, nil
+}
+
+// PostRevisions returns every version of a post, oldest first.
+func (s *Service) PostRevisions(ctx context.Context, strID string) ([]*model.Revision, error) {
+	id, err := conv.NodeID(conv.TypePost, strID)
+	if err != nil {
+		return nil, err
+	}
+
+	post, err := s.getPost(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	revisions, err := s.getRevisions(ctx, conv.TypePost, id)
+	if err != nil {
+		slog.Error("failed to get revisions", sl.Err(err), "post_id", id)
+		return nil, err
+	}
+
+	return model.ConvertRevisions(append(revisions, post.Revision(len(revisions)+1))), nil
+}
+
+// CommentRevisions returns every version of a comment, oldest first.
+func (s *Service) CommentRevisions(ctx context.Context, strID string) ([]*model.Revision, error) {
+	id, err := conv.NodeID(conv.TypeComment, strID)
+	if err != nil {
+		return nil, err
+	}
+
+	comment, err := s.getComment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	revisions, err := s.getRevisions(ctx, conv.TypeComment, id)
+	if err != nil {
+		slog.Error("failed to get revisions", sl.Err(err), "comment_id", id)
+		return nil, err
+	}
+
+	return model.ConvertRevisions(append(revisions, comment.Revision(len(revisions)+1))), nil
+}
+
+// checkEdit makes the viewer the editor of content by author, and returns the
+// actor to audit the edit under: moderators editing someone else's content.
+// Edits by authors are checked like new content, except drafts, which are
+// checked when they are published. A held edit sends published and
+// scheduled content back to the moderation queue.
+func (s *Service) checkEdit(ctx context.Context, edit *model.CustomEdit, author string, status model.ContentStatus, content filter.Content) (actor string, err error) {
+	viewer := auth.From(ctx)
+	if viewer == nil || (viewer.Name != author && !viewer.IsModerator()) {
+		return "", errEditForbidden
+	}
+
+	edit.Editor = viewer.Name
+
+	if viewer.IsModerator() {
+		if viewer.Name != author {
+			actor = viewer.Name
+		}
+		return actor, nil
+	}
+
+	if _, err := s.checkBan(ctx, author); err != nil {
+		return "", err
+	}
+
+	if status == model.ContentStatusDraft {
+		return "", nil
+	}
+
+	checked, reason, err := s.checkContent(ctx, content)
+	if err != nil {
+		return "", err
+	}
+
+	if checked == model.ContentStatusPending && (status.OrPublished() == model.ContentStatusPublished || status == model.ContentStatusScheduled) {
+		edit.Status, edit.StatusReason = checked, reason
+	}
+
+	return "", nil
+}
+
+// getRevisions returns a copy of the stored revisions of a target, which the
+// caller may append to.
+func (s *Service) getRevisions(ctx context.Context, targetType string, id int) ([]model.CustomRevision, error) {
+	var (
+		revisions []model.CustomRevision
+		err       error
+	)
+
+	if loaders := loader.For(ctx); loaders != nil {
+		revisions, err = loaders.Revisions.Load(ctx, loader.RevisionsKey{TargetType: targetType, TargetID: id})
+	} else {
+		var byTarget map[int][]model.CustomRevision
+		byTarget, err = s.store.GetRevisions(ctx, targetType, []int{id})
+		revisions = byTarget[id]
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return slices.Clone(revisions), nil
+}
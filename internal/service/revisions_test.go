@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/erknas/forum/graph/model"
+	"github.com/erknas/forum/internal/auth"
+	"github.com/erknas/forum/internal/filter"
+	"github.com/erknas/forum/internal/storage/mocks"
+	"github.com/erknas/forum/pkg/apperr"
+	"github.com/erknas/forum/pkg/conv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEditPost(t *testing.T) {
+	storerMock := mocks.NewStorer(t)
+	notBanned(storerMock)
+	s := New(storerMock, Options{})
+
+	stored := model.CustomPost{ID: 1, Title: "Title", Content: "Content", Author: model.CustomAuthor{Name: "Alice"}, Status: model.ContentStatusPublished}
+	storerMock.On("GetPostByID", mock.Anything, 1).Return(stored, nil)
+
+	input := model.PostEditInput{ID: conv.GlobalID(conv.TypePost, 1), Title: "New title", Content: "New content"}
+
+	_, err := s.EditPost(context.Background(), input)
+	assert.Equal(t, errEditForbidden, err, "anonymous")
+
+	_, err = s.EditPost(auth.With(context.Background(), &auth.Viewer{Name: "Bob", Role: auth.RoleUser}), input)
+	assert.Equal(t, errEditForbidden, err, "someone else")
+
+	_, err = s.EditPost(auth.With(context.Background(), alice), model.PostEditInput{ID: input.ID})
+	assert.Equal(t, apperr.CodeValidation, apperr.CodeOf(err))
+
+	editedAt := time.Now()
+
+	storerMock.On("EditPost", mock.Anything, model.CustomEdit{ID: 1, Title: "New title", Content: "New content", Editor: "Alice"}, "").
+		Return(model.CustomPost{ID: 1, Title: "New title", Content: "New content", Author: stored.Author, EditedBy: "Alice", EditedAt: &editedAt}, nil).Once()
+
+	post, err := s.EditPost(auth.With(context.Background(), alice), input)
+	require.NoError(t, err)
+	assert.Equal(t, "New title", post.Title)
+	assert.True(t, post.Edited)
+
+	storerMock.On("EditPost", mock.Anything, model.CustomEdit{ID: 1, Title: "New title", Content: "New content", Editor: "Mod"}, "Mod").
+		Return(model.CustomPost{ID: 1, Author: stored.Author, EditedBy: "Mod", EditedAt: &editedAt}, nil).Once()
+
+	_, err = s.EditPost(auth.With(context.Background(), moderator), input)
+	require.NoError(t, err, "moderators edit anyone's posts, audited")
+}
+
+func TestEditPost_Hidden(t *testing.T) {
+	storerMock := mocks.NewStorer(t)
+	s := New(storerMock, Options{})
+
+	storerMock.On("GetPostByID", mock.Anything, 1).
+		Return(model.CustomPost{ID: 1, Author: model.CustomAuthor{Name: "Bob"}, Status: model.ContentStatusPending}, nil)
+
+	_, err := s.EditPost(auth.With(context.Background(), alice), model.PostEditInput{ID: conv.GlobalID(conv.TypePost, 1), Title: "Title", Content: "Content"})
+	assert.ErrorIs(t, err, apperr.ErrPostNotFound)
+}
+
+func TestEditComment_Filtered(t *testing.T) {
+	storerMock := mocks.NewStorer(t)
+	notBanned(storerMock)
+	s := New(storerMock, Options{Filters: []filter.Filter{filter.NewBannedWords([]string{"casino"}), holdAll{}}})
+
+	storerMock.On("GetCommentByID", mock.Anything, 2).
+		Return(model.CustomComment{ID: 2, PostID: 1, Content: "Content", Author: model.CustomAuthor{Name: "Alice"}}, nil)
+
+	ctx := auth.With(context.Background(), alice)
+
+	_, err := s.EditComment(ctx, model.CommentEditInput{ID: conv.GlobalID(conv.TypeComment, 2), Content: "Visit my casino"})
+	assert.Equal(t, apperr.CodeContentRejected, apperr.CodeOf(err))
+
+	held := model.CustomEdit{ID: 2, Content: "Fixed", Editor: "Alice", Status: model.ContentStatusPending, StatusReason: "held"}
+	storerMock.On("EditComment", mock.Anything, held, "").
+		Return(model.CustomComment{ID: 2, PostID: 1, Content: "Fixed", Author: model.CustomAuthor{Name: "Alice"}, Status: model.ContentStatusPending, StatusReason: "held"}, nil).Once()
+
+	comment, err := s.EditComment(ctx, model.CommentEditInput{ID: conv.GlobalID(conv.TypeComment, 2), Content: "Fixed"})
+	require.NoError(t, err)
+	assert.Equal(t, model.ContentStatusPending, comment.Status, "a held edit goes back to the moderation queue")
+}
+
+func TestPostRevisions(t *testing.T) {
+	storerMock := mocks.NewStorer(t)
+	s := New(storerMock, Options{})
+
+	createdAt := time.Now().Add(-time.Hour)
+	editedAt := time.Now()
+
+	storerMock.On("GetPostByID", mock.Anything, 1).Return(model.CustomPost{
+		ID:        1,
+		Title:     "Title",
+		Content:   "one\n2",
+		Author:    model.CustomAuthor{Name: "Alice"},
+		CreatedAt: createdAt,
+		EditedBy:  "Mod",
+		EditedAt:  &editedAt,
+	}, nil)
+	storerMock.On("GetRevisions", mock.Anything, conv.TypePost, []int{1}).Return(map[int][]model.CustomRevision{
+		1: {{TargetType: conv.TypePost, TargetID: 1, Version: 1, Title: "Title", Content: "one\ntwo", Editor: "Alice", CreatedAt: createdAt}},
+	}, nil)
+
+	revisions, err := s.PostRevisions(context.Background(), conv.GlobalID(conv.TypePost, 1))
+	require.NoError(t, err)
+	require.Len(t, revisions, 2)
+
+	assert.Equal(t, int32(1), revisions[0].Version)
+	assert.Empty(t, revisions[0].ContentDiff)
+
+	assert.Equal(t, int32(2), revisions[1].Version)
+	assert.Equal(t, "Mod", revisions[1].Editor)
+	assert.Equal(t, editedAt, revisions[1].CreatedAt)
+	assert.Equal(t, []*model.DiffLine{
+		{Op: model.DiffOpEqual, Text: "one"},
+		{Op: model.DiffOpDelete, Text: "two"},
+		{Op: model.DiffOpInsert, Text: "2"},
+	}, revisions[1].ContentDiff)
+}
+
+func TestCommentRevisions_NeverEdited(t *testing.T) {
+	storerMock := mocks.NewStorer(t)
+	s := New(storerMock, Options{})
+
+	createdAt := time.Now()
+
+	storerMock.On("GetCommentByID", mock.Anything, 2).
+		Return(model.CustomComment{ID: 2, PostID: 1, Content: "Content", Author: model.CustomAuthor{Name: "Alice"}, CreatedAt: createdAt}, nil)
+	storerMock.On("GetRevisions", mock.Anything, conv.TypeComment, []int{2}).Return(map[int][]model.CustomRevision{}, nil)
+
+	revisions, err := s.CommentRevisions(context.Background(), conv.GlobalID(conv.TypeComment, 2))
+	require.NoError(t, err)
+	require.Len(t, revisions, 1)
+	assert.Equal(t, "Alice", revisions[0].Editor)
+	assert.Equal(t, createdAt, revisions[0].CreatedAt)
+	assert.Equal(t, "Content", revisions[0].Content)
+}
@@ -3,50 +3,139 @@ package service
 import (
 	"context"
 	"log/slog"
+	"strings"
+	"time"
 
 	"github.com/erknas/forum/graph/model"
+	"github.com/erknas/forum/internal/auth"
+	"github.com/erknas/forum/internal/filter"
+	"github.com/erknas/forum/internal/loader"
 	"github.com/erknas/forum/internal/storage"
-	"github.com/erknas/forum/internal/subscription"
+	"github.com/erknas/forum/pkg/apperr"
 	"github.com/erknas/forum/pkg/conv"
 	"github.com/erknas/forum/pkg/pagination"
 	"github.com/erknas/forum/pkg/sl"
-	"github.com/vektah/gqlparser/v2/gqlerror"
 )
 
 type Servicer interface {
 	CreatePost(context.Context, model.PostInput) (*model.Post, error)
 	Posts(context.Context) ([]*model.Post, error)
+	LatestPosts(context.Context, int) ([]*model.Post, error)
+	LatestComments(context.Context, string, int) ([]*model.Comment, error)
 	PostByID(context.Context, string) (*model.Post, error)
 	CreateComment(context.Context, model.CommentInput) (*model.Comment, error)
 	CommentsByPost(context.Context, string, *int32, *int32) ([]*model.Comment, error)
+	Replies(context.Context, string) ([]*model.Comment, error)
+	AuthorByID(context.Context, string) (*model.Author, error)
+	AuthorByName(context.Context, string) (*model.Author, error)
+	AuthorStats(context.Context, string) (model.AuthorStats, error)
+	PostsByAuthor(context.Context, string, *int32, *string) (*model.PostConnection, error)
+	CommentsByAuthor(context.Context, string, *int32, *string) (*model.CommentConnection, error)
+	Node(context.Context, string) (model.Node, error)
+	Nodes(context.Context, []string) ([]model.Node, error)
+	ModerationQueue(context.Context, *int32, *string) (*model.ModerationConnection, error)
+	ApproveContent(context.Context, string) (model.Content, error)
+	RejectContent(context.Context, string, string) (model.Content, error)
+	Report(context.Context, string, model.ReportReason, *string) (*model.Report, error)
+	Reports(context.Context, *int32, *string) (*model.ReportGroupConnection, error)
+	ResolveReports(context.Context, string, model.ReportAction) (*model.ReportGroup, error)
+	AuditLog(context.Context, *model.AuditLogFilter, *int32, *string) (*model.AuditEntryConnection, error)
+	BanUser(context.Context, string, model.BanKind, string, *time.Time) (*model.Ban, error)
+	UnbanUser(context.Context, string) (*model.Ban, error)
+	Bans(context.Context, *int32, *string) (*model.BanConnection, error)
+	CheckSubscriber(context.Context) error
+	EditPost(context.Context, model.PostEditInput) (*model.Post, error)
+	EditComment(context.Context, model.CommentEditInput) (*model.Comment, error)
+	PostRevisions(context.Context, string) ([]*model.Revision, error)
+	CommentRevisions(context.Context, string) ([]*model.Revision, error)
+	PublishPost(context.Context, string, *time.Time) (*model.Post, error)
+	MyDrafts(context.Context, *int32, *string) (*model.PostConnection, error)
+	PinPost(context.Context, string, *time.Time) (*model.Post, error)
+	UnpinPost(context.Context, string) (*model.Post, error)
+	PostPoll(context.Context, string) (*model.Poll, error)
+	VotePoll(context.Context, string, []string) (*model.Poll, error)
+	PostAttachments(context.Context, string) ([]*model.Attachment, error)
+	CommentAttachments(context.Context, string) ([]*model.Attachment, error)
 }
 
+var (
+	errModeratorOnly  = apperr.Forbidden("moderator role required")
+	errAuthorMismatch = apperr.Forbidden("signed-in users can only write under their own name")
+	errSignInRequired = apperr.Forbidden("sign in to post while bans are in force")
+)
+
 type Service struct {
-	store storage.Storer
-	sub   subscription.Subscriber
+	store             storage.Storer
+	filters           filter.Chain
+	reportThreshold   int
+	signInWhileBanned bool
+	attachments       AttachmentOptions
+}
+
+// Options configures a Service. Filters check new posts and comments in
+// order. Published content with ReportThreshold open reports is hidden until
+// a moderator resolves them; a zero threshold never hides content. With
+// SignInWhileBanned anonymous content is rejected while any ban is in force,
+// so that banned users cannot post without signing in under a new name.
+type Options struct {
+	Filters           []filter.Filter
+	ReportThreshold   int
+	SignInWhileBanned bool
+	Attachments       AttachmentOptions
 }
 
-func New(store storage.Storer, sub subscription.Subscriber) *Service {
+func New(store storage.Storer, opts Options) *Service {
 	return &Service{
-		store: store,
-		sub:   sub,
+		store:             store,
+		filters:           opts.Filters,
+		reportThreshold:   opts.ReportThreshold,
+		signInWhileBanned: opts.SignInWhileBanned,
+		attachments:       opts.Attachments,
 	}
 }
 
 func (s *Service) CreatePost(ctx context.Context, input model.PostInput) (*model.Post, error) {
 	if errors := input.ValidatePostInput(); len(errors) > 0 {
-		return nil, &gqlerror.Error{
-			Message:    "invalid request data",
-			Extensions: errors,
-		}
+		return nil, apperr.Validation("invalid request data", errors)
+	}
+
+	uploads, err := s.readUploads(input.Attachments)
+	if err != nil {
+		return nil, err
+	}
+
+	customInput := input.Convert()
+	content := filter.Content{Author: input.Author, Title: input.Title, Text: input.Content}
+
+	var (
+		status model.ContentStatus
+		reason string
+	)
+
+	if input.PublishAt != nil || (input.Draft != nil && *input.Draft) {
+		status, reason, err = s.draftStatus(ctx, input)
+	} else {
+		status, reason, err = s.checkAuthorAndContent(ctx, content)
+	}
+	if err != nil {
+		return nil, err
 	}
+	customInput.Status, customInput.StatusReason = status, reason
 
-	customPost, err := s.store.CreatePost(ctx, input)
+	if customInput.Attachments, err = s.putUploads(ctx, uploads); err != nil {
+		return nil, err
+	}
+
+	customPost, err := s.store.CreatePost(ctx, customInput)
 	if err != nil {
 		slog.Error("failed to create post", sl.Err(err), "input", input)
+		s.deleteBlobs(ctx, customInput.Attachments)
 		return nil, err
 	}
 
+	s.record(content, customPost.Status)
+
+	disguise(ctx, &customPost.Status, &customPost.StatusReason)
 	post := customPost.Convert()
 
 	slog.Info("CreatePost OK", "post", post)
@@ -55,7 +144,7 @@ func (s *Service) CreatePost(ctx context.Context, input model.PostInput) (*model
 }
 
 func (s *Service) Posts(ctx context.Context) ([]*model.Post, error) {
-	customPosts, err := s.store.GetPosts(ctx)
+	customPosts, err := s.store.GetPosts(ctx, auth.Name(ctx))
 	if err != nil {
 		slog.Error("failed to get posts", sl.Err(err))
 		return nil, err
@@ -64,6 +153,7 @@ func (s *Service) Posts(ctx context.Context) ([]*model.Post, error) {
 	posts := make([]*model.Post, 0, len(customPosts))
 
 	for _, customPost := range customPosts {
+		disguise(ctx, &customPost.Status, &customPost.StatusReason)
 		post := customPost.Convert()
 		posts = append(posts, &post)
 	}
@@ -73,13 +163,53 @@ func (s *Service) Posts(ctx context.Context) ([]*model.Post, error) {
 	return posts, nil
 }
 
+// LatestPosts returns up to limit published posts, newest first, whether
+// pinned or not.
+func (s *Service) LatestPosts(ctx context.Context, limit int) ([]*model.Post, error) {
+	customPosts, err := s.store.GetLatestPosts(ctx, limit)
+	if err != nil {
+		slog.Error("failed to get latest posts", sl.Err(err))
+		return nil, err
+	}
+
+	posts := make([]*model.Post, 0, len(customPosts))
+
+	for _, customPost := range customPosts {
+		post := customPost.Convert()
+		posts = append(posts, &post)
+	}
+
+	slog.Info("LatestPosts OK", "limit", limit)
+
+	return posts, nil
+}
+
+// LatestComments returns up to limit published comments of a post, replies
+// included, newest first.
+func (s *Service) LatestComments(ctx context.Context, strID string, limit int) ([]*model.Comment, error) {
+	id, err := conv.NodeID(conv.TypePost, strID)
+	if err != nil {
+		return nil, err
+	}
+
+	customComments, err := s.store.GetLatestComments(ctx, id, limit)
+	if err != nil {
+		slog.Error("failed to get latest comments", sl.Err(err), "post_id", id)
+		return nil, err
+	}
+
+	slog.Info("LatestComments OK", "post_id", id, "limit", limit)
+
+	return convertComments(ctx, customComments), nil
+}
+
 func (s *Service) PostByID(ctx context.Context, strID string) (*model.Post, error) {
-	id, err := conv.ID(strID)
+	id, err := conv.NodeID(conv.TypePost, strID)
 	if err != nil {
 		return nil, err
 	}
 
-	customPost, err := s.store.GetPostByID(ctx, id)
+	customPost, err := s.getPost(ctx, id)
 	if err != nil {
 		slog.Error("failed to get post", sl.Err(err), "id", id)
 		return nil, err
@@ -94,10 +224,12 @@ func (s *Service) PostByID(ctx context.Context, strID string) (*model.Post, erro
 
 func (s *Service) CreateComment(ctx context.Context, input model.CommentInput) (*model.Comment, error) {
 	if errors := input.ValidateCommentInput(); len(errors) > 0 {
-		return nil, &gqlerror.Error{
-			Message:    "invalid request data",
-			Extensions: errors,
-		}
+		return nil, apperr.Validation("invalid request data", errors)
+	}
+
+	uploads, err := s.readUploads(input.Attachments)
+	if err != nil {
+		return nil, err
 	}
 
 	customInput, err := input.Convert()
@@ -106,15 +238,29 @@ func (s *Service) CreateComment(ctx context.Context, input model.CommentInput) (
 		return nil, err
 	}
 
+	content := filter.Content{Author: input.Author, Text: input.Content}
+
+	status, reason, err := s.checkAuthorAndContent(ctx, content)
+	if err != nil {
+		return nil, err
+	}
+	customInput.Status, customInput.StatusReason = status, reason
+
+	if customInput.Attachments, err = s.putUploads(ctx, uploads); err != nil {
+		return nil, err
+	}
+
 	customComment, err := s.store.CreateComment(ctx, customInput)
 	if err != nil {
 		slog.Error("failed to create comment", sl.Err(err))
+		s.deleteBlobs(ctx, customInput.Attachments)
 		return nil, err
 	}
 
-	comment := customComment.Convert()
+	s.record(content, customComment.Status)
 
-	s.sub.Publish(comment.PostID, &comment)
+	disguise(ctx, &customComment.Status, &customComment.StatusReason)
+	comment := customComment.Convert()
 
 	slog.Info("CreateComment OK", "comment", comment)
 
@@ -135,69 +281,446 @@ func (s *Service) CommentsByPost(ctx context.Context, id string, page *int32, pa
 	return comments, nil
 }
 
-func (s *Service) getComments(ctx context.Context, strID string, offset int, limit int) ([]*model.Comment, error) {
-	id, err := conv.ID(strID)
+func (s *Service) Replies(ctx context.Context, strID string) ([]*model.Comment, error) {
+	id, err := conv.NodeID(conv.TypeComment, strID)
 	if err != nil {
 		return nil, err
 	}
 
-	customComments, err := s.store.GetCommentsByPost(ctx, id, offset, limit)
+	var customComments []model.CustomComment
+
+	if loaders := loader.For(ctx); loaders != nil {
+		customComments, err = loaders.Replies.Load(ctx, id)
+	} else {
+		customComments, err = s.store.GetCommentReplies(ctx, id, auth.Name(ctx))
+	}
 	if err != nil {
+		slog.Error("failed to get comment replies", sl.Err(err), "comment_id", id)
 		return nil, err
 	}
 
-	comments := make([]*model.Comment, 0, len(customComments))
+	return convertComments(ctx, customComments), nil
+}
 
-	for _, customComment := range customComments {
+func (s *Service) AuthorByID(ctx context.Context, strID string) (*model.Author, error) {
+	id, err := conv.NodeID(conv.TypeAuthor, strID)
+	if err != nil {
+		return nil, err
+	}
+
+	customAuthor, err := s.store.GetAuthorByID(ctx, id)
+	if err != nil {
+		slog.Error("failed to get author", sl.Err(err), "id", id)
+		return nil, err
+	}
+
+	author := customAuthor.Convert()
+
+	slog.Info("AuthorByID OK", "author", author)
+
+	return &author, nil
+}
+
+func (s *Service) AuthorByName(ctx context.Context, name string) (*model.Author, error) {
+	customAuthor, err := s.store.GetAuthorByName(ctx, name)
+	if err != nil {
+		slog.Error("failed to get author", sl.Err(err), "name", name)
+		return nil, err
+	}
+
+	author := customAuthor.Convert()
+
+	slog.Info("AuthorByName OK", "author", author)
+
+	return &author, nil
+}
+
+func (s *Service) AuthorStats(ctx context.Context, strID string) (model.AuthorStats, error) {
+	id, err := conv.NodeID(conv.TypeAuthor, strID)
+	if err != nil {
+		return model.AuthorStats{}, err
+	}
+
+	stats, err := s.getAuthorStats(ctx, id)
+	if err != nil {
+		slog.Error("failed to get author stats", sl.Err(err), "author_id", id)
+		return model.AuthorStats{}, err
+	}
+
+	return stats, nil
+}
+
+func (s *Service) PostsByAuthor(ctx context.Context, strID string, first *int32, after *string) (*model.PostConnection, error) {
+	id, err := conv.NodeID(conv.TypeAuthor, strID)
+	if err != nil {
+		return nil, err
+	}
+
+	limit, offset, err := pagination.FromCursor(first, after)
+	if err != nil {
+		return nil, err
+	}
+
+	customPosts, err := s.store.GetPostsByAuthor(ctx, id, offset, limit+1, auth.Name(ctx))
+	if err != nil {
+		slog.Error("failed to get posts by author", sl.Err(err), "author_id", id)
+		return nil, err
+	}
+
+	stats, err := s.getAuthorStats(ctx, id)
+	if err != nil {
+		slog.Error("failed to get author stats", sl.Err(err), "author_id", id)
+		return nil, err
+	}
+
+	connection := &model.PostConnection{TotalCount: int32(stats.PostCount)}
+	connection.Edges, connection.PageInfo = pagination.Edges(customPosts, offset, limit, func(customPost model.CustomPost, cursor string) *model.PostEdge {
+		disguise(ctx, &customPost.Status, &customPost.StatusReason)
+		post := customPost.Convert()
+		return &model.PostEdge{Cursor: cursor, Node: &post}
+	})
+
+	slog.Info("PostsByAuthor OK", "author_id", id, "offset", offset, "limit", limit)
+
+	return connection, nil
+}
+
+func (s *Service) CommentsByAuthor(ctx context.Context, strID string, first *int32, after *string) (*model.CommentConnection, error) {
+	id, err := conv.NodeID(conv.TypeAuthor, strID)
+	if err != nil {
+		return nil, err
+	}
+
+	limit, offset, err := pagination.FromCursor(first, after)
+	if err != nil {
+		return nil, err
+	}
+
+	customComments, err := s.store.GetCommentsByAuthor(ctx, id, offset, limit+1, auth.Name(ctx))
+	if err != nil {
+		slog.Error("failed to get comments by author", sl.Err(err), "author_id", id)
+		return nil, err
+	}
+
+	stats, err := s.getAuthorStats(ctx, id)
+	if err != nil {
+		slog.Error("failed to get author stats", sl.Err(err), "author_id", id)
+		return nil, err
+	}
+
+	connection := &model.CommentConnection{TotalCount: int32(stats.CommentCount)}
+	connection.Edges, connection.PageInfo = pagination.Edges(customComments, offset, limit, func(customComment model.CustomComment, cursor string) *model.CommentEdge {
+		disguise(ctx, &customComment.Status, &customComment.StatusReason)
 		comment := customComment.Convert()
-		comment.Replies = nil
-		comments = append(comments, &comment)
+		return &model.CommentEdge{Cursor: cursor, Node: &comment}
+	})
+
+	slog.Info("CommentsByAuthor OK", "author_id", id, "offset", offset, "limit", limit)
+
+	return connection, nil
+}
+
+// Node returns the post, comment or author with the given global ID, or nil if
+// it does not exist.
+func (s *Service) Node(ctx context.Context, globalID string) (model.Node, error) {
+	typ, id, err := conv.FromGlobalID(globalID)
+	if err != nil {
+		return nil, err
+	}
+
+	var node model.Node
+
+	switch typ {
+	case conv.TypePost:
+		var customPost model.CustomPost
+		if customPost, err = s.getPost(ctx, id); err == nil {
+			post := customPost.Convert()
+			node = &post
+		}
+	case conv.TypeComment:
+		var customComment model.CustomComment
+		if customComment, err = s.getComment(ctx, id); err == nil {
+			comment := customComment.Convert()
+			node = &comment
+		}
+	case conv.TypeAuthor:
+		var customAuthor model.CustomAuthor
+		if customAuthor, err = s.store.GetAuthorByID(ctx, id); err == nil {
+			author := customAuthor.Convert()
+			node = &author
+		}
+	default:
+		return nil, apperr.Validationf("invalid ID %s", globalID)
+	}
+
+	if err != nil {
+		if apperr.CodeOf(err) == apperr.CodeNotFound {
+			return nil, nil
+		}
+		slog.Error("failed to get node", sl.Err(err), "type", typ, "id", id)
+		return nil, err
 	}
 
-	for _, comment := range comments {
-		if err := s.populateReplies(ctx, comment); err != nil {
+	return node, nil
+}
+
+func (s *Service) Nodes(ctx context.Context, globalIDs []string) ([]model.Node, error) {
+	nodes := make([]model.Node, 0, len(globalIDs))
+
+	for _, globalID := range globalIDs {
+		node, err := s.Node(ctx, globalID)
+		if err != nil {
 			return nil, err
 		}
+		nodes = append(nodes, node)
 	}
 
-	return comments, nil
+	return nodes, nil
 }
 
-func (s *Service) getCommentReplies(ctx context.Context, strID string) ([]*model.Comment, error) {
-	id, err := conv.ID(strID)
+// ModerationQueue returns pending posts and comments, oldest first.
+func (s *Service) ModerationQueue(ctx context.Context, first *int32, after *string) (*model.ModerationConnection, error) {
+	if !auth.From(ctx).IsModerator() {
+		return nil, errModeratorOnly
+	}
+
+	limit, offset, err := pagination.FromCursor(first, after)
 	if err != nil {
 		return nil, err
 	}
 
-	customComments, err := s.store.GetCommentReplies(ctx, id)
+	pending, err := s.store.GetPendingContent(ctx, offset, limit+1)
 	if err != nil {
-		slog.Error("failed to get comment replies", sl.Err(err), "comment_id", id)
+		slog.Error("failed to get pending content", sl.Err(err))
 		return nil, err
 	}
 
-	comments := make([]*model.Comment, 0, len(customComments))
+	connection := new(model.ModerationConnection)
+	connection.Edges, connection.PageInfo = pagination.Edges(pending, offset, limit, func(content model.CustomContent, cursor string) *model.ModerationEdge {
+		return &model.ModerationEdge{Cursor: cursor, Node: content.Convert()}
+	})
 
-	for _, customComment := range customComments {
-		comment := customComment.Convert()
-		comments = append(comments, &comment)
+	slog.Info("ModerationQueue OK", "offset", offset, "limit", limit)
+
+	return connection, nil
+}
+
+// ApproveContent publishes the post or comment with the given global ID and
+// teaches the filters that it is not spam.
+func (s *Service) ApproveContent(ctx context.Context, globalID string) (model.Content, error) {
+	return s.moderate(ctx, globalID, model.ContentStatusPublished, "")
+}
+
+// RejectContent hides the post or comment with the given global ID and
+// teaches the filters that it is spam.
+func (s *Service) RejectContent(ctx context.Context, globalID string, reason string) (model.Content, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, apperr.Validation("invalid request data", map[string]interface{}{"reason": "reason is required"})
 	}
 
-	return comments, nil
+	return s.moderate(ctx, globalID, model.ContentStatusRejected, reason)
 }
 
-func (s *Service) populateReplies(ctx context.Context, comment *model.Comment) error {
-	replies, err := s.getCommentReplies(ctx, comment.ID)
+func (s *Service) moderate(ctx context.Context, globalID string, status model.ContentStatus, reason string) (model.Content, error) {
+	viewer := auth.From(ctx)
+	if !viewer.IsModerator() {
+		return nil, errModeratorOnly
+	}
+
+	typ, id, err := conv.FromGlobalID(globalID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	comment.Replies = replies
+	var content model.CustomContent
 
-	for _, reply := range replies {
-		if err := s.populateReplies(ctx, reply); err != nil {
-			return err
+	switch typ {
+	case conv.TypePost:
+		var customPost model.CustomPost
+		if customPost, err = s.store.SetPostStatus(ctx, id, status, reason, viewer.Name); err == nil {
+			content.Post = &customPost
 		}
+	case conv.TypeComment:
+		var customComment model.CustomComment
+		if customComment, err = s.store.SetCommentStatus(ctx, id, status, reason, viewer.Name); err == nil {
+			content.Comment = &customComment
+		}
+	default:
+		return nil, apperr.Validationf("invalid ID %s", globalID)
+	}
+
+	if err != nil {
+		slog.Error("failed to set content status", sl.Err(err), "type", typ, "id", id, "status", status)
+		return nil, err
+	}
+
+	s.learn(content, status != model.ContentStatusPublished)
+
+	slog.Info("content moderated", "type", typ, "id", id, "status", status, "moderator", viewer.Name)
+
+	return content.Convert(), nil
+}
+
+// learn teaches the filters a moderator decision on content.
+func (s *Service) learn(content model.CustomContent, spam bool) {
+	if content.Post != nil {
+		s.filters.Learn(filter.Content{Author: content.Post.Author.Name, Title: content.Post.Title, Text: content.Post.Content}, spam)
+		return
+	}
+	s.filters.Learn(filter.Content{Author: content.Comment.Author.Name, Text: content.Comment.Content}, spam)
+}
+
+func (s *Service) getComments(ctx context.Context, strID string, offset int, limit int) ([]*model.Comment, error) {
+	id, err := conv.NodeID(conv.TypePost, strID)
+	if err != nil {
+		return nil, err
+	}
+
+	var customComments []model.CustomComment
+
+	if loaders := loader.For(ctx); loaders != nil {
+		customComments, err = loaders.Comments.Load(ctx, loader.CommentsKey{PostID: id, Offset: offset, Limit: limit})
+	} else {
+		customComments, err = s.store.GetCommentsByPost(ctx, id, offset, limit, auth.Name(ctx))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return convertComments(ctx, customComments), nil
+}
+
+// checkAuthorAndContent rejects content that a signed-in viewer writes under
+// another name and content by banned authors, and shadows content by
+// shadowbanned ones, then runs the filters. Anonymous content is rejected
+// while any ban is in force if the service requires signing in then.
+func (s *Service) checkAuthorAndContent(ctx context.Context, content filter.Content) (model.ContentStatus, string, error) {
+	viewer := auth.From(ctx)
+	if viewer != nil && viewer.Name != content.Author {
+		return "", "", errAuthorMismatch
+	}
+
+	shadowed, err := s.checkBan(ctx, content.Author)
+	if err != nil {
+		return "", "", err
+	}
+
+	if viewer == nil && s.signInWhileBanned {
+		if err := s.checkAnonymous(ctx); err != nil {
+			return "", "", err
+		}
+	}
+
+	status, reason, err := s.checkContent(ctx, content)
+	if err != nil || !shadowed {
+		return status, reason, err
+	}
+
+	return model.ContentStatusShadowed, model.ShadowedReason, nil
+}
+
+// checkContent returns the status new content is stored with: held content
+// waits for moderators, rejected content is not stored.
+func (s *Service) checkContent(ctx context.Context, content filter.Content) (model.ContentStatus, string, error) {
+	verdict, err := s.filters.Check(ctx, content)
+	if err != nil {
+		slog.Error("failed to filter content", sl.Err(err), "author", content.Author)
+		return "", "", err
+	}
+
+	if verdict.Action == filter.Allow {
+		return model.ContentStatusPublished, "", nil
+	}
+
+	slog.Info("content filtered", "action", verdict.Action, "filter", verdict.Filter, "author", content.Author)
+
+	if verdict.Action == filter.Hold {
+		return model.ContentStatusPending, verdict.Reason, nil
+	}
+
+	return "", "", apperr.New(apperr.CodeContentRejected, verdict.Reason)
+}
+
+// record passes stored content to the filters. Drafts are not recorded, as
+// they are checked when they are published.
+func (s *Service) record(content filter.Content, status model.ContentStatus) {
+	if status != model.ContentStatusDraft {
+		s.filters.Record(content)
+	}
+}
+
+// getPost returns the post, or ErrPostNotFound if the viewer may not see it.
+func (s *Service) getPost(ctx context.Context, id int) (model.CustomPost, error) {
+	var (
+		post model.CustomPost
+		err  error
+	)
+
+	if loaders := loader.For(ctx); loaders != nil {
+		post, err = loaders.Post.Load(ctx, id)
+	} else {
+		post, err = s.store.GetPostByID(ctx, id)
+	}
+	if err != nil {
+		return model.CustomPost{}, err
+	}
+
+	if !visible(ctx, post.Status, post.Author.Name) {
+		return model.CustomPost{}, apperr.ErrPostNotFound
+	}
+
+	disguise(ctx, &post.Status, &post.StatusReason)
+
+	return post, nil
+}
+
+// getComment returns the comment, or ErrCommentNotFound if the viewer may not
+// see it.
+func (s *Service) getComment(ctx context.Context, id int) (model.CustomComment, error) {
+	comment, err := s.store.GetCommentByID(ctx, id)
+	if err != nil {
+		return model.CustomComment{}, err
+	}
+
+	if !visible(ctx, comment.Status, comment.Author.Name) {
+		return model.CustomComment{}, apperr.ErrCommentNotFound
+	}
+
+	disguise(ctx, &comment.Status, &comment.StatusReason)
+
+	return comment, nil
+}
+
+func (s *Service) getAuthorStats(ctx context.Context, id int) (model.AuthorStats, error) {
+	if loaders := loader.For(ctx); loaders != nil {
+		return loaders.AuthorStats.Load(ctx, id)
+	}
+
+	return s.store.GetAuthorStats(ctx, id, auth.Name(ctx))
+}
+
+// visible reports whether the viewer may see content by author: content that
+// is not published is only shown to moderators and to its author, and drafts
+// only to their author.
+func visible(ctx context.Context, status model.ContentStatus, author string) bool {
+	if status.OrPublished() == model.ContentStatusPublished {
+		return true
+	}
+
+	viewer := auth.From(ctx)
+
+	return (viewer.IsModerator() && !status.Draft()) || (viewer != nil && viewer.Name == author)
+}
+
+func convertComments(ctx context.Context, customComments []model.CustomComment) []*model.Comment {
+	comments := make([]*model.Comment, 0, len(customComments))
+
+	for _, customComment := range customComments {
+		disguise(ctx, &customComment.Status, &customComment.StatusReason)
+		comment := customComment.Convert()
+		comments = append(comments, &comment)
 	}
 
-	return nil
+	return comments
 }
@@ -2,18 +2,21 @@ package service
 
 import (
 	"context"
-	"strconv"
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/erknas/forum/graph/model"
+	"github.com/erknas/forum/internal/auth"
+	"github.com/erknas/forum/internal/filter"
+	"github.com/erknas/forum/internal/loader"
 	"github.com/erknas/forum/internal/storage/mocks"
-	sub "github.com/erknas/forum/internal/subscription/mocks"
+	"github.com/erknas/forum/pkg/apperr"
+	"github.com/erknas/forum/pkg/conv"
 	"github.com/erknas/forum/pkg/pagination"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
-	"github.com/vektah/gqlparser/v2/gqlerror"
 )
 
 const layout = "02.01.2006 15:04"
@@ -42,7 +45,7 @@ func TestCreatePost(t *testing.T) {
 				Content:         "123 something",
 				CommentsAllowed: true,
 			},
-			wantErr: &gqlerror.Error{Message: "invalid request data", Extensions: map[string]interface{}{"title": "title length cannot be zero"}},
+			wantErr: apperr.Validation("invalid request data", map[string]interface{}{"title": "title length cannot be zero"}),
 		},
 		{
 			name: "Empty Author",
@@ -52,7 +55,17 @@ func TestCreatePost(t *testing.T) {
 				Content:         "123 something",
 				CommentsAllowed: true,
 			},
-			wantErr: &gqlerror.Error{Message: "invalid request data", Extensions: map[string]interface{}{"author": "author name length cannot be zero"}},
+			wantErr: apperr.Validation("invalid request data", map[string]interface{}{"author": "author name length cannot be zero"}),
+		},
+		{
+			name: "Too long Author",
+			input: model.PostInput{
+				Title:           "As123",
+				Author:          "Bartholomew Smith",
+				Content:         "123 something",
+				CommentsAllowed: true,
+			},
+			wantErr: apperr.Validation("invalid request data", map[string]interface{}{"author": "author name length cannot be more than 16 symbols"}),
 		},
 		{
 			name: "Empty Content",
@@ -62,7 +75,7 @@ func TestCreatePost(t *testing.T) {
 				Content:         "",
 				CommentsAllowed: true,
 			},
-			wantErr: &gqlerror.Error{Message: "invalid request data", Extensions: map[string]interface{}{"content": "content length cannot be zero"}},
+			wantErr: apperr.Validation("invalid request data", map[string]interface{}{"content": "content length cannot be zero"}),
 		},
 		{
 			name: "Empty all",
@@ -72,7 +85,7 @@ func TestCreatePost(t *testing.T) {
 				Content:         "",
 				CommentsAllowed: true,
 			},
-			wantErr: &gqlerror.Error{Message: "invalid request data", Extensions: map[string]interface{}{"title": "title length cannot be zero", "author": "author name length cannot be zero", "content": "content length cannot be zero"}},
+			wantErr: apperr.Validation("invalid request data", map[string]interface{}{"title": "title length cannot be zero", "author": "author name length cannot be zero", "content": "content length cannot be zero"}),
 		},
 	}
 
@@ -80,8 +93,12 @@ func TestCreatePost(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			storerMock := mocks.NewStorer(t)
 
+			customInput := tt.input.Convert()
+			customInput.Status = model.ContentStatusPublished
+
 			if tt.wantErr == nil {
-				storerMock.On("CreatePost", mock.Anything, tt.input).Return(model.CustomPost{}, nil)
+				notBanned(storerMock)
+				storerMock.On("CreatePost", mock.Anything, customInput).Return(model.CustomPost{}, nil)
 			}
 
 			s := &Service{store: storerMock}
@@ -89,9 +106,9 @@ func TestCreatePost(t *testing.T) {
 			post, err := s.CreatePost(context.Background(), tt.input)
 
 			if tt.wantErr != nil {
-				assert.EqualError(t, err, tt.wantErr.Error())
+				assert.Equal(t, tt.wantErr, err)
 				assert.Empty(t, post)
-				storerMock.AssertNotCalled(t, "CreatePost", mock.Anything, tt.input)
+				storerMock.AssertNotCalled(t, "CreatePost", mock.Anything, customInput)
 			} else {
 				assert.NoError(t, err)
 				assert.NotEmpty(t, post)
@@ -100,16 +117,74 @@ func TestCreatePost(t *testing.T) {
 	}
 }
 
+func TestCreatePost_Filtered(t *testing.T) {
+	storerMock := mocks.NewStorer(t)
+	notBanned(storerMock)
+	s := New(storerMock, Options{Filters: []filter.Filter{filter.NewBannedWords([]string{"casino"})}})
+
+	input := model.PostInput{Title: "Best cаsino", Author: "Bob", Content: "Visit us", CommentsAllowed: true}
+
+	post, err := s.CreatePost(context.Background(), input)
+	assert.Nil(t, post)
+	assert.Equal(t, apperr.CodeContentRejected, apperr.CodeOf(err))
+
+	storerMock.AssertNotCalled(t, "CreatePost", mock.Anything, mock.Anything)
+}
+
+func TestCreatePost_AuthorMismatch(t *testing.T) {
+	storerMock := mocks.NewStorer(t)
+	notBanned(storerMock)
+	s := New(storerMock, Options{})
+
+	ctx := auth.With(context.Background(), &auth.Viewer{Name: "Bob", Role: auth.RoleUser})
+
+	_, err := s.CreatePost(ctx, model.PostInput{Title: "Title", Author: "Robert", Content: "Content"})
+	assert.Equal(t, errAuthorMismatch, err)
+
+	_, err = s.CreateComment(ctx, model.CommentInput{PostID: "1", Author: "Robert", Content: "Content"})
+	assert.Equal(t, errAuthorMismatch, err)
+
+	storerMock.AssertNotCalled(t, "CreatePost", mock.Anything, mock.Anything)
+	storerMock.AssertNotCalled(t, "CreateComment", mock.Anything, mock.Anything)
+
+	storerMock.On("CreatePost", mock.Anything, mock.Anything).Return(model.CustomPost{ID: 1, Author: model.CustomAuthor{Name: "Bob"}}, nil).Once()
+
+	_, err = s.CreatePost(ctx, model.PostInput{Title: "Title", Author: "Bob", Content: "Content"})
+	require.NoError(t, err)
+}
+
+func TestCreateComment_Filtered(t *testing.T) {
+	storerMock := mocks.NewStorer(t)
+	notBanned(storerMock)
+	s := New(storerMock, Options{Filters: []filter.Filter{filter.NewDuplicates(time.Minute)}})
+
+	input := model.CommentInput{PostID: "1", Author: "Bob", Content: "First!"}
+	customInput := model.CustomCommentInput{PostID: 1, Author: "Bob", Content: "First!", Status: model.ContentStatusPublished}
+
+	storerMock.On("CreateComment", mock.Anything, customInput).Return(model.CustomComment{}, errors.New("connection reset")).Once()
+	storerMock.On("CreateComment", mock.Anything, customInput).Return(model.CustomComment{ID: 1, PostID: 1}, nil).Once()
+
+	_, err := s.CreateComment(context.Background(), input)
+	require.Error(t, err)
+
+	_, err = s.CreateComment(context.Background(), input)
+	require.NoError(t, err, "content that failed to store is not a duplicate")
+
+	comment, err := s.CreateComment(context.Background(), input)
+	assert.Nil(t, comment)
+	assert.Equal(t, apperr.CodeContentRejected, apperr.CodeOf(err))
+}
+
 func TestPosts(t *testing.T) {
 	storerMock := mocks.NewStorer(t)
 	s := &Service{store: storerMock}
 
 	customPosts := []model.CustomPost{
-		{ID: 1, Title: "Title1", Author: "Author1", Content: "Content1", CreatedAt: time.Now(), CommentsAllowed: true},
-		{ID: 2, Title: "Title2", Author: "Author2", Content: "Content2", CreatedAt: time.Now(), CommentsAllowed: false},
+		{ID: 1, Title: "Title1", Author: model.CustomAuthor{ID: 1, Name: "Author1"}, Content: "Content1", CreatedAt: time.Now(), CommentsAllowed: true},
+		{ID: 2, Title: "Title2", Author: model.CustomAuthor{ID: 2, Name: "Author2"}, Content: "Content2", CreatedAt: time.Now(), CommentsAllowed: false},
 	}
 
-	storerMock.On("GetPosts", mock.Anything).Return(customPosts, nil)
+	storerMock.On("GetPosts", mock.Anything, "").Return(customPosts, nil)
 
 	posts, err := s.Posts(context.Background())
 	require.NoError(t, err)
@@ -126,7 +201,7 @@ func TestPostByID(t *testing.T) {
 	customPost := model.CustomPost{
 		ID:              1,
 		Title:           "Title",
-		Author:          "Bob",
+		Author:          model.CustomAuthor{ID: 1, Name: "Bob"},
 		Content:         "Something",
 		CreatedAt:       time.Now().UTC(),
 		CommentsAllowed: true,
@@ -139,11 +214,13 @@ func TestPostByID(t *testing.T) {
 	require.NoError(t, err)
 
 	assert.NotEmpty(t, post)
-	assert.Equal(t, post.ID, strconv.Itoa(customPost.ID))
+	assert.Equal(t, post.ID, conv.GlobalID(conv.TypePost, customPost.ID))
 	assert.Equal(t, post.Title, customPost.Title)
+	assert.Equal(t, post.Author.Name, customPost.Author.Name)
 	assert.Equal(t, post.Content, customPost.Content)
 
-	assert.Equal(t, post.CreatedAt, customPost.CreatedAt.Format(layout))
+	assert.Equal(t, post.CreatedAt, customPost.CreatedAt)
+	assert.Equal(t, post.CreatedAtString, customPost.CreatedAt.Format(layout))
 	assert.Equal(t, post.CommentsAllowed, customPost.CommentsAllowed)
 	assert.Equal(t, len(post.Comments), len(customPost.Comments))
 
@@ -175,7 +252,7 @@ func TestCreateComment(t *testing.T) {
 			},
 			expectedComment: model.CustomComment{
 				ID:        1,
-				Author:    "Bob",
+				Author:    model.CustomAuthor{ID: 1, Name: "Bob"},
 				Content:   "Something",
 				CreatedAt: time.Now(),
 				PostID:    1,
@@ -183,9 +260,9 @@ func TestCreateComment(t *testing.T) {
 			},
 			comment: model.Comment{
 				ID:        "1",
-				Author:    "Bob",
+				Author:    &model.Author{ID: "1", Name: "Bob"},
 				Content:   "Something",
-				CreatedAt: time.Now().Format(layout),
+				CreatedAt: time.Now(),
 				PostID:    "1",
 				ParentID:  nil,
 			},
@@ -199,7 +276,7 @@ func TestCreateComment(t *testing.T) {
 				PostID:   "",
 				ParentID: nil,
 			},
-			wantErr: &gqlerror.Error{Message: "invalid request data", Extensions: map[string]interface{}{"postID": "postID cannot be empty"}},
+			wantErr: apperr.Validation("invalid request data", map[string]interface{}{"postID": "postID cannot be empty"}),
 		},
 		{
 			name: "Empty author",
@@ -209,7 +286,7 @@ func TestCreateComment(t *testing.T) {
 				PostID:   "2",
 				ParentID: nil,
 			},
-			wantErr: &gqlerror.Error{Message: "invalid request data", Extensions: map[string]interface{}{"author": "author name length cannot be zero"}},
+			wantErr: apperr.Validation("invalid request data", map[string]interface{}{"author": "author name length cannot be zero"}),
 		},
 		{
 			name: "Empty content",
@@ -219,7 +296,7 @@ func TestCreateComment(t *testing.T) {
 				PostID:   "2",
 				ParentID: nil,
 			},
-			wantErr: &gqlerror.Error{Message: "invalid request data", Extensions: map[string]interface{}{"content": "content length cannot be zero"}},
+			wantErr: apperr.Validation("invalid request data", map[string]interface{}{"content": "content length cannot be zero"}),
 		},
 		{
 			name: "Empty all",
@@ -229,29 +306,30 @@ func TestCreateComment(t *testing.T) {
 				PostID:   "",
 				ParentID: nil,
 			},
-			wantErr: &gqlerror.Error{Message: "invalid request data", Extensions: map[string]interface{}{"postID": "postID cannot be empty", "author": "author name length cannot be zero", "content": "content length cannot be zero"}},
+			wantErr: apperr.Validation("invalid request data", map[string]interface{}{"postID": "postID cannot be empty", "author": "author name length cannot be zero", "content": "content length cannot be zero"}),
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			storerMock := mocks.NewStorer(t)
-			subscribeMock := sub.NewSubscriber(t)
+
+			customInput := tt.customCommentInput
+			customInput.Status = model.ContentStatusPublished
 
 			if tt.wantErr == nil {
-				storerMock.On("CreateComment", mock.Anything, tt.customCommentInput).Return(tt.expectedComment, nil)
-				subscribeMock.On("Publish", tt.comment.PostID, &tt.comment).Return(nil)
+				notBanned(storerMock)
+				storerMock.On("CreateComment", mock.Anything, customInput).Return(tt.expectedComment, nil)
 			}
 
-			s := &Service{store: storerMock, sub: subscribeMock}
+			s := &Service{store: storerMock}
 
 			comment, err := s.CreateComment(context.Background(), tt.commentInput)
 
 			if tt.wantErr != nil {
-				assert.EqualError(t, err, tt.wantErr.Error())
+				assert.Equal(t, tt.wantErr, err)
 				assert.Empty(t, comment)
-				storerMock.AssertNotCalled(t, "CreateComment", mock.Anything, tt.customCommentInput)
-				subscribeMock.AssertNotCalled(t, "Publish", tt.commentInput.PostID, &tt.comment)
+				storerMock.AssertNotCalled(t, "CreateComment", mock.Anything, customInput)
 			} else {
 				assert.NoError(t, err)
 				assert.NotEmpty(t, comment)
@@ -261,24 +339,316 @@ func TestCreateComment(t *testing.T) {
 
 }
 
+func TestAuthorStats(t *testing.T) {
+	storerMock := mocks.NewStorer(t)
+	s := &Service{store: storerMock}
+
+	storerMock.On("GetAuthorStats", mock.Anything, 1, "").Return(model.AuthorStats{PostCount: 2, CommentCount: 5}, nil)
+
+	stats, err := s.AuthorStats(context.Background(), "1")
+	require.NoError(t, err)
+	assert.Equal(t, model.AuthorStats{PostCount: 2, CommentCount: 5}, stats)
+
+	_, err = s.AuthorStats(context.Background(), "author")
+	assert.Error(t, err)
+
+	storerMock.AssertExpectations(t)
+}
+
+func TestPostsByAuthor(t *testing.T) {
+	storerMock := mocks.NewStorer(t)
+	s := &Service{store: storerMock}
+
+	author := model.CustomAuthor{ID: 1, Name: "Bob"}
+	customPosts := []model.CustomPost{
+		{ID: 3, Title: "Title3", Author: author, Content: "Content3", CreatedAt: time.Now()},
+		{ID: 2, Title: "Title2", Author: author, Content: "Content2", CreatedAt: time.Now()},
+		{ID: 1, Title: "Title1", Author: author, Content: "Content1", CreatedAt: time.Now()},
+	}
+
+	var first int32 = 2
+
+	storerMock.On("GetPostsByAuthor", mock.Anything, 1, 0, 3, "").Return(customPosts, nil)
+	storerMock.On("GetAuthorStats", mock.Anything, 1, "").Return(model.AuthorStats{PostCount: 3}, nil)
+
+	connection, err := s.PostsByAuthor(context.Background(), "1", &first, nil)
+	require.NoError(t, err)
+
+	require.Len(t, connection.Edges, 2)
+	assert.Equal(t, conv.GlobalID(conv.TypePost, 3), connection.Edges[0].Node.ID)
+	assert.Equal(t, conv.GlobalID(conv.TypePost, 2), connection.Edges[1].Node.ID)
+	assert.True(t, connection.PageInfo.HasNextPage)
+	assert.Equal(t, connection.Edges[1].Cursor, *connection.PageInfo.EndCursor)
+	assert.Equal(t, int32(3), connection.TotalCount)
+
+	storerMock.AssertExpectations(t)
+}
+
+func TestCommentsByAuthor_LastPage(t *testing.T) {
+	storerMock := mocks.NewStorer(t)
+	s := &Service{store: storerMock}
+
+	customComments := []model.CustomComment{
+		{ID: 1, Author: model.CustomAuthor{ID: 1, Name: "Bob"}, Content: "Content1", CreatedAt: time.Now(), PostID: 1},
+	}
+
+	var first int32 = 2
+	after := pagination.Cursor(1)
+
+	storerMock.On("GetCommentsByAuthor", mock.Anything, 1, 2, 3, "").Return(customComments, nil)
+	storerMock.On("GetAuthorStats", mock.Anything, 1, "").Return(model.AuthorStats{CommentCount: 3}, nil)
+
+	connection, err := s.CommentsByAuthor(context.Background(), "1", &first, &after)
+	require.NoError(t, err)
+
+	require.Len(t, connection.Edges, 1)
+	assert.False(t, connection.PageInfo.HasNextPage)
+	assert.Equal(t, pagination.Cursor(2), connection.Edges[0].Cursor)
+
+	storerMock.AssertExpectations(t)
+}
+
 func TestCommetsByPost(t *testing.T) {
 	storerMock := mocks.NewStorer(t)
 	s := &Service{store: storerMock}
 
 	customCommetns := []model.CustomComment{
-		{ID: 1, Author: "Author1", Content: "Content1", CreatedAt: time.Now(), PostID: 1},
+		{ID: 1, Author: model.CustomAuthor{ID: 1, Name: "Author1"}, Content: "Content1", CreatedAt: time.Now(), PostID: 1},
 	}
 
 	var page, pageSie int32 = 1, 10
 
 	limit, offset := pagination.New(&page, &pageSie)
 
-	storerMock.On("GetCommentsByPost", mock.Anything, 1, offset, limit).Return(customCommetns, nil)
-	storerMock.On("GetCommentReplies", mock.Anything, 1).Return(nil, nil)
+	storerMock.On("GetCommentsByPost", mock.Anything, 1, offset, limit, "").Return(customCommetns, nil)
 
 	comments, err := s.CommentsByPost(context.Background(), "1", &page, &pageSie)
 	require.NoError(t, err)
 	assert.NotEmpty(t, comments)
+	assert.Nil(t, comments[0].Replies)
 
 	storerMock.AssertExpectations(t)
 }
+
+func TestReplies_UsesLoader(t *testing.T) {
+	storerMock := mocks.NewStorer(t)
+	s := &Service{store: storerMock}
+
+	parentID := 1
+	customReplies := []model.CustomComment{
+		{ID: 2, Author: model.CustomAuthor{ID: 1, Name: "Author1"}, Content: "Reply", CreatedAt: time.Now(), PostID: 1, ParentID: &parentID},
+	}
+
+	storerMock.On("GetRepliesByParentIDs", mock.Anything, []int{1}, "").Return(customReplies, nil).Once()
+
+	ctx := loader.With(context.Background(), loader.New(storerMock, ""))
+
+	for i := 0; i < 2; i++ {
+		replies, err := s.Replies(ctx, conv.GlobalID(conv.TypeComment, 1))
+		require.NoError(t, err)
+		require.Len(t, replies, 1)
+		assert.Equal(t, conv.GlobalID(conv.TypeComment, 2), replies[0].ID)
+	}
+
+	storerMock.AssertExpectations(t)
+}
+
+func TestNode(t *testing.T) {
+	storerMock := mocks.NewStorer(t)
+	s := &Service{store: storerMock}
+
+	customComment := model.CustomComment{ID: 1, Author: model.CustomAuthor{ID: 1, Name: "Bob"}, Content: "Content", CreatedAt: time.Now(), PostID: 1}
+
+	storerMock.On("GetCommentByID", mock.Anything, 1).Return(customComment, nil)
+	storerMock.On("GetPostByID", mock.Anything, 2).Return(model.CustomPost{}, apperr.ErrPostNotFound)
+
+	node, err := s.Node(context.Background(), conv.GlobalID(conv.TypeComment, 1))
+	require.NoError(t, err)
+
+	comment, ok := node.(*model.Comment)
+	require.True(t, ok)
+	assert.Equal(t, conv.GlobalID(conv.TypeComment, 1), comment.ID)
+	assert.Equal(t, conv.GlobalID(conv.TypePost, 1), comment.PostID)
+
+	node, err = s.Node(context.Background(), conv.GlobalID(conv.TypePost, 2))
+	require.NoError(t, err)
+	assert.Nil(t, node)
+
+	_, err = s.Node(context.Background(), "1")
+	assert.EqualError(t, err, "invalid ID 1")
+
+	_, err = s.Node(context.Background(), conv.GlobalID("Board", 1))
+	assert.Equal(t, apperr.CodeValidation, apperr.CodeOf(err))
+}
+
+func TestNodes(t *testing.T) {
+	storerMock := mocks.NewStorer(t)
+	s := &Service{store: storerMock}
+
+	storerMock.On("GetAuthorByID", mock.Anything, 1).Return(model.CustomAuthor{ID: 1, Name: "Bob"}, nil)
+	storerMock.On("GetAuthorByID", mock.Anything, 2).Return(model.CustomAuthor{}, apperr.ErrAuthorNotFound)
+
+	nodes, err := s.Nodes(context.Background(), []string{conv.GlobalID(conv.TypeAuthor, 2), conv.GlobalID(conv.TypeAuthor, 1)})
+	require.NoError(t, err)
+	require.Len(t, nodes, 2)
+
+	assert.Nil(t, nodes[0])
+	author, ok := nodes[1].(*model.Author)
+	require.True(t, ok)
+	assert.Equal(t, "Bob", author.Name)
+}
+
+func TestCreatePost_Held(t *testing.T) {
+	storerMock := mocks.NewStorer(t)
+	notBanned(storerMock)
+	s := New(storerMock, Options{Filters: []filter.Filter{filter.NewDuplicates(time.Minute), holdAll{}}})
+
+	input := model.PostInput{Title: "Title", Author: "Bob", Content: "Content", CommentsAllowed: true}
+	customInput := input.Convert()
+	customInput.Status = model.ContentStatusPending
+	customInput.StatusReason = "held"
+
+	storerMock.On("CreatePost", mock.Anything, customInput).
+		Return(model.CustomPost{ID: 1, Status: model.ContentStatusPending, StatusReason: "held"}, nil)
+
+	post, err := s.CreatePost(context.Background(), input)
+	require.NoError(t, err)
+	assert.Equal(t, model.ContentStatusPending, post.Status)
+	require.NotNil(t, post.StatusReason)
+	assert.Equal(t, "held", *post.StatusReason)
+}
+
+func TestPostByID_Pending(t *testing.T) {
+	storerMock := mocks.NewStorer(t)
+	s := &Service{store: storerMock}
+
+	customPost := model.CustomPost{ID: 1, Author: model.CustomAuthor{ID: 1, Name: "Bob"}, Status: model.ContentStatusPending}
+	storerMock.On("GetPostByID", mock.Anything, 1).Return(customPost, nil)
+
+	tests := []struct {
+		name    string
+		viewer  *auth.Viewer
+		visible bool
+	}{
+		{name: "Anonymous"},
+		{name: "Another user", viewer: &auth.Viewer{Name: "Alice", Role: auth.RoleUser}},
+		{name: "Author", viewer: &auth.Viewer{Name: "Bob", Role: auth.RoleUser}, visible: true},
+		{name: "Moderator", viewer: &auth.Viewer{Name: "Mod", Role: auth.RoleModerator}, visible: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			post, err := s.PostByID(auth.With(context.Background(), tt.viewer), "1")
+
+			if tt.visible {
+				require.NoError(t, err)
+				assert.Equal(t, model.ContentStatusPending, post.Status)
+			} else {
+				assert.Equal(t, apperr.ErrPostNotFound, err)
+			}
+		})
+	}
+}
+
+func TestModerationQueue(t *testing.T) {
+	storerMock := mocks.NewStorer(t)
+	s := &Service{store: storerMock}
+
+	_, err := s.ModerationQueue(auth.With(context.Background(), &auth.Viewer{Name: "Bob", Role: auth.RoleUser}), nil, nil)
+	assert.Equal(t, apperr.CodeForbidden, apperr.CodeOf(err))
+
+	pending := []model.CustomContent{
+		{Post: &model.CustomPost{ID: 1, Status: model.ContentStatusPending}},
+		{Comment: &model.CustomComment{ID: 2, PostID: 3, Status: model.ContentStatusPending}},
+		{Post: &model.CustomPost{ID: 4, Status: model.ContentStatusPending}},
+	}
+	storerMock.On("GetPendingContent", mock.Anything, 0, 3).Return(pending, nil)
+
+	first := int32(2)
+	ctx := auth.With(context.Background(), &auth.Viewer{Name: "Mod", Role: auth.RoleModerator})
+
+	connection, err := s.ModerationQueue(ctx, &first, nil)
+	require.NoError(t, err)
+
+	require.Len(t, connection.Edges, 2)
+	assert.True(t, connection.PageInfo.HasNextPage)
+	assert.Equal(t, pagination.Cursor(1), *connection.PageInfo.EndCursor)
+
+	post, ok := connection.Edges[0].Node.(*model.Post)
+	require.True(t, ok)
+	assert.Equal(t, conv.GlobalID(conv.TypePost, 1), post.ID)
+
+	comment, ok := connection.Edges[1].Node.(*model.Comment)
+	require.True(t, ok)
+	assert.Equal(t, conv.GlobalID(conv.TypeComment, 2), comment.ID)
+}
+
+func TestApproveContent(t *testing.T) {
+	storerMock := mocks.NewStorer(t)
+	classifier := filter.NewClassifier()
+	s := New(storerMock, Options{Filters: []filter.Filter{filter.NewSpam(classifier, 0.9, 1)}})
+
+	_, err := s.ApproveContent(context.Background(), conv.GlobalID(conv.TypePost, 1))
+	assert.Equal(t, apperr.CodeForbidden, apperr.CodeOf(err))
+
+	ctx := auth.With(context.Background(), &auth.Viewer{Name: "Mod", Role: auth.RoleAdmin})
+
+	storerMock.On("SetPostStatus", mock.Anything, 1, model.ContentStatusPublished, "", "Mod").
+		Return(model.CustomPost{ID: 1, Title: "Title", Content: "friday meeting notes", Status: model.ContentStatusPublished}, nil)
+
+	content, err := s.ApproveContent(ctx, conv.GlobalID(conv.TypePost, 1))
+	require.NoError(t, err)
+
+	post, ok := content.(*model.Post)
+	require.True(t, ok)
+	assert.Equal(t, model.ContentStatusPublished, post.Status)
+
+	hamDocs, spamDocs := classifier.Samples()
+	assert.Equal(t, 1, hamDocs)
+	assert.Zero(t, spamDocs)
+
+	storerMock.On("SetCommentStatus", mock.Anything, 2, model.ContentStatusPublished, "", "Mod").Return(model.CustomComment{}, apperr.ErrCommentNotFound)
+
+	_, err = s.ApproveContent(ctx, conv.GlobalID(conv.TypeComment, 2))
+	assert.Equal(t, apperr.ErrCommentNotFound, err)
+
+	_, err = s.ApproveContent(ctx, conv.GlobalID(conv.TypeAuthor, 1))
+	assert.Equal(t, apperr.CodeValidation, apperr.CodeOf(err))
+}
+
+func TestRejectContent(t *testing.T) {
+	storerMock := mocks.NewStorer(t)
+	classifier := filter.NewClassifier()
+	s := New(storerMock, Options{Filters: []filter.Filter{filter.NewSpam(classifier, 0.9, 1)}})
+
+	ctx := auth.With(context.Background(), &auth.Viewer{Name: "Mod", Role: auth.RoleModerator})
+
+	_, err := s.RejectContent(ctx, conv.GlobalID(conv.TypeComment, 2), " ")
+	assert.Equal(t, apperr.CodeValidation, apperr.CodeOf(err))
+
+	storerMock.On("SetCommentStatus", mock.Anything, 2, model.ContentStatusRejected, "spam", "Mod").
+		Return(model.CustomComment{ID: 2, PostID: 1, Content: "buy cheap pills", Status: model.ContentStatusRejected, StatusReason: "spam"}, nil)
+
+	content, err := s.RejectContent(ctx, conv.GlobalID(conv.TypeComment, 2), "spam")
+	require.NoError(t, err)
+
+	comment, ok := content.(*model.Comment)
+	require.True(t, ok)
+	assert.Equal(t, model.ContentStatusRejected, comment.Status)
+	require.NotNil(t, comment.StatusReason)
+	assert.Equal(t, "spam", *comment.StatusReason)
+
+	hamDocs, spamDocs := classifier.Samples()
+	assert.Zero(t, hamDocs)
+	assert.Equal(t, 1, spamDocs)
+}
+
+type holdAll struct{}
+
+func (holdAll) Name() string {
+	return "hold"
+}
+
+func (holdAll) Check(context.Context, filter.Content) (filter.Verdict, error) {
+	return filter.Verdict{Action: filter.Hold, Reason: "held"}, nil
+}
@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/erknas/forum/graph/model"
+)
+
+// newAuditEntry returns the entry for an actor changing a target from before
+// to after, or nil if there is no actor. A nil before or after, as for
+// something created, is left empty.
+func newAuditEntry(actor string, action model.AuditAction, targetType string, targetID int, before any, after any) (*model.CustomAuditEntry, error) {
+	if actor == "" {
+		return nil, nil
+	}
+
+	beforeJSON, err := auditJSON(before)
+	if err != nil {
+		return nil, err
+	}
+
+	afterJSON, err := auditJSON(after)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.CustomAuditEntry{
+		Actor:      actor,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Before:     beforeJSON,
+		After:      afterJSON,
+		CreatedAt:  time.Now().UTC(),
+	}, nil
+}
+
+func auditJSON(v any) (json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return json.Marshal(v)
+}
+
+// auditConditions returns the WHERE clause for filter, numbering its
+// placeholders with placeholder, and its arguments.
+func auditConditions(filter model.CustomAuditFilter, placeholder func(n int) string) (string, []any) {
+	var (
+		conditions []string
+		args       []any
+	)
+
+	add := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, placeholder(len(args))))
+	}
+
+	if filter.Actor != "" {
+		add("actor = %s", filter.Actor)
+	}
+	if filter.Action != "" {
+		add("action = %s", filter.Action)
+	}
+	if filter.TargetType != "" {
+		add("target_type = %s", filter.TargetType)
+		add("target_id = %s", filter.TargetID)
+	}
+	if !filter.Since.IsZero() {
+		add("created_at >= %s", filter.Since.UTC())
+	}
+	if !filter.Until.IsZero() {
+		add("created_at < %s", filter.Until.UTC())
+	}
+
+	if len(conditions) == 0 {
+		return "", nil
+	}
+
+	return "WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// unresolved returns copies of resolved reports as they were while open.
+func unresolved(reports []model.CustomReport) []model.CustomReport {
+	open := make([]model.CustomReport, len(reports))
+	for i, report := range reports {
+		report.ResolvedBy, report.ResolvedAt, report.Action = "", nil, ""
+		open[i] = report
+	}
+	return open
+}
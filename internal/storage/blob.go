@@ -0,0 +1,189 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/erknas/forum/pkg/apperr"
+	"github.com/erknas/forum/pkg/sl"
+)
+
+// BlobStore keeps the content of attachments. Keys are made of letters,
+// digits, dots, dashes and underscores, and are never reused: a blob is not
+// changed once it is put. Get reports ErrBlobNotFound for missing blobs, and
+// Delete ignores them.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.52.2 --name=BlobStore
+type BlobStore interface {
+	Put(ctx context.Context, key string, contentType string, body io.Reader, size int64) error
+	Get(ctx context.Context, key string) (Blob, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// Blob is the content of a blob, which the caller must close.
+type Blob struct {
+	io.ReadCloser
+	ContentType string
+	Size        int64
+}
+
+var blobKey = regexp.MustCompile(`^[A-Za-z0-9_-][A-Za-z0-9._-]*$`)
+
+func validBlobKey(key string) error {
+	if !blobKey.MatchString(key) {
+		return apperr.Validationf("invalid blob key %q", key)
+	}
+	return nil
+}
+
+// LocalBlobStore keeps blobs as files in a directory. Their content type is
+// detected from their content when they are read.
+type LocalBlobStore struct {
+	dir string
+}
+
+func NewLocalBlobStore(dir string) (*LocalBlobStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &LocalBlobStore{dir: dir}, nil
+}
+
+// Put writes the blob to a temporary file first, so that a failed write
+// never leaves a partial blob behind.
+func (l *LocalBlobStore) Put(_ context.Context, key string, _ string, body io.Reader, _ int64) error {
+	if err := validBlobKey(key); err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(l.dir, ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := io.Copy(file, body); err != nil {
+		file.Close()
+		return err
+	}
+
+	// CreateTemp makes files only their owner can read, while the blobs may
+	// be served by another web server from the directory.
+	if err := file.Chmod(0o644); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), filepath.Join(l.dir, key))
+}
+
+func (l *LocalBlobStore) Get(_ context.Context, key string) (Blob, error) {
+	if err := validBlobKey(key); err != nil {
+		return Blob{}, err
+	}
+
+	file, err := os.Open(filepath.Join(l.dir, key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return Blob{}, apperr.ErrBlobNotFound
+	}
+	if err != nil {
+		return Blob{}, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return Blob{}, err
+	}
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		file.Close()
+		return Blob{}, err
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		file.Close()
+		return Blob{}, err
+	}
+
+	return Blob{ReadCloser: file, ContentType: http.DetectContentType(head[:n]), Size: info.Size()}, nil
+}
+
+func (l *LocalBlobStore) Delete(_ context.Context, key string) error {
+	if err := validBlobKey(key); err != nil {
+		return err
+	}
+
+	err := os.Remove(filepath.Join(l.dir, key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	return err
+}
+
+// ServeBlobs serves the blob named by the request path. Blobs never change,
+// so they may be cached for good. Files other than images are served as
+// downloads, and none of them may run scripts.
+func ServeBlobs(blobs BlobStore) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		key := strings.TrimPrefix(r.URL.Path, "/")
+		if validBlobKey(key) != nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		blob, err := blobs.Get(r.Context(), key)
+		if errors.Is(err, apperr.ErrBlobNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		if err != nil {
+			slog.Error("failed to get blob", sl.Err(err), "key", key)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		defer blob.Close()
+
+		header := w.Header()
+		header.Set("Content-Type", blob.ContentType)
+		header.Set("Content-Length", strconv.FormatInt(blob.Size, 10))
+		header.Set("Cache-Control", "public, max-age=31536000, immutable")
+		header.Set("Content-Security-Policy", "default-src 'none'; sandbox")
+		header.Set("X-Content-Type-Options", "nosniff")
+
+		if !strings.HasPrefix(blob.ContentType, "image/") {
+			header.Set("Content-Disposition", "attachment")
+		}
+
+		if r.Method == http.MethodHead {
+			return
+		}
+
+		if _, err := io.Copy(w, blob); err != nil {
+			slog.Error("failed to serve blob", sl.Err(err), "key", key)
+		}
+	})
+}
@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/erknas/forum/pkg/apperr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var pngHeader = "\x89PNG\r\n\x1a\n"
+
+// testBlobStore checks the behavior every BlobStore shares.
+func testBlobStore(t *testing.T, blobs BlobStore) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	content := pngHeader + strings.Repeat("x", 1000)
+	require.NoError(t, blobs.Put(ctx, "a1.png", "image/png", strings.NewReader(content), int64(len(content))))
+
+	blob, err := blobs.Get(ctx, "a1.png")
+	require.NoError(t, err)
+
+	data, err := io.ReadAll(blob)
+	require.NoError(t, err)
+	require.NoError(t, blob.Close())
+
+	assert.Equal(t, content, string(data))
+	assert.Equal(t, "image/png", blob.ContentType)
+	assert.Equal(t, int64(len(content)), blob.Size)
+
+	_, err = blobs.Get(ctx, "missing.png")
+	assert.ErrorIs(t, err, apperr.ErrBlobNotFound)
+
+	require.NoError(t, blobs.Delete(ctx, "a1.png"))
+	require.NoError(t, blobs.Delete(ctx, "a1.png"), "missing blobs are ignored")
+
+	_, err = blobs.Get(ctx, "a1.png")
+	assert.ErrorIs(t, err, apperr.ErrBlobNotFound)
+
+	for _, key := range []string{"../escape", "a/b", ".hidden", ""} {
+		assert.Error(t, blobs.Put(ctx, key, "text/plain", strings.NewReader("x"), 1), key)
+	}
+}
+
+func TestLocalBlobStore(t *testing.T) {
+	blobs, err := NewLocalBlobStore(t.TempDir())
+	require.NoError(t, err)
+
+	testBlobStore(t, blobs)
+}
+
+func TestServeBlobs(t *testing.T) {
+	blobs, err := NewLocalBlobStore(t.TempDir())
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, blobs.Put(ctx, "image.png", "image/png", strings.NewReader(pngHeader), int64(len(pngHeader))))
+	require.NoError(t, blobs.Put(ctx, "page.html", "text/html", strings.NewReader("<script>alert(1)</script>"), 25))
+
+	srv := httptest.NewServer(http.StripPrefix("/attachments", ServeBlobs(blobs)))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/attachments/image.png")
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "image/png", resp.Header.Get("Content-Type"))
+	assert.Equal(t, "nosniff", resp.Header.Get("X-Content-Type-Options"))
+	assert.Empty(t, resp.Header.Get("Content-Disposition"), "images are shown inline")
+
+	resp, err = http.Get(srv.URL + "/attachments/page.html")
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, "attachment", resp.Header.Get("Content-Disposition"))
+	assert.Contains(t, resp.Header.Get("Content-Security-Policy"), "sandbox")
+
+	for _, path := range []string{"/attachments/missing.png", "/attachments/..%2F..%2Fetc%2Fpasswd"} {
+		resp, err = http.Get(srv.URL + path)
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode, path)
+	}
+
+	resp, err = http.Post(srv.URL+"/attachments/image.png", "image/png", strings.NewReader(pngHeader))
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
@@ -0,0 +1,47 @@
+package storage_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/erknas/forum/internal/config"
+	"github.com/erknas/forum/internal/storage"
+	"github.com/erknas/forum/internal/storage/storagetest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryStorage(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.Storer {
+		return storage.NewInMemoryStorage()
+	})
+}
+
+func TestDurableInMemoryStorage(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.Storer {
+		cfg := &config.Config{MemoryConfig: config.MemoryConfig{DataDir: t.TempDir(), Fsync: "always"}}
+
+		s, err := storage.NewDurableInMemoryStorage(context.Background(), cfg)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = s.Close() })
+
+		return s
+	})
+}
+
+func TestDurableInMemoryStorage_Reopened(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.Storer {
+		return newReopeningStorage(t)
+	})
+}
+
+func TestSQLiteStorage(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.Storer {
+		return storage.NewTestSQLiteStorage(t)
+	})
+}
+
+func TestPostgresPool(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.Storer {
+		return storage.NewTestPostgresPool(t)
+	})
+}
@@ -0,0 +1,14 @@
+package storage
+
+// Exported for the conformance tests in package storage_test, which cannot be
+// part of this package because storagetest imports it.
+var (
+	NewTestPostgresPool  = newTestPostgresPool
+	NewTestSQLiteStorage = newTestSQLiteStorage
+)
+
+// CrashInMemoryStorage closes the log of s without taking a snapshot, as a
+// crash would leave it.
+func CrashInMemoryStorage(s *InMemoryStorage) error {
+	return s.wal.Close()
+}
@@ -0,0 +1,655 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/erknas/forum/graph/model"
+	"github.com/erknas/forum/internal/config"
+	"github.com/erknas/forum/internal/outbox"
+	"github.com/erknas/forum/pkg/sl"
+	"github.com/erknas/forum/pkg/wal"
+)
+
+type memoryOp string
+
+const (
+	opCreatePost    memoryOp = "create_post"
+	opCreateComment memoryOp = "create_comment"
+	// Delivery records hold the sink and the events it delivered, or its
+	// failure to deliver one. Delivered records without a sink are from
+	// before deliveries were kept per sink and drop the events.
+	opMarkDelivered memoryOp = "mark_delivered"
+	opMarkFailed    memoryOp = "mark_failed"
+	// Status records hold the whole post or comment with the new status.
+	opSetPostStatus    memoryOp = "set_post_status"
+	opSetCommentStatus memoryOp = "set_comment_status"
+	opCreateReport     memoryOp = "create_report"
+	// Resolve records hold the resolved reports.
+	opResolveReports memoryOp = "resolve_reports"
+	// Ban records hold the new and the lifted bans.
+	opSaveBans memoryOp = "save_bans"
+	// Edit records hold the edited post or comment and the revision it
+	// replaced.
+	opEditPost    memoryOp = "edit_post"
+	opEditComment memoryOp = "edit_comment"
+	// Publish records hold the whole post with its new status and times.
+	opPublishPost memoryOp = "publish_post"
+	// Pin records hold the whole post with its new pin, or none.
+	opPinPost memoryOp = "pin_post"
+	// Vote records hold the ballot that replaces the previous one of its
+	// voter.
+	opVote memoryOp = "vote"
+)
+
+// memoryRecord is a single mutation of InMemoryStorage. Records are numbered
+// and written to the log before they are applied. The snapshot keeps the
+// number of the last record it includes, so that replaying a log left over
+// from before the snapshot, after a crash before the log was truncated,
+// skips the records already applied. Records logged before they were
+// numbered have no Seq and are applied again, which is a no-op for most.
+type memoryRecord struct {
+	Seq         int64                    `json:"seq,omitempty"`
+	Op          memoryOp                 `json:"op"`
+	Post        *model.CustomPost        `json:"post,omitempty"`
+	Comment     *model.CustomComment     `json:"comment,omitempty"`
+	Report      *model.CustomReport      `json:"report,omitempty"`
+	Reports     []model.CustomReport     `json:"reports,omitempty"`
+	Bans        []model.CustomBan        `json:"bans,omitempty"`
+	Revision    *model.CustomRevision    `json:"revision,omitempty"`
+	Poll        *model.CustomPoll        `json:"poll,omitempty"`
+	Ballot      *model.CustomBallot      `json:"ballot,omitempty"`
+	Attachments []model.CustomAttachment `json:"attachments,omitempty"`
+	Audit       *model.CustomAuditEntry  `json:"audit,omitempty"`
+	Event       *memoryEvent             `json:"event,omitempty"`
+	EventIDs    []int64                  `json:"eventIds,omitempty"`
+	Sink        string                   `json:"sink,omitempty"`
+	Failure     *outbox.Failure          `json:"failure,omitempty"`
+	// Status is the status change of reported content made along with the
+	// reports.
+	Status *memoryRecord `json:"status,omitempty"`
+}
+
+// memoryEvent keeps the outbox sequence number, which outbox.Event does not
+// serialize.
+type memoryEvent struct {
+	Seq int64 `json:"seq"`
+	outbox.Event
+}
+
+// memoryDelivery is the state of an event for a sink.
+type memoryDelivery struct {
+	Sink      string    `json:"sink"`
+	EventID   int64     `json:"eventId"`
+	Attempts  int       `json:"attempts,omitempty"`
+	RetryAt   time.Time `json:"retryAt,omitempty"`
+	Delivered bool      `json:"delivered,omitempty"`
+	Dead      bool      `json:"dead,omitempty"`
+}
+
+type memorySnapshot struct {
+	Posts        []model.CustomPost       `json:"posts"`
+	Comments     []model.CustomComment    `json:"comments"`
+	Authors      []model.CustomAuthor     `json:"authors"`
+	Reports      []model.CustomReport     `json:"reports"`
+	Bans         []model.CustomBan        `json:"bans"`
+	Revisions    []model.CustomRevision   `json:"revisions"`
+	Polls        []model.CustomPoll       `json:"polls"`
+	Ballots      []model.CustomBallot     `json:"ballots"`
+	Attachments  []model.CustomAttachment `json:"attachments"`
+	Audit        []model.CustomAuditEntry `json:"audit"`
+	Events       []memoryEvent            `json:"events"`
+	Deliveries   []memoryDelivery         `json:"deliveries"`
+	PostID       int                      `json:"postId"`
+	CommentID    int                      `json:"commentId"`
+	AuthorID     int                      `json:"authorId"`
+	ReportID     int                      `json:"reportId"`
+	BanID        int                      `json:"banId"`
+	RevisionID   int                      `json:"revisionId"`
+	AttachmentID int                      `json:"attachmentId"`
+	AuditID      int                      `json:"auditId"`
+	PollID       int                      `json:"pollId"`
+	OptionID     int                      `json:"optionId"`
+	EventID      int64                    `json:"eventId"`
+	Seq          int64                    `json:"seq"`
+}
+
+// NewDurableInMemoryStorage restores the storage from the latest snapshot and
+// the log in cfg.MemoryConfig.DataDir, and keeps logging every mutation there.
+func NewDurableInMemoryStorage(ctx context.Context, cfg *config.Config) (*InMemoryStorage, error) {
+	policy, err := wal.ParseSyncPolicy(cfg.MemoryConfig.Fsync)
+	if err != nil {
+		return nil, err
+	}
+
+	log, err := wal.Open(cfg.MemoryConfig.DataDir, policy, cfg.MemoryConfig.FsyncInterval)
+	if err != nil {
+		return nil, err
+	}
+
+	s := NewInMemoryStorage()
+
+	snapshot := memorySnapshot{}
+
+	ok, err := log.LoadSnapshot(&snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("load snapshot: %w", err)
+	}
+
+	if ok {
+		s.restore(snapshot)
+	}
+
+	err = log.Replay(func(data json.RawMessage) error {
+		record := memoryRecord{}
+		if err := json.Unmarshal(data, &record); err != nil {
+			return err
+		}
+
+		s.apply(record)
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("replay log: %w", err)
+	}
+
+	s.wal = log
+
+	if err := s.Snapshot(); err != nil {
+		return nil, fmt.Errorf("compact log: %w", err)
+	}
+
+	if cfg.MemoryConfig.SnapshotInterval > 0 {
+		go s.snapshotLoop(ctx, cfg.MemoryConfig.SnapshotInterval)
+	}
+
+	return s, nil
+}
+
+// Snapshot writes the current state to disk and truncates the log.
+func (s *InMemoryStorage) Snapshot() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.wal == nil {
+		return nil
+	}
+
+	return s.wal.Snapshot(s.snapshot())
+}
+
+func (s *InMemoryStorage) Close() error {
+	if s.wal == nil {
+		return nil
+	}
+
+	if err := s.Snapshot(); err != nil {
+		return err
+	}
+
+	return s.wal.Close()
+}
+
+func (s *InMemoryStorage) snapshotLoop(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Snapshot(); err != nil {
+				slog.Error("failed to snapshot in-memory storage", sl.Err(err))
+			}
+		}
+	}
+}
+
+// commit logs the record, if persistence is enabled, and applies it. The
+// caller must hold the write lock.
+func (s *InMemoryStorage) commit(record memoryRecord) error {
+	record.Seq = s.seq + 1
+
+	if s.wal != nil {
+		if err := s.wal.Append(record); err != nil {
+			return err
+		}
+	}
+
+	s.apply(record)
+
+	return nil
+}
+
+func (s *InMemoryStorage) apply(record memoryRecord) {
+	if record.Seq != 0 {
+		if record.Seq <= s.seq {
+			return
+		}
+		s.seq = record.Seq
+	}
+
+	s.applyAudit(record.Audit)
+
+	switch record.Op {
+	case opCreatePost:
+		s.applyPost(*record.Post)
+		s.applyPoll(record.Poll)
+		s.applyAttachments(record.Attachments)
+		s.applyEvent(record.Event)
+	case opCreateComment:
+		s.applyComment(*record.Comment)
+		s.applyAttachments(record.Attachments)
+		s.applyEvent(record.Event)
+	case opSetPostStatus:
+		s.applyPostStatus(*record.Post)
+		s.applyEvent(record.Event)
+	case opSetCommentStatus:
+		s.applyCommentStatus(*record.Comment)
+		s.applyEvent(record.Event)
+	case opCreateReport:
+		s.applyReport(*record.Report)
+		s.applyStatus(record.Status)
+	case opResolveReports:
+		s.applyResolvedReports(record.Reports)
+		s.applyStatus(record.Status)
+	case opSaveBans:
+		s.applyBans(record.Bans)
+	case opEditPost:
+		if s.applyRevision(*record.Revision) {
+			s.applyPostEdit(*record.Post)
+		}
+	case opEditComment:
+		if s.applyRevision(*record.Revision) {
+			s.applyCommentEdit(*record.Comment)
+		}
+	case opPublishPost:
+		s.applyPostPublish(*record.Post)
+		s.applyEvent(record.Event)
+	case opPinPost:
+		s.applyPostPin(*record.Post)
+	case opVote:
+		s.applyBallot(*record.Ballot)
+		s.applyEvent(record.Event)
+	case opMarkDelivered:
+		s.applyDelivered(record.Sink, record.EventIDs)
+	case opMarkFailed:
+		s.applyFailure(record.Sink, *record.Failure)
+	}
+}
+
+func (s *InMemoryStorage) applyPost(post model.CustomPost) {
+	if _, ok := s.posts[post.ID]; ok {
+		return
+	}
+
+	s.applyAuthor(post.Author)
+
+	post.Comments = nil
+	s.posts[post.ID] = &post
+	s.postID = max(s.postID, post.ID)
+}
+
+func (s *InMemoryStorage) applyComment(comment model.CustomComment) {
+	if _, ok := s.comments[comment.ID]; ok {
+		return
+	}
+
+	s.applyAuthor(comment.Author)
+
+	s.comments[comment.ID] = &comment
+	s.commentID = max(s.commentID, comment.ID)
+
+	if post, ok := s.posts[comment.PostID]; ok {
+		post.Comments = append(post.Comments, &comment)
+	}
+}
+
+func (s *InMemoryStorage) applyPostStatus(post model.CustomPost) {
+	if stored, ok := s.posts[post.ID]; ok {
+		stored.Status = post.Status
+		stored.StatusReason = post.StatusReason
+	}
+}
+
+func (s *InMemoryStorage) applyCommentStatus(comment model.CustomComment) {
+	if stored, ok := s.comments[comment.ID]; ok {
+		stored.Status = comment.Status
+		stored.StatusReason = comment.StatusReason
+	}
+}
+
+func (s *InMemoryStorage) applyStatus(record *memoryRecord) {
+	if record != nil {
+		s.apply(*record)
+	}
+}
+
+func (s *InMemoryStorage) applyPostEdit(post model.CustomPost) {
+	if stored, ok := s.posts[post.ID]; ok {
+		stored.Title, stored.Content = post.Title, post.Content
+		stored.Status, stored.StatusReason = post.Status, post.StatusReason
+		stored.EditedBy, stored.EditedAt = post.EditedBy, post.EditedAt
+	}
+}
+
+func (s *InMemoryStorage) applyPostPublish(post model.CustomPost) {
+	if stored, ok := s.posts[post.ID]; ok {
+		stored.Status, stored.StatusReason = post.Status, post.StatusReason
+		stored.PublishAt, stored.CreatedAt = post.PublishAt, post.CreatedAt
+	}
+}
+
+func (s *InMemoryStorage) applyPostPin(post model.CustomPost) {
+	if stored, ok := s.posts[post.ID]; ok {
+		stored.PinnedBy, stored.PinnedAt, stored.PinnedUntil = post.PinnedBy, post.PinnedAt, post.PinnedUntil
+	}
+}
+
+func (s *InMemoryStorage) applyPoll(poll *model.CustomPoll) {
+	if poll == nil {
+		return
+	}
+
+	if _, ok := s.polls[poll.ID]; ok {
+		return
+	}
+
+	s.polls[poll.ID] = poll
+	s.postPolls[poll.PostID] = poll.ID
+	s.pollID = max(s.pollID, poll.ID)
+
+	for _, option := range poll.Options {
+		s.optionID = max(s.optionID, option.ID)
+	}
+}
+
+// applyBallot replaces the ballot of its voter unless that one is newer. A
+// withdrawn vote is kept as a ballot without options.
+func (s *InMemoryStorage) applyBallot(ballot model.CustomBallot) {
+	if s.ballots[ballot.PollID] == nil {
+		s.ballots[ballot.PollID] = make(map[string]model.CustomBallot)
+	}
+
+	if stored, ok := s.ballots[ballot.PollID][ballot.Voter]; ok && stored.CreatedAt.After(ballot.CreatedAt) {
+		return
+	}
+
+	s.ballots[ballot.PollID][ballot.Voter] = ballot
+}
+
+// applyAttachments appends the attachments that are new, in order.
+func (s *InMemoryStorage) applyAttachments(attachments []model.CustomAttachment) {
+	for _, attachment := range attachments {
+		if attachment.ID <= s.attachmentID {
+			continue
+		}
+
+		key := targetKey{targetType: attachment.TargetType, targetID: attachment.TargetID}
+		s.attachments[key] = append(s.attachments[key], attachment)
+		s.attachmentID = attachment.ID
+	}
+}
+
+func (s *InMemoryStorage) applyCommentEdit(comment model.CustomComment) {
+	if stored, ok := s.comments[comment.ID]; ok {
+		stored.Content = comment.Content
+		stored.Status, stored.StatusReason = comment.Status, comment.StatusReason
+		stored.EditedBy, stored.EditedAt = comment.EditedBy, comment.EditedAt
+	}
+}
+
+// applyRevision appends a revision and reports whether it was new. The edit
+// that stored it is only applied along with it, so that replaying an old edit
+// does not undo a newer one.
+func (s *InMemoryStorage) applyRevision(revision model.CustomRevision) bool {
+	if revision.ID <= s.revisionID {
+		return false
+	}
+
+	key := targetKey{targetType: revision.TargetType, targetID: revision.TargetID}
+	s.revisions[key] = append(s.revisions[key], revision)
+	s.revisionID = revision.ID
+
+	return true
+}
+
+func (s *InMemoryStorage) applyReport(report model.CustomReport) {
+	if _, ok := s.reports[report.ID]; ok {
+		return
+	}
+
+	s.reports[report.ID] = &report
+	s.reportID = max(s.reportID, report.ID)
+}
+
+func (s *InMemoryStorage) applyResolvedReports(reports []model.CustomReport) {
+	for _, report := range reports {
+		if stored, ok := s.reports[report.ID]; ok {
+			*stored = report
+		}
+	}
+}
+
+func (s *InMemoryStorage) applyBans(bans []model.CustomBan) {
+	for _, ban := range bans {
+		s.bans[ban.ID] = &ban
+		s.banID = max(s.banID, ban.ID)
+	}
+}
+
+// applyAudit appends the entry of an audited record. Entries are never
+// changed once applied.
+func (s *InMemoryStorage) applyAudit(entry *model.CustomAuditEntry) {
+	if entry == nil || entry.ID <= s.auditID {
+		return
+	}
+
+	s.audit = append(s.audit, *entry)
+	s.auditID = entry.ID
+}
+
+func (s *InMemoryStorage) applyAuthor(author model.CustomAuthor) {
+	if _, ok := s.authors[author.Name]; ok {
+		return
+	}
+
+	s.authors[author.Name] = &author
+	s.authorID = max(s.authorID, author.ID)
+}
+
+func (s *InMemoryStorage) applyEvent(event *memoryEvent) {
+	if event == nil || event.Seq <= s.eventID {
+		return
+	}
+
+	event.Event.ID = event.Seq
+	s.events = append(s.events, event.Event)
+	s.eventID = event.Seq
+}
+
+func (s *InMemoryStorage) applyDelivered(sink string, ids []int64) {
+	if sink != "" {
+		for _, id := range ids {
+			key := deliveryKey{sink: sink, eventID: id}
+			s.deliveries[key] = memoryDelivery{Sink: sink, EventID: id, Attempts: s.deliveries[key].Attempts, Delivered: true}
+		}
+		return
+	}
+
+	delivered := make(map[int64]struct{}, len(ids))
+	for _, id := range ids {
+		delivered[id] = struct{}{}
+	}
+
+	pending := s.events[:0]
+	for _, event := range s.events {
+		if _, ok := delivered[event.ID]; !ok {
+			pending = append(pending, event)
+		}
+	}
+
+	s.events = pending
+}
+
+func (s *InMemoryStorage) applyFailure(sink string, failure outbox.Failure) {
+	key := deliveryKey{sink: sink, eventID: failure.ID}
+	if s.deliveries[key].Delivered {
+		return
+	}
+
+	s.deliveries[key] = memoryDelivery{Sink: sink, EventID: failure.ID, Attempts: failure.Attempts, RetryAt: failure.RetryAt, Dead: failure.Dead}
+}
+
+func (s *InMemoryStorage) snapshot() memorySnapshot {
+	snapshot := memorySnapshot{
+		Posts:        make([]model.CustomPost, 0, len(s.posts)),
+		Comments:     make([]model.CustomComment, 0, len(s.comments)),
+		Authors:      make([]model.CustomAuthor, 0, len(s.authors)),
+		Reports:      make([]model.CustomReport, 0, len(s.reports)),
+		Bans:         make([]model.CustomBan, 0, len(s.bans)),
+		Revisions:    make([]model.CustomRevision, 0),
+		Audit:        s.audit,
+		Events:       make([]memoryEvent, 0, len(s.events)),
+		Deliveries:   make([]memoryDelivery, 0, len(s.deliveries)),
+		PostID:       s.postID,
+		CommentID:    s.commentID,
+		AuthorID:     s.authorID,
+		ReportID:     s.reportID,
+		BanID:        s.banID,
+		RevisionID:   s.revisionID,
+		AttachmentID: s.attachmentID,
+		AuditID:      s.auditID,
+		PollID:       s.pollID,
+		OptionID:     s.optionID,
+		EventID:      s.eventID,
+		Seq:          s.seq,
+	}
+
+	for _, post := range s.posts {
+		p := *post
+		p.Comments = nil
+		snapshot.Posts = append(snapshot.Posts, p)
+	}
+
+	for _, comment := range s.comments {
+		snapshot.Comments = append(snapshot.Comments, *comment)
+	}
+
+	for _, author := range s.authors {
+		snapshot.Authors = append(snapshot.Authors, *author)
+	}
+
+	for _, report := range s.reports {
+		snapshot.Reports = append(snapshot.Reports, *report)
+	}
+
+	for _, ban := range s.bans {
+		snapshot.Bans = append(snapshot.Bans, *ban)
+	}
+
+	for _, revisions := range s.revisions {
+		snapshot.Revisions = append(snapshot.Revisions, revisions...)
+	}
+
+	for _, attachments := range s.attachments {
+		snapshot.Attachments = append(snapshot.Attachments, attachments...)
+	}
+
+	for _, poll := range s.polls {
+		snapshot.Polls = append(snapshot.Polls, *poll)
+	}
+
+	for _, ballots := range s.ballots {
+		for _, ballot := range ballots {
+			snapshot.Ballots = append(snapshot.Ballots, ballot)
+		}
+	}
+
+	for _, event := range s.events {
+		snapshot.Events = append(snapshot.Events, memoryEvent{Seq: event.ID, Event: event})
+	}
+
+	for _, delivery := range s.deliveries {
+		snapshot.Deliveries = append(snapshot.Deliveries, delivery)
+	}
+
+	return snapshot
+}
+
+func (s *InMemoryStorage) restore(snapshot memorySnapshot) {
+	sort.Slice(snapshot.Comments, func(i, j int) bool {
+		return snapshot.Comments[i].ID < snapshot.Comments[j].ID
+	})
+
+	for _, author := range snapshot.Authors {
+		s.applyAuthor(author)
+	}
+
+	for _, post := range snapshot.Posts {
+		s.applyPost(post)
+	}
+
+	for _, comment := range snapshot.Comments {
+		s.applyComment(comment)
+	}
+
+	for _, report := range snapshot.Reports {
+		s.applyReport(report)
+	}
+
+	s.applyBans(snapshot.Bans)
+
+	sort.Slice(snapshot.Revisions, func(i, j int) bool {
+		return snapshot.Revisions[i].ID < snapshot.Revisions[j].ID
+	})
+
+	for _, revision := range snapshot.Revisions {
+		s.applyRevision(revision)
+	}
+
+	sort.Slice(snapshot.Attachments, func(i, j int) bool {
+		return snapshot.Attachments[i].ID < snapshot.Attachments[j].ID
+	})
+
+	s.applyAttachments(snapshot.Attachments)
+
+	for i := range snapshot.Polls {
+		s.applyPoll(&snapshot.Polls[i])
+	}
+
+	for _, ballot := range snapshot.Ballots {
+		s.applyBallot(ballot)
+	}
+
+	for i := range snapshot.Audit {
+		s.applyAudit(&snapshot.Audit[i])
+	}
+
+	for i := range snapshot.Events {
+		s.applyEvent(&snapshot.Events[i])
+	}
+
+	for _, delivery := range snapshot.Deliveries {
+		s.deliveries[deliveryKey{sink: delivery.Sink, eventID: delivery.EventID}] = delivery
+	}
+
+	s.postID = max(s.postID, snapshot.PostID)
+	s.commentID = max(s.commentID, snapshot.CommentID)
+	s.authorID = max(s.authorID, snapshot.AuthorID)
+	s.reportID = max(s.reportID, snapshot.ReportID)
+	s.banID = max(s.banID, snapshot.BanID)
+	s.revisionID = max(s.revisionID, snapshot.RevisionID)
+	s.attachmentID = max(s.attachmentID, snapshot.AttachmentID)
+	s.auditID = max(s.auditID, snapshot.AuditID)
+	s.pollID = max(s.pollID, snapshot.PollID)
+	s.optionID = max(s.optionID, snapshot.OptionID)
+	s.eventID = max(s.eventID, snapshot.EventID)
+	s.seq = max(s.seq, snapshot.Seq)
+}
@@ -0,0 +1,126 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/erknas/forum/graph/model"
+	"github.com/erknas/forum/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newDurableConfig(dir string) *config.Config {
+	return &config.Config{
+		MemoryConfig: config.MemoryConfig{
+			DataDir: dir,
+			Fsync:   "always",
+		},
+	}
+}
+
+// reopenAfterCrash simulates a crash of s, leaving its log uncompacted, and
+// reopens the storage from cfg.
+func reopenAfterCrash(t *testing.T, s *InMemoryStorage, cfg *config.Config) *InMemoryStorage {
+	t.Helper()
+
+	require.NoError(t, s.wal.Close())
+
+	s, err := NewDurableInMemoryStorage(context.Background(), cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = s.Close() })
+
+	return s
+}
+
+func TestDurableInMemoryStorage_Restart(t *testing.T) {
+	var (
+		ctx = context.Background()
+		cfg = newDurableConfig(t.TempDir())
+	)
+
+	s, err := NewDurableInMemoryStorage(ctx, cfg)
+	require.NoError(t, err)
+
+	post, err := s.CreatePost(ctx, model.CustomPostInput{Title: "Title", Author: "Bob", Content: "Content", CommentsAllowed: true})
+	require.NoError(t, err)
+
+	parent, err := s.CreateComment(ctx, model.CustomCommentInput{PostID: post.ID, Author: "Alice", Content: "Parent"})
+	require.NoError(t, err)
+
+	require.NoError(t, s.Snapshot())
+
+	reply, err := s.CreateComment(ctx, model.CustomCommentInput{PostID: post.ID, Author: "Bob", Content: "Reply", ParentID: &parent.ID})
+	require.NoError(t, err)
+
+	events, err := s.PendingEvents(ctx, "sink", time.Now(), 10)
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	require.NoError(t, s.MarkDelivered(ctx, "sink", []int64{events[0].ID}))
+
+	s = reopenAfterCrash(t, s, cfg)
+
+	restored, err := s.GetPostByID(ctx, post.ID)
+	require.NoError(t, err)
+	assert.Equal(t, post.Title, restored.Title)
+
+	comments, err := s.GetCommentsByPost(ctx, post.ID, 0, 10, "")
+	require.NoError(t, err)
+	assert.Len(t, comments, 1)
+
+	replies, err := s.GetCommentReplies(ctx, parent.ID, "")
+	require.NoError(t, err)
+	require.Len(t, replies, 1)
+	assert.Equal(t, reply.ID, replies[0].ID)
+	assert.Equal(t, post.Author.ID, replies[0].Author.ID)
+
+	events, err = s.PendingEvents(ctx, "sink", time.Now(), 10)
+	require.NoError(t, err)
+	assert.Len(t, events, 2)
+
+	next, err := s.CreatePost(ctx, model.CustomPostInput{Title: "Next", Author: "Carol", Content: "Content"})
+	require.NoError(t, err)
+	assert.Equal(t, post.ID+1, next.ID)
+	assert.Equal(t, 3, next.Author.ID)
+}
+
+func TestDurableInMemoryStorage_CrashBeforeLogTruncate(t *testing.T) {
+	var (
+		ctx = context.Background()
+		dir = t.TempDir()
+		cfg = newDurableConfig(dir)
+	)
+
+	s, err := NewDurableInMemoryStorage(ctx, cfg)
+	require.NoError(t, err)
+
+	post, err := s.CreatePost(ctx, model.CustomPostInput{Title: "Title", Author: "Bob", Content: "Content"})
+	require.NoError(t, err)
+
+	_, err = s.SetPostStatus(ctx, post.ID, model.ContentStatusRejected, "spam", "Mod")
+	require.NoError(t, err)
+
+	_, err = s.EditPost(ctx, model.CustomEdit{ID: post.ID, Title: "Title", Content: "Edited", Editor: "Bob", Status: model.ContentStatusPending, StatusReason: "too many links"}, "")
+	require.NoError(t, err)
+
+	log, err := os.ReadFile(filepath.Join(dir, "wal.log"))
+	require.NoError(t, err)
+
+	// The snapshot is renamed into place, but the log is left as it was.
+	require.NoError(t, s.Snapshot())
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "wal.log"), log, 0o644))
+
+	s = reopenAfterCrash(t, s, cfg)
+
+	restored, err := s.GetPostByID(ctx, post.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.ContentStatusPending, restored.Status, "the status record is not applied again")
+	assert.Equal(t, "too many links", restored.StatusReason)
+
+	entries, err := s.GetAuditLog(ctx, model.CustomAuditFilter{}, 0, 10)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
@@ -2,58 +2,149 @@ package storage
 
 import (
 	"context"
-	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/erknas/forum/graph/model"
+	"github.com/erknas/forum/internal/outbox"
+	"github.com/erknas/forum/pkg/apperr"
+	"github.com/erknas/forum/pkg/conv"
+	"github.com/erknas/forum/pkg/wal"
 )
 
 type InMemoryStorage struct {
 	mu       sync.RWMutex
 	posts    map[int]*model.CustomPost
 	comments map[int]*model.CustomComment
+	authors  map[string]*model.CustomAuthor
+	reports  map[int]*model.CustomReport
+	bans     map[int]*model.CustomBan
+	// revisions holds the replaced versions of each post and comment, oldest
+	// first.
+	revisions map[targetKey][]model.CustomRevision
+	// attachments holds the attachments of each post and comment in the
+	// order they were given.
+	attachments map[targetKey][]model.CustomAttachment
+	// polls hold their options without results, which are tallied from the
+	// ballots of each poll, keyed by voter.
+	polls     map[int]*model.CustomPoll
+	postPolls map[int]int
+	ballots   map[int]map[string]model.CustomBallot
+	audit     []model.CustomAuditEntry
+	// events holds every outbox event, and deliveries the state of each
+	// event for the sinks that delivered it or failed to.
+	events     []outbox.Event
+	deliveries map[deliveryKey]memoryDelivery
+	wal        *wal.Log
 
-	postID    int
-	commentID int
+	postID       int
+	commentID    int
+	authorID     int
+	reportID     int
+	banID        int
+	revisionID   int
+	attachmentID int
+	auditID      int
+	pollID       int
+	optionID     int
+	eventID      int64
+	// seq is the number of the last record applied.
+	seq int64
+}
+
+type targetKey struct {
+	targetType string
+	targetID   int
+}
+
+type deliveryKey struct {
+	sink    string
+	eventID int64
 }
 
 func NewInMemoryStorage() *InMemoryStorage {
 	return &InMemoryStorage{
-		posts:    make(map[int]*model.CustomPost),
-		comments: make(map[int]*model.CustomComment),
+		posts:       make(map[int]*model.CustomPost),
+		comments:    make(map[int]*model.CustomComment),
+		authors:     make(map[string]*model.CustomAuthor),
+		reports:     make(map[int]*model.CustomReport),
+		bans:        make(map[int]*model.CustomBan),
+		revisions:   make(map[targetKey][]model.CustomRevision),
+		attachments: make(map[targetKey][]model.CustomAttachment),
+		polls:       make(map[int]*model.CustomPoll),
+		postPolls:   make(map[int]int),
+		ballots:     make(map[int]map[string]model.CustomBallot),
+		deliveries:  make(map[deliveryKey]memoryDelivery),
 	}
 }
 
-func (s *InMemoryStorage) CreatePost(_ context.Context, input model.PostInput) (post model.CustomPost, err error) {
+func (s *InMemoryStorage) CreatePost(_ context.Context, input model.CustomPostInput) (post model.CustomPost, err error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.postID++
-
 	post = model.CustomPost{
-		ID:              s.postID,
+		ID:              s.postID + 1,
 		Title:           input.Title,
-		Author:          input.Author,
+		Author:          s.authorFor(input.Author),
 		Content:         input.Content,
 		CreatedAt:       time.Now(),
 		CommentsAllowed: input.CommentsAllowed,
+		Status:          input.Status.OrPublished(),
+		StatusReason:    input.StatusReason,
+		PublishAt:       input.PublishAt,
+	}
+
+	event, err := s.publishedEvent(outbox.PostCreated, "posts", post.Status, post)
+	if err != nil {
+		return model.CustomPost{}, err
+	}
+
+	var poll *model.CustomPoll
+	if input.Poll != nil {
+		poll = s.newPoll(post, *input.Poll)
 	}
 
-	s.posts[post.ID] = &post
+	attachments := s.newAttachments(conv.TypePost, post.ID, post.CreatedAt, input.Attachments)
+
+	if err := s.commit(memoryRecord{Op: opCreatePost, Post: &post, Poll: poll, Attachments: attachments, Event: event}); err != nil {
+		return model.CustomPost{}, err
+	}
 
 	return post, nil
 }
 
-func (s *InMemoryStorage) GetPosts(_ context.Context) ([]model.CustomPost, error) {
+func (s *InMemoryStorage) GetPosts(_ context.Context, viewer string) ([]model.CustomPost, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
 	posts := make([]model.CustomPost, 0, len(s.posts))
 	for _, post := range s.posts {
-		posts = append(posts, *post)
+		if !listed(post.Status, post.Author.Name, viewer) {
+			continue
+		}
+
+		p := *post
+		p.Comments = nil
+		posts = append(posts, p)
 	}
 
+	now := time.Now()
+
+	sort.Slice(posts, func(i, j int) bool {
+		iPinned, jPinned := posts[i].Pinned(now), posts[j].Pinned(now)
+		switch {
+		case iPinned && jPinned:
+			return newerPin(posts[i], posts[j])
+		case iPinned != jPinned:
+			return iPinned
+		}
+		return newerPost(posts[j], posts[i])
+	})
+
 	return posts, nil
 }
 
@@ -63,10 +154,34 @@ func (s *InMemoryStorage) GetPostByID(_ context.Context, id int) (model.CustomPo
 
 	post, ok := s.posts[id]
 	if !ok {
-		return model.CustomPost{}, fmt.Errorf("post not found")
+		return model.CustomPost{}, apperr.ErrPostNotFound
 	}
 
-	return *post, nil
+	p := *post
+	p.Comments = nil
+
+	return p, nil
+}
+
+func (s *InMemoryStorage) GetPostsByIDs(_ context.Context, ids []int) ([]model.CustomPost, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var posts []model.CustomPost
+
+	for _, id := range ids {
+		if post, ok := s.posts[id]; ok {
+			p := *post
+			p.Comments = nil
+			posts = append(posts, p)
+		}
+	}
+
+	sort.Slice(posts, func(i, j int) bool {
+		return posts[i].ID < posts[j].ID
+	})
+
+	return posts, nil
 }
 
 func (s *InMemoryStorage) CreateComment(_ context.Context, input model.CustomCommentInput) (comment model.CustomComment, err error) {
@@ -74,97 +189,1274 @@ func (s *InMemoryStorage) CreateComment(_ context.Context, input model.CustomCom
 	defer s.mu.Unlock()
 
 	post, ok := s.posts[input.PostID]
-	if !ok {
-		return comment, fmt.Errorf("post not found")
+	if !ok || !listed(post.Status, post.Author.Name, input.Author) {
+		return comment, apperr.ErrPostNotFound
 	}
 
 	if !post.CommentsAllowed {
-		return comment, fmt.Errorf("comments not allowed")
+		return comment, apperr.ErrCommentsDisabled
 	}
 
-	if input.ParentID != nil && *input.ParentID > len(post.Comments) {
-		return comment, fmt.Errorf("comment does not exist")
+	if input.ParentID != nil {
+		parent, ok := s.comments[*input.ParentID]
+		if !ok || parent.PostID != post.ID || !listed(parent.Status, parent.Author.Name, input.Author) {
+			return comment, apperr.ErrCommentNotFound
+		}
 	}
 
-	if input.ParentID != nil && post.Comments[*input.ParentID-1] == nil {
-		return comment, fmt.Errorf("comment does not exist")
+	comment = model.CustomComment{
+		ID:           s.commentID + 1,
+		Author:       s.authorFor(input.Author),
+		Content:      input.Content,
+		CreatedAt:    time.Now(),
+		PostID:       post.ID,
+		ParentID:     input.ParentID,
+		Status:       input.Status.OrPublished(),
+		StatusReason: input.StatusReason,
 	}
 
-	s.commentID++
-
-	comment = model.CustomComment{
-		ID:        s.commentID,
-		Author:    input.Author,
-		Content:   input.Content,
-		CreatedAt: time.Now(),
-		PostID:    post.ID,
-		ParentID:  input.ParentID,
+	event, err := s.publishedEvent(outbox.CommentCreated, strconv.Itoa(comment.PostID), comment.Status, comment)
+	if err != nil {
+		return model.CustomComment{}, err
 	}
 
-	s.comments[comment.ID] = &comment
+	attachments := s.newAttachments(conv.TypeComment, comment.ID, comment.CreatedAt, input.Attachments)
 
-	post.Comments = append(post.Comments, &comment)
+	if err := s.commit(memoryRecord{Op: opCreateComment, Comment: &comment, Attachments: attachments, Event: event}); err != nil {
+		return model.CustomComment{}, err
+	}
 
 	return comment, nil
 }
 
-func (s *InMemoryStorage) GetCommentsByPost(_ context.Context, postID int, offset int, limit int) ([]model.CustomComment, error) {
+func (s *InMemoryStorage) GetLatestPosts(_ context.Context, limit int) ([]model.CustomPost, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var posts []model.CustomPost
+
+	for _, post := range s.posts {
+		if published(post.Status) {
+			p := *post
+			p.Comments = nil
+			posts = append(posts, p)
+		}
+	}
+
+	sort.Slice(posts, func(i, j int) bool {
+		return newerPost(posts[i], posts[j])
+	})
+
+	return page(posts, 0, limit), nil
+}
+
+func (s *InMemoryStorage) GetLatestComments(_ context.Context, postID int, limit int) ([]model.CustomComment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	post, ok := s.posts[postID]
+	if !ok {
+		return nil, apperr.ErrPostNotFound
+	}
+
+	var (
+		comments []model.CustomComment
+		listed   = make(map[int]bool)
+	)
+
+	// Comments are kept in the order they were created, so a parent comes
+	// before its replies.
+	for _, comment := range post.Comments {
+		if !published(comment.Status) || (comment.ParentID != nil && !listed[*comment.ParentID]) {
+			continue
+		}
+
+		listed[comment.ID] = true
+		comments = append(comments, *comment)
+	}
+
+	sort.Slice(comments, func(i, j int) bool {
+		if !comments[i].CreatedAt.Equal(comments[j].CreatedAt) {
+			return comments[i].CreatedAt.After(comments[j].CreatedAt)
+		}
+		return comments[i].ID > comments[j].ID
+	})
+
+	return page(comments, 0, limit), nil
+}
+
+func (s *InMemoryStorage) GetCommentsByPost(_ context.Context, postID int, offset int, limit int, viewer string) ([]model.CustomComment, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
 	post, ok := s.posts[postID]
 	if !ok {
-		return nil, fmt.Errorf("post not found")
+		return nil, apperr.ErrPostNotFound
 	}
 
 	var comments []model.CustomComment
 
 	for _, comment := range post.Comments {
-		if comment.ParentID == nil {
-			comments = append(comments, model.CustomComment{
-				ID:        comment.ID,
-				Author:    comment.Author,
-				Content:   comment.Content,
-				CreatedAt: comment.CreatedAt,
-				PostID:    comment.PostID,
-				ParentID:  comment.ParentID,
-			})
+		if comment.ParentID == nil && listed(comment.Status, comment.Author.Name, viewer) {
+			comments = append(comments, *comment)
 		}
 	}
 
-	start := offset
-	end := start + limit
+	return page(comments, offset, limit), nil
+}
+
+// GetCommentsByPostIDs returns the same page of top-level comments for every
+// post, ordered by post.
+func (s *InMemoryStorage) GetCommentsByPostIDs(_ context.Context, postIDs []int, offset int, limit int, viewer string) ([]model.CustomComment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 
-	if start > len(comments) {
-		return nil, nil
+	sorted := append([]int(nil), postIDs...)
+	sort.Ints(sorted)
+
+	var comments []model.CustomComment
+
+	for i, postID := range sorted {
+		post, ok := s.posts[postID]
+		if !ok || (i > 0 && sorted[i-1] == postID) {
+			continue
+		}
+
+		var topLevel []model.CustomComment
+		for _, comment := range post.Comments {
+			if comment.ParentID == nil && listed(comment.Status, comment.Author.Name, viewer) {
+				topLevel = append(topLevel, *comment)
+			}
+		}
+
+		comments = append(comments, page(topLevel, offset, limit)...)
 	}
 
-	if end > len(comments) {
-		end = len(comments)
+	return comments, nil
+}
+
+func (s *InMemoryStorage) GetCommentByID(_ context.Context, id int) (model.CustomComment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	comment, ok := s.comments[id]
+	if !ok {
+		return model.CustomComment{}, apperr.ErrCommentNotFound
 	}
 
-	return comments[start:end], nil
+	return *comment, nil
 }
 
-func (s *InMemoryStorage) GetCommentReplies(_ context.Context, parentID int) ([]model.CustomComment, error) {
+func (s *InMemoryStorage) GetCommentReplies(_ context.Context, parentID int, viewer string) ([]model.CustomComment, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
 	var replies []model.CustomComment
 
 	for _, comment := range s.comments {
-		if comment.ParentID != nil && *comment.ParentID == parentID {
-			replies = append(replies, model.CustomComment{
-				ID:        comment.ID,
-				Author:    comment.Author,
-				Content:   comment.Content,
-				CreatedAt: comment.CreatedAt,
-				PostID:    comment.PostID,
-				ParentID:  &parentID,
-			})
+		if comment.ParentID != nil && *comment.ParentID == parentID && listed(comment.Status, comment.Author.Name, viewer) {
+			replies = append(replies, *comment)
+		}
+	}
+
+	sort.Slice(replies, func(i, j int) bool {
+		return replies[i].ID < replies[j].ID
+	})
+
+	return replies, nil
+}
+
+func (s *InMemoryStorage) GetRepliesByParentIDs(_ context.Context, parentIDs []int, viewer string) ([]model.CustomComment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	parents := make(map[int]struct{}, len(parentIDs))
+	for _, id := range parentIDs {
+		parents[id] = struct{}{}
+	}
 
+	var replies []model.CustomComment
+
+	for _, comment := range s.comments {
+		if comment.ParentID == nil || !listed(comment.Status, comment.Author.Name, viewer) {
+			continue
+		}
+		if _, ok := parents[*comment.ParentID]; ok {
+			replies = append(replies, *comment)
 		}
 	}
 
+	sort.Slice(replies, func(i, j int) bool {
+		return replies[i].ID < replies[j].ID
+	})
+
 	return replies, nil
 }
+
+func (s *InMemoryStorage) GetAuthorByID(_ context.Context, id int) (model.CustomAuthor, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, author := range s.authors {
+		if author.ID == id {
+			return *author, nil
+		}
+	}
+
+	return model.CustomAuthor{}, apperr.ErrAuthorNotFound
+}
+
+func (s *InMemoryStorage) GetAuthorByName(_ context.Context, name string) (model.CustomAuthor, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	author, ok := s.authors[name]
+	if !ok {
+		return model.CustomAuthor{}, apperr.ErrAuthorNotFound
+	}
+
+	return *author, nil
+}
+
+func (s *InMemoryStorage) GetAuthorStats(_ context.Context, id int, viewer string) (model.AuthorStats, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stats := model.AuthorStats{}
+
+	for _, post := range s.posts {
+		if post.Author.ID == id && listed(post.Status, post.Author.Name, viewer) {
+			stats.PostCount++
+		}
+	}
+
+	for _, comment := range s.comments {
+		if comment.Author.ID == id && listed(comment.Status, comment.Author.Name, viewer) {
+			stats.CommentCount++
+		}
+	}
+
+	return stats, nil
+}
+
+func (s *InMemoryStorage) GetAuthorStatsByIDs(_ context.Context, ids []int, viewer string) (map[int]model.AuthorStats, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	requested := make(map[int]struct{}, len(ids))
+	for _, id := range ids {
+		requested[id] = struct{}{}
+	}
+
+	stats := make(map[int]model.AuthorStats, len(ids))
+
+	for _, author := range s.authors {
+		if _, ok := requested[author.ID]; ok {
+			stats[author.ID] = model.AuthorStats{}
+		}
+	}
+
+	for _, post := range s.posts {
+		if !listed(post.Status, post.Author.Name, viewer) {
+			continue
+		}
+		if authorStats, ok := stats[post.Author.ID]; ok {
+			authorStats.PostCount++
+			stats[post.Author.ID] = authorStats
+		}
+	}
+
+	for _, comment := range s.comments {
+		if !listed(comment.Status, comment.Author.Name, viewer) {
+			continue
+		}
+		if authorStats, ok := stats[comment.Author.ID]; ok {
+			authorStats.CommentCount++
+			stats[comment.Author.ID] = authorStats
+		}
+	}
+
+	return stats, nil
+}
+
+func (s *InMemoryStorage) GetPostsByAuthor(_ context.Context, authorID int, offset int, limit int, viewer string) ([]model.CustomPost, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var posts []model.CustomPost
+
+	for _, post := range s.posts {
+		if post.Author.ID == authorID && listed(post.Status, post.Author.Name, viewer) {
+			p := *post
+			p.Comments = nil
+			posts = append(posts, p)
+		}
+	}
+
+	sort.Slice(posts, func(i, j int) bool {
+		return newerPost(posts[i], posts[j])
+	})
+
+	return page(posts, offset, limit), nil
+}
+
+func (s *InMemoryStorage) GetCommentsByAuthor(_ context.Context, authorID int, offset int, limit int, viewer string) ([]model.CustomComment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var comments []model.CustomComment
+
+	for _, comment := range s.comments {
+		if comment.Author.ID == authorID && listed(comment.Status, comment.Author.Name, viewer) {
+			comments = append(comments, *comment)
+		}
+	}
+
+	sort.Slice(comments, func(i, j int) bool {
+		return comments[i].ID > comments[j].ID
+	})
+
+	return page(comments, offset, limit), nil
+}
+
+func (s *InMemoryStorage) SetPostStatus(_ context.Context, id int, status model.ContentStatus, reason string, actor string) (model.CustomPost, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, err := s.postStatusRecord(id, model.CustomStatusChange{Status: status, Reason: reason}, actor)
+	if err != nil {
+		return model.CustomPost{}, err
+	}
+
+	if err := s.commit(*record); err != nil {
+		return model.CustomPost{}, err
+	}
+
+	return *record.Post, nil
+}
+
+func (s *InMemoryStorage) SetCommentStatus(_ context.Context, id int, status model.ContentStatus, reason string, actor string) (model.CustomComment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, err := s.commentStatusRecord(id, model.CustomStatusChange{Status: status, Reason: reason}, actor)
+	if err != nil {
+		return model.CustomComment{}, err
+	}
+
+	if err := s.commit(*record); err != nil {
+		return model.CustomComment{}, err
+	}
+
+	return *record.Comment, nil
+}
+
+// statusRecord returns the record of change to the status of a post or
+// comment, or nil if the change does not apply to it. The caller must hold
+// the write lock.
+func (s *InMemoryStorage) statusRecord(targetType string, id int, change model.CustomStatusChange, actor string) (*memoryRecord, error) {
+	if change.Status == "" {
+		return nil, nil
+	}
+
+	if targetType == conv.TypePost {
+		return s.postStatusRecord(id, change, actor)
+	}
+	return s.commentStatusRecord(id, change, actor)
+}
+
+func (s *InMemoryStorage) postStatusRecord(id int, change model.CustomStatusChange, actor string) (*memoryRecord, error) {
+	stored, ok := s.posts[id]
+	if !ok || stored.Status.Draft() {
+		return nil, apperr.ErrPostNotFound
+	}
+
+	if !change.AppliesTo(stored.Status, stored.StatusReason) {
+		return nil, nil
+	}
+
+	before := *stored
+	before.Comments = nil
+
+	post := before
+	post.Status = change.Status
+	post.StatusReason = change.Reason
+
+	audit, err := s.auditEntry(actor, change.Status.AuditAction(), conv.TypePost, id, before, post)
+	if err != nil {
+		return nil, err
+	}
+
+	var event *memoryEvent
+	if !published(stored.Status) {
+		if event, err = s.publishedEvent(outbox.PostCreated, "posts", post.Status, post); err != nil {
+			return nil, err
+		}
+	}
+
+	return &memoryRecord{Op: opSetPostStatus, Post: &post, Event: event, Audit: audit}, nil
+}
+
+func (s *InMemoryStorage) commentStatusRecord(id int, change model.CustomStatusChange, actor string) (*memoryRecord, error) {
+	stored, ok := s.comments[id]
+	if !ok {
+		return nil, apperr.ErrCommentNotFound
+	}
+
+	if !change.AppliesTo(stored.Status, stored.StatusReason) {
+		return nil, nil
+	}
+
+	comment := *stored
+	comment.Status = change.Status
+	comment.StatusReason = change.Reason
+
+	audit, err := s.auditEntry(actor, change.Status.AuditAction(), conv.TypeComment, id, *stored, comment)
+	if err != nil {
+		return nil, err
+	}
+
+	var event *memoryEvent
+	if !published(stored.Status) {
+		if event, err = s.publishedEvent(outbox.CommentCreated, strconv.Itoa(comment.PostID), comment.Status, comment); err != nil {
+			return nil, err
+		}
+	}
+
+	return &memoryRecord{Op: opSetCommentStatus, Comment: &comment, Event: event, Audit: audit}, nil
+}
+
+func (s *InMemoryStorage) EditPost(_ context.Context, edit model.CustomEdit, actor string) (model.CustomPost, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.posts[edit.ID]
+	if !ok {
+		return model.CustomPost{}, apperr.ErrPostNotFound
+	}
+
+	before := *stored
+	before.Comments = nil
+
+	revision := before.Revision(len(s.revisions[targetKey{targetType: conv.TypePost, targetID: before.ID}]) + 1)
+	revision.ID = s.revisionID + 1
+
+	editedAt := time.Now()
+	post := before
+	post.Title, post.Content, post.EditedBy, post.EditedAt = edit.Title, edit.Content, edit.Editor, &editedAt
+	if edit.Status != "" {
+		post.Status, post.StatusReason = edit.Status, edit.StatusReason
+	}
+
+	audit, err := s.auditEntry(actor, model.AuditActionEditContent, conv.TypePost, post.ID, before, post)
+	if err != nil {
+		return model.CustomPost{}, err
+	}
+
+	if err := s.commit(memoryRecord{Op: opEditPost, Post: &post, Revision: &revision, Audit: audit}); err != nil {
+		return model.CustomPost{}, err
+	}
+
+	return post, nil
+}
+
+func (s *InMemoryStorage) EditComment(_ context.Context, edit model.CustomEdit, actor string) (model.CustomComment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.comments[edit.ID]
+	if !ok {
+		return model.CustomComment{}, apperr.ErrCommentNotFound
+	}
+
+	revision := stored.Revision(len(s.revisions[targetKey{targetType: conv.TypeComment, targetID: stored.ID}]) + 1)
+	revision.ID = s.revisionID + 1
+
+	editedAt := time.Now()
+	comment := *stored
+	comment.Content, comment.EditedBy, comment.EditedAt = edit.Content, edit.Editor, &editedAt
+	if edit.Status != "" {
+		comment.Status, comment.StatusReason = edit.Status, edit.StatusReason
+	}
+
+	audit, err := s.auditEntry(actor, model.AuditActionEditContent, conv.TypeComment, comment.ID, *stored, comment)
+	if err != nil {
+		return model.CustomComment{}, err
+	}
+
+	if err := s.commit(memoryRecord{Op: opEditComment, Comment: &comment, Revision: &revision, Audit: audit}); err != nil {
+		return model.CustomComment{}, err
+	}
+
+	return comment, nil
+}
+
+// GetDrafts returns the drafts and scheduled posts of author, newest first.
+func (s *InMemoryStorage) GetDrafts(_ context.Context, author string, offset int, limit int) ([]model.CustomPost, int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var drafts []model.CustomPost
+
+	for _, post := range s.posts {
+		if post.Author.Name == author && post.Status.Draft() {
+			p := *post
+			p.Comments = nil
+			drafts = append(drafts, p)
+		}
+	}
+
+	sort.Slice(drafts, func(i, j int) bool {
+		return newerPost(drafts[i], drafts[j])
+	})
+
+	return page(drafts, offset, limit), len(drafts), nil
+}
+
+func (s *InMemoryStorage) PublishPost(_ context.Context, id int, status model.ContentStatus, reason string, publishAt time.Time) (model.CustomPost, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.posts[id]
+	if !ok {
+		return model.CustomPost{}, apperr.ErrPostNotFound
+	}
+
+	if !stored.Status.Draft() {
+		return model.CustomPost{}, apperr.ErrNotDraft
+	}
+
+	return s.publish(*stored, status, reason, publishAt)
+}
+
+// PublishDuePosts publishes the scheduled posts due at now, in the order they
+// were due.
+func (s *InMemoryStorage) PublishDuePosts(_ context.Context, now time.Time) ([]model.CustomPost, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []model.CustomPost
+
+	for _, post := range s.posts {
+		if post.Status != model.ContentStatusScheduled || post.PublishAt.After(now) {
+			continue
+		}
+		if ban := s.unliftedBan(post.Author.Name); ban != nil && ban.Active(now) && ban.Kind == model.BanKindBan {
+			continue
+		}
+		due = append(due, *post)
+	}
+
+	sort.Slice(due, func(i, j int) bool {
+		if !due[i].PublishAt.Equal(*due[j].PublishAt) {
+			return due[i].PublishAt.Before(*due[j].PublishAt)
+		}
+		return due[i].ID < due[j].ID
+	})
+
+	posts := make([]model.CustomPost, 0, len(due))
+
+	for _, post := range due {
+		status, reason := model.ContentStatusPublished, ""
+		if ban := s.unliftedBan(post.Author.Name); ban != nil && ban.Active(now) {
+			status, reason = model.ContentStatusShadowed, model.ShadowedReason
+		}
+
+		p, err := s.publish(post, status, reason, *post.PublishAt)
+		if err != nil {
+			return posts, err
+		}
+		posts = append(posts, p)
+	}
+
+	return posts, nil
+}
+
+// PinPost pins the post until the given time, or for good if it is nil,
+// replacing its previous pin.
+func (s *InMemoryStorage) PinPost(_ context.Context, id int, until *time.Time, actor string) (model.CustomPost, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.posts[id]
+	if !ok || stored.Status.Draft() {
+		return model.CustomPost{}, apperr.ErrPostNotFound
+	}
+
+	now := time.Now()
+
+	before := *stored
+	before.Comments = nil
+
+	post := before
+	post.PinnedBy, post.PinnedAt, post.PinnedUntil = actor, &now, until
+
+	return s.pin(actor, model.AuditActionPinPost, before, post)
+}
+
+func (s *InMemoryStorage) UnpinPost(_ context.Context, id int, actor string) (model.CustomPost, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.posts[id]
+	if !ok || stored.Status.Draft() {
+		return model.CustomPost{}, apperr.ErrPostNotFound
+	}
+
+	if !stored.Pinned(time.Now()) {
+		return model.CustomPost{}, apperr.ErrNotPinned
+	}
+
+	before := *stored
+	before.Comments = nil
+
+	post := before
+	post.PinnedBy, post.PinnedAt, post.PinnedUntil = "", nil, nil
+
+	return s.pin(actor, model.AuditActionUnpinPost, before, post)
+}
+
+// pin saves the pin of post, or its removal, audited as action. The caller
+// must hold the write lock.
+func (s *InMemoryStorage) pin(actor string, action model.AuditAction, before, post model.CustomPost) (model.CustomPost, error) {
+	audit, err := s.auditEntry(actor, action, conv.TypePost, post.ID, before, post)
+	if err != nil {
+		return model.CustomPost{}, err
+	}
+
+	if err := s.commit(memoryRecord{Op: opPinPost, Post: &post, Audit: audit}); err != nil {
+		return model.CustomPost{}, err
+	}
+
+	return post, nil
+}
+
+func (s *InMemoryStorage) GetPoll(_ context.Context, id int) (model.CustomPoll, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	poll, ok := s.polls[id]
+	if !ok {
+		return model.CustomPoll{}, apperr.ErrPollNotFound
+	}
+
+	return tally(*poll, s.ballots[id]), nil
+}
+
+// GetPollsByPostIDs returns the polls of the posts that have one, by post ID.
+func (s *InMemoryStorage) GetPollsByPostIDs(_ context.Context, postIDs []int) (map[int]model.CustomPoll, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	polls := make(map[int]model.CustomPoll, len(postIDs))
+
+	for _, postID := range postIDs {
+		if id, ok := s.postPolls[postID]; ok {
+			polls[postID] = tally(*s.polls[id], s.ballots[id])
+		}
+	}
+
+	return polls, nil
+}
+
+func (s *InMemoryStorage) Vote(_ context.Context, ballot model.CustomBallot) (model.CustomPoll, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	poll, ok := s.polls[ballot.PollID]
+	if !ok {
+		return model.CustomPoll{}, apperr.ErrPollNotFound
+	}
+
+	ballot.CreatedAt = time.Now()
+
+	if poll.Closed(ballot.CreatedAt) {
+		return model.CustomPoll{}, apperr.ErrPollClosed
+	}
+
+	for _, id := range ballot.OptionIDs {
+		if !slices.ContainsFunc(poll.Options, func(o model.CustomPollOption) bool { return o.ID == id }) {
+			return model.CustomPoll{}, apperr.ErrInvalidOption
+		}
+	}
+
+	ballots := maps.Clone(s.ballots[poll.ID])
+	if ballots == nil {
+		ballots = make(map[string]model.CustomBallot)
+	}
+	ballots[ballot.Voter] = ballot
+
+	result := tally(*poll, ballots)
+
+	event, err := outbox.NewEvent(outbox.PollUpdated, strconv.Itoa(poll.ID), result)
+	if err != nil {
+		return model.CustomPoll{}, err
+	}
+
+	if err := s.commit(memoryRecord{Op: opVote, Ballot: &ballot, Event: s.nextEvent(event)}); err != nil {
+		return model.CustomPoll{}, err
+	}
+
+	return result, nil
+}
+
+// newPoll numbers the poll of post and its options. The caller must hold the
+// write lock.
+func (s *InMemoryStorage) newPoll(post model.CustomPost, input model.CustomPollInput) *model.CustomPoll {
+	poll := &model.CustomPoll{
+		ID:             s.pollID + 1,
+		PostID:         post.ID,
+		Question:       input.Question,
+		MultipleChoice: input.MultipleChoice,
+		Anonymous:      input.Anonymous,
+		ClosesAt:       input.ClosesAt,
+		CreatedAt:      post.CreatedAt,
+		Options:        make([]model.CustomPollOption, 0, len(input.Options)),
+	}
+
+	for i, text := range input.Options {
+		poll.Options = append(poll.Options, model.CustomPollOption{ID: s.optionID + i + 1, Text: text})
+	}
+
+	return poll
+}
+
+// newAttachments numbers the attachments of a new target.
+func (s *InMemoryStorage) newAttachments(targetType string, targetID int, createdAt time.Time, inputs []model.CustomAttachment) []model.CustomAttachment {
+	attachments := make([]model.CustomAttachment, 0, len(inputs))
+
+	for i, attachment := range inputs {
+		attachment.ID = s.attachmentID + i + 1
+		attachment.TargetType, attachment.TargetID = targetType, targetID
+		attachment.CreatedAt = createdAt
+		attachments = append(attachments, attachment)
+	}
+
+	return attachments
+}
+
+// GetAttachments returns the attachments of the targets in the order they
+// were given.
+func (s *InMemoryStorage) GetAttachments(_ context.Context, targetType string, targetIDs []int) (map[int][]model.CustomAttachment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	byTarget := make(map[int][]model.CustomAttachment, len(targetIDs))
+
+	for _, id := range targetIDs {
+		if attachments := s.attachments[targetKey{targetType: targetType, targetID: id}]; len(attachments) > 0 {
+			byTarget[id] = slices.Clone(attachments)
+		}
+	}
+
+	return byTarget, nil
+}
+
+// GetRevisions returns the replaced versions of the targets, oldest first.
+func (s *InMemoryStorage) GetRevisions(_ context.Context, targetType string, targetIDs []int) (map[int][]model.CustomRevision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	byTarget := make(map[int][]model.CustomRevision, len(targetIDs))
+
+	for _, id := range targetIDs {
+		if revisions := s.revisions[targetKey{targetType: targetType, targetID: id}]; len(revisions) > 0 {
+			byTarget[id] = append([]model.CustomRevision(nil), revisions...)
+		}
+	}
+
+	return byTarget, nil
+}
+
+// GetPendingContent returns pending posts and comments, oldest first.
+func (s *InMemoryStorage) GetPendingContent(_ context.Context, offset int, limit int) ([]model.CustomContent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var pending []model.CustomContent
+
+	for _, post := range s.posts {
+		if post.Status == model.ContentStatusPending {
+			p := *post
+			p.Comments = nil
+			pending = append(pending, model.CustomContent{Post: &p})
+		}
+	}
+
+	for _, comment := range s.comments {
+		if comment.Status == model.ContentStatusPending {
+			c := *comment
+			pending = append(pending, model.CustomContent{Comment: &c})
+		}
+	}
+
+	sort.Slice(pending, func(i, j int) bool {
+		return olderContent(pending[i], pending[j])
+	})
+
+	return page(pending, offset, limit), nil
+}
+
+func (s *InMemoryStorage) CreateReport(_ context.Context, input model.CustomReportInput) (model.CustomReport, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var (
+		existing *model.CustomReport
+		open     int
+	)
+
+	for _, report := range s.reports {
+		if report.ResolvedAt != nil || report.TargetType != input.TargetType || report.TargetID != input.TargetID {
+			continue
+		}
+
+		open++
+
+		if report.Reporter == input.Reporter {
+			existing = report
+		}
+	}
+
+	if existing == nil {
+		open++
+	}
+
+	var status *memoryRecord
+	if input.HideAt > 0 && open >= input.HideAt {
+		var err error
+		if status, err = s.statusRecord(input.TargetType, input.TargetID, input.Hide, ""); err != nil {
+			return model.CustomReport{}, 0, err
+		}
+	}
+
+	if existing != nil {
+		if status != nil {
+			if err := s.commit(*status); err != nil {
+				return model.CustomReport{}, 0, err
+			}
+		}
+		return *existing, open, nil
+	}
+
+	report := model.CustomReport{
+		ID:         s.reportID + 1,
+		TargetType: input.TargetType,
+		TargetID:   input.TargetID,
+		Reporter:   input.Reporter,
+		Reason:     input.Reason,
+		Details:    input.Details,
+		CreatedAt:  time.Now(),
+	}
+
+	if err := s.commit(memoryRecord{Op: opCreateReport, Report: &report, Status: status}); err != nil {
+		return model.CustomReport{}, 0, err
+	}
+
+	return report, open, nil
+}
+
+// GetReportGroups returns open reports grouped by target, most reported
+// first, then by the oldest report.
+func (s *InMemoryStorage) GetReportGroups(_ context.Context, offset int, limit int) ([]model.CustomReportGroup, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	type target struct {
+		typ string
+		id  int
+	}
+
+	var (
+		groups  []model.CustomReportGroup
+		indexes = make(map[target]int)
+	)
+
+	for _, report := range s.openReports() {
+		key := target{typ: report.TargetType, id: report.TargetID}
+
+		i, ok := indexes[key]
+		if !ok {
+			i = len(groups)
+			indexes[key] = i
+			groups = append(groups, model.CustomReportGroup{TargetType: report.TargetType, TargetID: report.TargetID})
+		}
+
+		groups[i].Reports = append(groups[i].Reports, report)
+	}
+
+	// Groups are in the order of their oldest report, which a stable sort
+	// keeps for groups with the same number of reports.
+	sort.SliceStable(groups, func(i, j int) bool {
+		return len(groups[i].Reports) > len(groups[j].Reports)
+	})
+
+	return page(groups, offset, limit), nil
+}
+
+func (s *InMemoryStorage) ResolveReports(_ context.Context, targetType string, targetID int, resolvedBy string, action model.ReportAction, change model.CustomStatusChange) ([]model.CustomReport, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var (
+		open       []model.CustomReport
+		resolved   []model.CustomReport
+		resolvedAt = time.Now()
+	)
+
+	for _, report := range s.openReports() {
+		if report.TargetType != targetType || report.TargetID != targetID {
+			continue
+		}
+
+		open = append(open, report)
+
+		report.ResolvedBy = resolvedBy
+		report.ResolvedAt = &resolvedAt
+		report.Action = action
+		resolved = append(resolved, report)
+	}
+
+	if len(resolved) == 0 {
+		return nil, apperr.ErrNoOpenReports
+	}
+
+	audit, err := s.auditEntry(resolvedBy, model.AuditActionResolveReports, targetType, targetID, open, resolved)
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := s.statusRecord(targetType, targetID, change, resolvedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	// The status change is applied after the reports are resolved, so its
+	// audit entry comes next.
+	if status != nil && status.Audit != nil {
+		status.Audit.ID++
+	}
+
+	if err := s.commit(memoryRecord{Op: opResolveReports, Reports: resolved, Audit: audit, Status: status}); err != nil {
+		return nil, err
+	}
+
+	return resolved, nil
+}
+
+func (s *InMemoryStorage) CreateBan(_ context.Context, input model.CustomBanInput) (model.CustomBan, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var (
+		bans   []model.CustomBan
+		before any
+		now    = time.Now()
+	)
+
+	if previous := s.unliftedBan(input.Author); previous != nil {
+		before = *previous
+
+		lifted := *previous
+		lifted.LiftedBy = input.Moderator
+		lifted.LiftedAt = &now
+		bans = append(bans, lifted)
+	}
+
+	ban := model.CustomBan{
+		ID:        s.banID + 1,
+		Author:    input.Author,
+		Kind:      input.Kind,
+		Reason:    input.Reason,
+		Moderator: input.Moderator,
+		CreatedAt: now,
+		ExpiresAt: input.ExpiresAt,
+	}
+	bans = append(bans, ban)
+
+	audit, err := s.auditEntry(input.Moderator, model.AuditActionBanUser, conv.TypeBan, ban.ID, before, ban)
+	if err != nil {
+		return model.CustomBan{}, err
+	}
+
+	if err := s.commit(memoryRecord{Op: opSaveBans, Bans: bans, Audit: audit}); err != nil {
+		return model.CustomBan{}, err
+	}
+
+	return ban, nil
+}
+
+func (s *InMemoryStorage) LiftBan(_ context.Context, author string, liftedBy string) (model.CustomBan, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+
+	active := s.unliftedBan(author)
+	if active == nil || !active.Active(now) {
+		return model.CustomBan{}, apperr.ErrNoActiveBan
+	}
+
+	ban := *active
+	ban.LiftedBy = liftedBy
+	ban.LiftedAt = &now
+
+	audit, err := s.auditEntry(liftedBy, model.AuditActionUnbanUser, conv.TypeBan, ban.ID, *active, ban)
+	if err != nil {
+		return model.CustomBan{}, err
+	}
+
+	if err := s.commit(memoryRecord{Op: opSaveBans, Bans: []model.CustomBan{ban}, Audit: audit}); err != nil {
+		return model.CustomBan{}, err
+	}
+
+	return ban, nil
+}
+
+func (s *InMemoryStorage) GetActiveBan(_ context.Context, author string) (model.CustomBan, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ban := s.unliftedBan(author)
+	if ban == nil || !ban.Active(time.Now()) {
+		return model.CustomBan{}, apperr.ErrNoActiveBan
+	}
+
+	return *ban, nil
+}
+
+// GetActiveBans returns the bans in force, newest first.
+func (s *InMemoryStorage) GetActiveBans(_ context.Context, offset int, limit int) ([]model.CustomBan, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var (
+		bans []model.CustomBan
+		now  = time.Now()
+	)
+
+	for _, ban := range s.bans {
+		if ban.Active(now) {
+			bans = append(bans, *ban)
+		}
+	}
+
+	sort.Slice(bans, func(i, j int) bool {
+		return bans[i].ID > bans[j].ID
+	})
+
+	return page(bans, offset, limit), nil
+}
+
+// GetAuditLog returns the entries that match filter, newest first.
+func (s *InMemoryStorage) GetAuditLog(_ context.Context, filter model.CustomAuditFilter, offset int, limit int) ([]model.CustomAuditEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var entries []model.CustomAuditEntry
+
+	for i := len(s.audit) - 1; i >= 0; i-- {
+		if filter.Match(s.audit[i]) {
+			entries = append(entries, s.audit[i])
+		}
+	}
+
+	return page(entries, offset, limit), nil
+}
+
+func (s *InMemoryStorage) PendingEvents(_ context.Context, sink string, now time.Time, limit int) ([]outbox.Event, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var events []outbox.Event
+
+	for _, event := range s.events {
+		if len(events) == limit {
+			break
+		}
+
+		delivery := s.deliveries[deliveryKey{sink: sink, eventID: event.ID}]
+		if delivery.Delivered || delivery.Dead || delivery.RetryAt.After(now) {
+			continue
+		}
+
+		event.Attempts = delivery.Attempts
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+func (s *InMemoryStorage) MarkDelivered(_ context.Context, sink string, ids []int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.commit(memoryRecord{Op: opMarkDelivered, Sink: sink, EventIDs: ids})
+}
+
+func (s *InMemoryStorage) MarkFailed(_ context.Context, sink string, failure outbox.Failure) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.commit(memoryRecord{Op: opMarkFailed, Sink: sink, Failure: &failure})
+}
+
+// authorFor returns the author with the given name, or the author that will
+// be created for it by the next committed record.
+func (s *InMemoryStorage) authorFor(name string) model.CustomAuthor {
+	if author, ok := s.authors[name]; ok {
+		return *author
+	}
+
+	return model.CustomAuthor{
+		ID:       s.authorID + 1,
+		Name:     name,
+		JoinedAt: time.Now(),
+	}
+}
+
+// publishedEvent returns the event for content created with status, or nil if
+// the content is not published.
+func (s *InMemoryStorage) publishedEvent(typ outbox.EventType, topic string, status model.ContentStatus, payload any) (*memoryEvent, error) {
+	if !published(status) {
+		return nil, nil
+	}
+
+	event, err := outbox.NewEvent(typ, topic, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.nextEvent(event), nil
+}
+
+// publish gives a draft its status and publishAt time, which becomes its
+// creation time unless it is scheduled. The caller must hold the write lock.
+func (s *InMemoryStorage) publish(post model.CustomPost, status model.ContentStatus, reason string, publishAt time.Time) (model.CustomPost, error) {
+	post.Comments = nil
+	post.Status, post.StatusReason, post.PublishAt = status, reason, &publishAt
+	if status != model.ContentStatusScheduled {
+		post.CreatedAt = publishAt
+	}
+
+	event, err := s.publishedEvent(outbox.PostCreated, "posts", post.Status, post)
+	if err != nil {
+		return model.CustomPost{}, err
+	}
+
+	if err := s.commit(memoryRecord{Op: opPublishPost, Post: &post, Event: event}); err != nil {
+		return model.CustomPost{}, err
+	}
+
+	return post, nil
+}
+
+// unliftedBan returns the ban on author that was not lifted, which may have
+// expired, or nil. The caller must hold the lock.
+func (s *InMemoryStorage) unliftedBan(author string) *model.CustomBan {
+	for _, ban := range s.bans {
+		if ban.Author == author && ban.LiftedAt == nil {
+			return ban
+		}
+	}
+	return nil
+}
+
+// auditEntry returns the next audit entry for an actor's change, or nil if
+// there is no actor.
+func (s *InMemoryStorage) auditEntry(actor string, action model.AuditAction, targetType string, targetID int, before any, after any) (*model.CustomAuditEntry, error) {
+	entry, err := newAuditEntry(actor, action, targetType, targetID, before, after)
+	if entry == nil || err != nil {
+		return nil, err
+	}
+
+	entry.ID = s.auditID + 1
+
+	return entry, nil
+}
+
+func (s *InMemoryStorage) nextEvent(event outbox.Event) *memoryEvent {
+	event.ID = s.eventID + 1
+	return &memoryEvent{Seq: event.ID, Event: event}
+}
+
+// openReports returns copies of the reports that are not resolved, oldest
+// first.
+func (s *InMemoryStorage) openReports() []model.CustomReport {
+	var reports []model.CustomReport
+
+	for _, report := range s.reports {
+		if report.ResolvedAt == nil {
+			reports = append(reports, *report)
+		}
+	}
+
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].ID < reports[j].ID
+	})
+
+	return reports
+}
+
+func published(status model.ContentStatus) bool {
+	return status.OrPublished() == model.ContentStatusPublished
+}
+
+// listed reports whether content with status by author is listed for viewer:
+// published content, and shadowed content of their own.
+func listed(status model.ContentStatus, author string, viewer string) bool {
+	return published(status) || (status == model.ContentStatusShadowed && author == viewer)
+}
+
+// newerPost orders posts by creation time, which a draft takes when it is
+// published, and by ID.
+func newerPost(a, b model.CustomPost) bool {
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.After(b.CreatedAt)
+	}
+	return a.ID > b.ID
+}
+
+// newerPin orders pinned posts by when they were pinned, and by ID.
+func newerPin(a, b model.CustomPost) bool {
+	if !a.PinnedAt.Equal(*b.PinnedAt) {
+		return a.PinnedAt.After(*b.PinnedAt)
+	}
+	return a.ID > b.ID
+}
+
+// olderContent orders content by creation time, and posts before comments
+// created at the same time.
+func olderContent(a, b model.CustomContent) bool {
+	aCreated, aID := contentKey(a)
+	bCreated, bID := contentKey(b)
+
+	switch {
+	case !aCreated.Equal(bCreated):
+		return aCreated.Before(bCreated)
+	case (a.Post != nil) != (b.Post != nil):
+		return a.Post != nil
+	default:
+		return aID < bID
+	}
+}
+
+func contentKey(c model.CustomContent) (time.Time, int) {
+	if c.Post != nil {
+		return c.Post.CreatedAt, c.Post.ID
+	}
+	return c.Comment.CreatedAt, c.Comment.ID
+}
+
+func page[T any](items []T, offset int, limit int) []T {
+	if offset >= len(items) {
+		return nil
+	}
+
+	end := offset + limit
+	if end > len(items) {
+		end = len(items)
+	}
+
+	return items[offset:end]
+}
@@ -7,6 +7,10 @@ import (
 
 	model "github.com/erknas/forum/graph/model"
 	mock "github.com/stretchr/testify/mock"
+
+	outbox "github.com/erknas/forum/internal/outbox"
+
+	time "time"
 )
 
 // Storer is an autogenerated mock type for the Storer type
@@ -14,6 +18,34 @@ type Storer struct {
 	mock.Mock
 }
 
+// CreateBan provides a mock function with given fields: _a0, _a1
+func (_m *Storer) CreateBan(_a0 context.Context, _a1 model.CustomBanInput) (model.CustomBan, error) {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateBan")
+	}
+
+	var r0 model.CustomBan
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, model.CustomBanInput) (model.CustomBan, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, model.CustomBanInput) model.CustomBan); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(model.CustomBan)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, model.CustomBanInput) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // CreateComment provides a mock function with given fields: _a0, _a1
 func (_m *Storer) CreateComment(_a0 context.Context, _a1 model.CustomCommentInput) (model.CustomComment, error) {
 	ret := _m.Called(_a0, _a1)
@@ -43,7 +75,7 @@ func (_m *Storer) CreateComment(_a0 context.Context, _a1 model.CustomCommentInpu
 }
 
 // CreatePost provides a mock function with given fields: _a0, _a1
-func (_m *Storer) CreatePost(_a0 context.Context, _a1 model.PostInput) (model.CustomPost, error) {
+func (_m *Storer) CreatePost(_a0 context.Context, _a1 model.CustomPostInput) (model.CustomPost, error) {
 	ret := _m.Called(_a0, _a1)
 
 	if len(ret) == 0 {
@@ -52,16 +84,16 @@ func (_m *Storer) CreatePost(_a0 context.Context, _a1 model.PostInput) (model.Cu
 
 	var r0 model.CustomPost
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, model.PostInput) (model.CustomPost, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, model.CustomPostInput) (model.CustomPost, error)); ok {
 		return rf(_a0, _a1)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, model.PostInput) model.CustomPost); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, model.CustomPostInput) model.CustomPost); ok {
 		r0 = rf(_a0, _a1)
 	} else {
 		r0 = ret.Get(0).(model.CustomPost)
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, model.PostInput) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, model.CustomPostInput) error); ok {
 		r1 = rf(_a0, _a1)
 	} else {
 		r1 = ret.Error(1)
@@ -70,29 +102,178 @@ func (_m *Storer) CreatePost(_a0 context.Context, _a1 model.PostInput) (model.Cu
 	return r0, r1
 }
 
-// GetCommentReplies provides a mock function with given fields: _a0, _a1
-func (_m *Storer) GetCommentReplies(_a0 context.Context, _a1 int) ([]model.CustomComment, error) {
+// CreateReport provides a mock function with given fields: _a0, _a1
+func (_m *Storer) CreateReport(_a0 context.Context, _a1 model.CustomReportInput) (model.CustomReport, int, error) {
 	ret := _m.Called(_a0, _a1)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetCommentReplies")
+		panic("no return value specified for CreateReport")
 	}
 
-	var r0 []model.CustomComment
-	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, int) ([]model.CustomComment, error)); ok {
+	var r0 model.CustomReport
+	var r1 int
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, model.CustomReportInput) (model.CustomReport, int, error)); ok {
 		return rf(_a0, _a1)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, int) []model.CustomComment); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, model.CustomReportInput) model.CustomReport); ok {
 		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(model.CustomReport)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, model.CustomReportInput) int); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, model.CustomReportInput) error); ok {
+		r2 = rf(_a0, _a1)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// EditComment provides a mock function with given fields: ctx, edit, actor
+func (_m *Storer) EditComment(ctx context.Context, edit model.CustomEdit, actor string) (model.CustomComment, error) {
+	ret := _m.Called(ctx, edit, actor)
+
+	if len(ret) == 0 {
+		panic("no return value specified for EditComment")
+	}
+
+	var r0 model.CustomComment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, model.CustomEdit, string) (model.CustomComment, error)); ok {
+		return rf(ctx, edit, actor)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, model.CustomEdit, string) model.CustomComment); ok {
+		r0 = rf(ctx, edit, actor)
+	} else {
+		r0 = ret.Get(0).(model.CustomComment)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, model.CustomEdit, string) error); ok {
+		r1 = rf(ctx, edit, actor)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// EditPost provides a mock function with given fields: ctx, edit, actor
+func (_m *Storer) EditPost(ctx context.Context, edit model.CustomEdit, actor string) (model.CustomPost, error) {
+	ret := _m.Called(ctx, edit, actor)
+
+	if len(ret) == 0 {
+		panic("no return value specified for EditPost")
+	}
+
+	var r0 model.CustomPost
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, model.CustomEdit, string) (model.CustomPost, error)); ok {
+		return rf(ctx, edit, actor)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, model.CustomEdit, string) model.CustomPost); ok {
+		r0 = rf(ctx, edit, actor)
+	} else {
+		r0 = ret.Get(0).(model.CustomPost)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, model.CustomEdit, string) error); ok {
+		r1 = rf(ctx, edit, actor)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetActiveBan provides a mock function with given fields: ctx, author
+func (_m *Storer) GetActiveBan(ctx context.Context, author string) (model.CustomBan, error) {
+	ret := _m.Called(ctx, author)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetActiveBan")
+	}
+
+	var r0 model.CustomBan
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (model.CustomBan, error)); ok {
+		return rf(ctx, author)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) model.CustomBan); ok {
+		r0 = rf(ctx, author)
+	} else {
+		r0 = ret.Get(0).(model.CustomBan)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, author)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetActiveBans provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Storer) GetActiveBans(_a0 context.Context, _a1 int, _a2 int) ([]model.CustomBan, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetActiveBans")
+	}
+
+	var r0 []model.CustomBan
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) ([]model.CustomBan, error)); ok {
+		return rf(_a0, _a1, _a2)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) []model.CustomBan); ok {
+		r0 = rf(_a0, _a1, _a2)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]model.CustomComment)
+			r0 = ret.Get(0).([]model.CustomBan)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
-		r1 = rf(_a0, _a1)
+	if rf, ok := ret.Get(1).(func(context.Context, int, int) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAttachments provides a mock function with given fields: ctx, targetType, targetIDs
+func (_m *Storer) GetAttachments(ctx context.Context, targetType string, targetIDs []int) (map[int][]model.CustomAttachment, error) {
+	ret := _m.Called(ctx, targetType, targetIDs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAttachments")
+	}
+
+	var r0 map[int][]model.CustomAttachment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []int) (map[int][]model.CustomAttachment, error)); ok {
+		return rf(ctx, targetType, targetIDs)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, []int) map[int][]model.CustomAttachment); ok {
+		r0 = rf(ctx, targetType, targetIDs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[int][]model.CustomAttachment)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, []int) error); ok {
+		r1 = rf(ctx, targetType, targetIDs)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -100,28 +281,28 @@ func (_m *Storer) GetCommentReplies(_a0 context.Context, _a1 int) ([]model.Custo
 	return r0, r1
 }
 
-// GetCommentsByPost provides a mock function with given fields: _a0, _a1, _a2, _a3
-func (_m *Storer) GetCommentsByPost(_a0 context.Context, _a1 int, _a2 int, _a3 int) ([]model.CustomComment, error) {
+// GetAuditLog provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *Storer) GetAuditLog(_a0 context.Context, _a1 model.CustomAuditFilter, _a2 int, _a3 int) ([]model.CustomAuditEntry, error) {
 	ret := _m.Called(_a0, _a1, _a2, _a3)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetCommentsByPost")
+		panic("no return value specified for GetAuditLog")
 	}
 
-	var r0 []model.CustomComment
+	var r0 []model.CustomAuditEntry
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, int, int, int) ([]model.CustomComment, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, model.CustomAuditFilter, int, int) ([]model.CustomAuditEntry, error)); ok {
 		return rf(_a0, _a1, _a2, _a3)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, int, int, int) []model.CustomComment); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, model.CustomAuditFilter, int, int) []model.CustomAuditEntry); ok {
 		r0 = rf(_a0, _a1, _a2, _a3)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]model.CustomComment)
+			r0 = ret.Get(0).([]model.CustomAuditEntry)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, int, int, int) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, model.CustomAuditFilter, int, int) error); ok {
 		r1 = rf(_a0, _a1, _a2, _a3)
 	} else {
 		r1 = ret.Error(1)
@@ -130,23 +311,23 @@ func (_m *Storer) GetCommentsByPost(_a0 context.Context, _a1 int, _a2 int, _a3 i
 	return r0, r1
 }
 
-// GetPostByID provides a mock function with given fields: _a0, _a1
-func (_m *Storer) GetPostByID(_a0 context.Context, _a1 int) (model.CustomPost, error) {
+// GetAuthorByID provides a mock function with given fields: _a0, _a1
+func (_m *Storer) GetAuthorByID(_a0 context.Context, _a1 int) (model.CustomAuthor, error) {
 	ret := _m.Called(_a0, _a1)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetPostByID")
+		panic("no return value specified for GetAuthorByID")
 	}
 
-	var r0 model.CustomPost
+	var r0 model.CustomAuthor
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, int) (model.CustomPost, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, int) (model.CustomAuthor, error)); ok {
 		return rf(_a0, _a1)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, int) model.CustomPost); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, int) model.CustomAuthor); ok {
 		r0 = rf(_a0, _a1)
 	} else {
-		r0 = ret.Get(0).(model.CustomPost)
+		r0 = ret.Get(0).(model.CustomAuthor)
 	}
 
 	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
@@ -158,29 +339,948 @@ func (_m *Storer) GetPostByID(_a0 context.Context, _a1 int) (model.CustomPost, e
 	return r0, r1
 }
 
-// GetPosts provides a mock function with given fields: _a0
-func (_m *Storer) GetPosts(_a0 context.Context) ([]model.CustomPost, error) {
-	ret := _m.Called(_a0)
+// GetAuthorByName provides a mock function with given fields: _a0, _a1
+func (_m *Storer) GetAuthorByName(_a0 context.Context, _a1 string) (model.CustomAuthor, error) {
+	ret := _m.Called(_a0, _a1)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetPosts")
+		panic("no return value specified for GetAuthorByName")
 	}
 
-	var r0 []model.CustomPost
+	var r0 model.CustomAuthor
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (model.CustomAuthor, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) model.CustomAuthor); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(model.CustomAuthor)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAuthorStats provides a mock function with given fields: ctx, id, viewer
+func (_m *Storer) GetAuthorStats(ctx context.Context, id int, viewer string) (model.AuthorStats, error) {
+	ret := _m.Called(ctx, id, viewer)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAuthorStats")
+	}
+
+	var r0 model.AuthorStats
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string) (model.AuthorStats, error)); ok {
+		return rf(ctx, id, viewer)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, string) model.AuthorStats); ok {
+		r0 = rf(ctx, id, viewer)
+	} else {
+		r0 = ret.Get(0).(model.AuthorStats)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, string) error); ok {
+		r1 = rf(ctx, id, viewer)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAuthorStatsByIDs provides a mock function with given fields: ctx, ids, viewer
+func (_m *Storer) GetAuthorStatsByIDs(ctx context.Context, ids []int, viewer string) (map[int]model.AuthorStats, error) {
+	ret := _m.Called(ctx, ids, viewer)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAuthorStatsByIDs")
+	}
+
+	var r0 map[int]model.AuthorStats
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context) ([]model.CustomPost, error)); ok {
-		return rf(_a0)
+	if rf, ok := ret.Get(0).(func(context.Context, []int, string) (map[int]model.AuthorStats, error)); ok {
+		return rf(ctx, ids, viewer)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context) []model.CustomPost); ok {
-		r0 = rf(_a0)
+	if rf, ok := ret.Get(0).(func(context.Context, []int, string) map[int]model.AuthorStats); ok {
+		r0 = rf(ctx, ids, viewer)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]model.CustomPost)
+			r0 = ret.Get(0).(map[int]model.AuthorStats)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []int, string) error); ok {
+		r1 = rf(ctx, ids, viewer)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetCommentByID provides a mock function with given fields: _a0, _a1
+func (_m *Storer) GetCommentByID(_a0 context.Context, _a1 int) (model.CustomComment, error) {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCommentByID")
+	}
+
+	var r0 model.CustomComment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (model.CustomComment, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) model.CustomComment); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(model.CustomComment)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetCommentReplies provides a mock function with given fields: ctx, parentID, viewer
+func (_m *Storer) GetCommentReplies(ctx context.Context, parentID int, viewer string) ([]model.CustomComment, error) {
+	ret := _m.Called(ctx, parentID, viewer)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCommentReplies")
+	}
+
+	var r0 []model.CustomComment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string) ([]model.CustomComment, error)); ok {
+		return rf(ctx, parentID, viewer)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, string) []model.CustomComment); ok {
+		r0 = rf(ctx, parentID, viewer)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.CustomComment)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, string) error); ok {
+		r1 = rf(ctx, parentID, viewer)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetCommentsByAuthor provides a mock function with given fields: ctx, authorID, offset, limit, viewer
+func (_m *Storer) GetCommentsByAuthor(ctx context.Context, authorID int, offset int, limit int, viewer string) ([]model.CustomComment, error) {
+	ret := _m.Called(ctx, authorID, offset, limit, viewer)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCommentsByAuthor")
+	}
+
+	var r0 []model.CustomComment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, int, string) ([]model.CustomComment, error)); ok {
+		return rf(ctx, authorID, offset, limit, viewer)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, int, string) []model.CustomComment); ok {
+		r0 = rf(ctx, authorID, offset, limit, viewer)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.CustomComment)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int, int, string) error); ok {
+		r1 = rf(ctx, authorID, offset, limit, viewer)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetCommentsByPost provides a mock function with given fields: ctx, postID, offset, limit, viewer
+func (_m *Storer) GetCommentsByPost(ctx context.Context, postID int, offset int, limit int, viewer string) ([]model.CustomComment, error) {
+	ret := _m.Called(ctx, postID, offset, limit, viewer)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCommentsByPost")
+	}
+
+	var r0 []model.CustomComment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, int, string) ([]model.CustomComment, error)); ok {
+		return rf(ctx, postID, offset, limit, viewer)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, int, string) []model.CustomComment); ok {
+		r0 = rf(ctx, postID, offset, limit, viewer)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.CustomComment)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
-		r1 = rf(_a0)
+	if rf, ok := ret.Get(1).(func(context.Context, int, int, int, string) error); ok {
+		r1 = rf(ctx, postID, offset, limit, viewer)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetCommentsByPostIDs provides a mock function with given fields: ctx, postIDs, offset, limit, viewer
+func (_m *Storer) GetCommentsByPostIDs(ctx context.Context, postIDs []int, offset int, limit int, viewer string) ([]model.CustomComment, error) {
+	ret := _m.Called(ctx, postIDs, offset, limit, viewer)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCommentsByPostIDs")
+	}
+
+	var r0 []model.CustomComment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []int, int, int, string) ([]model.CustomComment, error)); ok {
+		return rf(ctx, postIDs, offset, limit, viewer)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []int, int, int, string) []model.CustomComment); ok {
+		r0 = rf(ctx, postIDs, offset, limit, viewer)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.CustomComment)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []int, int, int, string) error); ok {
+		r1 = rf(ctx, postIDs, offset, limit, viewer)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetDrafts provides a mock function with given fields: ctx, author, offset, limit
+func (_m *Storer) GetDrafts(ctx context.Context, author string, offset int, limit int) ([]model.CustomPost, int, error) {
+	ret := _m.Called(ctx, author, offset, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetDrafts")
+	}
+
+	var r0 []model.CustomPost
+	var r1 int
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int, int) ([]model.CustomPost, int, error)); ok {
+		return rf(ctx, author, offset, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int, int) []model.CustomPost); ok {
+		r0 = rf(ctx, author, offset, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.CustomPost)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int, int) int); ok {
+		r1 = rf(ctx, author, offset, limit)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, string, int, int) error); ok {
+		r2 = rf(ctx, author, offset, limit)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetLatestComments provides a mock function with given fields: ctx, postID, limit
+func (_m *Storer) GetLatestComments(ctx context.Context, postID int, limit int) ([]model.CustomComment, error) {
+	ret := _m.Called(ctx, postID, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetLatestComments")
+	}
+
+	var r0 []model.CustomComment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) ([]model.CustomComment, error)); ok {
+		return rf(ctx, postID, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) []model.CustomComment); ok {
+		r0 = rf(ctx, postID, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.CustomComment)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int) error); ok {
+		r1 = rf(ctx, postID, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetLatestPosts provides a mock function with given fields: ctx, limit
+func (_m *Storer) GetLatestPosts(ctx context.Context, limit int) ([]model.CustomPost, error) {
+	ret := _m.Called(ctx, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetLatestPosts")
+	}
+
+	var r0 []model.CustomPost
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) ([]model.CustomPost, error)); ok {
+		return rf(ctx, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) []model.CustomPost); ok {
+		r0 = rf(ctx, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.CustomPost)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPendingContent provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Storer) GetPendingContent(_a0 context.Context, _a1 int, _a2 int) ([]model.CustomContent, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPendingContent")
+	}
+
+	var r0 []model.CustomContent
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) ([]model.CustomContent, error)); ok {
+		return rf(_a0, _a1, _a2)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) []model.CustomContent); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.CustomContent)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPoll provides a mock function with given fields: _a0, _a1
+func (_m *Storer) GetPoll(_a0 context.Context, _a1 int) (model.CustomPoll, error) {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPoll")
+	}
+
+	var r0 model.CustomPoll
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (model.CustomPoll, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) model.CustomPoll); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(model.CustomPoll)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPollsByPostIDs provides a mock function with given fields: _a0, _a1
+func (_m *Storer) GetPollsByPostIDs(_a0 context.Context, _a1 []int) (map[int]model.CustomPoll, error) {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPollsByPostIDs")
+	}
+
+	var r0 map[int]model.CustomPoll
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []int) (map[int]model.CustomPoll, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []int) map[int]model.CustomPoll); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[int]model.CustomPoll)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []int) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPostByID provides a mock function with given fields: _a0, _a1
+func (_m *Storer) GetPostByID(_a0 context.Context, _a1 int) (model.CustomPost, error) {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPostByID")
+	}
+
+	var r0 model.CustomPost
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (model.CustomPost, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) model.CustomPost); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(model.CustomPost)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPosts provides a mock function with given fields: ctx, viewer
+func (_m *Storer) GetPosts(ctx context.Context, viewer string) ([]model.CustomPost, error) {
+	ret := _m.Called(ctx, viewer)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPosts")
+	}
+
+	var r0 []model.CustomPost
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]model.CustomPost, error)); ok {
+		return rf(ctx, viewer)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []model.CustomPost); ok {
+		r0 = rf(ctx, viewer)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.CustomPost)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, viewer)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPostsByAuthor provides a mock function with given fields: ctx, authorID, offset, limit, viewer
+func (_m *Storer) GetPostsByAuthor(ctx context.Context, authorID int, offset int, limit int, viewer string) ([]model.CustomPost, error) {
+	ret := _m.Called(ctx, authorID, offset, limit, viewer)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPostsByAuthor")
+	}
+
+	var r0 []model.CustomPost
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, int, string) ([]model.CustomPost, error)); ok {
+		return rf(ctx, authorID, offset, limit, viewer)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, int, string) []model.CustomPost); ok {
+		r0 = rf(ctx, authorID, offset, limit, viewer)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.CustomPost)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int, int, string) error); ok {
+		r1 = rf(ctx, authorID, offset, limit, viewer)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPostsByIDs provides a mock function with given fields: _a0, _a1
+func (_m *Storer) GetPostsByIDs(_a0 context.Context, _a1 []int) ([]model.CustomPost, error) {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPostsByIDs")
+	}
+
+	var r0 []model.CustomPost
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []int) ([]model.CustomPost, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []int) []model.CustomPost); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.CustomPost)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []int) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRepliesByParentIDs provides a mock function with given fields: ctx, parentIDs, viewer
+func (_m *Storer) GetRepliesByParentIDs(ctx context.Context, parentIDs []int, viewer string) ([]model.CustomComment, error) {
+	ret := _m.Called(ctx, parentIDs, viewer)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetRepliesByParentIDs")
+	}
+
+	var r0 []model.CustomComment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []int, string) ([]model.CustomComment, error)); ok {
+		return rf(ctx, parentIDs, viewer)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []int, string) []model.CustomComment); ok {
+		r0 = rf(ctx, parentIDs, viewer)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.CustomComment)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []int, string) error); ok {
+		r1 = rf(ctx, parentIDs, viewer)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetReportGroups provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Storer) GetReportGroups(_a0 context.Context, _a1 int, _a2 int) ([]model.CustomReportGroup, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetReportGroups")
+	}
+
+	var r0 []model.CustomReportGroup
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) ([]model.CustomReportGroup, error)); ok {
+		return rf(_a0, _a1, _a2)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) []model.CustomReportGroup); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.CustomReportGroup)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRevisions provides a mock function with given fields: ctx, targetType, targetIDs
+func (_m *Storer) GetRevisions(ctx context.Context, targetType string, targetIDs []int) (map[int][]model.CustomRevision, error) {
+	ret := _m.Called(ctx, targetType, targetIDs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetRevisions")
+	}
+
+	var r0 map[int][]model.CustomRevision
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []int) (map[int][]model.CustomRevision, error)); ok {
+		return rf(ctx, targetType, targetIDs)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, []int) map[int][]model.CustomRevision); ok {
+		r0 = rf(ctx, targetType, targetIDs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[int][]model.CustomRevision)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, []int) error); ok {
+		r1 = rf(ctx, targetType, targetIDs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LiftBan provides a mock function with given fields: ctx, author, liftedBy
+func (_m *Storer) LiftBan(ctx context.Context, author string, liftedBy string) (model.CustomBan, error) {
+	ret := _m.Called(ctx, author, liftedBy)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LiftBan")
+	}
+
+	var r0 model.CustomBan
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (model.CustomBan, error)); ok {
+		return rf(ctx, author, liftedBy)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) model.CustomBan); ok {
+		r0 = rf(ctx, author, liftedBy)
+	} else {
+		r0 = ret.Get(0).(model.CustomBan)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, author, liftedBy)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MarkDelivered provides a mock function with given fields: ctx, sink, ids
+func (_m *Storer) MarkDelivered(ctx context.Context, sink string, ids []int64) error {
+	ret := _m.Called(ctx, sink, ids)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MarkDelivered")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []int64) error); ok {
+		r0 = rf(ctx, sink, ids)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MarkFailed provides a mock function with given fields: ctx, sink, failure
+func (_m *Storer) MarkFailed(ctx context.Context, sink string, failure outbox.Failure) error {
+	ret := _m.Called(ctx, sink, failure)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MarkFailed")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, outbox.Failure) error); ok {
+		r0 = rf(ctx, sink, failure)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// PendingEvents provides a mock function with given fields: ctx, sink, now, limit
+func (_m *Storer) PendingEvents(ctx context.Context, sink string, now time.Time, limit int) ([]outbox.Event, error) {
+	ret := _m.Called(ctx, sink, now, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PendingEvents")
+	}
+
+	var r0 []outbox.Event
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time, int) ([]outbox.Event, error)); ok {
+		return rf(ctx, sink, now, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time, int) []outbox.Event); ok {
+		r0 = rf(ctx, sink, now, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]outbox.Event)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, time.Time, int) error); ok {
+		r1 = rf(ctx, sink, now, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PinPost provides a mock function with given fields: ctx, id, until, actor
+func (_m *Storer) PinPost(ctx context.Context, id int, until *time.Time, actor string) (model.CustomPost, error) {
+	ret := _m.Called(ctx, id, until, actor)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PinPost")
+	}
+
+	var r0 model.CustomPost
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, *time.Time, string) (model.CustomPost, error)); ok {
+		return rf(ctx, id, until, actor)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, *time.Time, string) model.CustomPost); ok {
+		r0 = rf(ctx, id, until, actor)
+	} else {
+		r0 = ret.Get(0).(model.CustomPost)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, *time.Time, string) error); ok {
+		r1 = rf(ctx, id, until, actor)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PublishDuePosts provides a mock function with given fields: ctx, now
+func (_m *Storer) PublishDuePosts(ctx context.Context, now time.Time) ([]model.CustomPost, error) {
+	ret := _m.Called(ctx, now)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PublishDuePosts")
+	}
+
+	var r0 []model.CustomPost
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) ([]model.CustomPost, error)); ok {
+		return rf(ctx, now)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) []model.CustomPost); ok {
+		r0 = rf(ctx, now)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.CustomPost)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(ctx, now)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PublishPost provides a mock function with given fields: ctx, id, status, reason, publishAt
+func (_m *Storer) PublishPost(ctx context.Context, id int, status model.ContentStatus, reason string, publishAt time.Time) (model.CustomPost, error) {
+	ret := _m.Called(ctx, id, status, reason, publishAt)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PublishPost")
+	}
+
+	var r0 model.CustomPost
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, model.ContentStatus, string, time.Time) (model.CustomPost, error)); ok {
+		return rf(ctx, id, status, reason, publishAt)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, model.ContentStatus, string, time.Time) model.CustomPost); ok {
+		r0 = rf(ctx, id, status, reason, publishAt)
+	} else {
+		r0 = ret.Get(0).(model.CustomPost)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, model.ContentStatus, string, time.Time) error); ok {
+		r1 = rf(ctx, id, status, reason, publishAt)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ResolveReports provides a mock function with given fields: ctx, targetType, targetID, resolvedBy, action, change
+func (_m *Storer) ResolveReports(ctx context.Context, targetType string, targetID int, resolvedBy string, action model.ReportAction, change model.CustomStatusChange) ([]model.CustomReport, error) {
+	ret := _m.Called(ctx, targetType, targetID, resolvedBy, action, change)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ResolveReports")
+	}
+
+	var r0 []model.CustomReport
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int, string, model.ReportAction, model.CustomStatusChange) ([]model.CustomReport, error)); ok {
+		return rf(ctx, targetType, targetID, resolvedBy, action, change)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int, string, model.ReportAction, model.CustomStatusChange) []model.CustomReport); ok {
+		r0 = rf(ctx, targetType, targetID, resolvedBy, action, change)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.CustomReport)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int, string, model.ReportAction, model.CustomStatusChange) error); ok {
+		r1 = rf(ctx, targetType, targetID, resolvedBy, action, change)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetCommentStatus provides a mock function with given fields: ctx, id, status, reason, actor
+func (_m *Storer) SetCommentStatus(ctx context.Context, id int, status model.ContentStatus, reason string, actor string) (model.CustomComment, error) {
+	ret := _m.Called(ctx, id, status, reason, actor)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetCommentStatus")
+	}
+
+	var r0 model.CustomComment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, model.ContentStatus, string, string) (model.CustomComment, error)); ok {
+		return rf(ctx, id, status, reason, actor)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, model.ContentStatus, string, string) model.CustomComment); ok {
+		r0 = rf(ctx, id, status, reason, actor)
+	} else {
+		r0 = ret.Get(0).(model.CustomComment)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, model.ContentStatus, string, string) error); ok {
+		r1 = rf(ctx, id, status, reason, actor)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetPostStatus provides a mock function with given fields: ctx, id, status, reason, actor
+func (_m *Storer) SetPostStatus(ctx context.Context, id int, status model.ContentStatus, reason string, actor string) (model.CustomPost, error) {
+	ret := _m.Called(ctx, id, status, reason, actor)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetPostStatus")
+	}
+
+	var r0 model.CustomPost
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, model.ContentStatus, string, string) (model.CustomPost, error)); ok {
+		return rf(ctx, id, status, reason, actor)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, model.ContentStatus, string, string) model.CustomPost); ok {
+		r0 = rf(ctx, id, status, reason, actor)
+	} else {
+		r0 = ret.Get(0).(model.CustomPost)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, model.ContentStatus, string, string) error); ok {
+		r1 = rf(ctx, id, status, reason, actor)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UnpinPost provides a mock function with given fields: ctx, id, actor
+func (_m *Storer) UnpinPost(ctx context.Context, id int, actor string) (model.CustomPost, error) {
+	ret := _m.Called(ctx, id, actor)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UnpinPost")
+	}
+
+	var r0 model.CustomPost
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string) (model.CustomPost, error)); ok {
+		return rf(ctx, id, actor)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, string) model.CustomPost); ok {
+		r0 = rf(ctx, id, actor)
+	} else {
+		r0 = ret.Get(0).(model.CustomPost)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, string) error); ok {
+		r1 = rf(ctx, id, actor)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Vote provides a mock function with given fields: _a0, _a1
+func (_m *Storer) Vote(_a0 context.Context, _a1 model.CustomBallot) (model.CustomPoll, error) {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Vote")
+	}
+
+	var r0 model.CustomPoll
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, model.CustomBallot) (model.CustomPoll, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, model.CustomBallot) model.CustomPoll); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(model.CustomPoll)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, model.CustomBallot) error); ok {
+		r1 = rf(_a0, _a1)
 	} else {
 		r1 = ret.Error(1)
 	}
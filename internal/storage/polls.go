@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"slices"
+	"sort"
+
+	"github.com/erknas/forum/graph/model"
+)
+
+const pollColumns = `id, post_id, question, multiple_choice, anonymous, closes_at, created_at`
+
+// ballotRow is a single option chosen by a voter, as the SQL storages keep
+// ballots.
+type ballotRow struct {
+	pollID   int
+	optionID int
+	model.CustomBallot
+}
+
+// groupBallots gathers the options chosen by each voter into ballots, by poll
+// and voter.
+func groupBallots(rows []ballotRow) map[int]map[string]model.CustomBallot {
+	byPoll := make(map[int]map[string]model.CustomBallot)
+
+	for _, row := range rows {
+		if byPoll[row.pollID] == nil {
+			byPoll[row.pollID] = make(map[string]model.CustomBallot)
+		}
+
+		ballot, ok := byPoll[row.pollID][row.Voter]
+		if !ok {
+			ballot = model.CustomBallot{PollID: row.pollID, Voter: row.Voter, CreatedAt: row.CreatedAt}
+		}
+		ballot.OptionIDs = append(ballot.OptionIDs, row.optionID)
+		byPoll[row.pollID][row.Voter] = ballot
+	}
+
+	return byPoll
+}
+
+// tally counts the ballots of poll. Voters are listed in the order they
+// voted, unless the poll is anonymous.
+func tally(poll model.CustomPoll, ballots map[string]model.CustomBallot) model.CustomPoll {
+	ordered := make([]model.CustomBallot, 0, len(ballots))
+	for _, ballot := range ballots {
+		if len(ballot.OptionIDs) > 0 {
+			ordered = append(ordered, ballot)
+		}
+	}
+
+	sort.Slice(ordered, func(i, j int) bool {
+		if !ordered[i].CreatedAt.Equal(ordered[j].CreatedAt) {
+			return ordered[i].CreatedAt.Before(ordered[j].CreatedAt)
+		}
+		return ordered[i].Voter < ordered[j].Voter
+	})
+
+	options := make([]model.CustomPollOption, len(poll.Options))
+	for i, option := range poll.Options {
+		options[i] = model.CustomPollOption{ID: option.ID, Text: option.Text}
+	}
+
+	for _, ballot := range ordered {
+		for i := range options {
+			if !slices.Contains(ballot.OptionIDs, options[i].ID) {
+				continue
+			}
+
+			options[i].Votes++
+			if !poll.Anonymous {
+				options[i].Voters = append(options[i].Voters, ballot.Voter)
+			}
+		}
+	}
+
+	poll.Options, poll.Voters = options, len(ordered)
+
+	return poll
+}
@@ -3,11 +3,15 @@ package storage
 import (
 	"context"
 	"errors"
-	"fmt"
+	"sort"
+	"strconv"
 	"time"
 
 	"github.com/erknas/forum/graph/model"
 	"github.com/erknas/forum/internal/config"
+	"github.com/erknas/forum/internal/outbox"
+	"github.com/erknas/forum/pkg/apperr"
+	"github.com/erknas/forum/pkg/conv"
 	poolcfg "github.com/erknas/forum/pkg/pool-cfg"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -15,6 +19,19 @@ import (
 
 const ctxTimeout time.Duration = time.Second * 5
 
+const (
+	postColumns       = `post.id, post.title, post.content, post.created_at, post.comments_allowed, post.status, post.status_reason, post.publish_at, post.pinned_by, post.pinned_at, post.pinned_until, post.edited_by, post.edited_at, author.id, author.name, author.joined_at`
+	commentColumns    = `comment.id, comment.content, comment.created_at, comment.post_id, comment.parent_id, comment.status, comment.status_reason, comment.edited_by, comment.edited_at, author.id, author.name, author.joined_at`
+	reportColumns     = `id, target_type, target_id, reporter, reason, details, created_at, resolved_by, resolved_at, action`
+	banColumns        = `id, author, kind, reason, moderator, created_at, expires_at, lifted_by, lifted_at`
+	revisionColumns   = `id, target_type, target_id, version, title, content, editor, created_at`
+	attachmentColumns = `id, target_type, target_id, blob_key, thumbnail_key, filename, content_type, size, width, height, created_at`
+	// pinnedOrder puts the posts pinned at $1 first, most recently pinned
+	// first.
+	pinnedOrder = `CASE WHEN post.pinned_at IS NOT NULL AND (post.pinned_until IS NULL OR post.pinned_until > $1) THEN post.pinned_at END DESC NULLS LAST,
+				   CASE WHEN post.pinned_at IS NOT NULL AND (post.pinned_until IS NULL OR post.pinned_until > $1) THEN post.id END DESC NULLS LAST`
+)
+
 type PostgresPool struct {
 	pool *pgxpool.Pool
 }
@@ -40,360 +57,1626 @@ func NewPostgresPool(ctx context.Context, cfg *config.Config) (*PostgresPool, er
 	return &PostgresPool{pool: pool}, nil
 }
 
-func (p *PostgresPool) CreatePost(ctx context.Context, input model.PostInput) (post model.CustomPost, err error) {
-	var (
-		postID    int
-		createdAt time.Time
-	)
+func (p *PostgresPool) CreatePost(ctx context.Context, input model.CustomPostInput) (post model.CustomPost, err error) {
+	err = pgx.BeginFunc(ctx, p.pool, func(tx pgx.Tx) error {
+		author, err := upsertAuthor(ctx, tx, input.Author)
+		if err != nil {
+			return err
+		}
 
-	tx, err := p.pool.Begin(ctx)
-	if err != nil {
-		return post, err
-	}
+		insertPost := `INSERT INTO post (title, content, comments_allowed, author_id, status, status_reason, publish_at) 
+					   VALUES ($1, $2, $3, $4, $5, $6, $7) 
+					   RETURNING id, created_at`
+
+		post = model.CustomPost{
+			Title:           input.Title,
+			Author:          author,
+			Content:         input.Content,
+			CommentsAllowed: input.CommentsAllowed,
+			Status:          input.Status.OrPublished(),
+			StatusReason:    input.StatusReason,
+			PublishAt:       input.PublishAt,
+		}
 
-	defer func() {
-		if err != nil {
-			_ = tx.Rollback(ctx)
-			return
+		if err := tx.QueryRow(ctx, insertPost, post.Title, post.Content, post.CommentsAllowed, author.ID, post.Status, post.StatusReason, post.PublishAt).Scan(&post.ID, &post.CreatedAt); err != nil {
+			return err
+		}
+
+		if input.Poll != nil {
+			if err := insertPoll(ctx, tx, post, *input.Poll); err != nil {
+				return err
+			}
 		}
 
-		commitErr := tx.Commit(ctx)
-		if commitErr != nil {
-			fmt.Println(commitErr)
-			err = commitErr
+		if err := insertAttachments(ctx, tx, conv.TypePost, post.ID, post.CreatedAt, input.Attachments); err != nil {
+			return err
 		}
-	}()
 
-	exists, err := p.postAuthorExists(ctx, input.Author)
+		return insertPublishedEvent(ctx, tx, outbox.PostCreated, "posts", post.Status, post)
+	})
 	if err != nil {
-		return post, err
+		return model.CustomPost{}, err
 	}
 
-	insertPost := `INSERT INTO post (title, content, comments_allowed, author_id) 
-				   VALUES ($1, $2, $3, $4) 
-				   RETURNING id, created_at`
+	return post, nil
+}
 
-	if !exists {
-		authorID, err := p.insertPostAuthor(ctx, input.Author)
+func (p *PostgresPool) GetPosts(ctx context.Context, viewer string) ([]model.CustomPost, error) {
+	query := `SELECT ` + postColumns + ` FROM post 
+			  JOIN author ON post.author_id = author.id 
+			  WHERE (post.status = 'PUBLISHED' OR (post.status = 'SHADOWED' AND author.name = $2))
+			  ORDER BY ` + pinnedOrder + `, post.created_at, post.id`
+
+	rows, err := p.pool.Query(ctx, query, time.Now(), viewer)
+	if err != nil {
+		return nil, err
+	}
+
+	return collectPosts(rows)
+}
+
+func (p *PostgresPool) GetPostByID(ctx context.Context, id int) (model.CustomPost, error) {
+	query := `SELECT ` + postColumns + ` FROM post 
+			  JOIN author ON post.author_id = author.id 
+			  WHERE post.id = $1`
+
+	post, err := scanPost(p.pool.QueryRow(ctx, query, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return post, apperr.ErrPostNotFound
+	}
+
+	return post, err
+}
+
+func (p *PostgresPool) GetPostsByIDs(ctx context.Context, ids []int) ([]model.CustomPost, error) {
+	query := `SELECT ` + postColumns + ` FROM post
+			  JOIN author ON post.author_id = author.id
+			  WHERE post.id = ANY($1)
+			  ORDER BY post.id`
+
+	rows, err := p.pool.Query(ctx, query, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	return collectPosts(rows)
+}
+
+func (p *PostgresPool) CreateComment(ctx context.Context, input model.CustomCommentInput) (comment model.CustomComment, err error) {
+	err = pgx.BeginFunc(ctx, p.pool, func(tx pgx.Tx) error {
+		allowed, err := isAllowed(ctx, tx, input.PostID, input.Author)
 		if err != nil {
-			return post, err
+			return err
 		}
 
-		if err = tx.QueryRow(ctx, insertPost, input.Title, input.Content, input.CommentsAllowed, authorID).Scan(&postID, &createdAt); err != nil {
-			return post, err
+		if !allowed {
+			return apperr.ErrCommentsDisabled
 		}
-	} else {
-		authorID, err := p.postAuthorID(ctx, input.Author)
+
+		if input.ParentID != nil {
+			exists, err := commentExists(ctx, tx, input.PostID, *input.ParentID, input.Author)
+			if err != nil {
+				return err
+			}
+
+			if !exists {
+				return apperr.ErrCommentNotFound
+			}
+		}
+
+		author, err := upsertAuthor(ctx, tx, input.Author)
 		if err != nil {
-			return post, err
+			return err
 		}
 
-		if err = tx.QueryRow(ctx, insertPost, input.Title, input.Content, input.CommentsAllowed, authorID).Scan(&postID, &createdAt); err != nil {
-			return post, err
+		insertComment := `INSERT INTO comment (content, post_id, parent_id, author_id, status, status_reason) 
+						  VALUES ($1, $2, $3, $4, $5, $6) 
+						  RETURNING id, created_at`
+
+		comment = model.CustomComment{
+			Author:       author,
+			Content:      input.Content,
+			PostID:       input.PostID,
+			ParentID:     input.ParentID,
+			Status:       input.Status.OrPublished(),
+			StatusReason: input.StatusReason,
+		}
+
+		if err := tx.QueryRow(ctx, insertComment, comment.Content, comment.PostID, comment.ParentID, author.ID, comment.Status, comment.StatusReason).Scan(&comment.ID, &comment.CreatedAt); err != nil {
+			return err
+		}
+
+		if err := insertAttachments(ctx, tx, conv.TypeComment, comment.ID, comment.CreatedAt, input.Attachments); err != nil {
+			return err
 		}
-	}
 
-	post = model.CustomPost{
-		ID:              postID,
-		Title:           input.Title,
-		Author:          input.Author,
-		Content:         input.Content,
-		CreatedAt:       createdAt,
-		CommentsAllowed: input.CommentsAllowed,
+		return insertPublishedEvent(ctx, tx, outbox.CommentCreated, strconv.Itoa(comment.PostID), comment.Status, comment)
+	})
+	if err != nil {
+		return model.CustomComment{}, err
 	}
 
-	return post, nil
+	return comment, nil
 }
 
-func (p *PostgresPool) GetPosts(ctx context.Context) ([]model.CustomPost, error) {
-	query := `SELECT post.id, post.title, post.content, post.created_at, post.comments_allowed, post_author.author_name FROM post 
-			  JOIN post_author ON post.author_id = post_author.id 
-			  ORDER BY post.created_at`
+func (p *PostgresPool) GetLatestPosts(ctx context.Context, limit int) ([]model.CustomPost, error) {
+	query := `SELECT ` + postColumns + ` FROM post
+			  JOIN author ON post.author_id = author.id
+			  WHERE post.status = 'PUBLISHED'
+			  ORDER BY post.created_at DESC, post.id DESC
+			  LIMIT $1`
 
-	rows, err := p.pool.Query(ctx, query)
+	rows, err := p.pool.Query(ctx, query, limit)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
-	var posts []model.CustomPost
+	return collectPosts(rows)
+}
 
-	for rows.Next() {
-		post := model.CustomPost{}
-		if err := rows.Scan(&post.ID, &post.Title, &post.Content, &post.CreatedAt, &post.CommentsAllowed, &post.Author); err != nil {
-			return nil, err
-		}
-		posts = append(posts, post)
+func (p *PostgresPool) GetLatestComments(ctx context.Context, postID int, limit int) ([]model.CustomComment, error) {
+	query := `WITH RECURSIVE thread AS (
+				  SELECT id FROM comment WHERE post_id = $1 AND parent_id IS NULL AND status = 'PUBLISHED'
+				  UNION ALL
+				  SELECT comment.id FROM comment JOIN thread ON comment.parent_id = thread.id WHERE comment.status = 'PUBLISHED'
+			  )
+			  SELECT ` + commentColumns + `
+			  FROM comment
+			  JOIN author ON comment.author_id = author.id
+			  WHERE comment.id IN (SELECT id FROM thread)
+			  ORDER BY comment.created_at DESC, comment.id DESC
+			  LIMIT $2`
+
+	rows, err := p.pool.Query(ctx, query, postID, limit)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := rows.Err(); err != nil {
+	comments, err := collectComments(rows)
+	if err != nil {
 		return nil, err
 	}
 
-	return posts, nil
-}
-
-func (p *PostgresPool) GetPostByID(ctx context.Context, id int) (model.CustomPost, error) {
-	query := `SELECT post.id, post.title, post.content, post.created_at, post.comments_allowed, post_author.author_name FROM post 
-			  JOIN post_author ON post.author_id = post_author.id 
-			  WHERE post.id = $1`
-
-	post := model.CustomPost{}
-	if err := p.pool.QueryRow(ctx, query, id).Scan(&post.ID, &post.Title, &post.Content, &post.CreatedAt, &post.CommentsAllowed, &post.Author); err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return post, fmt.Errorf("post not found")
+	if len(comments) == 0 {
+		if err := p.postExists(ctx, postID); err != nil {
+			return nil, err
 		}
-		return post, err
 	}
 
-	return post, nil
+	return comments, nil
 }
 
-func (p *PostgresPool) CreateComment(ctx context.Context, input model.CustomCommentInput) (comment model.CustomComment, err error) {
-	var (
-		commentID int
-		createdAt time.Time
-	)
+func (p *PostgresPool) GetCommentsByPost(ctx context.Context, postID int, offset int, limit int, viewer string) ([]model.CustomComment, error) {
+	query := `SELECT ` + commentColumns + ` 
+			  FROM comment 
+			  JOIN author ON comment.author_id = author.id 
+			  WHERE comment.post_id = $1
+			  AND parent_id IS NULL
+			  AND (comment.status = 'PUBLISHED' OR (comment.status = 'SHADOWED' AND author.name = $4))
+			  ORDER BY comment.created_at, comment.id
+			  LIMIT $2 OFFSET $3`
 
-	tx, err := p.pool.Begin(ctx)
+	rows, err := p.pool.Query(ctx, query, postID, limit, offset, viewer)
 	if err != nil {
-		return comment, err
+		return nil, err
 	}
 
-	defer func() {
-		if err != nil {
-			_ = tx.Rollback(ctx)
-			return
-		}
+	comments, err := collectComments(rows)
+	if err != nil {
+		return nil, err
+	}
 
-		commitErr := tx.Commit(ctx)
-		if commitErr != nil {
-			fmt.Println(commitErr)
-			err = commitErr
+	if len(comments) == 0 {
+		if err := p.postExists(ctx, postID); err != nil {
+			return nil, err
 		}
-	}()
+	}
+
+	return comments, nil
+}
 
-	commentExists, err := p.commentExists(ctx, input.PostID, input.ParentID)
+// GetCommentsByPostIDs returns the same page of top-level comments for every
+// post, ordered by post.
+func (p *PostgresPool) GetCommentsByPostIDs(ctx context.Context, postIDs []int, offset int, limit int, viewer string) ([]model.CustomComment, error) {
+	query := `SELECT ` + commentColumns + `
+			  FROM (
+				SELECT *, ROW_NUMBER() OVER (PARTITION BY post_id ORDER BY created_at, id) AS position
+				FROM comment
+				WHERE post_id = ANY($1)
+				AND parent_id IS NULL
+				AND (status = 'PUBLISHED' OR (status = 'SHADOWED' AND author_id = (SELECT id FROM author WHERE name = $4)))
+			  ) comment
+			  JOIN author ON comment.author_id = author.id
+			  WHERE comment.position > $2 AND comment.position <= $2 + $3
+			  ORDER BY comment.post_id, comment.position`
+
+	rows, err := p.pool.Query(ctx, query, postIDs, offset, limit, viewer)
 	if err != nil {
-		return comment, err
+		return nil, err
 	}
 
-	if !commentExists && input.ParentID != nil {
-		return comment, fmt.Errorf("comment does not exist")
+	return collectComments(rows)
+}
+
+func (p *PostgresPool) GetCommentByID(ctx context.Context, id int) (model.CustomComment, error) {
+	query := `SELECT ` + commentColumns + `
+			  FROM comment
+			  JOIN author ON comment.author_id = author.id
+			  WHERE comment.id = $1`
+
+	comment, err := scanComment(p.pool.QueryRow(ctx, query, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return comment, apperr.ErrCommentNotFound
 	}
 
-	allowed, err := p.isAllowed(ctx, input.PostID)
+	return comment, err
+}
+
+func (p *PostgresPool) GetCommentReplies(ctx context.Context, parentID int, viewer string) ([]model.CustomComment, error) {
+	query := `SELECT ` + commentColumns + ` 
+			  FROM comment 
+			  JOIN author ON comment.author_id = author.id 
+			  WHERE comment.parent_id = $1
+			  AND (comment.status = 'PUBLISHED' OR (comment.status = 'SHADOWED' AND author.name = $2))
+			  ORDER BY comment.created_at, comment.id`
+
+	rows, err := p.pool.Query(ctx, query, parentID, viewer)
 	if err != nil {
-		return comment, err
+		return nil, err
 	}
 
-	if !allowed {
-		return comment, fmt.Errorf("comments not allowed")
-	}
+	return collectComments(rows)
+}
 
-	authorExists, err := p.commentAuthorExists(ctx, input.Author)
+func (p *PostgresPool) GetRepliesByParentIDs(ctx context.Context, parentIDs []int, viewer string) ([]model.CustomComment, error) {
+	query := `SELECT ` + commentColumns + `
+			  FROM comment
+			  JOIN author ON comment.author_id = author.id
+			  WHERE comment.parent_id = ANY($1)
+			  AND (comment.status = 'PUBLISHED' OR (comment.status = 'SHADOWED' AND author.name = $2))
+			  ORDER BY comment.created_at, comment.id`
+
+	rows, err := p.pool.Query(ctx, query, parentIDs, viewer)
 	if err != nil {
-		return comment, err
+		return nil, err
 	}
 
-	insertComment := `INSERT INTO comment (content, post_id, parent_id, author_id) 
-					  VALUES ($1, $2, $3, $4) 
-					  RETURNING id, created_at`
+	return collectComments(rows)
+}
 
-	if !authorExists {
-		authorID, err := p.insertCommentAuthor(ctx, input.Author)
-		if err != nil {
-			return comment, err
-		}
+func (p *PostgresPool) GetAuthorByID(ctx context.Context, id int) (model.CustomAuthor, error) {
+	query := `SELECT id, name, joined_at FROM author WHERE id = $1`
 
-		if err = tx.QueryRow(ctx, insertComment, input.Content, input.PostID, input.ParentID, authorID).Scan(&commentID, &createdAt); err != nil {
-			return comment, err
-		}
-	} else {
-		authorID, err := p.commentAuthorID(ctx, input.Author)
-		if err != nil {
-			return comment, err
+	author := model.CustomAuthor{}
+	if err := p.pool.QueryRow(ctx, query, id).Scan(&author.ID, &author.Name, &author.JoinedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return author, apperr.ErrAuthorNotFound
 		}
+		return author, err
+	}
+
+	return author, nil
+}
 
-		if err = tx.QueryRow(ctx, insertComment, input.Content, input.PostID, input.ParentID, authorID).Scan(&commentID, &createdAt); err != nil {
-			return comment, err
+func (p *PostgresPool) GetAuthorByName(ctx context.Context, name string) (model.CustomAuthor, error) {
+	query := `SELECT id, name, joined_at FROM author WHERE name = $1`
+
+	author := model.CustomAuthor{}
+	if err := p.pool.QueryRow(ctx, query, name).Scan(&author.ID, &author.Name, &author.JoinedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return author, apperr.ErrAuthorNotFound
 		}
+		return author, err
 	}
 
-	comment = model.CustomComment{
-		ID:        commentID,
-		Author:    input.Author,
-		Content:   input.Content,
-		CreatedAt: createdAt,
-		PostID:    input.PostID,
-		ParentID:  input.ParentID,
+	return author, nil
+}
+
+func (p *PostgresPool) GetAuthorStats(ctx context.Context, id int, viewer string) (model.AuthorStats, error) {
+	query := `SELECT (SELECT COUNT(*) FROM post WHERE author_id = $1
+					  AND (status = 'PUBLISHED' OR (status = 'SHADOWED' AND author_id = (SELECT id FROM author WHERE name = $2)))), 
+					 (SELECT COUNT(*) FROM comment WHERE author_id = $1
+					  AND (status = 'PUBLISHED' OR (status = 'SHADOWED' AND author_id = (SELECT id FROM author WHERE name = $2))))`
+
+	stats := model.AuthorStats{}
+	if err := p.pool.QueryRow(ctx, query, id, viewer).Scan(&stats.PostCount, &stats.CommentCount); err != nil {
+		return stats, err
 	}
 
-	return comment, nil
+	return stats, nil
 }
 
-func (p *PostgresPool) GetCommentsByPost(ctx context.Context, postID int, offset int, limit int) ([]model.CustomComment, error) {
-	query := `SELECT comment.id, comment.content, comment.created_at, comment.post_id, comment_author.author_name 
-			  FROM comment 
-			  JOIN comment_author ON comment.author_id = comment_author.id 
-			  WHERE comment.post_id = $1
-			  AND parent_id IS NULL
-			  ORDER BY comment.created_at
-			  LIMIT $2 OFFSET $3`
+func (p *PostgresPool) GetAuthorStatsByIDs(ctx context.Context, ids []int, viewer string) (map[int]model.AuthorStats, error) {
+	query := `SELECT author.id,
+					 (SELECT COUNT(*) FROM post WHERE post.author_id = author.id
+					  AND (post.status = 'PUBLISHED' OR (post.status = 'SHADOWED' AND author.name = $2))),
+					 (SELECT COUNT(*) FROM comment WHERE comment.author_id = author.id
+					  AND (comment.status = 'PUBLISHED' OR (comment.status = 'SHADOWED' AND author.name = $2)))
+			  FROM author
+			  WHERE author.id = ANY($1)`
 
-	rows, err := p.pool.Query(ctx, query, postID, limit, offset)
+	rows, err := p.pool.Query(ctx, query, ids, viewer)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var comments []model.CustomComment
+	stats := make(map[int]model.AuthorStats, len(ids))
 
 	for rows.Next() {
-		comment := model.CustomComment{}
-		if err := rows.Scan(&comment.ID, &comment.Content, &comment.CreatedAt, &comment.PostID, &comment.Author); err != nil {
+		var (
+			id          int
+			authorStats model.AuthorStats
+		)
+		if err := rows.Scan(&id, &authorStats.PostCount, &authorStats.CommentCount); err != nil {
 			return nil, err
 		}
-		comments = append(comments, comment)
+		stats[id] = authorStats
 	}
 
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
 
-	return comments, nil
+	return stats, nil
 }
 
-func (p *PostgresPool) GetCommentReplies(ctx context.Context, parentID int) ([]model.CustomComment, error) {
-	query := `SELECT comment.id, comment.content, comment.created_at, comment.post_id, comment.parent_id, comment_author.author_name 
-			  FROM comment 
-			  JOIN comment_author ON comment.author_id = comment_author.id 
-			  WHERE comment.parent_id = $1
-			  ORDER BY comment.created_at`
+func (p *PostgresPool) GetPostsByAuthor(ctx context.Context, authorID int, offset int, limit int, viewer string) ([]model.CustomPost, error) {
+	query := `SELECT ` + postColumns + ` FROM post 
+			  JOIN author ON post.author_id = author.id 
+			  WHERE post.author_id = $1
+			  AND (post.status = 'PUBLISHED' OR (post.status = 'SHADOWED' AND author.name = $4))
+			  ORDER BY post.created_at DESC, post.id DESC
+			  LIMIT $2 OFFSET $3`
 
-	rows, err := p.pool.Query(ctx, query, parentID)
+	rows, err := p.pool.Query(ctx, query, authorID, limit, offset, viewer)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
-	var comments []model.CustomComment
+	return collectPosts(rows)
+}
+
+func (p *PostgresPool) GetCommentsByAuthor(ctx context.Context, authorID int, offset int, limit int, viewer string) ([]model.CustomComment, error) {
+	query := `SELECT ` + commentColumns + ` 
+			  FROM comment 
+			  JOIN author ON comment.author_id = author.id 
+			  WHERE comment.author_id = $1
+			  AND (comment.status = 'PUBLISHED' OR (comment.status = 'SHADOWED' AND author.name = $4))
+			  ORDER BY comment.created_at DESC, comment.id DESC
+			  LIMIT $2 OFFSET $3`
 
-	for rows.Next() {
-		comment := model.CustomComment{}
-		if err := rows.Scan(&comment.ID, &comment.Content, &comment.CreatedAt, &comment.PostID, &comment.ParentID, &comment.Author); err != nil {
-			return nil, err
-		}
-		comments = append(comments, comment)
+	rows, err := p.pool.Query(ctx, query, authorID, limit, offset, viewer)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := rows.Err(); err != nil {
-		return nil, err
+	return collectComments(rows)
+}
+
+func (p *PostgresPool) SetPostStatus(ctx context.Context, id int, status model.ContentStatus, reason string, actor string) (post model.CustomPost, err error) {
+	err = pgx.BeginFunc(ctx, p.pool, func(tx pgx.Tx) error {
+		post, err = setPostStatus(ctx, tx, id, model.CustomStatusChange{Status: status, Reason: reason}, actor)
+		return err
+	})
+	if err != nil {
+		return model.CustomPost{}, err
 	}
 
-	return comments, nil
+	return post, nil
 }
 
-func (p *PostgresPool) postAuthorExists(ctx context.Context, author string) (bool, error) {
-	var (
-		exists bool
-		query  = `SELECT EXISTS(SELECT 1 FROM post_author WHERE author_name = $1)`
-	)
+func (p *PostgresPool) SetCommentStatus(ctx context.Context, id int, status model.ContentStatus, reason string, actor string) (comment model.CustomComment, err error) {
+	err = pgx.BeginFunc(ctx, p.pool, func(tx pgx.Tx) error {
+		comment, err = setCommentStatus(ctx, tx, id, model.CustomStatusChange{Status: status, Reason: reason}, actor)
+		return err
+	})
+	if err != nil {
+		return model.CustomComment{}, err
+	}
 
-	if err := p.pool.QueryRow(ctx, query, author).Scan(&exists); err != nil {
-		return exists, err
+	return comment, nil
+}
+
+// changeStatus makes change to the status of a post or comment if the change
+// applies to it.
+func changeStatus(ctx context.Context, tx pgx.Tx, targetType string, id int, change model.CustomStatusChange, actor string) error {
+	if change.Status == "" {
+		return nil
 	}
 
-	return exists, nil
+	var err error
+	if targetType == conv.TypePost {
+		_, err = setPostStatus(ctx, tx, id, change, actor)
+	} else {
+		_, err = setCommentStatus(ctx, tx, id, change, actor)
+	}
+	return err
 }
 
-func (p *PostgresPool) postAuthorID(ctx context.Context, author string) (int, error) {
-	var (
-		id    int
-		query = `SELECT id FROM post_author WHERE author_name = $1`
-	)
+// setPostStatus makes change to the status of a post if the change applies to
+// it, and returns the post.
+func setPostStatus(ctx context.Context, tx pgx.Tx, id int, change model.CustomStatusChange, actor string) (model.CustomPost, error) {
+	query := `SELECT ` + postColumns + ` FROM post
+			  JOIN author ON post.author_id = author.id
+			  WHERE post.id = $1 AND post.status NOT IN ('DRAFT', 'SCHEDULED')
+			  FOR UPDATE OF post`
+
+	post, err := scanPost(tx.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.CustomPost{}, apperr.ErrPostNotFound
+		}
+		return model.CustomPost{}, err
+	}
+
+	if !change.AppliesTo(post.Status, post.StatusReason) {
+		return post, nil
+	}
+
+	before := post
+	wasPublished := post.Status == model.ContentStatusPublished
+	post.Status, post.StatusReason = change.Status, change.Reason
+
+	if _, err := tx.Exec(ctx, `UPDATE post SET status = $1, status_reason = $2 WHERE id = $3`, post.Status, post.StatusReason, id); err != nil {
+		return model.CustomPost{}, err
+	}
+
+	if err := insertAudit(ctx, tx, actor, post.Status.AuditAction(), conv.TypePost, id, before, post); err != nil {
+		return model.CustomPost{}, err
+	}
 
-	if err := p.pool.QueryRow(ctx, query, author).Scan(&id); err != nil {
-		return id, err
+	if !wasPublished {
+		if err := insertPublishedEvent(ctx, tx, outbox.PostCreated, "posts", post.Status, post); err != nil {
+			return model.CustomPost{}, err
+		}
 	}
 
-	return id, nil
+	return post, nil
 }
 
-func (p *PostgresPool) insertPostAuthor(ctx context.Context, author string) (int, error) {
-	var (
-		id           int
-		insertAuthor = `INSERT INTO post_author (author_name) VALUES ($1) RETURNING id`
-	)
+// setCommentStatus makes change to the status of a comment if the change
+// applies to it, and returns the comment.
+func setCommentStatus(ctx context.Context, tx pgx.Tx, id int, change model.CustomStatusChange, actor string) (model.CustomComment, error) {
+	query := `SELECT ` + commentColumns + ` FROM comment
+			  JOIN author ON comment.author_id = author.id
+			  WHERE comment.id = $1
+			  FOR UPDATE OF comment`
+
+	comment, err := scanComment(tx.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.CustomComment{}, apperr.ErrCommentNotFound
+		}
+		return model.CustomComment{}, err
+	}
+
+	if !change.AppliesTo(comment.Status, comment.StatusReason) {
+		return comment, nil
+	}
+
+	before := comment
+	wasPublished := comment.Status == model.ContentStatusPublished
+	comment.Status, comment.StatusReason = change.Status, change.Reason
+
+	if _, err := tx.Exec(ctx, `UPDATE comment SET status = $1, status_reason = $2 WHERE id = $3`, comment.Status, comment.StatusReason, id); err != nil {
+		return model.CustomComment{}, err
+	}
+
+	if err := insertAudit(ctx, tx, actor, comment.Status.AuditAction(), conv.TypeComment, id, before, comment); err != nil {
+		return model.CustomComment{}, err
+	}
 
-	if err := p.pool.QueryRow(ctx, insertAuthor, author).Scan(&id); err != nil {
-		return id, err
+	if !wasPublished {
+		if err := insertPublishedEvent(ctx, tx, outbox.CommentCreated, strconv.Itoa(comment.PostID), comment.Status, comment); err != nil {
+			return model.CustomComment{}, err
+		}
 	}
 
-	return id, nil
+	return comment, nil
 }
 
-func (p *PostgresPool) commentAuthorExists(ctx context.Context, author string) (bool, error) {
-	var (
-		exists bool
-		query  = `SELECT EXISTS(SELECT 1 FROM comment_author WHERE author_name = $1)`
-	)
+func (p *PostgresPool) EditPost(ctx context.Context, edit model.CustomEdit, actor string) (post model.CustomPost, err error) {
+	err = pgx.BeginFunc(ctx, p.pool, func(tx pgx.Tx) error {
+		query := `SELECT ` + postColumns + ` FROM post
+				  JOIN author ON post.author_id = author.id
+				  WHERE post.id = $1
+				  FOR UPDATE OF post`
 
-	if err := p.pool.QueryRow(ctx, query, author).Scan(&exists); err != nil {
-		return exists, err
+		post, err = scanPost(tx.QueryRow(ctx, query, edit.ID))
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return apperr.ErrPostNotFound
+			}
+			return err
+		}
+
+		versions, err := countRevisions(ctx, tx, conv.TypePost, post.ID)
+		if err != nil {
+			return err
+		}
+
+		if err := insertRevision(ctx, tx, post.Revision(versions+1)); err != nil {
+			return err
+		}
+
+		before := post
+		post.Title, post.Content, post.EditedBy = edit.Title, edit.Content, edit.Editor
+		if edit.Status != "" {
+			post.Status, post.StatusReason = edit.Status, edit.StatusReason
+		}
+
+		update := `UPDATE post SET title = $1, content = $2, status = $3, status_reason = $4, edited_by = $5, edited_at = NOW()
+				   WHERE id = $6
+				   RETURNING edited_at`
+
+		if err := tx.QueryRow(ctx, update, post.Title, post.Content, post.Status, post.StatusReason, post.EditedBy, post.ID).Scan(&post.EditedAt); err != nil {
+			return err
+		}
+
+		return insertAudit(ctx, tx, actor, model.AuditActionEditContent, conv.TypePost, post.ID, before, post)
+	})
+	if err != nil {
+		return model.CustomPost{}, err
 	}
 
-	return exists, nil
+	return post, nil
 }
 
-func (p *PostgresPool) commentAuthorID(ctx context.Context, author string) (int, error) {
-	var (
-		id    int
-		query = `SELECT id FROM comment_author WHERE author_name = $1`
-	)
+func (p *PostgresPool) EditComment(ctx context.Context, edit model.CustomEdit, actor string) (comment model.CustomComment, err error) {
+	err = pgx.BeginFunc(ctx, p.pool, func(tx pgx.Tx) error {
+		query := `SELECT ` + commentColumns + ` FROM comment
+				  JOIN author ON comment.author_id = author.id
+				  WHERE comment.id = $1
+				  FOR UPDATE OF comment`
+
+		comment, err = scanComment(tx.QueryRow(ctx, query, edit.ID))
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return apperr.ErrCommentNotFound
+			}
+			return err
+		}
+
+		versions, err := countRevisions(ctx, tx, conv.TypeComment, comment.ID)
+		if err != nil {
+			return err
+		}
+
+		if err := insertRevision(ctx, tx, comment.Revision(versions+1)); err != nil {
+			return err
+		}
+
+		before := comment
+		comment.Content, comment.EditedBy = edit.Content, edit.Editor
+		if edit.Status != "" {
+			comment.Status, comment.StatusReason = edit.Status, edit.StatusReason
+		}
+
+		update := `UPDATE comment SET content = $1, status = $2, status_reason = $3, edited_by = $4, edited_at = NOW()
+				   WHERE id = $5
+				   RETURNING edited_at`
 
-	if err := p.pool.QueryRow(ctx, query, author).Scan(&id); err != nil {
-		return id, err
+		if err := tx.QueryRow(ctx, update, comment.Content, comment.Status, comment.StatusReason, comment.EditedBy, comment.ID).Scan(&comment.EditedAt); err != nil {
+			return err
+		}
+
+		return insertAudit(ctx, tx, actor, model.AuditActionEditContent, conv.TypeComment, comment.ID, before, comment)
+	})
+	if err != nil {
+		return model.CustomComment{}, err
 	}
 
-	return id, nil
+	return comment, nil
 }
 
-func (p *PostgresPool) insertCommentAuthor(ctx context.Context, author string) (int, error) {
-	var (
-		id           int
-		insertAuthor = `INSERT INTO comment_author (author_name) VALUES ($1) RETURNING id`
-	)
+// GetRevisions returns the replaced versions of the targets, oldest first.
+func (p *PostgresPool) GetRevisions(ctx context.Context, targetType string, targetIDs []int) (map[int][]model.CustomRevision, error) {
+	query := `SELECT ` + revisionColumns + ` FROM revision
+			  WHERE target_type = $1 AND target_id = ANY($2)
+			  ORDER BY target_id, version`
+
+	rows, err := p.pool.Query(ctx, query, targetType, targetIDs)
+	if err != nil {
+		return nil, err
+	}
 
-	if err := p.pool.QueryRow(ctx, insertAuthor, author).Scan(&id); err != nil {
-		return id, err
+	revisions, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.CustomRevision, error) {
+		revision := model.CustomRevision{}
+		err := row.Scan(&revision.ID, &revision.TargetType, &revision.TargetID, &revision.Version, &revision.Title, &revision.Content, &revision.Editor, &revision.CreatedAt)
+		return revision, err
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return id, nil
+	return groupRevisions(revisions), nil
 }
 
-func (p *PostgresPool) isAllowed(ctx context.Context, id int) (bool, error) {
-	var (
-		allowed bool
-		query   = `SELECT comments_allowed FROM post WHERE id = $1`
-	)
+// GetAttachments returns the attachments of the targets in the order they
+// were given.
+func (p *PostgresPool) GetAttachments(ctx context.Context, targetType string, targetIDs []int) (map[int][]model.CustomAttachment, error) {
+	query := `SELECT ` + attachmentColumns + ` FROM attachment
+			  WHERE target_type = $1 AND target_id = ANY($2)
+			  ORDER BY target_id, id`
 
-	if err := p.pool.QueryRow(ctx, query, id).Scan(&allowed); err != nil {
-		return allowed, err
+	rows, err := p.pool.Query(ctx, query, targetType, targetIDs)
+	if err != nil {
+		return nil, err
 	}
 
-	return allowed, nil
+	attachments, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.CustomAttachment, error) {
+		attachment := model.CustomAttachment{}
+		err := row.Scan(&attachment.ID, &attachment.TargetType, &attachment.TargetID, &attachment.Key, &attachment.ThumbnailKey, &attachment.Filename, &attachment.ContentType, &attachment.Size, &attachment.Width, &attachment.Height, &attachment.CreatedAt)
+		return attachment, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return groupAttachments(attachments), nil
 }
 
-func (p *PostgresPool) commentExists(ctx context.Context, postID int, id *int) (bool, error) {
-	var (
-		exists bool
-		query  = `SELECT EXISTS(SELECT 1 FROM comment WHERE post_id = $1 AND id = $2)`
-	)
+// GetDrafts returns the drafts and scheduled posts of author, newest first.
+func (p *PostgresPool) GetDrafts(ctx context.Context, author string, offset int, limit int) (posts []model.CustomPost, total int, err error) {
+	count := `SELECT COUNT(*) FROM post
+			  JOIN author ON post.author_id = author.id
+			  WHERE author.name = $1 AND post.status IN ('DRAFT', 'SCHEDULED')`
 
-	if err := p.pool.QueryRow(ctx, query, postID, id).Scan(&exists); err != nil {
-		return exists, err
+	if err := p.pool.QueryRow(ctx, count, author).Scan(&total); err != nil {
+		return nil, 0, err
 	}
 
-	return exists, nil
+	query := `SELECT ` + postColumns + ` FROM post
+			  JOIN author ON post.author_id = author.id
+			  WHERE author.name = $1 AND post.status IN ('DRAFT', 'SCHEDULED')
+			  ORDER BY post.created_at DESC, post.id DESC
+			  LIMIT $2 OFFSET $3`
+
+	rows, err := p.pool.Query(ctx, query, author, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	posts, err = collectPosts(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return posts, total, nil
+}
+
+func (p *PostgresPool) PublishPost(ctx context.Context, id int, status model.ContentStatus, reason string, publishAt time.Time) (post model.CustomPost, err error) {
+	err = pgx.BeginFunc(ctx, p.pool, func(tx pgx.Tx) error {
+		query := `SELECT ` + postColumns + ` FROM post
+				  JOIN author ON post.author_id = author.id
+				  WHERE post.id = $1
+				  FOR UPDATE OF post`
+
+		post, err = scanPost(tx.QueryRow(ctx, query, id))
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return apperr.ErrPostNotFound
+			}
+			return err
+		}
+
+		if !post.Status.Draft() {
+			return apperr.ErrNotDraft
+		}
+
+		return publishPost(ctx, tx, &post, status, reason, publishAt)
+	})
+	if err != nil {
+		return model.CustomPost{}, err
+	}
+
+	return post, nil
+}
+
+// PublishDuePosts publishes the scheduled posts due at now, in the order they
+// were due. Posts locked by another instance publishing them are skipped.
+func (p *PostgresPool) PublishDuePosts(ctx context.Context, now time.Time) (posts []model.CustomPost, err error) {
+	err = pgx.BeginFunc(ctx, p.pool, func(tx pgx.Tx) error {
+		query := `SELECT ` + postColumns + ` FROM post
+				  JOIN author ON post.author_id = author.id
+				  WHERE post.status = 'SCHEDULED' AND post.publish_at <= $1
+				  AND NOT EXISTS (
+					SELECT 1 FROM ban
+					WHERE ban.author = author.name AND ban.kind = 'BAN'
+					AND ban.lifted_at IS NULL AND (ban.expires_at IS NULL OR ban.expires_at > $1)
+				  )
+				  ORDER BY post.publish_at, post.id
+				  FOR UPDATE OF post SKIP LOCKED`
+
+		rows, err := tx.Query(ctx, query, now)
+		if err != nil {
+			return err
+		}
+
+		posts, err = collectPosts(rows)
+		if err != nil {
+			return err
+		}
+
+		for i := range posts {
+			status, reason := model.ContentStatusPublished, ""
+
+			shadowed, err := isShadowbanned(ctx, tx, posts[i].Author.Name, now)
+			if err != nil {
+				return err
+			}
+			if shadowed {
+				status, reason = model.ContentStatusShadowed, model.ShadowedReason
+			}
+
+			if err := publishPost(ctx, tx, &posts[i], status, reason, *posts[i].PublishAt); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return posts, nil
+}
+
+func (p *PostgresPool) PinPost(ctx context.Context, id int, until *time.Time, actor string) (post model.CustomPost, err error) {
+	err = pgx.BeginFunc(ctx, p.pool, func(tx pgx.Tx) error {
+		post, err = lockPinnablePost(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		before := post
+		now := time.Now()
+		post.PinnedBy, post.PinnedAt, post.PinnedUntil = actor, &now, until
+
+		return pinPost(ctx, tx, actor, model.AuditActionPinPost, before, post)
+	})
+	if err != nil {
+		return model.CustomPost{}, err
+	}
+
+	return post, nil
+}
+
+func (p *PostgresPool) UnpinPost(ctx context.Context, id int, actor string) (post model.CustomPost, err error) {
+	err = pgx.BeginFunc(ctx, p.pool, func(tx pgx.Tx) error {
+		post, err = lockPinnablePost(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		if !post.Pinned(time.Now()) {
+			return apperr.ErrNotPinned
+		}
+
+		before := post
+		post.PinnedBy, post.PinnedAt, post.PinnedUntil = "", nil, nil
+
+		return pinPost(ctx, tx, actor, model.AuditActionUnpinPost, before, post)
+	})
+	if err != nil {
+		return model.CustomPost{}, err
+	}
+
+	return post, nil
+}
+
+func (p *PostgresPool) GetPoll(ctx context.Context, id int) (model.CustomPoll, error) {
+	polls, err := queryPolls(ctx, p.pool, "id", []int{id})
+	if err != nil {
+		return model.CustomPoll{}, err
+	}
+
+	if len(polls) == 0 {
+		return model.CustomPoll{}, apperr.ErrPollNotFound
+	}
+
+	return polls[0], nil
+}
+
+// GetPollsByPostIDs returns the polls of the posts that have one, by post ID.
+func (p *PostgresPool) GetPollsByPostIDs(ctx context.Context, postIDs []int) (map[int]model.CustomPoll, error) {
+	polls, err := queryPolls(ctx, p.pool, "post_id", postIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	byPost := make(map[int]model.CustomPoll, len(polls))
+	for _, poll := range polls {
+		byPost[poll.PostID] = poll
+	}
+
+	return byPost, nil
+}
+
+func (p *PostgresPool) Vote(ctx context.Context, ballot model.CustomBallot) (poll model.CustomPoll, err error) {
+	err = pgx.BeginFunc(ctx, p.pool, func(tx pgx.Tx) error {
+		// Votes on a poll are serialized so that each event holds the results
+		// after its vote.
+		var closesAt *time.Time
+		if err := tx.QueryRow(ctx, `SELECT closes_at FROM poll WHERE id = $1 FOR UPDATE`, ballot.PollID).Scan(&closesAt); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return apperr.ErrPollNotFound
+			}
+			return err
+		}
+
+		ballot.CreatedAt = time.Now()
+
+		if (model.CustomPoll{ClosesAt: closesAt}).Closed(ballot.CreatedAt) {
+			return apperr.ErrPollClosed
+		}
+
+		var found int
+		if err := tx.QueryRow(ctx, `SELECT COUNT(*) FROM poll_option WHERE poll_id = $1 AND id = ANY($2)`, ballot.PollID, ballot.OptionIDs).Scan(&found); err != nil {
+			return err
+		}
+
+		if found != len(ballot.OptionIDs) {
+			return apperr.ErrInvalidOption
+		}
+
+		if _, err := tx.Exec(ctx, `DELETE FROM ballot WHERE poll_id = $1 AND voter = $2`, ballot.PollID, ballot.Voter); err != nil {
+			return err
+		}
+
+		for _, optionID := range ballot.OptionIDs {
+			insertBallot := `INSERT INTO ballot (poll_id, option_id, voter, created_at) VALUES ($1, $2, $3, $4)`
+
+			if _, err := tx.Exec(ctx, insertBallot, ballot.PollID, optionID, ballot.Voter, ballot.CreatedAt); err != nil {
+				return err
+			}
+		}
+
+		polls, err := queryPolls(ctx, tx, "id", []int{ballot.PollID})
+		if err != nil {
+			return err
+		}
+		poll = polls[0]
+
+		event, err := outbox.NewEvent(outbox.PollUpdated, strconv.Itoa(poll.ID), poll)
+		if err != nil {
+			return err
+		}
+
+		return insertEvent(ctx, tx, event)
+	})
+	if err != nil {
+		return model.CustomPoll{}, err
+	}
+
+	return poll, nil
+}
+
+// GetPendingContent returns pending posts and comments, oldest first.
+func (p *PostgresPool) GetPendingContent(ctx context.Context, offset int, limit int) ([]model.CustomContent, error) {
+	query := `SELECT kind, id FROM (
+				SELECT 'post' AS kind, id, created_at FROM post WHERE status = 'PENDING'
+				UNION ALL
+				SELECT 'comment', id, created_at FROM comment WHERE status = 'PENDING'
+			  ) pending
+			  ORDER BY created_at, kind DESC, id
+			  LIMIT $1 OFFSET $2`
+
+	rows, err := p.pool.Query(ctx, query, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	type pendingItem struct {
+		kind string
+		id   int
+	}
+
+	items, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (pendingItem, error) {
+		item := pendingItem{}
+		err := row.Scan(&item.kind, &item.id)
+		return item, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	content := make([]model.CustomContent, 0, len(items))
+
+	for _, item := range items {
+		if item.kind == "post" {
+			post, err := p.GetPostByID(ctx, item.id)
+			if err != nil {
+				return nil, err
+			}
+			content = append(content, model.CustomContent{Post: &post})
+			continue
+		}
+
+		comment, err := p.GetCommentByID(ctx, item.id)
+		if err != nil {
+			return nil, err
+		}
+		content = append(content, model.CustomContent{Comment: &comment})
+	}
+
+	return content, nil
+}
+
+func (p *PostgresPool) CreateReport(ctx context.Context, input model.CustomReportInput) (report model.CustomReport, open int, err error) {
+	err = pgx.BeginFunc(ctx, p.pool, func(tx pgx.Tx) error {
+		insertReport := `INSERT INTO report (target_type, target_id, reporter, reason, details)
+						 VALUES ($1, $2, $3, $4, $5)
+						 ON CONFLICT (target_type, target_id, reporter) WHERE resolved_at IS NULL DO NOTHING
+						 RETURNING ` + reportColumns
+
+		report, err = scanReport(tx.QueryRow(ctx, insertReport, input.TargetType, input.TargetID, input.Reporter, input.Reason, input.Details))
+		if errors.Is(err, pgx.ErrNoRows) {
+			query := `SELECT ` + reportColumns + ` FROM report
+					  WHERE target_type = $1 AND target_id = $2 AND reporter = $3 AND resolved_at IS NULL`
+
+			report, err = scanReport(tx.QueryRow(ctx, query, input.TargetType, input.TargetID, input.Reporter))
+		}
+		if err != nil {
+			return err
+		}
+
+		count := `SELECT COUNT(*) FROM report WHERE target_type = $1 AND target_id = $2 AND resolved_at IS NULL`
+
+		if err := tx.QueryRow(ctx, count, input.TargetType, input.TargetID).Scan(&open); err != nil {
+			return err
+		}
+
+		if input.HideAt == 0 || open < input.HideAt {
+			return nil
+		}
+
+		return changeStatus(ctx, tx, input.TargetType, input.TargetID, input.Hide, "")
+	})
+	if err != nil {
+		return model.CustomReport{}, 0, err
+	}
+
+	return report, open, nil
+}
+
+// GetReportGroups returns open reports grouped by target, most reported
+// first, then by the oldest report.
+func (p *PostgresPool) GetReportGroups(ctx context.Context, offset int, limit int) ([]model.CustomReportGroup, error) {
+	query := `WITH grouped AS (
+				SELECT target_type, target_id, COUNT(*) AS report_count, MIN(id) AS first_id
+				FROM report
+				WHERE resolved_at IS NULL
+				GROUP BY target_type, target_id
+				ORDER BY report_count DESC, first_id
+				LIMIT $1 OFFSET $2
+			  )
+			  SELECT ` + reportColumns + ` FROM report
+			  JOIN grouped USING (target_type, target_id)
+			  WHERE resolved_at IS NULL
+			  ORDER BY grouped.report_count DESC, grouped.first_id, id`
+
+	rows, err := p.pool.Query(ctx, query, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	reports, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.CustomReport, error) {
+		return scanReport(row)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return groupReports(reports), nil
+}
+
+func (p *PostgresPool) ResolveReports(ctx context.Context, targetType string, targetID int, resolvedBy string, action model.ReportAction, change model.CustomStatusChange) (reports []model.CustomReport, err error) {
+	err = pgx.BeginFunc(ctx, p.pool, func(tx pgx.Tx) error {
+		query := `UPDATE report SET resolved_by = $1, resolved_at = NOW(), action = $2
+				  WHERE target_type = $3 AND target_id = $4 AND resolved_at IS NULL
+				  RETURNING ` + reportColumns
+
+		rows, err := tx.Query(ctx, query, resolvedBy, action, targetType, targetID)
+		if err != nil {
+			return err
+		}
+
+		reports, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.CustomReport, error) {
+			return scanReport(row)
+		})
+		if err != nil {
+			return err
+		}
+
+		if len(reports) == 0 {
+			return apperr.ErrNoOpenReports
+		}
+
+		sortReports(reports)
+
+		if err := insertAudit(ctx, tx, resolvedBy, model.AuditActionResolveReports, targetType, targetID, unresolved(reports), reports); err != nil {
+			return err
+		}
+
+		return changeStatus(ctx, tx, targetType, targetID, change, resolvedBy)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reports, nil
+}
+
+func (p *PostgresPool) CreateBan(ctx context.Context, input model.CustomBanInput) (ban model.CustomBan, err error) {
+	err = pgx.BeginFunc(ctx, p.pool, func(tx pgx.Tx) error {
+		var before any
+
+		query := `SELECT ` + banColumns + ` FROM ban WHERE author = $1 AND lifted_at IS NULL FOR UPDATE`
+
+		previous, err := scanBan(tx.QueryRow(ctx, query, input.Author))
+		switch {
+		case err == nil:
+			before = previous
+
+			if _, err := tx.Exec(ctx, `UPDATE ban SET lifted_by = $1, lifted_at = NOW() WHERE id = $2`, input.Moderator, previous.ID); err != nil {
+				return err
+			}
+		case !errors.Is(err, pgx.ErrNoRows):
+			return err
+		}
+
+		insertBan := `INSERT INTO ban (author, kind, reason, moderator, expires_at)
+					  VALUES ($1, $2, $3, $4, $5)
+					  RETURNING ` + banColumns
+
+		ban, err = scanBan(tx.QueryRow(ctx, insertBan, input.Author, input.Kind, input.Reason, input.Moderator, input.ExpiresAt))
+		if err != nil {
+			return err
+		}
+
+		return insertAudit(ctx, tx, input.Moderator, model.AuditActionBanUser, conv.TypeBan, ban.ID, before, ban)
+	})
+	if err != nil {
+		return model.CustomBan{}, err
+	}
+
+	return ban, nil
+}
+
+func (p *PostgresPool) LiftBan(ctx context.Context, author string, liftedBy string) (ban model.CustomBan, err error) {
+	err = pgx.BeginFunc(ctx, p.pool, func(tx pgx.Tx) error {
+		query := `UPDATE ban SET lifted_by = $1, lifted_at = NOW()
+				  WHERE author = $2 AND lifted_at IS NULL AND (expires_at IS NULL OR expires_at > NOW())
+				  RETURNING ` + banColumns
+
+		ban, err = scanBan(tx.QueryRow(ctx, query, liftedBy, author))
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return apperr.ErrNoActiveBan
+			}
+			return err
+		}
+
+		before := ban
+		before.LiftedBy, before.LiftedAt = "", nil
+
+		return insertAudit(ctx, tx, liftedBy, model.AuditActionUnbanUser, conv.TypeBan, ban.ID, before, ban)
+	})
+	if err != nil {
+		return model.CustomBan{}, err
+	}
+
+	return ban, nil
+}
+
+func (p *PostgresPool) GetActiveBan(ctx context.Context, author string) (model.CustomBan, error) {
+	query := `SELECT ` + banColumns + ` FROM ban
+			  WHERE author = $1 AND lifted_at IS NULL AND (expires_at IS NULL OR expires_at > NOW())`
+
+	ban, err := scanBan(p.pool.QueryRow(ctx, query, author))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.CustomBan{}, apperr.ErrNoActiveBan
+		}
+		return model.CustomBan{}, err
+	}
+
+	return ban, nil
+}
+
+// GetActiveBans returns the bans in force, newest first.
+func (p *PostgresPool) GetActiveBans(ctx context.Context, offset int, limit int) ([]model.CustomBan, error) {
+	query := `SELECT ` + banColumns + ` FROM ban
+			  WHERE lifted_at IS NULL AND (expires_at IS NULL OR expires_at > NOW())
+			  ORDER BY id DESC
+			  LIMIT $1 OFFSET $2`
+
+	rows, err := p.pool.Query(ctx, query, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.CustomBan, error) {
+		return scanBan(row)
+	})
+}
+
+// GetAuditLog returns the entries that match filter, newest first.
+func (p *PostgresPool) GetAuditLog(ctx context.Context, filter model.CustomAuditFilter, offset int, limit int) ([]model.CustomAuditEntry, error) {
+	where, args := auditConditions(filter, func(n int) string { return "$" + strconv.Itoa(n) })
+
+	query := `SELECT id, actor, action, target_type, target_id, before, after, created_at
+			  FROM audit_log ` + where + `
+			  ORDER BY id DESC
+			  LIMIT $` + strconv.Itoa(len(args)+1) + ` OFFSET $` + strconv.Itoa(len(args)+2)
+
+	rows, err := p.pool.Query(ctx, query, append(args, limit, offset)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.CustomAuditEntry, error) {
+		entry := model.CustomAuditEntry{}
+		err := row.Scan(&entry.ID, &entry.Actor, &entry.Action, &entry.TargetType, &entry.TargetID, &entry.Before, &entry.After, &entry.CreatedAt)
+		return entry, err
+	})
+}
+
+func (p *PostgresPool) PendingEvents(ctx context.Context, sink string, now time.Time, limit int) ([]outbox.Event, error) {
+	query := `SELECT outbox.id, outbox.event_id::text, outbox.event_type, outbox.topic, outbox.payload, outbox.created_at, COALESCE(delivery.attempts, 0)
+			  FROM outbox
+			  LEFT JOIN outbox_delivery delivery ON delivery.outbox_id = outbox.id AND delivery.sink = $1
+			  WHERE outbox.delivered_at IS NULL
+			  AND (delivery.outbox_id IS NULL OR (delivery.delivered_at IS NULL AND delivery.failed_at IS NULL AND delivery.retry_at <= $2))
+			  ORDER BY outbox.id
+			  LIMIT $3`
+
+	rows, err := p.pool.Query(ctx, query, sink, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []outbox.Event
+
+	for rows.Next() {
+		event := outbox.Event{}
+		if err := rows.Scan(&event.ID, &event.EventID, &event.Type, &event.Topic, &event.Payload, &event.CreatedAt, &event.Attempts); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+func (p *PostgresPool) MarkDelivered(ctx context.Context, sink string, ids []int64) error {
+	query := `INSERT INTO outbox_delivery (outbox_id, sink, delivered_at)
+			  SELECT id, $1::text, NOW() FROM unnest($2::bigint[]) AS id
+			  ON CONFLICT (sink, outbox_id) DO UPDATE SET delivered_at = EXCLUDED.delivered_at, retry_at = NULL`
+
+	if _, err := p.pool.Exec(ctx, query, sink, ids); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (p *PostgresPool) MarkFailed(ctx context.Context, sink string, failure outbox.Failure) error {
+	var retryAt, failedAt *time.Time
+	if failure.Dead {
+		now := time.Now()
+		failedAt = &now
+	} else {
+		retryAt = &failure.RetryAt
+	}
+
+	query := `INSERT INTO outbox_delivery (outbox_id, sink, attempts, retry_at, failed_at)
+			  VALUES ($1, $2, $3, $4, $5)
+			  ON CONFLICT (sink, outbox_id) DO UPDATE
+			  SET attempts = EXCLUDED.attempts, retry_at = EXCLUDED.retry_at, failed_at = EXCLUDED.failed_at
+			  WHERE outbox_delivery.delivered_at IS NULL`
+
+	if _, err := p.pool.Exec(ctx, query, failure.ID, sink, failure.Attempts, retryAt, failedAt); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// insertPublishedEvent stores the event for content with status, if the
+// content is published.
+func insertPublishedEvent(ctx context.Context, tx pgx.Tx, eventType outbox.EventType, topic string, status model.ContentStatus, payload any) error {
+	if status != model.ContentStatusPublished {
+		return nil
+	}
+
+	event, err := outbox.NewEvent(eventType, topic, payload)
+	if err != nil {
+		return err
+	}
+
+	return insertEvent(ctx, tx, event)
+}
+
+func insertEvent(ctx context.Context, tx pgx.Tx, event outbox.Event) error {
+	query := `INSERT INTO outbox (event_id, event_type, topic, payload, created_at) 
+			  VALUES ($1, $2, $3, $4, $5)`
+
+	if _, err := tx.Exec(ctx, query, event.EventID, string(event.Type), event.Topic, event.Payload, event.CreatedAt); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// insertAudit records the change of an actor, if there is one.
+func insertAudit(ctx context.Context, tx pgx.Tx, actor string, action model.AuditAction, targetType string, targetID int, before any, after any) error {
+	entry, err := newAuditEntry(actor, action, targetType, targetID, before, after)
+	if entry == nil || err != nil {
+		return err
+	}
+
+	query := `INSERT INTO audit_log (actor, action, target_type, target_id, before, after, created_at)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	if _, err := tx.Exec(ctx, query, entry.Actor, entry.Action, entry.TargetType, entry.TargetID, entry.Before, entry.After, entry.CreatedAt); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// publishPost gives a draft its status and publishAt time, which becomes its
+// creation time unless it is scheduled.
+func publishPost(ctx context.Context, tx pgx.Tx, post *model.CustomPost, status model.ContentStatus, reason string, publishAt time.Time) error {
+	post.Status, post.StatusReason, post.PublishAt = status, reason, &publishAt
+	if status != model.ContentStatusScheduled {
+		post.CreatedAt = publishAt
+	}
+
+	update := `UPDATE post SET status = $1, status_reason = $2, publish_at = $3, created_at = $4 WHERE id = $5`
+
+	if _, err := tx.Exec(ctx, update, post.Status, post.StatusReason, publishAt, post.CreatedAt, post.ID); err != nil {
+		return err
+	}
+
+	return insertPublishedEvent(ctx, tx, outbox.PostCreated, "posts", post.Status, *post)
+}
+
+func isShadowbanned(ctx context.Context, tx pgx.Tx, author string, now time.Time) (bool, error) {
+	var (
+		shadowed bool
+		query    = `SELECT EXISTS(SELECT 1 FROM ban
+					WHERE author = $1 AND kind = 'SHADOWBAN'
+					AND lifted_at IS NULL AND (expires_at IS NULL OR expires_at > $2))`
+	)
+
+	if err := tx.QueryRow(ctx, query, author, now).Scan(&shadowed); err != nil {
+		return false, err
+	}
+
+	return shadowed, nil
+}
+
+func countRevisions(ctx context.Context, tx pgx.Tx, targetType string, targetID int) (int, error) {
+	var (
+		count int
+		query = `SELECT COUNT(*) FROM revision WHERE target_type = $1 AND target_id = $2`
+	)
+
+	if err := tx.QueryRow(ctx, query, targetType, targetID).Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// insertRevision keeps the current version of a target before an edit
+// replaces it.
+func insertRevision(ctx context.Context, tx pgx.Tx, revision model.CustomRevision) error {
+	query := `INSERT INTO revision (target_type, target_id, version, title, content, editor, created_at)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	if _, err := tx.Exec(ctx, query, revision.TargetType, revision.TargetID, revision.Version, revision.Title, revision.Content, revision.Editor, revision.CreatedAt); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// upsertAuthor returns the author with the given name, creating the row if
+// needed. DO UPDATE (rather than DO NOTHING) makes RETURNING yield the existing
+// row, so concurrent first writes by a new author do not fail on the UNIQUE
+// constraint.
+func upsertAuthor(ctx context.Context, tx pgx.Tx, name string) (model.CustomAuthor, error) {
+	var (
+		author model.CustomAuthor
+		query  = `INSERT INTO author (name) VALUES ($1) 
+				  ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name 
+				  RETURNING id, name, joined_at`
+	)
+
+	if err := tx.QueryRow(ctx, query, name).Scan(&author.ID, &author.Name, &author.JoinedAt); err != nil {
+		return author, err
+	}
+
+	return author, nil
+}
+
+func (p *PostgresPool) postExists(ctx context.Context, id int) error {
+	var (
+		exists bool
+		query  = `SELECT EXISTS(SELECT 1 FROM post WHERE id = $1)`
+	)
+
+	if err := p.pool.QueryRow(ctx, query, id).Scan(&exists); err != nil {
+		return err
+	}
+
+	if !exists {
+		return apperr.ErrPostNotFound
+	}
+
+	return nil
+}
+
+func isAllowed(ctx context.Context, tx pgx.Tx, id int, viewer string) (bool, error) {
+	var (
+		allowed bool
+		query   = `SELECT post.comments_allowed FROM post
+				   JOIN author ON post.author_id = author.id
+				   WHERE post.id = $1 AND (post.status = 'PUBLISHED' OR (post.status = 'SHADOWED' AND author.name = $2))
+				   FOR SHARE OF post`
+	)
+
+	if err := tx.QueryRow(ctx, query, id, viewer).Scan(&allowed); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return allowed, apperr.ErrPostNotFound
+		}
+		return allowed, err
+	}
+
+	return allowed, nil
+}
+
+func commentExists(ctx context.Context, tx pgx.Tx, postID int, id int, viewer string) (bool, error) {
+	var (
+		exists bool
+		query  = `SELECT EXISTS(SELECT 1 FROM comment
+				  JOIN author ON comment.author_id = author.id
+				  WHERE comment.post_id = $1 AND comment.id = $2
+				  AND (comment.status = 'PUBLISHED' OR (comment.status = 'SHADOWED' AND author.name = $3)))`
+	)
+
+	if err := tx.QueryRow(ctx, query, postID, id, viewer).Scan(&exists); err != nil {
+		return exists, err
+	}
+
+	return exists, nil
+}
+
+// pgQuerier is implemented by the pool and by transactions.
+type pgQuerier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+func insertPoll(ctx context.Context, tx pgx.Tx, post model.CustomPost, input model.CustomPollInput) error {
+	insert := `INSERT INTO poll (post_id, question, multiple_choice, anonymous, closes_at, created_at)
+			   VALUES ($1, $2, $3, $4, $5, $6)
+			   RETURNING id`
+
+	var pollID int
+	if err := tx.QueryRow(ctx, insert, post.ID, input.Question, input.MultipleChoice, input.Anonymous, input.ClosesAt, post.CreatedAt).Scan(&pollID); err != nil {
+		return err
+	}
+
+	for i, text := range input.Options {
+		if _, err := tx.Exec(ctx, `INSERT INTO poll_option (poll_id, position, text) VALUES ($1, $2, $3)`, pollID, i, text); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func insertAttachments(ctx context.Context, tx pgx.Tx, targetType string, targetID int, createdAt time.Time, attachments []model.CustomAttachment) error {
+	insert := `INSERT INTO attachment (target_type, target_id, blob_key, thumbnail_key, filename, content_type, size, width, height, created_at)
+			   VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+
+	for _, a := range attachments {
+		if _, err := tx.Exec(ctx, insert, targetType, targetID, a.Key, a.ThumbnailKey, a.Filename, a.ContentType, a.Size, a.Width, a.Height, createdAt); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// queryPolls returns the polls whose column is one of ids, with their results.
+func queryPolls(ctx context.Context, q pgQuerier, column string, ids []int) ([]model.CustomPoll, error) {
+	rows, err := q.Query(ctx, `SELECT `+pollColumns+` FROM poll WHERE `+column+` = ANY($1) ORDER BY id`, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	polls, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.CustomPoll, error) {
+		poll := model.CustomPoll{}
+		err := row.Scan(&poll.ID, &poll.PostID, &poll.Question, &poll.MultipleChoice, &poll.Anonymous, &poll.ClosesAt, &poll.CreatedAt)
+		return poll, err
+	})
+	if err != nil || len(polls) == 0 {
+		return nil, err
+	}
+
+	pollIDs := make([]int, 0, len(polls))
+	byID := make(map[int]*model.CustomPoll, len(polls))
+
+	for i := range polls {
+		pollIDs = append(pollIDs, polls[i].ID)
+		byID[polls[i].ID] = &polls[i]
+	}
+
+	rows, err = q.Query(ctx, `SELECT id, poll_id, text FROM poll_option WHERE poll_id = ANY($1) ORDER BY poll_id, position`, pollIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		option model.CustomPollOption
+		pollID int
+	)
+
+	_, err = pgx.ForEachRow(rows, []any{&option.ID, &pollID, &option.Text}, func() error {
+		byID[pollID].Options = append(byID[pollID].Options, option)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err = q.Query(ctx, `SELECT poll_id, option_id, voter, created_at FROM ballot WHERE poll_id = ANY($1)`, pollIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	ballots, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (ballotRow, error) {
+		ballot := ballotRow{}
+		err := row.Scan(&ballot.pollID, &ballot.optionID, &ballot.Voter, &ballot.CreatedAt)
+		return ballot, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	byPoll := groupBallots(ballots)
+
+	for i := range polls {
+		polls[i] = tally(polls[i], byPoll[polls[i].ID])
+	}
+
+	return polls, nil
+}
+
+// lockPinnablePost locks a post that is not a draft for a change of its pin.
+func lockPinnablePost(ctx context.Context, tx pgx.Tx, id int) (model.CustomPost, error) {
+	query := `SELECT ` + postColumns + ` FROM post
+			  JOIN author ON post.author_id = author.id
+			  WHERE post.id = $1 AND post.status NOT IN ('DRAFT', 'SCHEDULED')
+			  FOR UPDATE OF post`
+
+	post, err := scanPost(tx.QueryRow(ctx, query, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return post, apperr.ErrPostNotFound
+	}
+
+	return post, err
+}
+
+// pinPost saves the pin of post, or its removal, audited as action.
+func pinPost(ctx context.Context, tx pgx.Tx, actor string, action model.AuditAction, before, post model.CustomPost) error {
+	update := `UPDATE post SET pinned_by = $1, pinned_at = $2, pinned_until = $3 WHERE id = $4`
+
+	if _, err := tx.Exec(ctx, update, post.PinnedBy, post.PinnedAt, post.PinnedUntil, post.ID); err != nil {
+		return err
+	}
+
+	return insertAudit(ctx, tx, actor, action, conv.TypePost, post.ID, before, post)
+}
+
+// scanPost scans a row of postColumns.
+func scanPost(row pgx.Row) (model.CustomPost, error) {
+	post := model.CustomPost{}
+	err := row.Scan(&post.ID, &post.Title, &post.Content, &post.CreatedAt, &post.CommentsAllowed, &post.Status, &post.StatusReason, &post.PublishAt, &post.PinnedBy, &post.PinnedAt, &post.PinnedUntil, &post.EditedBy, &post.EditedAt, &post.Author.ID, &post.Author.Name, &post.Author.JoinedAt)
+	return post, err
+}
+
+// scanComment scans a row of commentColumns.
+func scanComment(row pgx.Row) (model.CustomComment, error) {
+	comment := model.CustomComment{}
+	err := row.Scan(&comment.ID, &comment.Content, &comment.CreatedAt, &comment.PostID, &comment.ParentID, &comment.Status, &comment.StatusReason, &comment.EditedBy, &comment.EditedAt, &comment.Author.ID, &comment.Author.Name, &comment.Author.JoinedAt)
+	return comment, err
+}
+
+// scanReport scans a row of reportColumns.
+func scanReport(row pgx.Row) (model.CustomReport, error) {
+	report := model.CustomReport{}
+	err := row.Scan(&report.ID, &report.TargetType, &report.TargetID, &report.Reporter, &report.Reason, &report.Details, &report.CreatedAt, &report.ResolvedBy, &report.ResolvedAt, &report.Action)
+	return report, err
+}
+
+// scanBan scans a row of banColumns.
+func scanBan(row pgx.Row) (model.CustomBan, error) {
+	ban := model.CustomBan{}
+	err := row.Scan(&ban.ID, &ban.Author, &ban.Kind, &ban.Reason, &ban.Moderator, &ban.CreatedAt, &ban.ExpiresAt, &ban.LiftedBy, &ban.LiftedAt)
+	return ban, err
+}
+
+func collectPosts(rows pgx.Rows) ([]model.CustomPost, error) {
+	posts, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.CustomPost, error) {
+		return scanPost(row)
+	})
+	if err != nil || len(posts) == 0 {
+		return nil, err
+	}
+
+	return posts, nil
+}
+
+func collectComments(rows pgx.Rows) ([]model.CustomComment, error) {
+	comments, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.CustomComment, error) {
+		return scanComment(row)
+	})
+	if err != nil || len(comments) == 0 {
+		return nil, err
+	}
+
+	return comments, nil
+}
+
+// groupReports groups reports that are ordered by target.
+func groupReports(reports []model.CustomReport) []model.CustomReportGroup {
+	var groups []model.CustomReportGroup
+
+	for _, report := range reports {
+		if n := len(groups); n == 0 || groups[n-1].TargetType != report.TargetType || groups[n-1].TargetID != report.TargetID {
+			groups = append(groups, model.CustomReportGroup{TargetType: report.TargetType, TargetID: report.TargetID})
+		}
+
+		last := &groups[len(groups)-1]
+		last.Reports = append(last.Reports, report)
+	}
+
+	return groups
+}
+
+// groupRevisions groups revisions by target ID, keeping their order.
+func groupRevisions(revisions []model.CustomRevision) map[int][]model.CustomRevision {
+	byTarget := make(map[int][]model.CustomRevision)
+	for _, revision := range revisions {
+		byTarget[revision.TargetID] = append(byTarget[revision.TargetID], revision)
+	}
+	return byTarget
+}
+
+// groupAttachments groups attachments by target ID, keeping their order.
+func groupAttachments(attachments []model.CustomAttachment) map[int][]model.CustomAttachment {
+	byTarget := make(map[int][]model.CustomAttachment)
+	for _, attachment := range attachments {
+		byTarget[attachment.TargetID] = append(byTarget[attachment.TargetID], attachment)
+	}
+	return byTarget
+}
+
+func sortReports(reports []model.CustomReport) {
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].ID < reports[j].ID
+	})
 }
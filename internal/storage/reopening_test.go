@@ -0,0 +1,226 @@
+package storage_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/erknas/forum/graph/model"
+	"github.com/erknas/forum/internal/config"
+	"github.com/erknas/forum/internal/outbox"
+	"github.com/erknas/forum/internal/storage"
+	"github.com/stretchr/testify/require"
+)
+
+// reopeningStorage is a durable in-memory storage that crashes and is
+// reopened from its data directory before every call, so that each call
+// sees only what the snapshot and the log kept of the calls before it.
+type reopeningStorage struct {
+	t   *testing.T
+	cfg *config.Config
+	s   *storage.InMemoryStorage
+}
+
+func newReopeningStorage(t *testing.T) *reopeningStorage {
+	r := &reopeningStorage{
+		t:   t,
+		cfg: &config.Config{MemoryConfig: config.MemoryConfig{DataDir: t.TempDir(), Fsync: "never"}},
+	}
+	t.Cleanup(func() {
+		if r.s != nil {
+			_ = r.s.Close()
+		}
+	})
+
+	return r
+}
+
+func (r *reopeningStorage) reopen() *storage.InMemoryStorage {
+	r.t.Helper()
+
+	if r.s != nil {
+		require.NoError(r.t, storage.CrashInMemoryStorage(r.s))
+	}
+
+	s, err := storage.NewDurableInMemoryStorage(context.Background(), r.cfg)
+	require.NoError(r.t, err)
+	r.s = s
+
+	return s
+}
+
+func (r *reopeningStorage) CreatePost(ctx context.Context, input model.CustomPostInput) (model.CustomPost, error) {
+	return r.reopen().CreatePost(ctx, input)
+}
+
+func (r *reopeningStorage) GetPosts(ctx context.Context, viewer string) ([]model.CustomPost, error) {
+	return r.reopen().GetPosts(ctx, viewer)
+}
+
+func (r *reopeningStorage) GetLatestPosts(ctx context.Context, limit int) ([]model.CustomPost, error) {
+	return r.reopen().GetLatestPosts(ctx, limit)
+}
+
+func (r *reopeningStorage) GetLatestComments(ctx context.Context, postID int, limit int) ([]model.CustomComment, error) {
+	return r.reopen().GetLatestComments(ctx, postID, limit)
+}
+
+func (r *reopeningStorage) GetPostByID(ctx context.Context, id int) (model.CustomPost, error) {
+	return r.reopen().GetPostByID(ctx, id)
+}
+
+func (r *reopeningStorage) GetPostsByIDs(ctx context.Context, ids []int) ([]model.CustomPost, error) {
+	return r.reopen().GetPostsByIDs(ctx, ids)
+}
+
+func (r *reopeningStorage) CreateComment(ctx context.Context, input model.CustomCommentInput) (model.CustomComment, error) {
+	return r.reopen().CreateComment(ctx, input)
+}
+
+func (r *reopeningStorage) GetCommentsByPost(ctx context.Context, postID int, offset int, limit int, viewer string) ([]model.CustomComment, error) {
+	return r.reopen().GetCommentsByPost(ctx, postID, offset, limit, viewer)
+}
+
+func (r *reopeningStorage) GetCommentsByPostIDs(ctx context.Context, postIDs []int, offset int, limit int, viewer string) ([]model.CustomComment, error) {
+	return r.reopen().GetCommentsByPostIDs(ctx, postIDs, offset, limit, viewer)
+}
+
+func (r *reopeningStorage) GetCommentByID(ctx context.Context, id int) (model.CustomComment, error) {
+	return r.reopen().GetCommentByID(ctx, id)
+}
+
+func (r *reopeningStorage) GetCommentReplies(ctx context.Context, parentID int, viewer string) ([]model.CustomComment, error) {
+	return r.reopen().GetCommentReplies(ctx, parentID, viewer)
+}
+
+func (r *reopeningStorage) GetRepliesByParentIDs(ctx context.Context, parentIDs []int, viewer string) ([]model.CustomComment, error) {
+	return r.reopen().GetRepliesByParentIDs(ctx, parentIDs, viewer)
+}
+
+func (r *reopeningStorage) GetAuthorByID(ctx context.Context, id int) (model.CustomAuthor, error) {
+	return r.reopen().GetAuthorByID(ctx, id)
+}
+
+func (r *reopeningStorage) GetAuthorByName(ctx context.Context, name string) (model.CustomAuthor, error) {
+	return r.reopen().GetAuthorByName(ctx, name)
+}
+
+func (r *reopeningStorage) GetAuthorStats(ctx context.Context, id int, viewer string) (model.AuthorStats, error) {
+	return r.reopen().GetAuthorStats(ctx, id, viewer)
+}
+
+func (r *reopeningStorage) GetAuthorStatsByIDs(ctx context.Context, ids []int, viewer string) (map[int]model.AuthorStats, error) {
+	return r.reopen().GetAuthorStatsByIDs(ctx, ids, viewer)
+}
+
+func (r *reopeningStorage) GetPostsByAuthor(ctx context.Context, authorID int, offset int, limit int, viewer string) ([]model.CustomPost, error) {
+	return r.reopen().GetPostsByAuthor(ctx, authorID, offset, limit, viewer)
+}
+
+func (r *reopeningStorage) GetCommentsByAuthor(ctx context.Context, authorID int, offset int, limit int, viewer string) ([]model.CustomComment, error) {
+	return r.reopen().GetCommentsByAuthor(ctx, authorID, offset, limit, viewer)
+}
+
+func (r *reopeningStorage) SetPostStatus(ctx context.Context, id int, status model.ContentStatus, reason string, actor string) (model.CustomPost, error) {
+	return r.reopen().SetPostStatus(ctx, id, status, reason, actor)
+}
+
+func (r *reopeningStorage) SetCommentStatus(ctx context.Context, id int, status model.ContentStatus, reason string, actor string) (model.CustomComment, error) {
+	return r.reopen().SetCommentStatus(ctx, id, status, reason, actor)
+}
+
+func (r *reopeningStorage) EditPost(ctx context.Context, edit model.CustomEdit, actor string) (model.CustomPost, error) {
+	return r.reopen().EditPost(ctx, edit, actor)
+}
+
+func (r *reopeningStorage) EditComment(ctx context.Context, edit model.CustomEdit, actor string) (model.CustomComment, error) {
+	return r.reopen().EditComment(ctx, edit, actor)
+}
+
+func (r *reopeningStorage) GetRevisions(ctx context.Context, targetType string, targetIDs []int) (map[int][]model.CustomRevision, error) {
+	return r.reopen().GetRevisions(ctx, targetType, targetIDs)
+}
+
+func (r *reopeningStorage) GetAttachments(ctx context.Context, targetType string, targetIDs []int) (map[int][]model.CustomAttachment, error) {
+	return r.reopen().GetAttachments(ctx, targetType, targetIDs)
+}
+
+func (r *reopeningStorage) GetDrafts(ctx context.Context, author string, offset int, limit int) ([]model.CustomPost, int, error) {
+	return r.reopen().GetDrafts(ctx, author, offset, limit)
+}
+
+func (r *reopeningStorage) PublishPost(ctx context.Context, id int, status model.ContentStatus, reason string, publishAt time.Time) (model.CustomPost, error) {
+	return r.reopen().PublishPost(ctx, id, status, reason, publishAt)
+}
+
+func (r *reopeningStorage) PublishDuePosts(ctx context.Context, now time.Time) ([]model.CustomPost, error) {
+	return r.reopen().PublishDuePosts(ctx, now)
+}
+
+func (r *reopeningStorage) PinPost(ctx context.Context, id int, until *time.Time, actor string) (model.CustomPost, error) {
+	return r.reopen().PinPost(ctx, id, until, actor)
+}
+
+func (r *reopeningStorage) UnpinPost(ctx context.Context, id int, actor string) (model.CustomPost, error) {
+	return r.reopen().UnpinPost(ctx, id, actor)
+}
+
+func (r *reopeningStorage) GetPoll(ctx context.Context, id int) (model.CustomPoll, error) {
+	return r.reopen().GetPoll(ctx, id)
+}
+
+func (r *reopeningStorage) GetPollsByPostIDs(ctx context.Context, postIDs []int) (map[int]model.CustomPoll, error) {
+	return r.reopen().GetPollsByPostIDs(ctx, postIDs)
+}
+
+func (r *reopeningStorage) Vote(ctx context.Context, ballot model.CustomBallot) (model.CustomPoll, error) {
+	return r.reopen().Vote(ctx, ballot)
+}
+
+func (r *reopeningStorage) GetPendingContent(ctx context.Context, offset int, limit int) ([]model.CustomContent, error) {
+	return r.reopen().GetPendingContent(ctx, offset, limit)
+}
+
+func (r *reopeningStorage) CreateReport(ctx context.Context, input model.CustomReportInput) (model.CustomReport, int, error) {
+	return r.reopen().CreateReport(ctx, input)
+}
+
+func (r *reopeningStorage) GetReportGroups(ctx context.Context, offset int, limit int) ([]model.CustomReportGroup, error) {
+	return r.reopen().GetReportGroups(ctx, offset, limit)
+}
+
+func (r *reopeningStorage) ResolveReports(ctx context.Context, targetType string, targetID int, resolvedBy string, action model.ReportAction, change model.CustomStatusChange) ([]model.CustomReport, error) {
+	return r.reopen().ResolveReports(ctx, targetType, targetID, resolvedBy, action, change)
+}
+
+func (r *reopeningStorage) GetAuditLog(ctx context.Context, filter model.CustomAuditFilter, offset int, limit int) ([]model.CustomAuditEntry, error) {
+	return r.reopen().GetAuditLog(ctx, filter, offset, limit)
+}
+
+func (r *reopeningStorage) CreateBan(ctx context.Context, input model.CustomBanInput) (model.CustomBan, error) {
+	return r.reopen().CreateBan(ctx, input)
+}
+
+func (r *reopeningStorage) LiftBan(ctx context.Context, author string, liftedBy string) (model.CustomBan, error) {
+	return r.reopen().LiftBan(ctx, author, liftedBy)
+}
+
+func (r *reopeningStorage) GetActiveBan(ctx context.Context, author string) (model.CustomBan, error) {
+	return r.reopen().GetActiveBan(ctx, author)
+}
+
+func (r *reopeningStorage) GetActiveBans(ctx context.Context, offset int, limit int) ([]model.CustomBan, error) {
+	return r.reopen().GetActiveBans(ctx, offset, limit)
+}
+
+func (r *reopeningStorage) PendingEvents(ctx context.Context, sink string, now time.Time, limit int) ([]outbox.Event, error) {
+	return r.reopen().PendingEvents(ctx, sink, now, limit)
+}
+
+func (r *reopeningStorage) MarkDelivered(ctx context.Context, sink string, ids []int64) error {
+	return r.reopen().MarkDelivered(ctx, sink, ids)
+}
+
+func (r *reopeningStorage) MarkFailed(ctx context.Context, sink string, failure outbox.Failure) error {
+	return r.reopen().MarkFailed(ctx, sink, failure)
+}
@@ -0,0 +1,1810 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/erknas/forum/graph/model"
+	"github.com/erknas/forum/internal/config"
+	"github.com/erknas/forum/internal/outbox"
+	"github.com/erknas/forum/pkg/apperr"
+	"github.com/erknas/forum/pkg/conv"
+	_ "modernc.org/sqlite"
+)
+
+// sqlitePinnedOrder is pinnedOrder with SQLite parameters.
+const sqlitePinnedOrder = `CASE WHEN post.pinned_at IS NOT NULL AND (post.pinned_until IS NULL OR post.pinned_until > ?1) THEN post.pinned_at END DESC NULLS LAST,
+						   CASE WHEN post.pinned_at IS NOT NULL AND (post.pinned_until IS NULL OR post.pinned_until > ?1) THEN post.id END DESC NULLS LAST`
+
+type SQLiteStorage struct {
+	db *sql.DB
+}
+
+func NewSQLiteStorage(ctx context.Context, cfg *config.Config) (*SQLiteStorage, error) {
+	ctx, cancel := context.WithTimeout(ctx, ctxTimeout)
+	defer cancel()
+
+	dsn := fmt.Sprintf("file:%s?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)", cfg.SQLiteConfig.Path)
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	// SQLite allows a single writer; one connection serializes transactions
+	// instead of failing them with SQLITE_BUSY.
+	db.SetMaxOpenConns(1)
+
+	if err := db.PingContext(ctx); err != nil {
+		return nil, err
+	}
+
+	return &SQLiteStorage{db: db}, nil
+}
+
+func (s *SQLiteStorage) CreatePost(ctx context.Context, input model.CustomPostInput) (post model.CustomPost, err error) {
+	err = s.withTx(ctx, func(tx *sql.Tx) error {
+		author, err := upsertSQLiteAuthor(ctx, tx, input.Author)
+		if err != nil {
+			return err
+		}
+
+		insertPost := `INSERT INTO post (title, content, created_at, comments_allowed, author_id, status, status_reason, publish_at)
+					   VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+					   RETURNING id`
+
+		post = model.CustomPost{
+			Title:           input.Title,
+			Author:          author,
+			Content:         input.Content,
+			CreatedAt:       time.Now().UTC(),
+			CommentsAllowed: input.CommentsAllowed,
+			Status:          input.Status.OrPublished(),
+			StatusReason:    input.StatusReason,
+		}
+
+		if input.PublishAt != nil {
+			publishAt := input.PublishAt.UTC()
+			post.PublishAt = &publishAt
+		}
+
+		if err := tx.QueryRowContext(ctx, insertPost, post.Title, post.Content, post.CreatedAt, post.CommentsAllowed, author.ID, post.Status, post.StatusReason, post.PublishAt).Scan(&post.ID); err != nil {
+			return err
+		}
+
+		if input.Poll != nil {
+			if err := insertSQLitePoll(ctx, tx, post, *input.Poll); err != nil {
+				return err
+			}
+		}
+
+		if err := insertSQLiteAttachments(ctx, tx, conv.TypePost, post.ID, post.CreatedAt, input.Attachments); err != nil {
+			return err
+		}
+
+		return insertPublishedSQLiteEvent(ctx, tx, outbox.PostCreated, "posts", post.Status, post)
+	})
+	if err != nil {
+		return model.CustomPost{}, err
+	}
+
+	return post, nil
+}
+
+func (s *SQLiteStorage) GetPosts(ctx context.Context, viewer string) ([]model.CustomPost, error) {
+	query := `SELECT ` + postColumns + ` FROM post
+			  JOIN author ON post.author_id = author.id
+			  WHERE (post.status = 'PUBLISHED' OR (post.status = 'SHADOWED' AND author.name = ?2))
+			  ORDER BY ` + sqlitePinnedOrder + `, post.created_at, post.id`
+
+	rows, err := s.db.QueryContext(ctx, query, time.Now().UTC(), viewer)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanSQLitePosts(rows)
+}
+
+func (s *SQLiteStorage) GetPostByID(ctx context.Context, id int) (model.CustomPost, error) {
+	query := `SELECT ` + postColumns + ` FROM post
+			  JOIN author ON post.author_id = author.id
+			  WHERE post.id = ?`
+
+	post, err := scanSQLitePost(s.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return post, apperr.ErrPostNotFound
+	}
+
+	return post, err
+}
+
+func (s *SQLiteStorage) GetPostsByIDs(ctx context.Context, ids []int) ([]model.CustomPost, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	query := `SELECT ` + postColumns + ` FROM post
+			  JOIN author ON post.author_id = author.id
+			  WHERE post.id IN (` + placeholders(len(ids)) + `)
+			  ORDER BY post.id`
+
+	rows, err := s.db.QueryContext(ctx, query, intArgs(ids)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanSQLitePosts(rows)
+}
+
+func (s *SQLiteStorage) CreateComment(ctx context.Context, input model.CustomCommentInput) (comment model.CustomComment, err error) {
+	err = s.withTx(ctx, func(tx *sql.Tx) error {
+		var allowed bool
+		query := `SELECT post.comments_allowed FROM post
+				  JOIN author ON post.author_id = author.id
+				  WHERE post.id = ? AND (post.status = 'PUBLISHED' OR (post.status = 'SHADOWED' AND author.name = ?))`
+		if err := tx.QueryRowContext(ctx, query, input.PostID, input.Author).Scan(&allowed); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return apperr.ErrPostNotFound
+			}
+			return err
+		}
+
+		if !allowed {
+			return apperr.ErrCommentsDisabled
+		}
+
+		if input.ParentID != nil {
+			var exists bool
+			query := `SELECT EXISTS(SELECT 1 FROM comment
+					  JOIN author ON comment.author_id = author.id
+					  WHERE comment.post_id = ? AND comment.id = ?
+					  AND (comment.status = 'PUBLISHED' OR (comment.status = 'SHADOWED' AND author.name = ?)))`
+			if err := tx.QueryRowContext(ctx, query, input.PostID, *input.ParentID, input.Author).Scan(&exists); err != nil {
+				return err
+			}
+
+			if !exists {
+				return apperr.ErrCommentNotFound
+			}
+		}
+
+		author, err := upsertSQLiteAuthor(ctx, tx, input.Author)
+		if err != nil {
+			return err
+		}
+
+		insertComment := `INSERT INTO comment (content, created_at, post_id, parent_id, author_id, status, status_reason)
+						  VALUES (?, ?, ?, ?, ?, ?, ?)
+						  RETURNING id`
+
+		comment = model.CustomComment{
+			Author:       author,
+			Content:      input.Content,
+			CreatedAt:    time.Now().UTC(),
+			PostID:       input.PostID,
+			ParentID:     input.ParentID,
+			Status:       input.Status.OrPublished(),
+			StatusReason: input.StatusReason,
+		}
+
+		if err := tx.QueryRowContext(ctx, insertComment, comment.Content, comment.CreatedAt, comment.PostID, comment.ParentID, author.ID, comment.Status, comment.StatusReason).Scan(&comment.ID); err != nil {
+			return err
+		}
+
+		if err := insertSQLiteAttachments(ctx, tx, conv.TypeComment, comment.ID, comment.CreatedAt, input.Attachments); err != nil {
+			return err
+		}
+
+		return insertPublishedSQLiteEvent(ctx, tx, outbox.CommentCreated, strconv.Itoa(comment.PostID), comment.Status, comment)
+	})
+	if err != nil {
+		return model.CustomComment{}, err
+	}
+
+	return comment, nil
+}
+
+func (s *SQLiteStorage) GetLatestPosts(ctx context.Context, limit int) ([]model.CustomPost, error) {
+	query := `SELECT ` + postColumns + ` FROM post
+			  JOIN author ON post.author_id = author.id
+			  WHERE post.status = 'PUBLISHED'
+			  ORDER BY post.created_at DESC, post.id DESC
+			  LIMIT ?`
+
+	rows, err := s.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanSQLitePosts(rows)
+}
+
+func (s *SQLiteStorage) GetLatestComments(ctx context.Context, postID int, limit int) ([]model.CustomComment, error) {
+	query := `WITH RECURSIVE thread AS (
+				  SELECT id FROM comment WHERE post_id = ? AND parent_id IS NULL AND status = 'PUBLISHED'
+				  UNION ALL
+				  SELECT comment.id FROM comment JOIN thread ON comment.parent_id = thread.id WHERE comment.status = 'PUBLISHED'
+			  )
+			  SELECT ` + commentColumns + `
+			  FROM comment
+			  JOIN author ON comment.author_id = author.id
+			  WHERE comment.id IN (SELECT id FROM thread)
+			  ORDER BY comment.created_at DESC, comment.id DESC
+			  LIMIT ?`
+
+	rows, err := s.db.QueryContext(ctx, query, postID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	comments, err := scanSQLiteComments(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(comments) == 0 {
+		if err := s.postExists(ctx, postID); err != nil {
+			return nil, err
+		}
+	}
+
+	return comments, nil
+}
+
+func (s *SQLiteStorage) GetCommentsByPost(ctx context.Context, postID int, offset int, limit int, viewer string) ([]model.CustomComment, error) {
+	query := `SELECT ` + commentColumns + `
+			  FROM comment
+			  JOIN author ON comment.author_id = author.id
+			  WHERE comment.post_id = ?
+			  AND parent_id IS NULL
+			  AND (comment.status = 'PUBLISHED' OR (comment.status = 'SHADOWED' AND author.name = ?))
+			  ORDER BY comment.created_at, comment.id
+			  LIMIT ? OFFSET ?`
+
+	rows, err := s.db.QueryContext(ctx, query, postID, viewer, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	comments, err := scanSQLiteComments(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(comments) == 0 {
+		if err := s.postExists(ctx, postID); err != nil {
+			return nil, err
+		}
+	}
+
+	return comments, nil
+}
+
+// GetCommentsByPostIDs returns the same page of top-level comments for every
+// post, ordered by post.
+func (s *SQLiteStorage) GetCommentsByPostIDs(ctx context.Context, postIDs []int, offset int, limit int, viewer string) ([]model.CustomComment, error) {
+	if len(postIDs) == 0 {
+		return nil, nil
+	}
+
+	query := `SELECT ` + commentColumns + `
+			  FROM (
+				SELECT *, ROW_NUMBER() OVER (PARTITION BY post_id ORDER BY created_at, id) AS position
+				FROM comment
+				WHERE post_id IN (` + placeholders(len(postIDs)) + `)
+				AND parent_id IS NULL
+				AND (status = 'PUBLISHED' OR (status = 'SHADOWED' AND author_id = (SELECT id FROM author WHERE name = ?)))
+			  ) comment
+			  JOIN author ON comment.author_id = author.id
+			  WHERE comment.position > ? AND comment.position <= ?
+			  ORDER BY comment.post_id, comment.position`
+
+	args := append(intArgs(postIDs), viewer, offset, offset+limit)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanSQLiteComments(rows)
+}
+
+func (s *SQLiteStorage) GetCommentByID(ctx context.Context, id int) (model.CustomComment, error) {
+	query := `SELECT ` + commentColumns + `
+			  FROM comment
+			  JOIN author ON comment.author_id = author.id
+			  WHERE comment.id = ?`
+
+	rows, err := s.db.QueryContext(ctx, query, id)
+	if err != nil {
+		return model.CustomComment{}, err
+	}
+
+	comments, err := scanSQLiteComments(rows)
+	if err != nil {
+		return model.CustomComment{}, err
+	}
+
+	if len(comments) == 0 {
+		return model.CustomComment{}, apperr.ErrCommentNotFound
+	}
+
+	return comments[0], nil
+}
+
+func (s *SQLiteStorage) GetCommentReplies(ctx context.Context, parentID int, viewer string) ([]model.CustomComment, error) {
+	query := `SELECT ` + commentColumns + `
+			  FROM comment
+			  JOIN author ON comment.author_id = author.id
+			  WHERE comment.parent_id = ?
+			  AND (comment.status = 'PUBLISHED' OR (comment.status = 'SHADOWED' AND author.name = ?))
+			  ORDER BY comment.created_at, comment.id`
+
+	rows, err := s.db.QueryContext(ctx, query, parentID, viewer)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanSQLiteComments(rows)
+}
+
+func (s *SQLiteStorage) GetRepliesByParentIDs(ctx context.Context, parentIDs []int, viewer string) ([]model.CustomComment, error) {
+	if len(parentIDs) == 0 {
+		return nil, nil
+	}
+
+	query := `SELECT ` + commentColumns + `
+			  FROM comment
+			  JOIN author ON comment.author_id = author.id
+			  WHERE comment.parent_id IN (` + placeholders(len(parentIDs)) + `)
+			  AND (comment.status = 'PUBLISHED' OR (comment.status = 'SHADOWED' AND author.name = ?))
+			  ORDER BY comment.created_at, comment.id`
+
+	rows, err := s.db.QueryContext(ctx, query, append(intArgs(parentIDs), viewer)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanSQLiteComments(rows)
+}
+
+func (s *SQLiteStorage) GetAuthorByID(ctx context.Context, id int) (model.CustomAuthor, error) {
+	query := `SELECT id, name, joined_at FROM author WHERE id = ?`
+
+	author := model.CustomAuthor{}
+	if err := s.db.QueryRowContext(ctx, query, id).Scan(&author.ID, &author.Name, &author.JoinedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return author, apperr.ErrAuthorNotFound
+		}
+		return author, err
+	}
+
+	return author, nil
+}
+
+func (s *SQLiteStorage) GetAuthorByName(ctx context.Context, name string) (model.CustomAuthor, error) {
+	query := `SELECT id, name, joined_at FROM author WHERE name = ?`
+
+	author := model.CustomAuthor{}
+	if err := s.db.QueryRowContext(ctx, query, name).Scan(&author.ID, &author.Name, &author.JoinedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return author, apperr.ErrAuthorNotFound
+		}
+		return author, err
+	}
+
+	return author, nil
+}
+
+func (s *SQLiteStorage) GetAuthorStats(ctx context.Context, id int, viewer string) (model.AuthorStats, error) {
+	query := `SELECT (SELECT COUNT(*) FROM post WHERE author_id = ?1
+					  AND (status = 'PUBLISHED' OR (status = 'SHADOWED' AND author_id = (SELECT id FROM author WHERE name = ?2)))),
+					 (SELECT COUNT(*) FROM comment WHERE author_id = ?1
+					  AND (status = 'PUBLISHED' OR (status = 'SHADOWED' AND author_id = (SELECT id FROM author WHERE name = ?2))))`
+
+	stats := model.AuthorStats{}
+	if err := s.db.QueryRowContext(ctx, query, id, viewer).Scan(&stats.PostCount, &stats.CommentCount); err != nil {
+		return stats, err
+	}
+
+	return stats, nil
+}
+
+func (s *SQLiteStorage) GetAuthorStatsByIDs(ctx context.Context, ids []int, viewer string) (map[int]model.AuthorStats, error) {
+	stats := make(map[int]model.AuthorStats, len(ids))
+
+	if len(ids) == 0 {
+		return stats, nil
+	}
+
+	query := `SELECT author.id,
+					 (SELECT COUNT(*) FROM post WHERE post.author_id = author.id
+					  AND (post.status = 'PUBLISHED' OR (post.status = 'SHADOWED' AND author.name = ?))),
+					 (SELECT COUNT(*) FROM comment WHERE comment.author_id = author.id
+					  AND (comment.status = 'PUBLISHED' OR (comment.status = 'SHADOWED' AND author.name = ?)))
+			  FROM author
+			  WHERE author.id IN (` + placeholders(len(ids)) + `)`
+
+	rows, err := s.db.QueryContext(ctx, query, append([]any{viewer, viewer}, intArgs(ids)...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			id          int
+			authorStats model.AuthorStats
+		)
+		if err := rows.Scan(&id, &authorStats.PostCount, &authorStats.CommentCount); err != nil {
+			return nil, err
+		}
+		stats[id] = authorStats
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+func (s *SQLiteStorage) GetPostsByAuthor(ctx context.Context, authorID int, offset int, limit int, viewer string) ([]model.CustomPost, error) {
+	query := `SELECT ` + postColumns + ` FROM post
+			  JOIN author ON post.author_id = author.id
+			  WHERE post.author_id = ?
+			  AND (post.status = 'PUBLISHED' OR (post.status = 'SHADOWED' AND author.name = ?))
+			  ORDER BY post.created_at DESC, post.id DESC
+			  LIMIT ? OFFSET ?`
+
+	rows, err := s.db.QueryContext(ctx, query, authorID, viewer, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanSQLitePosts(rows)
+}
+
+func (s *SQLiteStorage) GetCommentsByAuthor(ctx context.Context, authorID int, offset int, limit int, viewer string) ([]model.CustomComment, error) {
+	query := `SELECT ` + commentColumns + `
+			  FROM comment
+			  JOIN author ON comment.author_id = author.id
+			  WHERE comment.author_id = ?
+			  AND (comment.status = 'PUBLISHED' OR (comment.status = 'SHADOWED' AND author.name = ?))
+			  ORDER BY comment.created_at DESC, comment.id DESC
+			  LIMIT ? OFFSET ?`
+
+	rows, err := s.db.QueryContext(ctx, query, authorID, viewer, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanSQLiteComments(rows)
+}
+
+func (s *SQLiteStorage) SetPostStatus(ctx context.Context, id int, status model.ContentStatus, reason string, actor string) (post model.CustomPost, err error) {
+	err = s.withTx(ctx, func(tx *sql.Tx) error {
+		post, err = setSQLitePostStatus(ctx, tx, id, model.CustomStatusChange{Status: status, Reason: reason}, actor)
+		return err
+	})
+	if err != nil {
+		return model.CustomPost{}, err
+	}
+
+	return post, nil
+}
+
+func (s *SQLiteStorage) SetCommentStatus(ctx context.Context, id int, status model.ContentStatus, reason string, actor string) (comment model.CustomComment, err error) {
+	err = s.withTx(ctx, func(tx *sql.Tx) error {
+		comment, err = setSQLiteCommentStatus(ctx, tx, id, model.CustomStatusChange{Status: status, Reason: reason}, actor)
+		return err
+	})
+	if err != nil {
+		return model.CustomComment{}, err
+	}
+
+	return comment, nil
+}
+
+// changeSQLiteStatus makes change to the status of a post or comment if the
+// change applies to it.
+func changeSQLiteStatus(ctx context.Context, tx *sql.Tx, targetType string, id int, change model.CustomStatusChange, actor string) error {
+	if change.Status == "" {
+		return nil
+	}
+
+	var err error
+	if targetType == conv.TypePost {
+		_, err = setSQLitePostStatus(ctx, tx, id, change, actor)
+	} else {
+		_, err = setSQLiteCommentStatus(ctx, tx, id, change, actor)
+	}
+	return err
+}
+
+// setSQLitePostStatus makes change to the status of a post if the change
+// applies to it, and returns the post.
+func setSQLitePostStatus(ctx context.Context, tx *sql.Tx, id int, change model.CustomStatusChange, actor string) (model.CustomPost, error) {
+	query := `SELECT ` + postColumns + ` FROM post
+			  JOIN author ON post.author_id = author.id
+			  WHERE post.id = ? AND post.status NOT IN ('DRAFT', 'SCHEDULED')`
+
+	post, err := scanSQLitePost(tx.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.CustomPost{}, apperr.ErrPostNotFound
+		}
+		return model.CustomPost{}, err
+	}
+
+	if !change.AppliesTo(post.Status, post.StatusReason) {
+		return post, nil
+	}
+
+	before := post
+	wasPublished := post.Status == model.ContentStatusPublished
+	post.Status, post.StatusReason = change.Status, change.Reason
+
+	if _, err := tx.ExecContext(ctx, `UPDATE post SET status = ?, status_reason = ? WHERE id = ?`, post.Status, post.StatusReason, id); err != nil {
+		return model.CustomPost{}, err
+	}
+
+	if err := insertSQLiteAudit(ctx, tx, actor, post.Status.AuditAction(), conv.TypePost, id, before, post); err != nil {
+		return model.CustomPost{}, err
+	}
+
+	if !wasPublished {
+		if err := insertPublishedSQLiteEvent(ctx, tx, outbox.PostCreated, "posts", post.Status, post); err != nil {
+			return model.CustomPost{}, err
+		}
+	}
+
+	return post, nil
+}
+
+// setSQLiteCommentStatus makes change to the status of a comment if the change
+// applies to it, and returns the comment.
+func setSQLiteCommentStatus(ctx context.Context, tx *sql.Tx, id int, change model.CustomStatusChange, actor string) (model.CustomComment, error) {
+	query := `SELECT ` + commentColumns + ` FROM comment
+			  JOIN author ON comment.author_id = author.id
+			  WHERE comment.id = ?`
+
+	comment, err := scanSQLiteComment(tx.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.CustomComment{}, apperr.ErrCommentNotFound
+		}
+		return model.CustomComment{}, err
+	}
+
+	if !change.AppliesTo(comment.Status, comment.StatusReason) {
+		return comment, nil
+	}
+
+	before := comment
+	wasPublished := comment.Status == model.ContentStatusPublished
+	comment.Status, comment.StatusReason = change.Status, change.Reason
+
+	if _, err := tx.ExecContext(ctx, `UPDATE comment SET status = ?, status_reason = ? WHERE id = ?`, comment.Status, comment.StatusReason, id); err != nil {
+		return model.CustomComment{}, err
+	}
+
+	if err := insertSQLiteAudit(ctx, tx, actor, comment.Status.AuditAction(), conv.TypeComment, id, before, comment); err != nil {
+		return model.CustomComment{}, err
+	}
+
+	if !wasPublished {
+		if err := insertPublishedSQLiteEvent(ctx, tx, outbox.CommentCreated, strconv.Itoa(comment.PostID), comment.Status, comment); err != nil {
+			return model.CustomComment{}, err
+		}
+	}
+
+	return comment, nil
+}
+
+func (s *SQLiteStorage) EditPost(ctx context.Context, edit model.CustomEdit, actor string) (post model.CustomPost, err error) {
+	err = s.withTx(ctx, func(tx *sql.Tx) error {
+		query := `SELECT ` + postColumns + ` FROM post
+				  JOIN author ON post.author_id = author.id
+				  WHERE post.id = ?`
+
+		post, err = scanSQLitePost(tx.QueryRowContext(ctx, query, edit.ID))
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return apperr.ErrPostNotFound
+			}
+			return err
+		}
+
+		versions, err := countSQLiteRevisions(ctx, tx, conv.TypePost, post.ID)
+		if err != nil {
+			return err
+		}
+
+		if err := insertSQLiteRevision(ctx, tx, post.Revision(versions+1)); err != nil {
+			return err
+		}
+
+		before := post
+		editedAt := time.Now().UTC()
+		post.Title, post.Content, post.EditedBy, post.EditedAt = edit.Title, edit.Content, edit.Editor, &editedAt
+		if edit.Status != "" {
+			post.Status, post.StatusReason = edit.Status, edit.StatusReason
+		}
+
+		update := `UPDATE post SET title = ?, content = ?, status = ?, status_reason = ?, edited_by = ?, edited_at = ?
+				   WHERE id = ?`
+
+		if _, err := tx.ExecContext(ctx, update, post.Title, post.Content, post.Status, post.StatusReason, post.EditedBy, editedAt, post.ID); err != nil {
+			return err
+		}
+
+		return insertSQLiteAudit(ctx, tx, actor, model.AuditActionEditContent, conv.TypePost, post.ID, before, post)
+	})
+	if err != nil {
+		return model.CustomPost{}, err
+	}
+
+	return post, nil
+}
+
+func (s *SQLiteStorage) EditComment(ctx context.Context, edit model.CustomEdit, actor string) (comment model.CustomComment, err error) {
+	err = s.withTx(ctx, func(tx *sql.Tx) error {
+		query := `SELECT ` + commentColumns + ` FROM comment
+				  JOIN author ON comment.author_id = author.id
+				  WHERE comment.id = ?`
+
+		comment, err = scanSQLiteComment(tx.QueryRowContext(ctx, query, edit.ID))
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return apperr.ErrCommentNotFound
+			}
+			return err
+		}
+
+		versions, err := countSQLiteRevisions(ctx, tx, conv.TypeComment, comment.ID)
+		if err != nil {
+			return err
+		}
+
+		if err := insertSQLiteRevision(ctx, tx, comment.Revision(versions+1)); err != nil {
+			return err
+		}
+
+		before := comment
+		editedAt := time.Now().UTC()
+		comment.Content, comment.EditedBy, comment.EditedAt = edit.Content, edit.Editor, &editedAt
+		if edit.Status != "" {
+			comment.Status, comment.StatusReason = edit.Status, edit.StatusReason
+		}
+
+		update := `UPDATE comment SET content = ?, status = ?, status_reason = ?, edited_by = ?, edited_at = ?
+				   WHERE id = ?`
+
+		if _, err := tx.ExecContext(ctx, update, comment.Content, comment.Status, comment.StatusReason, comment.EditedBy, editedAt, comment.ID); err != nil {
+			return err
+		}
+
+		return insertSQLiteAudit(ctx, tx, actor, model.AuditActionEditContent, conv.TypeComment, comment.ID, before, comment)
+	})
+	if err != nil {
+		return model.CustomComment{}, err
+	}
+
+	return comment, nil
+}
+
+// GetRevisions returns the replaced versions of the targets, oldest first.
+func (s *SQLiteStorage) GetRevisions(ctx context.Context, targetType string, targetIDs []int) (map[int][]model.CustomRevision, error) {
+	if len(targetIDs) == 0 {
+		return map[int][]model.CustomRevision{}, nil
+	}
+
+	query := `SELECT ` + revisionColumns + ` FROM revision
+			  WHERE target_type = ? AND target_id IN (` + placeholders(len(targetIDs)) + `)
+			  ORDER BY target_id, version`
+
+	rows, err := s.db.QueryContext(ctx, query, append([]any{targetType}, intArgs(targetIDs)...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var revisions []model.CustomRevision
+
+	for rows.Next() {
+		revision := model.CustomRevision{}
+		if err := rows.Scan(&revision.ID, &revision.TargetType, &revision.TargetID, &revision.Version, &revision.Title, &revision.Content, &revision.Editor, &revision.CreatedAt); err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return groupRevisions(revisions), nil
+}
+
+// GetAttachments returns the attachments of the targets in the order they
+// were given.
+func (s *SQLiteStorage) GetAttachments(ctx context.Context, targetType string, targetIDs []int) (map[int][]model.CustomAttachment, error) {
+	if len(targetIDs) == 0 {
+		return map[int][]model.CustomAttachment{}, nil
+	}
+
+	query := `SELECT ` + attachmentColumns + ` FROM attachment
+			  WHERE target_type = ? AND target_id IN (` + placeholders(len(targetIDs)) + `)
+			  ORDER BY target_id, id`
+
+	rows, err := s.db.QueryContext(ctx, query, append([]any{targetType}, intArgs(targetIDs)...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attachments []model.CustomAttachment
+
+	for rows.Next() {
+		attachment := model.CustomAttachment{}
+		if err := rows.Scan(&attachment.ID, &attachment.TargetType, &attachment.TargetID, &attachment.Key, &attachment.ThumbnailKey, &attachment.Filename, &attachment.ContentType, &attachment.Size, &attachment.Width, &attachment.Height, &attachment.CreatedAt); err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, attachment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return groupAttachments(attachments), nil
+}
+
+// GetDrafts returns the drafts and scheduled posts of author, newest first.
+func (s *SQLiteStorage) GetDrafts(ctx context.Context, author string, offset int, limit int) (posts []model.CustomPost, total int, err error) {
+	count := `SELECT COUNT(*) FROM post
+			  JOIN author ON post.author_id = author.id
+			  WHERE author.name = ? AND post.status IN ('DRAFT', 'SCHEDULED')`
+
+	if err := s.db.QueryRowContext(ctx, count, author).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := `SELECT ` + postColumns + ` FROM post
+			  JOIN author ON post.author_id = author.id
+			  WHERE author.name = ? AND post.status IN ('DRAFT', 'SCHEDULED')
+			  ORDER BY post.created_at DESC, post.id DESC
+			  LIMIT ? OFFSET ?`
+
+	rows, err := s.db.QueryContext(ctx, query, author, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	posts, err = scanSQLitePosts(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return posts, total, nil
+}
+
+func (s *SQLiteStorage) PublishPost(ctx context.Context, id int, status model.ContentStatus, reason string, publishAt time.Time) (post model.CustomPost, err error) {
+	err = s.withTx(ctx, func(tx *sql.Tx) error {
+		query := `SELECT ` + postColumns + ` FROM post
+				  JOIN author ON post.author_id = author.id
+				  WHERE post.id = ?`
+
+		post, err = scanSQLitePost(tx.QueryRowContext(ctx, query, id))
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return apperr.ErrPostNotFound
+			}
+			return err
+		}
+
+		if !post.Status.Draft() {
+			return apperr.ErrNotDraft
+		}
+
+		return publishSQLitePost(ctx, tx, &post, status, reason, publishAt.UTC())
+	})
+	if err != nil {
+		return model.CustomPost{}, err
+	}
+
+	return post, nil
+}
+
+// PublishDuePosts publishes the scheduled posts due at now, in the order they
+// were due.
+func (s *SQLiteStorage) PublishDuePosts(ctx context.Context, now time.Time) (posts []model.CustomPost, err error) {
+	err = s.withTx(ctx, func(tx *sql.Tx) error {
+		query := `SELECT ` + postColumns + ` FROM post
+				  JOIN author ON post.author_id = author.id
+				  WHERE post.status = 'SCHEDULED' AND post.publish_at <= ?1
+				  AND NOT EXISTS (
+					SELECT 1 FROM ban
+					WHERE ban.author = author.name AND ban.kind = 'BAN'
+					AND ban.lifted_at IS NULL AND (ban.expires_at IS NULL OR ban.expires_at > ?1)
+				  )
+				  ORDER BY post.publish_at, post.id`
+
+		rows, err := tx.QueryContext(ctx, query, now.UTC())
+		if err != nil {
+			return err
+		}
+
+		posts, err = scanSQLitePosts(rows)
+		if err != nil {
+			return err
+		}
+
+		for i := range posts {
+			status, reason := model.ContentStatusPublished, ""
+
+			var shadowed bool
+			query := `SELECT EXISTS(SELECT 1 FROM ban
+					  WHERE author = ? AND kind = 'SHADOWBAN'
+					  AND lifted_at IS NULL AND (expires_at IS NULL OR expires_at > ?))`
+			if err := tx.QueryRowContext(ctx, query, posts[i].Author.Name, now.UTC()).Scan(&shadowed); err != nil {
+				return err
+			}
+			if shadowed {
+				status, reason = model.ContentStatusShadowed, model.ShadowedReason
+			}
+
+			if err := publishSQLitePost(ctx, tx, &posts[i], status, reason, posts[i].PublishAt.UTC()); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return posts, nil
+}
+
+func (s *SQLiteStorage) PinPost(ctx context.Context, id int, until *time.Time, actor string) (post model.CustomPost, err error) {
+	err = s.withTx(ctx, func(tx *sql.Tx) error {
+		post, err = getSQLitePinnablePost(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		before := post
+		now := time.Now().UTC()
+		if until != nil {
+			u := until.UTC()
+			until = &u
+		}
+		post.PinnedBy, post.PinnedAt, post.PinnedUntil = actor, &now, until
+
+		return pinSQLitePost(ctx, tx, actor, model.AuditActionPinPost, before, post)
+	})
+	if err != nil {
+		return model.CustomPost{}, err
+	}
+
+	return post, nil
+}
+
+func (s *SQLiteStorage) UnpinPost(ctx context.Context, id int, actor string) (post model.CustomPost, err error) {
+	err = s.withTx(ctx, func(tx *sql.Tx) error {
+		post, err = getSQLitePinnablePost(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		if !post.Pinned(time.Now()) {
+			return apperr.ErrNotPinned
+		}
+
+		before := post
+		post.PinnedBy, post.PinnedAt, post.PinnedUntil = "", nil, nil
+
+		return pinSQLitePost(ctx, tx, actor, model.AuditActionUnpinPost, before, post)
+	})
+	if err != nil {
+		return model.CustomPost{}, err
+	}
+
+	return post, nil
+}
+
+func (s *SQLiteStorage) GetPoll(ctx context.Context, id int) (model.CustomPoll, error) {
+	polls, err := querySQLitePolls(ctx, s.db, "id", []int{id})
+	if err != nil {
+		return model.CustomPoll{}, err
+	}
+
+	if len(polls) == 0 {
+		return model.CustomPoll{}, apperr.ErrPollNotFound
+	}
+
+	return polls[0], nil
+}
+
+// GetPollsByPostIDs returns the polls of the posts that have one, by post ID.
+func (s *SQLiteStorage) GetPollsByPostIDs(ctx context.Context, postIDs []int) (map[int]model.CustomPoll, error) {
+	byPost := make(map[int]model.CustomPoll, len(postIDs))
+	if len(postIDs) == 0 {
+		return byPost, nil
+	}
+
+	polls, err := querySQLitePolls(ctx, s.db, "post_id", postIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, poll := range polls {
+		byPost[poll.PostID] = poll
+	}
+
+	return byPost, nil
+}
+
+func (s *SQLiteStorage) Vote(ctx context.Context, ballot model.CustomBallot) (poll model.CustomPoll, err error) {
+	err = s.withTx(ctx, func(tx *sql.Tx) error {
+		var closesAt *time.Time
+		if err := tx.QueryRowContext(ctx, `SELECT closes_at FROM poll WHERE id = ?`, ballot.PollID).Scan(&closesAt); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return apperr.ErrPollNotFound
+			}
+			return err
+		}
+
+		ballot.CreatedAt = time.Now().UTC()
+
+		if (model.CustomPoll{ClosesAt: closesAt}).Closed(ballot.CreatedAt) {
+			return apperr.ErrPollClosed
+		}
+
+		if len(ballot.OptionIDs) > 0 {
+			var found int
+			query := `SELECT COUNT(*) FROM poll_option WHERE poll_id = ? AND id IN (` + placeholders(len(ballot.OptionIDs)) + `)`
+
+			if err := tx.QueryRowContext(ctx, query, append([]any{ballot.PollID}, intArgs(ballot.OptionIDs)...)...).Scan(&found); err != nil {
+				return err
+			}
+
+			if found != len(ballot.OptionIDs) {
+				return apperr.ErrInvalidOption
+			}
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM ballot WHERE poll_id = ? AND voter = ?`, ballot.PollID, ballot.Voter); err != nil {
+			return err
+		}
+
+		for _, optionID := range ballot.OptionIDs {
+			insertBallot := `INSERT INTO ballot (poll_id, option_id, voter, created_at) VALUES (?, ?, ?, ?)`
+
+			if _, err := tx.ExecContext(ctx, insertBallot, ballot.PollID, optionID, ballot.Voter, ballot.CreatedAt); err != nil {
+				return err
+			}
+		}
+
+		polls, err := querySQLitePolls(ctx, tx, "id", []int{ballot.PollID})
+		if err != nil {
+			return err
+		}
+		poll = polls[0]
+
+		event, err := outbox.NewEvent(outbox.PollUpdated, strconv.Itoa(poll.ID), poll)
+		if err != nil {
+			return err
+		}
+
+		return insertSQLiteEvent(ctx, tx, event)
+	})
+	if err != nil {
+		return model.CustomPoll{}, err
+	}
+
+	return poll, nil
+}
+
+// GetPendingContent returns pending posts and comments, oldest first.
+func (s *SQLiteStorage) GetPendingContent(ctx context.Context, offset int, limit int) ([]model.CustomContent, error) {
+	query := `SELECT kind, id FROM (
+				SELECT 'post' AS kind, id, created_at FROM post WHERE status = 'PENDING'
+				UNION ALL
+				SELECT 'comment', id, created_at FROM comment WHERE status = 'PENDING'
+			  )
+			  ORDER BY created_at, kind DESC, id
+			  LIMIT ? OFFSET ?`
+
+	rows, err := s.db.QueryContext(ctx, query, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type pendingItem struct {
+		kind string
+		id   int
+	}
+
+	var items []pendingItem
+
+	for rows.Next() {
+		item := pendingItem{}
+		if err := rows.Scan(&item.kind, &item.id); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Reading the items while rows is open would need a second connection.
+	rows.Close()
+
+	content := make([]model.CustomContent, 0, len(items))
+
+	for _, item := range items {
+		if item.kind == "post" {
+			post, err := s.GetPostByID(ctx, item.id)
+			if err != nil {
+				return nil, err
+			}
+			content = append(content, model.CustomContent{Post: &post})
+			continue
+		}
+
+		comment, err := s.GetCommentByID(ctx, item.id)
+		if err != nil {
+			return nil, err
+		}
+		content = append(content, model.CustomContent{Comment: &comment})
+	}
+
+	return content, nil
+}
+
+func (s *SQLiteStorage) CreateReport(ctx context.Context, input model.CustomReportInput) (report model.CustomReport, open int, err error) {
+	err = s.withTx(ctx, func(tx *sql.Tx) error {
+		insertReport := `INSERT INTO report (target_type, target_id, reporter, reason, details, created_at)
+						 VALUES (?, ?, ?, ?, ?, ?)
+						 ON CONFLICT (target_type, target_id, reporter) WHERE resolved_at IS NULL DO NOTHING
+						 RETURNING ` + reportColumns
+
+		report, err = scanSQLiteReport(tx.QueryRowContext(ctx, insertReport, input.TargetType, input.TargetID, input.Reporter, input.Reason, input.Details, time.Now().UTC()))
+		if errors.Is(err, sql.ErrNoRows) {
+			query := `SELECT ` + reportColumns + ` FROM report
+					  WHERE target_type = ? AND target_id = ? AND reporter = ? AND resolved_at IS NULL`
+
+			report, err = scanSQLiteReport(tx.QueryRowContext(ctx, query, input.TargetType, input.TargetID, input.Reporter))
+		}
+		if err != nil {
+			return err
+		}
+
+		count := `SELECT COUNT(*) FROM report WHERE target_type = ? AND target_id = ? AND resolved_at IS NULL`
+
+		if err := tx.QueryRowContext(ctx, count, input.TargetType, input.TargetID).Scan(&open); err != nil {
+			return err
+		}
+
+		if input.HideAt == 0 || open < input.HideAt {
+			return nil
+		}
+
+		return changeSQLiteStatus(ctx, tx, input.TargetType, input.TargetID, input.Hide, "")
+	})
+	if err != nil {
+		return model.CustomReport{}, 0, err
+	}
+
+	return report, open, nil
+}
+
+// GetReportGroups returns open reports grouped by target, most reported
+// first, then by the oldest report.
+func (s *SQLiteStorage) GetReportGroups(ctx context.Context, offset int, limit int) ([]model.CustomReportGroup, error) {
+	query := `WITH grouped AS (
+				SELECT target_type, target_id, COUNT(*) AS report_count, MIN(id) AS first_id
+				FROM report
+				WHERE resolved_at IS NULL
+				GROUP BY target_type, target_id
+				ORDER BY report_count DESC, first_id
+				LIMIT ? OFFSET ?
+			  )
+			  SELECT ` + reportColumns + ` FROM report
+			  JOIN grouped USING (target_type, target_id)
+			  WHERE resolved_at IS NULL
+			  ORDER BY grouped.report_count DESC, grouped.first_id, id`
+
+	rows, err := s.db.QueryContext(ctx, query, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	reports, err := scanSQLiteReports(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return groupReports(reports), nil
+}
+
+func (s *SQLiteStorage) ResolveReports(ctx context.Context, targetType string, targetID int, resolvedBy string, action model.ReportAction, change model.CustomStatusChange) (reports []model.CustomReport, err error) {
+	err = s.withTx(ctx, func(tx *sql.Tx) error {
+		query := `UPDATE report SET resolved_by = ?, resolved_at = ?, action = ?
+				  WHERE target_type = ? AND target_id = ? AND resolved_at IS NULL
+				  RETURNING ` + reportColumns
+
+		rows, err := tx.QueryContext(ctx, query, resolvedBy, time.Now().UTC(), action, targetType, targetID)
+		if err != nil {
+			return err
+		}
+
+		reports, err = scanSQLiteReports(rows)
+		if err != nil {
+			return err
+		}
+
+		if len(reports) == 0 {
+			return apperr.ErrNoOpenReports
+		}
+
+		sortReports(reports)
+
+		if err := insertSQLiteAudit(ctx, tx, resolvedBy, model.AuditActionResolveReports, targetType, targetID, unresolved(reports), reports); err != nil {
+			return err
+		}
+
+		return changeSQLiteStatus(ctx, tx, targetType, targetID, change, resolvedBy)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reports, nil
+}
+
+func (s *SQLiteStorage) CreateBan(ctx context.Context, input model.CustomBanInput) (ban model.CustomBan, err error) {
+	err = s.withTx(ctx, func(tx *sql.Tx) error {
+		var (
+			before any
+			now    = time.Now().UTC()
+		)
+
+		query := `SELECT ` + banColumns + ` FROM ban WHERE author = ? AND lifted_at IS NULL`
+
+		previous, err := scanSQLiteBan(tx.QueryRowContext(ctx, query, input.Author))
+		switch {
+		case err == nil:
+			before = previous
+
+			if _, err := tx.ExecContext(ctx, `UPDATE ban SET lifted_by = ?, lifted_at = ? WHERE id = ?`, input.Moderator, now, previous.ID); err != nil {
+				return err
+			}
+		case !errors.Is(err, sql.ErrNoRows):
+			return err
+		}
+
+		var expiresAt *time.Time
+		if input.ExpiresAt != nil {
+			utc := input.ExpiresAt.UTC()
+			expiresAt = &utc
+		}
+
+		insertBan := `INSERT INTO ban (author, kind, reason, moderator, created_at, expires_at)
+					  VALUES (?, ?, ?, ?, ?, ?)
+					  RETURNING ` + banColumns
+
+		ban, err = scanSQLiteBan(tx.QueryRowContext(ctx, insertBan, input.Author, input.Kind, input.Reason, input.Moderator, now, expiresAt))
+		if err != nil {
+			return err
+		}
+
+		return insertSQLiteAudit(ctx, tx, input.Moderator, model.AuditActionBanUser, conv.TypeBan, ban.ID, before, ban)
+	})
+	if err != nil {
+		return model.CustomBan{}, err
+	}
+
+	return ban, nil
+}
+
+func (s *SQLiteStorage) LiftBan(ctx context.Context, author string, liftedBy string) (ban model.CustomBan, err error) {
+	err = s.withTx(ctx, func(tx *sql.Tx) error {
+		now := time.Now().UTC()
+
+		query := `UPDATE ban SET lifted_by = ?, lifted_at = ?
+				  WHERE author = ? AND lifted_at IS NULL AND (expires_at IS NULL OR expires_at > ?)
+				  RETURNING ` + banColumns
+
+		ban, err = scanSQLiteBan(tx.QueryRowContext(ctx, query, liftedBy, now, author, now))
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return apperr.ErrNoActiveBan
+			}
+			return err
+		}
+
+		before := ban
+		before.LiftedBy, before.LiftedAt = "", nil
+
+		return insertSQLiteAudit(ctx, tx, liftedBy, model.AuditActionUnbanUser, conv.TypeBan, ban.ID, before, ban)
+	})
+	if err != nil {
+		return model.CustomBan{}, err
+	}
+
+	return ban, nil
+}
+
+func (s *SQLiteStorage) GetActiveBan(ctx context.Context, author string) (model.CustomBan, error) {
+	query := `SELECT ` + banColumns + ` FROM ban
+			  WHERE author = ? AND lifted_at IS NULL AND (expires_at IS NULL OR expires_at > ?)`
+
+	ban, err := scanSQLiteBan(s.db.QueryRowContext(ctx, query, author, time.Now().UTC()))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.CustomBan{}, apperr.ErrNoActiveBan
+		}
+		return model.CustomBan{}, err
+	}
+
+	return ban, nil
+}
+
+// GetActiveBans returns the bans in force, newest first.
+func (s *SQLiteStorage) GetActiveBans(ctx context.Context, offset int, limit int) ([]model.CustomBan, error) {
+	query := `SELECT ` + banColumns + ` FROM ban
+			  WHERE lifted_at IS NULL AND (expires_at IS NULL OR expires_at > ?)
+			  ORDER BY id DESC
+			  LIMIT ? OFFSET ?`
+
+	rows, err := s.db.QueryContext(ctx, query, time.Now().UTC(), limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bans []model.CustomBan
+
+	for rows.Next() {
+		ban, err := scanSQLiteBan(rows)
+		if err != nil {
+			return nil, err
+		}
+		bans = append(bans, ban)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return bans, nil
+}
+
+// GetAuditLog returns the entries that match filter, newest first.
+func (s *SQLiteStorage) GetAuditLog(ctx context.Context, filter model.CustomAuditFilter, offset int, limit int) ([]model.CustomAuditEntry, error) {
+	where, args := auditConditions(filter, func(int) string { return "?" })
+
+	query := `SELECT id, actor, action, target_type, target_id, before, after, created_at
+			  FROM audit_log ` + where + `
+			  ORDER BY id DESC
+			  LIMIT ? OFFSET ?`
+
+	rows, err := s.db.QueryContext(ctx, query, append(args, limit, offset)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []model.CustomAuditEntry
+
+	for rows.Next() {
+		var (
+			entry         = model.CustomAuditEntry{}
+			before, after sql.NullString
+		)
+		if err := rows.Scan(&entry.ID, &entry.Actor, &entry.Action, &entry.TargetType, &entry.TargetID, &before, &after, &entry.CreatedAt); err != nil {
+			return nil, err
+		}
+		if before.Valid {
+			entry.Before = json.RawMessage(before.String)
+		}
+		if after.Valid {
+			entry.After = json.RawMessage(after.String)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func (s *SQLiteStorage) PendingEvents(ctx context.Context, sink string, now time.Time, limit int) ([]outbox.Event, error) {
+	query := `SELECT outbox.id, outbox.event_id, outbox.event_type, outbox.topic, outbox.payload, outbox.created_at, COALESCE(delivery.attempts, 0)
+			  FROM outbox
+			  LEFT JOIN outbox_delivery delivery ON delivery.outbox_id = outbox.id AND delivery.sink = ?
+			  WHERE outbox.delivered_at IS NULL
+			  AND (delivery.outbox_id IS NULL OR (delivery.delivered_at IS NULL AND delivery.failed_at IS NULL AND delivery.retry_at <= ?))
+			  ORDER BY outbox.id
+			  LIMIT ?`
+
+	rows, err := s.db.QueryContext(ctx, query, sink, now.UTC(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []outbox.Event
+
+	for rows.Next() {
+		var (
+			event   = outbox.Event{}
+			payload string
+		)
+		if err := rows.Scan(&event.ID, &event.EventID, &event.Type, &event.Topic, &payload, &event.CreatedAt, &event.Attempts); err != nil {
+			return nil, err
+		}
+		event.Payload = []byte(payload)
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+func (s *SQLiteStorage) MarkDelivered(ctx context.Context, sink string, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	args := make([]any, 0, len(ids)+2)
+	args = append(args, sink, time.Now().UTC())
+	for _, id := range ids {
+		args = append(args, id)
+	}
+
+	query := `INSERT INTO outbox_delivery (outbox_id, sink, delivered_at)
+			  SELECT id, ?, ? FROM outbox WHERE id IN (` + placeholders(len(ids)) + `)
+			  ON CONFLICT (sink, outbox_id) DO UPDATE SET delivered_at = excluded.delivered_at, retry_at = NULL`
+
+	if _, err := s.db.ExecContext(ctx, query, args...); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *SQLiteStorage) MarkFailed(ctx context.Context, sink string, failure outbox.Failure) error {
+	var retryAt, failedAt *time.Time
+	if failure.Dead {
+		now := time.Now().UTC()
+		failedAt = &now
+	} else {
+		at := failure.RetryAt.UTC()
+		retryAt = &at
+	}
+
+	query := `INSERT INTO outbox_delivery (outbox_id, sink, attempts, retry_at, failed_at)
+			  VALUES (?, ?, ?, ?, ?)
+			  ON CONFLICT (sink, outbox_id) DO UPDATE
+			  SET attempts = excluded.attempts, retry_at = excluded.retry_at, failed_at = excluded.failed_at
+			  WHERE outbox_delivery.delivered_at IS NULL`
+
+	if _, err := s.db.ExecContext(ctx, query, failure.ID, sink, failure.Attempts, retryAt, failedAt); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *SQLiteStorage) postExists(ctx context.Context, id int) error {
+	var exists bool
+	if err := s.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM post WHERE id = ?)`, id).Scan(&exists); err != nil {
+		return err
+	}
+
+	if !exists {
+		return apperr.ErrPostNotFound
+	}
+
+	return nil
+}
+
+func (s *SQLiteStorage) Close() error {
+	return s.db.Close()
+}
+
+func (s *SQLiteStorage) withTx(ctx context.Context, fn func(*sql.Tx) error) (err error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+
+		err = tx.Commit()
+	}()
+
+	return fn(tx)
+}
+
+func upsertSQLiteAuthor(ctx context.Context, tx *sql.Tx, name string) (model.CustomAuthor, error) {
+	var (
+		author model.CustomAuthor
+		query  = `INSERT INTO author (name, joined_at) VALUES (?, ?)
+				  ON CONFLICT (name) DO UPDATE SET name = excluded.name
+				  RETURNING id, name, joined_at`
+	)
+
+	if err := tx.QueryRowContext(ctx, query, name, time.Now().UTC()).Scan(&author.ID, &author.Name, &author.JoinedAt); err != nil {
+		return author, err
+	}
+
+	return author, nil
+}
+
+func insertSQLiteAudit(ctx context.Context, tx *sql.Tx, actor string, action model.AuditAction, targetType string, targetID int, before any, after any) error {
+	entry, err := newAuditEntry(actor, action, targetType, targetID, before, after)
+	if entry == nil || err != nil {
+		return err
+	}
+
+	query := `INSERT INTO audit_log (actor, action, target_type, target_id, before, after, created_at)
+			  VALUES (?, ?, ?, ?, ?, ?, ?)`
+
+	if _, err := tx.ExecContext(ctx, query, entry.Actor, entry.Action, entry.TargetType, entry.TargetID, nullJSON(entry.Before), nullJSON(entry.After), entry.CreatedAt); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func countSQLiteRevisions(ctx context.Context, tx *sql.Tx, targetType string, targetID int) (int, error) {
+	var (
+		count int
+		query = `SELECT COUNT(*) FROM revision WHERE target_type = ? AND target_id = ?`
+	)
+
+	if err := tx.QueryRowContext(ctx, query, targetType, targetID).Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func insertSQLiteRevision(ctx context.Context, tx *sql.Tx, revision model.CustomRevision) error {
+	query := `INSERT INTO revision (target_type, target_id, version, title, content, editor, created_at)
+			  VALUES (?, ?, ?, ?, ?, ?, ?)`
+
+	if _, err := tx.ExecContext(ctx, query, revision.TargetType, revision.TargetID, revision.Version, revision.Title, revision.Content, revision.Editor, revision.CreatedAt.UTC()); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func insertSQLiteAttachments(ctx context.Context, tx *sql.Tx, targetType string, targetID int, createdAt time.Time, attachments []model.CustomAttachment) error {
+	insert := `INSERT INTO attachment (target_type, target_id, blob_key, thumbnail_key, filename, content_type, size, width, height, created_at)
+			   VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	for _, a := range attachments {
+		if _, err := tx.ExecContext(ctx, insert, targetType, targetID, a.Key, a.ThumbnailKey, a.Filename, a.ContentType, a.Size, a.Width, a.Height, createdAt.UTC()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// sqliteQuerier is implemented by the database and by transactions.
+type sqliteQuerier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+func insertSQLitePoll(ctx context.Context, tx *sql.Tx, post model.CustomPost, input model.CustomPollInput) error {
+	insert := `INSERT INTO poll (post_id, question, multiple_choice, anonymous, closes_at, created_at)
+			   VALUES (?, ?, ?, ?, ?, ?)
+			   RETURNING id`
+
+	var closesAt *time.Time
+	if input.ClosesAt != nil {
+		c := input.ClosesAt.UTC()
+		closesAt = &c
+	}
+
+	var pollID int
+	if err := tx.QueryRowContext(ctx, insert, post.ID, input.Question, input.MultipleChoice, input.Anonymous, closesAt, post.CreatedAt).Scan(&pollID); err != nil {
+		return err
+	}
+
+	for i, text := range input.Options {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO poll_option (poll_id, position, text) VALUES (?, ?, ?)`, pollID, i, text); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// querySQLitePolls returns the polls whose column is one of ids, with their
+// results.
+func querySQLitePolls(ctx context.Context, q sqliteQuerier, column string, ids []int) ([]model.CustomPoll, error) {
+	rows, err := q.QueryContext(ctx, `SELECT `+pollColumns+` FROM poll WHERE `+column+` IN (`+placeholders(len(ids))+`) ORDER BY id`, intArgs(ids)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var polls []model.CustomPoll
+
+	for rows.Next() {
+		poll := model.CustomPoll{}
+		if err := rows.Scan(&poll.ID, &poll.PostID, &poll.Question, &poll.MultipleChoice, &poll.Anonymous, &poll.ClosesAt, &poll.CreatedAt); err != nil {
+			return nil, err
+		}
+		polls = append(polls, poll)
+	}
+
+	if err := rows.Err(); err != nil || len(polls) == 0 {
+		return nil, err
+	}
+
+	pollIDs := make([]int, 0, len(polls))
+	byID := make(map[int]*model.CustomPoll, len(polls))
+
+	for i := range polls {
+		pollIDs = append(pollIDs, polls[i].ID)
+		byID[polls[i].ID] = &polls[i]
+	}
+
+	query := `SELECT id, poll_id, text FROM poll_option WHERE poll_id IN (` + placeholders(len(pollIDs)) + `) ORDER BY poll_id, position`
+
+	optionRows, err := q.QueryContext(ctx, query, intArgs(pollIDs)...)
+	if err != nil {
+		return nil, err
+	}
+	defer optionRows.Close()
+
+	for optionRows.Next() {
+		var (
+			option model.CustomPollOption
+			pollID int
+		)
+		if err := optionRows.Scan(&option.ID, &pollID, &option.Text); err != nil {
+			return nil, err
+		}
+		byID[pollID].Options = append(byID[pollID].Options, option)
+	}
+
+	if err := optionRows.Err(); err != nil {
+		return nil, err
+	}
+
+	query = `SELECT poll_id, option_id, voter, created_at FROM ballot WHERE poll_id IN (` + placeholders(len(pollIDs)) + `)`
+
+	ballotRows, err := q.QueryContext(ctx, query, intArgs(pollIDs)...)
+	if err != nil {
+		return nil, err
+	}
+	defer ballotRows.Close()
+
+	var ballots []ballotRow
+
+	for ballotRows.Next() {
+		ballot := ballotRow{}
+		if err := ballotRows.Scan(&ballot.pollID, &ballot.optionID, &ballot.Voter, &ballot.CreatedAt); err != nil {
+			return nil, err
+		}
+		ballots = append(ballots, ballot)
+	}
+
+	if err := ballotRows.Err(); err != nil {
+		return nil, err
+	}
+
+	byPoll := groupBallots(ballots)
+
+	for i := range polls {
+		polls[i] = tally(polls[i], byPoll[polls[i].ID])
+	}
+
+	return polls, nil
+}
+
+// getSQLitePinnablePost returns a post that is not a draft for a change of its
+// pin.
+func getSQLitePinnablePost(ctx context.Context, tx *sql.Tx, id int) (model.CustomPost, error) {
+	query := `SELECT ` + postColumns + ` FROM post
+			  JOIN author ON post.author_id = author.id
+			  WHERE post.id = ? AND post.status NOT IN ('DRAFT', 'SCHEDULED')`
+
+	post, err := scanSQLitePost(tx.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return post, apperr.ErrPostNotFound
+	}
+
+	return post, err
+}
+
+// pinSQLitePost saves the pin of post, or its removal, audited as action.
+func pinSQLitePost(ctx context.Context, tx *sql.Tx, actor string, action model.AuditAction, before, post model.CustomPost) error {
+	update := `UPDATE post SET pinned_by = ?, pinned_at = ?, pinned_until = ? WHERE id = ?`
+
+	if _, err := tx.ExecContext(ctx, update, post.PinnedBy, post.PinnedAt, post.PinnedUntil, post.ID); err != nil {
+		return err
+	}
+
+	return insertSQLiteAudit(ctx, tx, actor, action, conv.TypePost, post.ID, before, post)
+}
+
+// publishSQLitePost gives a draft its status and publishAt time, which becomes
+// its creation time unless it is scheduled.
+func publishSQLitePost(ctx context.Context, tx *sql.Tx, post *model.CustomPost, status model.ContentStatus, reason string, publishAt time.Time) error {
+	post.Status, post.StatusReason, post.PublishAt = status, reason, &publishAt
+	if status != model.ContentStatusScheduled {
+		post.CreatedAt = publishAt
+	}
+
+	update := `UPDATE post SET status = ?, status_reason = ?, publish_at = ?, created_at = ? WHERE id = ?`
+
+	if _, err := tx.ExecContext(ctx, update, post.Status, post.StatusReason, publishAt, post.CreatedAt, post.ID); err != nil {
+		return err
+	}
+
+	return insertPublishedSQLiteEvent(ctx, tx, outbox.PostCreated, "posts", post.Status, *post)
+}
+
+func nullJSON(data json.RawMessage) sql.NullString {
+	return sql.NullString{String: string(data), Valid: data != nil}
+}
+
+func insertPublishedSQLiteEvent(ctx context.Context, tx *sql.Tx, eventType outbox.EventType, topic string, status model.ContentStatus, payload any) error {
+	if status != model.ContentStatusPublished {
+		return nil
+	}
+
+	event, err := outbox.NewEvent(eventType, topic, payload)
+	if err != nil {
+		return err
+	}
+
+	return insertSQLiteEvent(ctx, tx, event)
+}
+
+func insertSQLiteEvent(ctx context.Context, tx *sql.Tx, event outbox.Event) error {
+	query := `INSERT INTO outbox (event_id, event_type, topic, payload, created_at)
+			  VALUES (?, ?, ?, ?, ?)`
+
+	if _, err := tx.ExecContext(ctx, query, event.EventID, string(event.Type), event.Topic, string(event.Payload), event.CreatedAt.UTC()); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func scanSQLitePosts(rows *sql.Rows) ([]model.CustomPost, error) {
+	defer rows.Close()
+
+	var posts []model.CustomPost
+
+	for rows.Next() {
+		post, err := scanSQLitePost(rows)
+		if err != nil {
+			return nil, err
+		}
+		posts = append(posts, post)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return posts, nil
+}
+
+func scanSQLiteComments(rows *sql.Rows) ([]model.CustomComment, error) {
+	defer rows.Close()
+
+	var comments []model.CustomComment
+
+	for rows.Next() {
+		comment, err := scanSQLiteComment(rows)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, comment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return comments, nil
+}
+
+func scanSQLiteReports(rows *sql.Rows) ([]model.CustomReport, error) {
+	defer rows.Close()
+
+	var reports []model.CustomReport
+
+	for rows.Next() {
+		report, err := scanSQLiteReport(rows)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return reports, nil
+}
+
+type sqliteRow interface {
+	Scan(dest ...any) error
+}
+
+// scanSQLitePost scans a row of postColumns.
+func scanSQLitePost(row sqliteRow) (model.CustomPost, error) {
+	post := model.CustomPost{}
+	err := row.Scan(&post.ID, &post.Title, &post.Content, &post.CreatedAt, &post.CommentsAllowed, &post.Status, &post.StatusReason, &post.PublishAt, &post.PinnedBy, &post.PinnedAt, &post.PinnedUntil, &post.EditedBy, &post.EditedAt, &post.Author.ID, &post.Author.Name, &post.Author.JoinedAt)
+	return post, err
+}
+
+// scanSQLiteComment scans a row of commentColumns.
+func scanSQLiteComment(row sqliteRow) (model.CustomComment, error) {
+	comment := model.CustomComment{}
+	err := row.Scan(&comment.ID, &comment.Content, &comment.CreatedAt, &comment.PostID, &comment.ParentID, &comment.Status, &comment.StatusReason, &comment.EditedBy, &comment.EditedAt, &comment.Author.ID, &comment.Author.Name, &comment.Author.JoinedAt)
+	return comment, err
+}
+
+// scanSQLiteReport scans a row of reportColumns.
+func scanSQLiteReport(row sqliteRow) (model.CustomReport, error) {
+	report := model.CustomReport{}
+	err := row.Scan(&report.ID, &report.TargetType, &report.TargetID, &report.Reporter, &report.Reason, &report.Details, &report.CreatedAt, &report.ResolvedBy, &report.ResolvedAt, &report.Action)
+	return report, err
+}
+
+// scanSQLiteBan scans a row of banColumns.
+func scanSQLiteBan(row sqliteRow) (model.CustomBan, error) {
+	ban := model.CustomBan{}
+	err := row.Scan(&ban.ID, &ban.Author, &ban.Kind, &ban.Reason, &ban.Moderator, &ban.CreatedAt, &ban.ExpiresAt, &ban.LiftedBy, &ban.LiftedAt)
+	return ban, err
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+func intArgs(ids []int) []any {
+	args := make([]any, 0, len(ids))
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	return args
+}
@@ -0,0 +1,177 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/erknas/forum/graph/model"
+	"github.com/erknas/forum/internal/config"
+	"github.com/erknas/forum/migrations/migrator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSQLiteStorage(t *testing.T) *SQLiteStorage {
+	t.Helper()
+
+	cfg := &config.Config{
+		SQLiteConfig: config.SQLiteConfig{
+			Path:          filepath.Join(t.TempDir(), "forum.db"),
+			MigrationPath: "file://../../migrations/sqlite",
+		},
+	}
+
+	require.NoError(t, migrator.NewSQLite(cfg))
+
+	s, err := NewSQLiteStorage(context.Background(), cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = s.Close() })
+
+	return s
+}
+
+func TestSQLiteCreateAndGetPost(t *testing.T) {
+	s := newTestSQLiteStorage(t)
+
+	created, err := s.CreatePost(context.Background(), model.CustomPostInput{
+		Title:           "Title",
+		Author:          "Bob",
+		Content:         "Content",
+		CommentsAllowed: true,
+	})
+	require.NoError(t, err)
+
+	post, err := s.GetPostByID(context.Background(), created.ID)
+	require.NoError(t, err)
+
+	assert.Equal(t, created.Title, post.Title)
+	assert.Equal(t, created.Author.ID, post.Author.ID)
+	assert.WithinDuration(t, created.CreatedAt, post.CreatedAt, 0)
+
+	_, err = s.GetPostByID(context.Background(), created.ID+1)
+	assert.EqualError(t, err, "post not found")
+}
+
+func TestSQLiteCreateComment_RollsBackAuthor(t *testing.T) {
+	s := newTestSQLiteStorage(t)
+
+	post, err := s.CreatePost(context.Background(), model.CustomPostInput{
+		Title:           "Title",
+		Author:          "Bob",
+		Content:         "Content",
+		CommentsAllowed: true,
+	})
+	require.NoError(t, err)
+
+	_, err = s.CreateComment(context.Background(), model.CustomCommentInput{
+		PostID:  post.ID,
+		Author:  "Orphan",
+		Content: strings.Repeat("a", 2001),
+	})
+	require.Error(t, err)
+
+	_, err = s.GetAuthorByName(context.Background(), "Orphan")
+	assert.EqualError(t, err, "author not found")
+
+	events, err := s.PendingEvents(context.Background(), "sink", time.Now(), 10)
+	require.NoError(t, err)
+	assert.Len(t, events, 1)
+}
+
+func TestSQLiteCreatePost_ConcurrentNewAuthor(t *testing.T) {
+	s := newTestSQLiteStorage(t)
+
+	var (
+		wg   sync.WaitGroup
+		errs = make(chan error, hammerWorkers)
+	)
+
+	for i := 0; i < hammerWorkers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			_, err := s.CreatePost(context.Background(), model.CustomPostInput{
+				Title:           fmt.Sprintf("Title %d", i),
+				Author:          "Hammer",
+				Content:         "Content",
+				CommentsAllowed: true,
+			})
+			errs <- err
+		}(i)
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+
+	author, err := s.GetAuthorByName(context.Background(), "Hammer")
+	require.NoError(t, err)
+
+	stats, err := s.GetAuthorStats(context.Background(), author.ID, "")
+	require.NoError(t, err)
+	assert.Equal(t, hammerWorkers, stats.PostCount)
+}
+
+func TestSQLiteOutbox(t *testing.T) {
+	s := newTestSQLiteStorage(t)
+
+	post, err := s.CreatePost(context.Background(), model.CustomPostInput{
+		Title:           "Title",
+		Author:          "Bob",
+		Content:         "Content",
+		CommentsAllowed: true,
+	})
+	require.NoError(t, err)
+
+	_, err = s.CreateComment(context.Background(), model.CustomCommentInput{
+		PostID:  post.ID,
+		Author:  "Alice",
+		Content: "Comment",
+	})
+	require.NoError(t, err)
+
+	events, err := s.PendingEvents(context.Background(), "sink", time.Now(), 10)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+
+	require.NoError(t, s.MarkDelivered(context.Background(), "sink", []int64{events[0].ID}))
+
+	events, err = s.PendingEvents(context.Background(), "sink", time.Now(), 10)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+
+	var comment model.CustomComment
+	require.NoError(t, json.Unmarshal(events[0].Payload, &comment))
+	assert.Equal(t, "Alice", comment.Author.Name)
+}
+
+func TestSQLiteAuditLog_AppendOnly(t *testing.T) {
+	s := newTestSQLiteStorage(t)
+
+	post, err := s.CreatePost(context.Background(), model.CustomPostInput{Title: "Title", Author: "Bob", Content: "Content"})
+	require.NoError(t, err)
+
+	_, err = s.SetPostStatus(context.Background(), post.ID, model.ContentStatusRejected, "spam", "Mod")
+	require.NoError(t, err)
+
+	_, err = s.db.Exec(`UPDATE audit_log SET actor = 'Someone'`)
+	assert.ErrorContains(t, err, "append-only")
+
+	_, err = s.db.Exec(`DELETE FROM audit_log`)
+	assert.ErrorContains(t, err, "append-only")
+
+	entries, err := s.GetAuditLog(context.Background(), model.CustomAuditFilter{}, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "Mod", entries[0].Actor)
+}
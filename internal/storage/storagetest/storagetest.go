@@ -0,0 +1,1900 @@
+// Package storagetest contains behavioral tests every storage.Storer
+// implementation must pass.
+package storagetest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/erknas/forum/graph/model"
+	"github.com/erknas/forum/internal/outbox"
+	"github.com/erknas/forum/internal/storage"
+	"github.com/erknas/forum/pkg/apperr"
+	"github.com/erknas/forum/pkg/conv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Factory returns an empty storage. It is called once per test case.
+type Factory func(t *testing.T) storage.Storer
+
+// Run runs the conformance suite against the storages returned by newStore.
+func Run(t *testing.T, newStore Factory) {
+	tests := []struct {
+		name string
+		run  func(*testing.T, storage.Storer)
+	}{
+		{name: "CreatePost", run: testCreatePost},
+		{name: "GetPosts/Ordering", run: testGetPostsOrdering},
+		{name: "GetPosts/Empty", run: testGetPostsEmpty},
+		{name: "GetLatestPosts", run: testGetLatestPosts},
+		{name: "GetLatestComments", run: testGetLatestComments},
+		{name: "GetPostByID/NotFound", run: testGetPostByIDNotFound},
+		{name: "CreateComment/PostNotFound", run: testCreateCommentPostNotFound},
+		{name: "CreateComment/CommentsNotAllowed", run: testCreateCommentNotAllowed},
+		{name: "CreateComment/Reply", run: testCreateCommentReply},
+		{name: "CreateComment/ReplyToMissingComment", run: testCreateCommentReplyMissing},
+		{name: "CreateComment/ReplyToCommentOfAnotherPost", run: testCreateCommentReplyOtherPost},
+		{name: "GetCommentsByPost/TopLevelOnly", run: testGetCommentsByPostTopLevel},
+		{name: "GetCommentsByPost/PaginationBounds", run: testGetCommentsByPostPagination},
+		{name: "GetCommentsByPost/PostNotFound", run: testGetCommentsByPostNotFound},
+		{name: "GetCommentByID", run: testGetCommentByID},
+		{name: "GetCommentReplies/Ordering", run: testGetCommentRepliesOrdering},
+		{name: "Authors/SharedIdentity", run: testAuthorsSharedIdentity},
+		{name: "Authors/NotFound", run: testAuthorsNotFound},
+		{name: "Authors/PostsAndComments", run: testAuthorActivity},
+		{name: "Batch/GetPostsByIDs", run: testGetPostsByIDs},
+		{name: "Batch/GetCommentsByPostIDs", run: testGetCommentsByPostIDs},
+		{name: "Batch/GetRepliesByParentIDs", run: testGetRepliesByParentIDs},
+		{name: "Batch/GetAuthorStatsByIDs", run: testGetAuthorStatsByIDs},
+		{name: "Outbox", run: testOutbox},
+		{name: "Outbox/Failures", run: testOutboxFailures},
+		{name: "Moderation/PendingIsHidden", run: testPendingIsHidden},
+		{name: "Moderation/Approve", run: testApprove},
+		{name: "Moderation/Reject", run: testReject},
+		{name: "Moderation/PendingContent", run: testGetPendingContent},
+		{name: "Moderation/NotFound", run: testSetStatusNotFound},
+		{name: "Reports/Deduplicate", run: testCreateReport},
+		{name: "Reports/Groups", run: testGetReportGroups},
+		{name: "Reports/Resolve", run: testResolveReports},
+		{name: "Reports/Hide", run: testCreateReportHide},
+		{name: "Reports/ResolveStatus", run: testResolveReportsStatus},
+		{name: "Audit/StatusChanges", run: testAuditStatusChanges},
+		{name: "Audit/ResolveReports", run: testAuditResolveReports},
+		{name: "Audit/Filter", run: testAuditFilter},
+		{name: "Bans/CreateAndLift", run: testBans},
+		{name: "Bans/Replace", run: testReplaceBan},
+		{name: "Bans/Expiry", run: testBanExpiry},
+		{name: "Bans/ShadowedListedForAuthor", run: testShadowedListedForAuthor},
+		{name: "Revisions/EditPost", run: testEditPost},
+		{name: "Revisions/EditComment", run: testEditComment},
+		{name: "Revisions/NotFound", run: testEditNotFound},
+		{name: "Drafts/Hidden", run: testDraftsHidden},
+		{name: "Drafts/Publish", run: testPublishPost},
+		{name: "Drafts/PublishDue", run: testPublishDuePosts},
+		{name: "Drafts/PublishDueBanned", run: testPublishDuePostsBanned},
+		{name: "Pins/Order", run: testPinOrder},
+		{name: "Pins/Unpin", run: testUnpinPost},
+		{name: "Pins/Expiry", run: testPinExpiry},
+		{name: "Polls/Create", run: testCreatePoll},
+		{name: "Polls/Vote", run: testVote},
+		{name: "Polls/Invalid", run: testVoteInvalid},
+		{name: "Attachments", run: testAttachments},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t, newStore(t))
+		})
+	}
+}
+
+func createPost(t *testing.T, s storage.Storer, author string, commentsAllowed bool) model.CustomPost {
+	t.Helper()
+
+	post, err := s.CreatePost(context.Background(), model.CustomPostInput{
+		Title:           "Title by " + author,
+		Author:          author,
+		Content:         "Content",
+		CommentsAllowed: commentsAllowed,
+	})
+	require.NoError(t, err)
+
+	return post
+}
+
+func createComment(t *testing.T, s storage.Storer, postID int, parentID *int, content string) model.CustomComment {
+	t.Helper()
+
+	comment, err := s.CreateComment(context.Background(), model.CustomCommentInput{
+		PostID:   postID,
+		Author:   "Commenter",
+		Content:  content,
+		ParentID: parentID,
+	})
+	require.NoError(t, err)
+
+	return comment
+}
+
+func ids[T any](items []T, id func(T) int) []int {
+	result := make([]int, 0, len(items))
+	for _, item := range items {
+		result = append(result, id(item))
+	}
+	return result
+}
+
+func postID(p model.CustomPost) int       { return p.ID }
+func commentID(c model.CustomComment) int { return c.ID }
+
+func testCreatePost(t *testing.T, s storage.Storer) {
+	created := createPost(t, s, "Bob", true)
+
+	assert.Positive(t, created.ID)
+	assert.False(t, created.CreatedAt.IsZero())
+	assert.Equal(t, "Bob", created.Author.Name)
+
+	post, err := s.GetPostByID(context.Background(), created.ID)
+	require.NoError(t, err)
+
+	assert.Equal(t, created.ID, post.ID)
+	assert.Equal(t, created.Title, post.Title)
+	assert.Equal(t, created.Content, post.Content)
+	assert.Equal(t, created.CommentsAllowed, post.CommentsAllowed)
+	assert.Equal(t, created.Author.ID, post.Author.ID)
+	assert.WithinDuration(t, created.CreatedAt, post.CreatedAt, 0)
+	assert.Empty(t, post.Comments)
+}
+
+func testGetPostsOrdering(t *testing.T, s storage.Storer) {
+	var want []int
+	for i := 0; i < 5; i++ {
+		want = append(want, createPost(t, s, fmt.Sprintf("Author%d", i), true).ID)
+	}
+
+	posts, err := s.GetPosts(context.Background(), "")
+	require.NoError(t, err)
+
+	assert.Equal(t, want, ids(posts, postID))
+}
+
+func testGetPostsEmpty(t *testing.T, s storage.Storer) {
+	posts, err := s.GetPosts(context.Background(), "")
+	require.NoError(t, err)
+	assert.Empty(t, posts)
+}
+
+func testGetLatestPosts(t *testing.T, s storage.Storer) {
+	ctx := context.Background()
+
+	first := createPost(t, s, "Alice", true)
+	second := createPost(t, s, "Bob", true)
+	pendingPost(t, s, "Carol")
+	third := createPost(t, s, "Dave", true)
+
+	_, err := s.PinPost(ctx, first.ID, nil, "Mod")
+	require.NoError(t, err)
+
+	posts, err := s.GetLatestPosts(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []int{third.ID, second.ID, first.ID}, ids(posts, postID), "newest first, pins ignored")
+
+	posts, err = s.GetLatestPosts(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []int{third.ID, second.ID}, ids(posts, postID))
+}
+
+func testGetLatestComments(t *testing.T, s storage.Storer) {
+	ctx := context.Background()
+
+	post := createPost(t, s, "Alice", true)
+	other := createPost(t, s, "Alice", true)
+
+	parent := createComment(t, s, post.ID, nil, "Parent")
+	reply := createComment(t, s, post.ID, &parent.ID, "Reply")
+	hidden := createComment(t, s, post.ID, nil, "Hidden later")
+	createComment(t, s, post.ID, &hidden.ID, "Reply to a hidden comment")
+	pendingComment(t, s, post.ID, nil)
+	nested := createComment(t, s, post.ID, &reply.ID, "Nested reply")
+	createComment(t, s, other.ID, nil, "Elsewhere")
+
+	_, err := s.SetCommentStatus(ctx, hidden.ID, model.ContentStatusRejected, "offensive", "")
+	require.NoError(t, err)
+
+	comments, err := s.GetLatestComments(ctx, post.ID, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []int{nested.ID, reply.ID, parent.ID}, ids(comments, commentID), "replies of hidden comments are not listed")
+
+	comments, err = s.GetLatestComments(ctx, post.ID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []int{nested.ID}, ids(comments, commentID))
+
+	comments, err = s.GetLatestComments(ctx, 1000, 10)
+	assert.ErrorIs(t, err, apperr.ErrPostNotFound)
+	assert.Empty(t, comments)
+}
+
+func testGetPostByIDNotFound(t *testing.T, s storage.Storer) {
+	_, err := s.GetPostByID(context.Background(), 42)
+	assert.ErrorIs(t, err, apperr.ErrPostNotFound)
+}
+
+func testCreateCommentPostNotFound(t *testing.T, s storage.Storer) {
+	_, err := s.CreateComment(context.Background(), model.CustomCommentInput{PostID: 42, Author: "Bob", Content: "Comment"})
+	assert.ErrorIs(t, err, apperr.ErrPostNotFound)
+}
+
+func testCreateCommentNotAllowed(t *testing.T, s storage.Storer) {
+	post := createPost(t, s, "Bob", false)
+
+	_, err := s.CreateComment(context.Background(), model.CustomCommentInput{PostID: post.ID, Author: "Bob", Content: "Comment"})
+	assert.ErrorIs(t, err, apperr.ErrCommentsDisabled)
+}
+
+func testCreateCommentReply(t *testing.T, s storage.Storer) {
+	post := createPost(t, s, "Bob", true)
+	parent := createComment(t, s, post.ID, nil, "Parent")
+	reply := createComment(t, s, post.ID, &parent.ID, "Reply")
+
+	require.NotNil(t, reply.ParentID)
+	assert.Equal(t, parent.ID, *reply.ParentID)
+	assert.Equal(t, post.ID, reply.PostID)
+}
+
+func testCreateCommentReplyMissing(t *testing.T, s storage.Storer) {
+	post := createPost(t, s, "Bob", true)
+	createComment(t, s, post.ID, nil, "Parent")
+
+	missing := 1000
+
+	_, err := s.CreateComment(context.Background(), model.CustomCommentInput{PostID: post.ID, Author: "Bob", Content: "Reply", ParentID: &missing})
+	assert.ErrorIs(t, err, apperr.ErrCommentNotFound)
+}
+
+func testCreateCommentReplyOtherPost(t *testing.T, s storage.Storer) {
+	first := createPost(t, s, "Bob", true)
+	second := createPost(t, s, "Bob", true)
+
+	// The comment ID must not be mistaken for a position in the second post.
+	createComment(t, s, second.ID, nil, "Second post comment")
+	parent := createComment(t, s, first.ID, nil, "First post comment")
+
+	_, err := s.CreateComment(context.Background(), model.CustomCommentInput{PostID: second.ID, Author: "Bob", Content: "Reply", ParentID: &parent.ID})
+	assert.ErrorIs(t, err, apperr.ErrCommentNotFound)
+}
+
+func testGetCommentsByPostTopLevel(t *testing.T, s storage.Storer) {
+	post := createPost(t, s, "Bob", true)
+	other := createPost(t, s, "Bob", true)
+
+	first := createComment(t, s, post.ID, nil, "First")
+	createComment(t, s, post.ID, &first.ID, "Reply")
+	createComment(t, s, other.ID, nil, "Other post")
+	second := createComment(t, s, post.ID, nil, "Second")
+
+	comments, err := s.GetCommentsByPost(context.Background(), post.ID, 0, 10, "")
+	require.NoError(t, err)
+
+	assert.Equal(t, []int{first.ID, second.ID}, ids(comments, commentID))
+	for _, comment := range comments {
+		assert.Nil(t, comment.ParentID)
+		assert.Equal(t, "Commenter", comment.Author.Name)
+	}
+}
+
+func testGetCommentsByPostPagination(t *testing.T, s storage.Storer) {
+	post := createPost(t, s, "Bob", true)
+
+	var all []int
+	for i := 0; i < 5; i++ {
+		all = append(all, createComment(t, s, post.ID, nil, fmt.Sprintf("Comment %d", i)).ID)
+	}
+
+	tests := []struct {
+		name   string
+		offset int
+		limit  int
+		want   []int
+	}{
+		{name: "First page", offset: 0, limit: 2, want: all[0:2]},
+		{name: "Middle page", offset: 2, limit: 2, want: all[2:4]},
+		{name: "Last partial page", offset: 4, limit: 2, want: all[4:5]},
+		{name: "Limit above total", offset: 0, limit: 100, want: all},
+		{name: "Offset at end", offset: 5, limit: 2, want: []int{}},
+		{name: "Offset beyond end", offset: 50, limit: 2, want: []int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			comments, err := s.GetCommentsByPost(context.Background(), post.ID, tt.offset, tt.limit, "")
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, ids(comments, commentID))
+		})
+	}
+}
+
+func testGetCommentsByPostNotFound(t *testing.T, s storage.Storer) {
+	_, err := s.GetCommentsByPost(context.Background(), 42, 0, 10, "")
+	assert.ErrorIs(t, err, apperr.ErrPostNotFound)
+}
+
+func testGetCommentByID(t *testing.T, s storage.Storer) {
+	post := createPost(t, s, "Bob", true)
+	parent := createComment(t, s, post.ID, nil, "Parent")
+	reply := createComment(t, s, post.ID, &parent.ID, "Reply")
+
+	comment, err := s.GetCommentByID(context.Background(), reply.ID)
+	require.NoError(t, err)
+
+	assert.Equal(t, reply.ID, comment.ID)
+	assert.Equal(t, "Reply", comment.Content)
+	assert.Equal(t, post.ID, comment.PostID)
+	require.NotNil(t, comment.ParentID)
+	assert.Equal(t, parent.ID, *comment.ParentID)
+	assert.Equal(t, reply.Author.ID, comment.Author.ID)
+
+	_, err = s.GetCommentByID(context.Background(), 1000)
+	assert.ErrorIs(t, err, apperr.ErrCommentNotFound)
+}
+
+func testGetCommentRepliesOrdering(t *testing.T, s storage.Storer) {
+	post := createPost(t, s, "Bob", true)
+	parent := createComment(t, s, post.ID, nil, "Parent")
+
+	var want []int
+	for i := 0; i < 5; i++ {
+		want = append(want, createComment(t, s, post.ID, &parent.ID, fmt.Sprintf("Reply %d", i)).ID)
+	}
+
+	nested := createComment(t, s, post.ID, &want[0], "Nested")
+
+	replies, err := s.GetCommentReplies(context.Background(), parent.ID, "")
+	require.NoError(t, err)
+	assert.Equal(t, want, ids(replies, commentID))
+
+	replies, err = s.GetCommentReplies(context.Background(), want[0], "")
+	require.NoError(t, err)
+	assert.Equal(t, []int{nested.ID}, ids(replies, commentID))
+
+	replies, err = s.GetCommentReplies(context.Background(), nested.ID, "")
+	require.NoError(t, err)
+	assert.Empty(t, replies)
+}
+
+func testAuthorsSharedIdentity(t *testing.T, s storage.Storer) {
+	post := createPost(t, s, "Commenter", true)
+	comment := createComment(t, s, post.ID, nil, "Comment")
+
+	assert.Equal(t, post.Author.ID, comment.Author.ID)
+
+	author, err := s.GetAuthorByName(context.Background(), "Commenter")
+	require.NoError(t, err)
+	assert.Equal(t, post.Author.ID, author.ID)
+
+	byID, err := s.GetAuthorByID(context.Background(), author.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Commenter", byID.Name)
+
+	stats, err := s.GetAuthorStats(context.Background(), author.ID, "")
+	require.NoError(t, err)
+	assert.Equal(t, model.AuthorStats{PostCount: 1, CommentCount: 1}, stats)
+}
+
+func testAuthorsNotFound(t *testing.T, s storage.Storer) {
+	_, err := s.GetAuthorByID(context.Background(), 42)
+	assert.ErrorIs(t, err, apperr.ErrAuthorNotFound)
+
+	_, err = s.GetAuthorByName(context.Background(), "Nobody")
+	assert.ErrorIs(t, err, apperr.ErrAuthorNotFound)
+}
+
+func testAuthorActivity(t *testing.T, s storage.Storer) {
+	first := createPost(t, s, "Commenter", true)
+	createPost(t, s, "Bob", true)
+	second := createPost(t, s, "Commenter", true)
+
+	c1 := createComment(t, s, first.ID, nil, "First")
+	c2 := createComment(t, s, second.ID, nil, "Second")
+
+	posts, err := s.GetPostsByAuthor(context.Background(), first.Author.ID, 0, 10, "")
+	require.NoError(t, err)
+	assert.Equal(t, []int{second.ID, first.ID}, ids(posts, postID))
+
+	posts, err = s.GetPostsByAuthor(context.Background(), first.Author.ID, 1, 10, "")
+	require.NoError(t, err)
+	assert.Equal(t, []int{first.ID}, ids(posts, postID))
+
+	comments, err := s.GetCommentsByAuthor(context.Background(), c1.Author.ID, 0, 1, "")
+	require.NoError(t, err)
+	assert.Equal(t, []int{c2.ID}, ids(comments, commentID))
+	assert.Equal(t, second.ID, comments[0].PostID)
+}
+
+func testGetPostsByIDs(t *testing.T, s storage.Storer) {
+	first := createPost(t, s, "Bob", true)
+	createPost(t, s, "Bob", true)
+	third := createPost(t, s, "Alice", true)
+
+	posts, err := s.GetPostsByIDs(context.Background(), []int{third.ID, 1000, first.ID})
+	require.NoError(t, err)
+
+	assert.Equal(t, []int{first.ID, third.ID}, ids(posts, postID))
+	assert.Equal(t, "Alice", posts[1].Author.Name)
+
+	posts, err = s.GetPostsByIDs(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Empty(t, posts)
+}
+
+func testGetCommentsByPostIDs(t *testing.T, s storage.Storer) {
+	first := createPost(t, s, "Bob", true)
+	second := createPost(t, s, "Bob", true)
+	empty := createPost(t, s, "Bob", true)
+
+	var firstComments, secondComments []int
+	for i := 0; i < 3; i++ {
+		firstComments = append(firstComments, createComment(t, s, first.ID, nil, fmt.Sprintf("First %d", i)).ID)
+		secondComments = append(secondComments, createComment(t, s, second.ID, nil, fmt.Sprintf("Second %d", i)).ID)
+	}
+	createComment(t, s, first.ID, &firstComments[0], "Reply")
+
+	postIDs := []int{second.ID, empty.ID, first.ID, 1000}
+
+	comments, err := s.GetCommentsByPostIDs(context.Background(), postIDs, 0, 2, "")
+	require.NoError(t, err)
+	assert.Equal(t, []int{firstComments[0], firstComments[1], secondComments[0], secondComments[1]}, ids(comments, commentID))
+
+	comments, err = s.GetCommentsByPostIDs(context.Background(), postIDs, 2, 2, "")
+	require.NoError(t, err)
+	assert.Equal(t, []int{firstComments[2], secondComments[2]}, ids(comments, commentID))
+
+	for _, comment := range comments {
+		assert.Nil(t, comment.ParentID)
+	}
+}
+
+func testGetRepliesByParentIDs(t *testing.T, s storage.Storer) {
+	post := createPost(t, s, "Bob", true)
+	first := createComment(t, s, post.ID, nil, "First")
+	second := createComment(t, s, post.ID, nil, "Second")
+	lonely := createComment(t, s, post.ID, nil, "Lonely")
+
+	r1 := createComment(t, s, post.ID, &first.ID, "Reply 1")
+	r2 := createComment(t, s, post.ID, &second.ID, "Reply 2")
+	r3 := createComment(t, s, post.ID, &first.ID, "Reply 3")
+	createComment(t, s, post.ID, &r1.ID, "Nested")
+
+	replies, err := s.GetRepliesByParentIDs(context.Background(), []int{second.ID, first.ID, lonely.ID}, "")
+	require.NoError(t, err)
+
+	assert.Equal(t, []int{r1.ID, r2.ID, r3.ID}, ids(replies, commentID))
+	assert.Equal(t, first.ID, *replies[0].ParentID)
+	assert.Equal(t, second.ID, *replies[1].ParentID)
+}
+
+func testGetAuthorStatsByIDs(t *testing.T, s storage.Storer) {
+	post := createPost(t, s, "Bob", true)
+	createPost(t, s, "Bob", true)
+	alice := createPost(t, s, "Alice", true).Author
+	createComment(t, s, post.ID, nil, "Comment")
+	createComment(t, s, post.ID, nil, "Comment")
+
+	bob := post.Author
+
+	commenter, err := s.GetAuthorByName(context.Background(), "Commenter")
+	require.NoError(t, err)
+
+	stats, err := s.GetAuthorStatsByIDs(context.Background(), []int{bob.ID, alice.ID, commenter.ID, 1000}, "")
+	require.NoError(t, err)
+
+	assert.Equal(t, map[int]model.AuthorStats{
+		bob.ID:       {PostCount: 2},
+		alice.ID:     {PostCount: 1},
+		commenter.ID: {CommentCount: 2},
+	}, stats)
+}
+
+func testOutbox(t *testing.T, s storage.Storer) {
+	post := createPost(t, s, "Bob", true)
+	comment := createComment(t, s, post.ID, nil, "Comment")
+
+	events, err := s.PendingEvents(context.Background(), "sink", time.Now(), 10)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+
+	assert.Equal(t, outbox.PostCreated, events[0].Type)
+	assert.Equal(t, outbox.CommentCreated, events[1].Type)
+	assert.Equal(t, fmt.Sprint(comment.PostID), events[1].Topic)
+	assert.NotEqual(t, events[0].EventID, events[1].EventID)
+
+	limited, err := s.PendingEvents(context.Background(), "sink", time.Now(), 1)
+	require.NoError(t, err)
+	assert.Len(t, limited, 1)
+
+	require.NoError(t, s.MarkDelivered(context.Background(), "sink", []int64{events[0].ID}))
+
+	events, err = s.PendingEvents(context.Background(), "sink", time.Now(), 10)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, outbox.CommentCreated, events[0].Type)
+
+	other, err := s.PendingEvents(context.Background(), "other", time.Now(), 10)
+	require.NoError(t, err)
+	assert.Len(t, other, 2, "deliveries are kept per sink")
+}
+
+func testOutboxFailures(t *testing.T, s storage.Storer) {
+	ctx := context.Background()
+	now := time.Now()
+
+	post := createPost(t, s, "Bob", true)
+	createComment(t, s, post.ID, nil, "Comment")
+
+	events, err := s.PendingEvents(ctx, "sink", now, 10)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Zero(t, events[0].Attempts)
+
+	retry := outbox.Failure{ID: events[0].ID, Attempts: 1, RetryAt: now.Add(time.Minute)}
+	require.NoError(t, s.MarkFailed(ctx, "sink", retry))
+
+	pending, err := s.PendingEvents(ctx, "sink", now, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{events[1].ID}, eventIDs(pending), "failed events wait for their retry")
+
+	pending, err = s.PendingEvents(ctx, "other", now, 10)
+	require.NoError(t, err)
+	assert.Len(t, pending, 2, "other sinks are not held back")
+
+	pending, err = s.PendingEvents(ctx, "sink", now.Add(2*time.Minute), 10)
+	require.NoError(t, err)
+	require.Equal(t, []int64{events[0].ID, events[1].ID}, eventIDs(pending))
+	assert.Equal(t, 1, pending[0].Attempts)
+
+	require.NoError(t, s.MarkFailed(ctx, "sink", outbox.Failure{ID: events[0].ID, Attempts: 2, Dead: true}))
+
+	pending, err = s.PendingEvents(ctx, "sink", now.Add(time.Hour), 10)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{events[1].ID}, eventIDs(pending), "dead events are not retried")
+
+	require.NoError(t, s.MarkFailed(ctx, "sink", outbox.Failure{ID: events[1].ID, Attempts: 1, RetryAt: now}))
+	require.NoError(t, s.MarkDelivered(ctx, "sink", []int64{events[1].ID}))
+
+	pending, err = s.PendingEvents(ctx, "sink", now.Add(time.Hour), 10)
+	require.NoError(t, err)
+	assert.Empty(t, pending, "a retried event is delivered")
+}
+
+func eventIDs(events []outbox.Event) []int64 {
+	ids := make([]int64, 0, len(events))
+	for _, event := range events {
+		ids = append(ids, event.ID)
+	}
+	return ids
+}
+
+func pendingPost(t *testing.T, s storage.Storer, author string) model.CustomPost {
+	t.Helper()
+
+	post, err := s.CreatePost(context.Background(), model.CustomPostInput{
+		Title:           "Pending by " + author,
+		Author:          author,
+		Content:         "Content",
+		CommentsAllowed: true,
+		Status:          model.ContentStatusPending,
+		StatusReason:    "too many links",
+	})
+	require.NoError(t, err)
+
+	return post
+}
+
+func pendingComment(t *testing.T, s storage.Storer, postID int, parentID *int) model.CustomComment {
+	t.Helper()
+
+	comment, err := s.CreateComment(context.Background(), model.CustomCommentInput{
+		PostID:       postID,
+		Author:       "Commenter",
+		Content:      "Pending comment",
+		ParentID:     parentID,
+		Status:       model.ContentStatusPending,
+		StatusReason: "looks like spam",
+	})
+	require.NoError(t, err)
+
+	return comment
+}
+
+func testPendingIsHidden(t *testing.T, s storage.Storer) {
+	ctx := context.Background()
+
+	published := createPost(t, s, "Bob", true)
+	pending := pendingPost(t, s, "Bob")
+	assert.Equal(t, model.ContentStatusPending, pending.Status)
+	assert.Equal(t, "too many links", pending.StatusReason)
+
+	parent := createComment(t, s, published.ID, nil, "Published")
+	pendingTop := pendingComment(t, s, published.ID, nil)
+	pendingReply := pendingComment(t, s, published.ID, &parent.ID)
+
+	posts, err := s.GetPosts(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, []int{published.ID}, ids(posts, postID))
+
+	post, err := s.GetPostByID(ctx, pending.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.ContentStatusPending, post.Status)
+
+	comments, err := s.GetCommentsByPost(ctx, published.ID, 0, 10, "")
+	require.NoError(t, err)
+	assert.Equal(t, []int{parent.ID}, ids(comments, commentID))
+
+	comments, err = s.GetCommentsByPostIDs(ctx, []int{published.ID}, 0, 10, "")
+	require.NoError(t, err)
+	assert.Equal(t, []int{parent.ID}, ids(comments, commentID))
+
+	replies, err := s.GetCommentReplies(ctx, parent.ID, "")
+	require.NoError(t, err)
+	assert.Empty(t, replies)
+
+	replies, err = s.GetRepliesByParentIDs(ctx, []int{parent.ID}, "")
+	require.NoError(t, err)
+	assert.Empty(t, replies)
+
+	comment, err := s.GetCommentByID(ctx, pendingTop.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.ContentStatusPending, comment.Status)
+
+	stats, err := s.GetAuthorStats(ctx, published.Author.ID, "")
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.PostCount)
+
+	byAuthor, err := s.GetPostsByAuthor(ctx, published.Author.ID, 0, 10, "")
+	require.NoError(t, err)
+	assert.Equal(t, []int{published.ID}, ids(byAuthor, postID))
+
+	commentsByAuthor, err := s.GetCommentsByAuthor(ctx, parent.Author.ID, 0, 10, "")
+	require.NoError(t, err)
+	assert.Equal(t, []int{parent.ID}, ids(commentsByAuthor, commentID))
+
+	_, err = s.CreateComment(ctx, model.CustomCommentInput{PostID: pending.ID, Author: "Commenter", Content: "Comment"})
+	assert.ErrorIs(t, err, apperr.ErrPostNotFound)
+
+	_, err = s.CreateComment(ctx, model.CustomCommentInput{PostID: published.ID, Author: "Commenter", Content: "Reply", ParentID: &pendingReply.ID})
+	assert.ErrorIs(t, err, apperr.ErrCommentNotFound)
+
+	events, err := s.PendingEvents(ctx, "sink", time.Now(), 10)
+	require.NoError(t, err)
+	assert.Len(t, events, 2, "only published content emits events")
+}
+
+func testApprove(t *testing.T, s storage.Storer) {
+	ctx := context.Background()
+
+	post := pendingPost(t, s, "Bob")
+
+	approved, err := s.SetPostStatus(ctx, post.ID, model.ContentStatusPublished, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, model.ContentStatusPublished, approved.Status)
+	assert.Empty(t, approved.StatusReason)
+	assert.Equal(t, post.Title, approved.Title)
+
+	posts, err := s.GetPosts(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, []int{post.ID}, ids(posts, postID))
+
+	comment := pendingComment(t, s, post.ID, nil)
+
+	approvedComment, err := s.SetCommentStatus(ctx, comment.ID, model.ContentStatusPublished, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, model.ContentStatusPublished, approvedComment.Status)
+	assert.Equal(t, comment.Content, approvedComment.Content)
+
+	comments, err := s.GetCommentsByPost(ctx, post.ID, 0, 10, "")
+	require.NoError(t, err)
+	assert.Equal(t, []int{comment.ID}, ids(comments, commentID))
+
+	events, err := s.PendingEvents(ctx, "sink", time.Now(), 10)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, outbox.PostCreated, events[0].Type)
+	assert.Equal(t, outbox.CommentCreated, events[1].Type)
+
+	_, err = s.SetPostStatus(ctx, post.ID, model.ContentStatusPublished, "", "")
+	require.NoError(t, err)
+
+	events, err = s.PendingEvents(ctx, "sink", time.Now(), 10)
+	require.NoError(t, err)
+	assert.Len(t, events, 2, "publishing twice emits a single event")
+}
+
+func testReject(t *testing.T, s storage.Storer) {
+	ctx := context.Background()
+
+	post := createPost(t, s, "Bob", true)
+	comment := createComment(t, s, post.ID, nil, "Comment")
+
+	rejected, err := s.SetCommentStatus(ctx, comment.ID, model.ContentStatusRejected, "offensive", "")
+	require.NoError(t, err)
+	assert.Equal(t, model.ContentStatusRejected, rejected.Status)
+	assert.Equal(t, "offensive", rejected.StatusReason)
+
+	stored, err := s.GetCommentByID(ctx, comment.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.ContentStatusRejected, stored.Status)
+	assert.Equal(t, "offensive", stored.StatusReason)
+
+	comments, err := s.GetCommentsByPost(ctx, post.ID, 0, 10, "")
+	require.NoError(t, err)
+	assert.Empty(t, comments)
+
+	_, err = s.SetPostStatus(ctx, post.ID, model.ContentStatusRejected, "spam", "")
+	require.NoError(t, err)
+
+	posts, err := s.GetPosts(ctx, "")
+	require.NoError(t, err)
+	assert.Empty(t, posts)
+
+	pending, err := s.GetPendingContent(ctx, 0, 10)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func testGetPendingContent(t *testing.T, s storage.Storer) {
+	ctx := context.Background()
+
+	first := pendingPost(t, s, "Bob")
+	published := createPost(t, s, "Alice", true)
+	comment := pendingComment(t, s, published.ID, nil)
+	second := pendingPost(t, s, "Alice")
+
+	pending, err := s.GetPendingContent(ctx, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 3)
+
+	require.NotNil(t, pending[0].Post)
+	assert.Equal(t, first.ID, pending[0].Post.ID)
+	assert.Equal(t, "Bob", pending[0].Post.Author.Name)
+	require.NotNil(t, pending[1].Comment)
+	assert.Equal(t, comment.ID, pending[1].Comment.ID)
+	assert.Equal(t, "Pending comment", pending[1].Comment.Content)
+	require.NotNil(t, pending[2].Post)
+	assert.Equal(t, second.ID, pending[2].Post.ID)
+
+	page, err := s.GetPendingContent(ctx, 1, 1)
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+	require.NotNil(t, page[0].Comment)
+	assert.Equal(t, comment.ID, page[0].Comment.ID)
+}
+
+func testSetStatusNotFound(t *testing.T, s storage.Storer) {
+	_, err := s.SetPostStatus(context.Background(), 1000, model.ContentStatusPublished, "", "")
+	assert.ErrorIs(t, err, apperr.ErrPostNotFound)
+
+	_, err = s.SetCommentStatus(context.Background(), 1000, model.ContentStatusPublished, "", "")
+	assert.ErrorIs(t, err, apperr.ErrCommentNotFound)
+}
+
+func report(t *testing.T, s storage.Storer, targetType string, targetID int, reporter string, reason model.ReportReason) (model.CustomReport, int) {
+	t.Helper()
+
+	created, open, err := s.CreateReport(context.Background(), model.CustomReportInput{
+		TargetType: targetType,
+		TargetID:   targetID,
+		Reporter:   reporter,
+		Reason:     reason,
+		Details:    "details by " + reporter,
+	})
+	require.NoError(t, err)
+
+	return created, open
+}
+
+func reportID(r model.CustomReport) int { return r.ID }
+
+func testCreateReport(t *testing.T, s storage.Storer) {
+	first, open := report(t, s, conv.TypePost, 1, "Alice", model.ReportReasonSpam)
+	assert.Positive(t, first.ID)
+	assert.Equal(t, 1, open)
+	assert.Equal(t, conv.TypePost, first.TargetType)
+	assert.Equal(t, 1, first.TargetID)
+	assert.Equal(t, "Alice", first.Reporter)
+	assert.Equal(t, model.ReportReasonSpam, first.Reason)
+	assert.Equal(t, "details by Alice", first.Details)
+	assert.False(t, first.CreatedAt.IsZero())
+	assert.Nil(t, first.ResolvedAt)
+
+	again, open := report(t, s, conv.TypePost, 1, "Alice", model.ReportReasonAbuse)
+	assert.Equal(t, first.ID, again.ID, "a second report by the same user is not stored")
+	assert.Equal(t, model.ReportReasonSpam, again.Reason)
+	assert.Equal(t, 1, open)
+
+	_, open = report(t, s, conv.TypePost, 1, "Bob", model.ReportReasonAbuse)
+	assert.Equal(t, 2, open)
+
+	_, open = report(t, s, conv.TypeComment, 1, "Alice", model.ReportReasonSpam)
+	assert.Equal(t, 1, open, "reports are counted per target")
+}
+
+func testGetReportGroups(t *testing.T, s storage.Storer) {
+	ctx := context.Background()
+
+	a, _ := report(t, s, conv.TypePost, 1, "Alice", model.ReportReasonSpam)
+	b, _ := report(t, s, conv.TypeComment, 2, "Alice", model.ReportReasonAbuse)
+	c, _ := report(t, s, conv.TypeComment, 2, "Bob", model.ReportReasonSpam)
+	d, _ := report(t, s, conv.TypePost, 3, "Bob", model.ReportReasonOther)
+
+	groups, err := s.GetReportGroups(ctx, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, groups, 3)
+
+	assert.Equal(t, conv.TypeComment, groups[0].TargetType)
+	assert.Equal(t, 2, groups[0].TargetID)
+	assert.Equal(t, []int{b.ID, c.ID}, ids(groups[0].Reports, reportID))
+
+	assert.Equal(t, conv.TypePost, groups[1].TargetType)
+	assert.Equal(t, 1, groups[1].TargetID)
+	assert.Equal(t, []int{a.ID}, ids(groups[1].Reports, reportID))
+
+	assert.Equal(t, 3, groups[2].TargetID)
+	assert.Equal(t, []int{d.ID}, ids(groups[2].Reports, reportID))
+
+	groups, err = s.GetReportGroups(ctx, 1, 1)
+	require.NoError(t, err)
+	require.Len(t, groups, 1)
+	assert.Equal(t, 1, groups[0].TargetID)
+}
+
+func testResolveReports(t *testing.T, s storage.Storer) {
+	ctx := context.Background()
+
+	a, _ := report(t, s, conv.TypePost, 1, "Alice", model.ReportReasonSpam)
+	b, _ := report(t, s, conv.TypePost, 1, "Bob", model.ReportReasonSpam)
+	report(t, s, conv.TypePost, 2, "Bob", model.ReportReasonSpam)
+
+	resolved, err := s.ResolveReports(ctx, conv.TypePost, 1, "Mod", model.ReportActionDismissed, model.CustomStatusChange{})
+	require.NoError(t, err)
+	require.Equal(t, []int{a.ID, b.ID}, ids(resolved, reportID))
+
+	for _, r := range resolved {
+		assert.Equal(t, "Mod", r.ResolvedBy)
+		assert.Equal(t, model.ReportActionDismissed, r.Action)
+		require.NotNil(t, r.ResolvedAt)
+		assert.False(t, r.ResolvedAt.IsZero())
+	}
+
+	groups, err := s.GetReportGroups(ctx, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, groups, 1)
+	assert.Equal(t, 2, groups[0].TargetID)
+
+	_, err = s.ResolveReports(ctx, conv.TypePost, 1, "Mod", model.ReportActionRejected, model.CustomStatusChange{})
+	assert.ErrorIs(t, err, apperr.ErrNoOpenReports)
+
+	again, open := report(t, s, conv.TypePost, 1, "Alice", model.ReportReasonAbuse)
+	assert.NotEqual(t, a.ID, again.ID, "a resolved report does not block a new one")
+	assert.Equal(t, 1, open)
+}
+
+func testCreateReportHide(t *testing.T, s storage.Storer) {
+	ctx := context.Background()
+
+	post := createPost(t, s, "Alice", true)
+	pending := pendingPost(t, s, "Alice")
+
+	hide := model.CustomStatusChange{
+		Status: model.ContentStatusPending,
+		Reason: model.ReportedReason,
+		From:   []model.ContentStatus{model.ContentStatusPublished},
+	}
+
+	hideReport := func(id int, reporter string) {
+		t.Helper()
+
+		_, _, err := s.CreateReport(ctx, model.CustomReportInput{
+			TargetType: conv.TypePost,
+			TargetID:   id,
+			Reporter:   reporter,
+			Reason:     model.ReportReasonSpam,
+			HideAt:     2,
+			Hide:       hide,
+		})
+		require.NoError(t, err)
+	}
+
+	hideReport(post.ID, "Bob")
+
+	got, err := s.GetPostByID(ctx, post.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.ContentStatusPublished, got.Status.OrPublished())
+
+	hideReport(post.ID, "Carol")
+
+	got, err = s.GetPostByID(ctx, post.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.ContentStatusPending, got.Status)
+	assert.Equal(t, model.ReportedReason, got.StatusReason)
+
+	hideReport(pending.ID, "Bob")
+	hideReport(pending.ID, "Carol")
+
+	got, err = s.GetPostByID(ctx, pending.ID)
+	require.NoError(t, err)
+	assert.Equal(t, pending.StatusReason, got.StatusReason, "only published content is hidden")
+
+	_, _, err = s.CreateReport(ctx, model.CustomReportInput{TargetType: conv.TypePost, TargetID: 1000, Reporter: "Bob", Reason: model.ReportReasonSpam, HideAt: 1, Hide: hide})
+	assert.ErrorIs(t, err, apperr.ErrPostNotFound)
+
+	groups, err := s.GetReportGroups(ctx, 0, 10)
+	require.NoError(t, err)
+	assert.Len(t, groups, 2, "a report is not stored if its target cannot be hidden")
+}
+
+func testResolveReportsStatus(t *testing.T, s storage.Storer) {
+	ctx := context.Background()
+
+	post := createPost(t, s, "Alice", true)
+	report(t, s, conv.TypePost, post.ID, "Bob", model.ReportReasonSpam)
+
+	_, err := s.ResolveReports(ctx, conv.TypeComment, 1000, "Mod", model.ReportActionRejected, model.CustomStatusChange{Status: model.ContentStatusRejected})
+	assert.ErrorIs(t, err, apperr.ErrNoOpenReports)
+
+	report(t, s, conv.TypeComment, 1000, "Bob", model.ReportReasonSpam)
+
+	_, err = s.ResolveReports(ctx, conv.TypeComment, 1000, "Mod", model.ReportActionRejected, model.CustomStatusChange{Status: model.ContentStatusRejected})
+	assert.ErrorIs(t, err, apperr.ErrCommentNotFound)
+
+	groups, err := s.GetReportGroups(ctx, 0, 10)
+	require.NoError(t, err)
+	assert.Len(t, groups, 2, "reports stay open if the status change fails")
+
+	reject := model.CustomStatusChange{Status: model.ContentStatusRejected, Reason: "removed after reports"}
+
+	_, err = s.ResolveReports(ctx, conv.TypePost, post.ID, "Mod", model.ReportActionRejected, reject)
+	require.NoError(t, err)
+
+	got, err := s.GetPostByID(ctx, post.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.ContentStatusRejected, got.Status)
+	assert.Equal(t, "removed after reports", got.StatusReason)
+
+	entries, err := s.GetAuditLog(ctx, model.CustomAuditFilter{}, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, model.AuditActionRejectContent, entries[0].Action, "newest first")
+	assert.Equal(t, model.AuditActionResolveReports, entries[1].Action)
+	assert.Greater(t, entries[0].ID, entries[1].ID)
+
+	rejected, err := s.GetAuditLog(ctx, model.CustomAuditFilter{Action: model.AuditActionRejectContent}, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, rejected, 1, "the rejection is replayed when the filters are trained")
+	assert.Equal(t, "Mod", rejected[0].Actor)
+
+	var rejectedPost model.CustomPost
+	require.NoError(t, json.Unmarshal(rejected[0].After, &rejectedPost))
+	assert.Equal(t, post.Content, rejectedPost.Content)
+	assert.Equal(t, post.Author.Name, rejectedPost.Author.Name)
+
+	report(t, s, conv.TypePost, post.ID, "Carol", model.ReportReasonSpam)
+
+	dismiss := model.CustomStatusChange{
+		Status:     model.ContentStatusPublished,
+		From:       []model.ContentStatus{model.ContentStatusPending},
+		FromReason: model.ReportedReason,
+	}
+
+	_, err = s.ResolveReports(ctx, conv.TypePost, post.ID, "Mod", model.ReportActionDismissed, dismiss)
+	require.NoError(t, err)
+
+	got, err = s.GetPostByID(ctx, post.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.ContentStatusRejected, got.Status, "the change only applies to its From statuses")
+
+	held := pendingPost(t, s, "Alice")
+	report(t, s, conv.TypePost, held.ID, "Bob", model.ReportReasonSpam)
+
+	_, err = s.ResolveReports(ctx, conv.TypePost, held.ID, "Mod", model.ReportActionDismissed, dismiss)
+	require.NoError(t, err)
+
+	got, err = s.GetPostByID(ctx, held.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.ContentStatusPending, got.Status, "content held by filters stays pending")
+	assert.Equal(t, held.StatusReason, got.StatusReason)
+
+	hidden := createPost(t, s, "Alice", true)
+	_, err = s.SetPostStatus(ctx, hidden.ID, model.ContentStatusPending, model.ReportedReason, "")
+	require.NoError(t, err)
+	report(t, s, conv.TypePost, hidden.ID, "Bob", model.ReportReasonSpam)
+
+	_, err = s.ResolveReports(ctx, conv.TypePost, hidden.ID, "Mod", model.ReportActionDismissed, dismiss)
+	require.NoError(t, err)
+
+	got, err = s.GetPostByID(ctx, hidden.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.ContentStatusPublished, got.Status, "content hidden by reports is published again")
+}
+
+func testAuditStatusChanges(t *testing.T, s storage.Storer) {
+	ctx := context.Background()
+
+	post := pendingPost(t, s, "Alice")
+	comment := createComment(t, s, createPost(t, s, "Bob", true).ID, nil, "Content")
+
+	_, err := s.SetPostStatus(ctx, post.ID, model.ContentStatusPublished, "", "Mod")
+	require.NoError(t, err)
+
+	_, err = s.SetCommentStatus(ctx, comment.ID, model.ContentStatusRejected, "offensive", "Mod")
+	require.NoError(t, err)
+
+	_, err = s.SetCommentStatus(ctx, comment.ID, model.ContentStatusPending, "hidden after 3 reports", "")
+	require.NoError(t, err)
+
+	entries, err := s.GetAuditLog(ctx, model.CustomAuditFilter{}, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, entries, 2, "changes without an actor are not audited")
+
+	rejected, approved := entries[0], entries[1]
+	assert.Greater(t, rejected.ID, approved.ID, "newest first")
+
+	assert.Equal(t, "Mod", approved.Actor)
+	assert.Equal(t, model.AuditActionApproveContent, approved.Action)
+	assert.Equal(t, conv.TypePost, approved.TargetType)
+	assert.Equal(t, post.ID, approved.TargetID)
+	assert.False(t, approved.CreatedAt.IsZero())
+
+	var before, after model.CustomPost
+	require.NoError(t, json.Unmarshal(approved.Before, &before))
+	require.NoError(t, json.Unmarshal(approved.After, &after))
+	assert.Equal(t, model.ContentStatusPending, before.Status)
+	assert.Equal(t, model.ContentStatusPublished, after.Status)
+	assert.Equal(t, post.Title, after.Title)
+
+	assert.Equal(t, model.AuditActionRejectContent, rejected.Action)
+	assert.Equal(t, conv.TypeComment, rejected.TargetType)
+
+	var rejectedComment model.CustomComment
+	require.NoError(t, json.Unmarshal(rejected.After, &rejectedComment))
+	assert.Equal(t, "offensive", rejectedComment.StatusReason)
+}
+
+func testAuditResolveReports(t *testing.T, s storage.Storer) {
+	ctx := context.Background()
+
+	report(t, s, conv.TypePost, 1, "Alice", model.ReportReasonSpam)
+	report(t, s, conv.TypePost, 1, "Bob", model.ReportReasonAbuse)
+
+	_, err := s.ResolveReports(ctx, conv.TypePost, 1, "Mod", model.ReportActionDismissed, model.CustomStatusChange{})
+	require.NoError(t, err)
+
+	entries, err := s.GetAuditLog(ctx, model.CustomAuditFilter{}, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	entry := entries[0]
+	assert.Equal(t, "Mod", entry.Actor)
+	assert.Equal(t, model.AuditActionResolveReports, entry.Action)
+	assert.Equal(t, conv.TypePost, entry.TargetType)
+	assert.Equal(t, 1, entry.TargetID)
+
+	var before, after []model.CustomReport
+	require.NoError(t, json.Unmarshal(entry.Before, &before))
+	require.NoError(t, json.Unmarshal(entry.After, &after))
+	require.Len(t, before, 2)
+	require.Len(t, after, 2)
+	assert.Nil(t, before[0].ResolvedAt)
+	assert.Equal(t, "Mod", after[0].ResolvedBy)
+	assert.Equal(t, model.ReportActionDismissed, after[1].Action)
+}
+
+func testAuditFilter(t *testing.T, s storage.Storer) {
+	ctx := context.Background()
+
+	first := createPost(t, s, "Alice", true)
+	second := createPost(t, s, "Bob", true)
+
+	_, err := s.SetPostStatus(ctx, first.ID, model.ContentStatusRejected, "spam", "Mod")
+	require.NoError(t, err)
+
+	_, err = s.SetPostStatus(ctx, second.ID, model.ContentStatusRejected, "spam", "Admin")
+	require.NoError(t, err)
+
+	_, err = s.SetPostStatus(ctx, first.ID, model.ContentStatusPublished, "", "Admin")
+	require.NoError(t, err)
+
+	targets := func(entries []model.CustomAuditEntry) []int {
+		return ids(entries, func(e model.CustomAuditEntry) int { return e.TargetID })
+	}
+
+	entries, err := s.GetAuditLog(ctx, model.CustomAuditFilter{Actor: "Admin"}, 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []int{first.ID, second.ID}, targets(entries))
+
+	entries, err = s.GetAuditLog(ctx, model.CustomAuditFilter{Action: model.AuditActionRejectContent}, 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []int{second.ID, first.ID}, targets(entries))
+
+	entries, err = s.GetAuditLog(ctx, model.CustomAuditFilter{TargetType: conv.TypePost, TargetID: first.ID}, 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []int{first.ID, first.ID}, targets(entries))
+
+	entries, err = s.GetAuditLog(ctx, model.CustomAuditFilter{TargetType: conv.TypeComment, TargetID: first.ID}, 0, 10)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	entries, err = s.GetAuditLog(ctx, model.CustomAuditFilter{}, 1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []int{second.ID}, targets(entries))
+
+	entries, err = s.GetAuditLog(ctx, model.CustomAuditFilter{Since: time.Now().Add(-time.Hour), Until: time.Now().Add(time.Hour)}, 0, 10)
+	require.NoError(t, err)
+	assert.Len(t, entries, 3)
+
+	entries, err = s.GetAuditLog(ctx, model.CustomAuditFilter{Since: time.Now().Add(time.Hour)}, 0, 10)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	entries, err = s.GetAuditLog(ctx, model.CustomAuditFilter{Until: time.Now().Add(-time.Hour)}, 0, 10)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func ban(t *testing.T, s storage.Storer, author string, kind model.BanKind, expiresAt *time.Time) model.CustomBan {
+	t.Helper()
+
+	ban, err := s.CreateBan(context.Background(), model.CustomBanInput{Author: author, Kind: kind, Reason: "spam", Moderator: "Mod", ExpiresAt: expiresAt})
+	require.NoError(t, err)
+
+	return ban
+}
+
+func banID(b model.CustomBan) int { return b.ID }
+
+func testBans(t *testing.T, s storage.Storer) {
+	ctx := context.Background()
+
+	_, err := s.GetActiveBan(ctx, "Alice")
+	assert.ErrorIs(t, err, apperr.ErrNoActiveBan)
+
+	expiresAt := time.Now().Add(time.Hour)
+
+	alice := ban(t, s, "Alice", model.BanKindBan, &expiresAt)
+	assert.Equal(t, "Alice", alice.Author)
+	assert.Equal(t, model.BanKindBan, alice.Kind)
+	assert.Equal(t, "Mod", alice.Moderator)
+	assert.False(t, alice.CreatedAt.IsZero())
+	require.NotNil(t, alice.ExpiresAt)
+	assert.WithinDuration(t, expiresAt, *alice.ExpiresAt, time.Millisecond)
+	assert.Nil(t, alice.LiftedAt)
+
+	bob := ban(t, s, "Bob", model.BanKindShadowban, nil)
+
+	active, err := s.GetActiveBan(ctx, "Alice")
+	require.NoError(t, err)
+	assert.Equal(t, alice.ID, active.ID)
+
+	bans, err := s.GetActiveBans(ctx, 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []int{bob.ID, alice.ID}, ids(bans, banID))
+
+	bans, err = s.GetActiveBans(ctx, 1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []int{alice.ID}, ids(bans, banID))
+
+	lifted, err := s.LiftBan(ctx, "Alice", "Admin")
+	require.NoError(t, err)
+	assert.Equal(t, alice.ID, lifted.ID)
+	assert.Equal(t, "Admin", lifted.LiftedBy)
+	require.NotNil(t, lifted.LiftedAt)
+
+	_, err = s.GetActiveBan(ctx, "Alice")
+	assert.ErrorIs(t, err, apperr.ErrNoActiveBan)
+
+	_, err = s.LiftBan(ctx, "Alice", "Admin")
+	assert.ErrorIs(t, err, apperr.ErrNoActiveBan)
+
+	bans, err = s.GetActiveBans(ctx, 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []int{bob.ID}, ids(bans, banID))
+
+	entries, err := s.GetAuditLog(ctx, model.CustomAuditFilter{TargetType: conv.TypeBan, TargetID: alice.ID}, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, model.AuditActionUnbanUser, entries[0].Action)
+	assert.Equal(t, "Admin", entries[0].Actor)
+	assert.Equal(t, model.AuditActionBanUser, entries[1].Action)
+	assert.Equal(t, "Mod", entries[1].Actor)
+	assert.Empty(t, entries[1].Before, "a new ban has no previous state")
+
+	var after model.CustomBan
+	require.NoError(t, json.Unmarshal(entries[0].After, &after))
+	assert.Equal(t, "Admin", after.LiftedBy)
+}
+
+func testReplaceBan(t *testing.T, s storage.Storer) {
+	ctx := context.Background()
+
+	first := ban(t, s, "Alice", model.BanKindShadowban, nil)
+	second := ban(t, s, "Alice", model.BanKindBan, nil)
+	assert.NotEqual(t, first.ID, second.ID)
+
+	active, err := s.GetActiveBan(ctx, "Alice")
+	require.NoError(t, err)
+	assert.Equal(t, second.ID, active.ID)
+	assert.Equal(t, model.BanKindBan, active.Kind)
+
+	bans, err := s.GetActiveBans(ctx, 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []int{second.ID}, ids(bans, banID))
+
+	entries, err := s.GetAuditLog(ctx, model.CustomAuditFilter{TargetType: conv.TypeBan, TargetID: second.ID}, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	var before model.CustomBan
+	require.NoError(t, json.Unmarshal(entries[0].Before, &before))
+	assert.Equal(t, first.ID, before.ID)
+}
+
+func testBanExpiry(t *testing.T, s storage.Storer) {
+	ctx := context.Background()
+
+	expired := time.Now().Add(-time.Minute)
+	ban(t, s, "Alice", model.BanKindBan, &expired)
+
+	_, err := s.GetActiveBan(ctx, "Alice")
+	assert.ErrorIs(t, err, apperr.ErrNoActiveBan)
+
+	_, err = s.LiftBan(ctx, "Alice", "Mod")
+	assert.ErrorIs(t, err, apperr.ErrNoActiveBan)
+
+	bans, err := s.GetActiveBans(ctx, 0, 10)
+	require.NoError(t, err)
+	assert.Empty(t, bans)
+
+	next := ban(t, s, "Alice", model.BanKindBan, nil)
+
+	active, err := s.GetActiveBan(ctx, "Alice")
+	require.NoError(t, err)
+	assert.Equal(t, next.ID, active.ID)
+}
+
+func testShadowedListedForAuthor(t *testing.T, s storage.Storer) {
+	ctx := context.Background()
+
+	published := createPost(t, s, "Bob", true)
+	shadowed, err := s.CreatePost(ctx, model.CustomPostInput{Title: "Title", Author: "Troll", Content: "Content", CommentsAllowed: true, Status: model.ContentStatusShadowed})
+	require.NoError(t, err)
+
+	top, err := s.CreateComment(ctx, model.CustomCommentInput{PostID: published.ID, Author: "Troll", Content: "Top", Status: model.ContentStatusShadowed})
+	require.NoError(t, err)
+
+	reply, err := s.CreateComment(ctx, model.CustomCommentInput{PostID: published.ID, Author: "Troll", Content: "Reply", ParentID: &top.ID, Status: model.ContentStatusShadowed})
+	require.NoError(t, err, "the author can reply to their own shadowed comment")
+
+	onShadowed, err := s.CreateComment(ctx, model.CustomCommentInput{PostID: shadowed.ID, Author: "Troll", Content: "Comment", Status: model.ContentStatusShadowed})
+	require.NoError(t, err, "the author can comment on their own shadowed post")
+
+	_, err = s.CreateComment(ctx, model.CustomCommentInput{PostID: shadowed.ID, Author: "Commenter", Content: "Comment"})
+	assert.ErrorIs(t, err, apperr.ErrPostNotFound)
+
+	_, err = s.CreateComment(ctx, model.CustomCommentInput{PostID: published.ID, Author: "Commenter", Content: "Reply", ParentID: &top.ID})
+	assert.ErrorIs(t, err, apperr.ErrCommentNotFound)
+
+	for viewer, want := range map[string]struct{ posts, comments, replies []int }{
+		"":      {posts: []int{published.ID}, comments: []int{}, replies: []int{}},
+		"Bob":   {posts: []int{published.ID}, comments: []int{}, replies: []int{}},
+		"Troll": {posts: []int{published.ID, shadowed.ID}, comments: []int{top.ID}, replies: []int{reply.ID}},
+	} {
+		posts, err := s.GetPosts(ctx, viewer)
+		require.NoError(t, err)
+		assert.Equal(t, want.posts, ids(posts, postID), viewer)
+
+		comments, err := s.GetCommentsByPost(ctx, published.ID, 0, 10, viewer)
+		require.NoError(t, err)
+		assert.Equal(t, want.comments, ids(comments, commentID), viewer)
+
+		comments, err = s.GetCommentsByPostIDs(ctx, []int{published.ID}, 0, 10, viewer)
+		require.NoError(t, err)
+		assert.Equal(t, want.comments, ids(comments, commentID), viewer)
+
+		replies, err := s.GetCommentReplies(ctx, top.ID, viewer)
+		require.NoError(t, err)
+		assert.Equal(t, want.replies, ids(replies, commentID), viewer)
+
+		replies, err = s.GetRepliesByParentIDs(ctx, []int{top.ID}, viewer)
+		require.NoError(t, err)
+		assert.Equal(t, want.replies, ids(replies, commentID), viewer)
+	}
+
+	byAuthor, err := s.GetPostsByAuthor(ctx, shadowed.Author.ID, 0, 10, "Bob")
+	require.NoError(t, err)
+	assert.Empty(t, byAuthor)
+
+	byAuthor, err = s.GetPostsByAuthor(ctx, shadowed.Author.ID, 0, 10, "Troll")
+	require.NoError(t, err)
+	assert.Equal(t, []int{shadowed.ID}, ids(byAuthor, postID))
+
+	commentsByAuthor, err := s.GetCommentsByAuthor(ctx, top.Author.ID, 0, 10, "Bob")
+	require.NoError(t, err)
+	assert.Empty(t, commentsByAuthor)
+
+	commentsByAuthor, err = s.GetCommentsByAuthor(ctx, top.Author.ID, 0, 10, "Troll")
+	require.NoError(t, err)
+	assert.Equal(t, []int{onShadowed.ID, reply.ID, top.ID}, ids(commentsByAuthor, commentID))
+
+	stats, err := s.GetAuthorStats(ctx, shadowed.Author.ID, "Bob")
+	require.NoError(t, err)
+	assert.Equal(t, model.AuthorStats{}, stats)
+
+	stats, err = s.GetAuthorStats(ctx, shadowed.Author.ID, "Troll")
+	require.NoError(t, err)
+	assert.Equal(t, model.AuthorStats{PostCount: 1, CommentCount: 3}, stats)
+
+	byIDs, err := s.GetAuthorStatsByIDs(ctx, []int{shadowed.Author.ID}, "Troll")
+	require.NoError(t, err)
+	assert.Equal(t, map[int]model.AuthorStats{shadowed.Author.ID: {PostCount: 1, CommentCount: 3}}, byIDs)
+}
+
+func testEditPost(t *testing.T, s storage.Storer) {
+	ctx := context.Background()
+
+	created := createPost(t, s, "Bob", true)
+
+	first, err := s.EditPost(ctx, model.CustomEdit{ID: created.ID, Title: "Fixed title", Content: "Fixed\ncontent", Editor: "Bob"}, "")
+	require.NoError(t, err)
+
+	assert.Equal(t, "Fixed title", first.Title)
+	assert.Equal(t, "Fixed\ncontent", first.Content)
+	assert.Equal(t, "Bob", first.EditedBy)
+	require.NotNil(t, first.EditedAt)
+	assert.Equal(t, model.ContentStatusPublished, first.Status)
+
+	second, err := s.EditPost(ctx, model.CustomEdit{ID: created.ID, Title: "Moderated", Content: "Moderated", Editor: "Mod"}, "Mod")
+	require.NoError(t, err)
+
+	post, err := s.GetPostByID(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Moderated", post.Title)
+	assert.Equal(t, "Mod", post.EditedBy)
+	require.NotNil(t, post.EditedAt)
+	assert.WithinDuration(t, *second.EditedAt, *post.EditedAt, time.Millisecond)
+
+	revisions, err := s.GetRevisions(ctx, conv.TypePost, []int{created.ID, created.ID + 100})
+	require.NoError(t, err)
+	require.Len(t, revisions, 1, "targets that were never edited have no revisions")
+	require.Len(t, revisions[created.ID], 2)
+
+	original, edited := revisions[created.ID][0], revisions[created.ID][1]
+
+	assert.Equal(t, 1, original.Version)
+	assert.Equal(t, created.Title, original.Title)
+	assert.Equal(t, created.Content, original.Content)
+	assert.Equal(t, "Bob", original.Editor)
+	assert.WithinDuration(t, created.CreatedAt, original.CreatedAt, time.Millisecond)
+
+	assert.Equal(t, 2, edited.Version)
+	assert.Equal(t, "Fixed title", edited.Title)
+	assert.Equal(t, "Fixed\ncontent", edited.Content)
+	assert.Equal(t, "Bob", edited.Editor)
+	assert.WithinDuration(t, *first.EditedAt, edited.CreatedAt, time.Millisecond)
+
+	entries, err := s.GetAuditLog(ctx, model.CustomAuditFilter{}, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, entries, 1, "edits by the author are not audited")
+	assert.Equal(t, model.AuditActionEditContent, entries[0].Action)
+	assert.Equal(t, "Mod", entries[0].Actor)
+
+	var before model.CustomPost
+	require.NoError(t, json.Unmarshal(entries[0].Before, &before))
+	assert.Equal(t, "Fixed title", before.Title)
+}
+
+func testEditComment(t *testing.T, s storage.Storer) {
+	ctx := context.Background()
+
+	post := createPost(t, s, "Bob", true)
+	created := createComment(t, s, post.ID, nil, "Original")
+
+	edited, err := s.EditComment(ctx, model.CustomEdit{
+		ID:           created.ID,
+		Content:      "Buy now",
+		Editor:       "Commenter",
+		Status:       model.ContentStatusPending,
+		StatusReason: "spam",
+	}, "")
+	require.NoError(t, err)
+
+	assert.Equal(t, "Buy now", edited.Content)
+	assert.Equal(t, model.ContentStatusPending, edited.Status)
+	assert.NotNil(t, edited.EditedAt)
+
+	comment, err := s.GetCommentByID(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Buy now", comment.Content)
+	assert.Equal(t, "spam", comment.StatusReason)
+
+	comments, err := s.GetCommentsByPost(ctx, post.ID, 0, 10, "")
+	require.NoError(t, err)
+	assert.Empty(t, comments, "a held edit hides the comment")
+
+	revisions, err := s.GetRevisions(ctx, conv.TypeComment, []int{created.ID})
+	require.NoError(t, err)
+	require.Len(t, revisions[created.ID], 1)
+	assert.Equal(t, "Original", revisions[created.ID][0].Content)
+	assert.Empty(t, revisions[created.ID][0].Title)
+
+	revisions, err = s.GetRevisions(ctx, conv.TypePost, []int{created.ID})
+	require.NoError(t, err)
+	assert.Empty(t, revisions, "revisions are kept per target type")
+}
+
+func testEditNotFound(t *testing.T, s storage.Storer) {
+	ctx := context.Background()
+
+	_, err := s.EditPost(ctx, model.CustomEdit{ID: 42, Title: "Title", Content: "Content", Editor: "Bob"}, "")
+	assert.ErrorIs(t, err, apperr.ErrPostNotFound)
+
+	_, err = s.EditComment(ctx, model.CustomEdit{ID: 42, Content: "Content", Editor: "Bob"}, "")
+	assert.ErrorIs(t, err, apperr.ErrCommentNotFound)
+}
+
+func draftPost(t *testing.T, s storage.Storer, author string, publishAt *time.Time) model.CustomPost {
+	t.Helper()
+
+	input := model.CustomPostInput{
+		Title:           "Draft by " + author,
+		Author:          author,
+		Content:         "Content",
+		CommentsAllowed: true,
+		Status:          model.ContentStatusDraft,
+	}
+
+	if publishAt != nil {
+		input.Status, input.PublishAt = model.ContentStatusScheduled, publishAt
+	}
+
+	post, err := s.CreatePost(context.Background(), input)
+	require.NoError(t, err)
+
+	return post
+}
+
+func testDraftsHidden(t *testing.T, s storage.Storer) {
+	ctx := context.Background()
+
+	publishAt := time.Now().Add(time.Hour)
+
+	published := createPost(t, s, "Bob", true)
+	draft := draftPost(t, s, "Bob", nil)
+	scheduled := draftPost(t, s, "Bob", &publishAt)
+	draftPost(t, s, "Alice", nil)
+
+	assert.Equal(t, model.ContentStatusDraft, draft.Status)
+	assert.Equal(t, model.ContentStatusScheduled, scheduled.Status)
+	require.NotNil(t, scheduled.PublishAt)
+	assert.WithinDuration(t, publishAt, *scheduled.PublishAt, time.Millisecond)
+
+	posts, err := s.GetPosts(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, []int{published.ID}, ids(posts, postID))
+
+	byAuthor, err := s.GetPostsByAuthor(ctx, published.Author.ID, 0, 10, "")
+	require.NoError(t, err)
+	assert.Equal(t, []int{published.ID}, ids(byAuthor, postID))
+
+	stats, err := s.GetAuthorStats(ctx, published.Author.ID, "")
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.PostCount)
+
+	drafts, total, err := s.GetDrafts(ctx, "Bob", 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []int{scheduled.ID, draft.ID}, ids(drafts, postID))
+	assert.Equal(t, 2, total)
+
+	drafts, total, err = s.GetDrafts(ctx, "Bob", 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []int{draft.ID}, ids(drafts, postID))
+	assert.Equal(t, 2, total)
+
+	drafts, total, err = s.GetDrafts(ctx, "Carol", 0, 10)
+	require.NoError(t, err)
+	assert.Empty(t, drafts)
+	assert.Zero(t, total)
+
+	post, err := s.GetPostByID(ctx, draft.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.ContentStatusDraft, post.Status)
+
+	_, err = s.CreateComment(ctx, model.CustomCommentInput{PostID: draft.ID, Author: "Commenter", Content: "Comment"})
+	assert.ErrorIs(t, err, apperr.ErrPostNotFound)
+
+	_, err = s.SetPostStatus(ctx, draft.ID, model.ContentStatusPublished, "", "Mod")
+	assert.ErrorIs(t, err, apperr.ErrPostNotFound, "moderators do not publish drafts")
+
+	events, err := s.PendingEvents(ctx, "sink", time.Now(), 10)
+	require.NoError(t, err)
+	assert.Len(t, events, 1, "drafts do not emit events")
+}
+
+func testPublishPost(t *testing.T, s storage.Storer) {
+	ctx := context.Background()
+
+	draft := draftPost(t, s, "Bob", nil)
+	other := draftPost(t, s, "Bob", nil)
+
+	publishAt := time.Now().Add(time.Hour)
+
+	scheduled, err := s.PublishPost(ctx, draft.ID, model.ContentStatusScheduled, "", publishAt)
+	require.NoError(t, err)
+	assert.Equal(t, model.ContentStatusScheduled, scheduled.Status)
+	require.NotNil(t, scheduled.PublishAt)
+	assert.WithinDuration(t, publishAt, *scheduled.PublishAt, time.Millisecond)
+	assert.WithinDuration(t, draft.CreatedAt, scheduled.CreatedAt, time.Millisecond)
+
+	now := time.Now()
+
+	published, err := s.PublishPost(ctx, draft.ID, model.ContentStatusPublished, "", now)
+	require.NoError(t, err, "scheduled posts can be published early")
+	assert.Equal(t, model.ContentStatusPublished, published.Status)
+	assert.WithinDuration(t, now, published.CreatedAt, time.Millisecond)
+
+	held, err := s.PublishPost(ctx, other.ID, model.ContentStatusPending, "too many links", now)
+	require.NoError(t, err)
+	assert.Equal(t, model.ContentStatusPending, held.Status)
+	assert.Equal(t, "too many links", held.StatusReason)
+
+	_, err = s.PublishPost(ctx, draft.ID, model.ContentStatusPublished, "", now)
+	assert.ErrorIs(t, err, apperr.ErrNotDraft)
+
+	_, err = s.PublishPost(ctx, 42, model.ContentStatusPublished, "", now)
+	assert.ErrorIs(t, err, apperr.ErrPostNotFound)
+
+	post, err := s.GetPostByID(ctx, draft.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.ContentStatusPublished, post.Status)
+	assert.WithinDuration(t, now, post.CreatedAt, time.Millisecond)
+
+	posts, err := s.GetPosts(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, []int{draft.ID}, ids(posts, postID))
+
+	events, err := s.PendingEvents(ctx, "sink", time.Now(), 10)
+	require.NoError(t, err)
+	require.Len(t, events, 1, "only publishing emits an event")
+	assert.Equal(t, outbox.PostCreated, events[0].Type)
+}
+
+func testPublishDuePosts(t *testing.T, s storage.Storer) {
+	ctx := context.Background()
+
+	now := time.Now()
+	earlier, past, future := now.Add(-2*time.Minute), now.Add(-time.Minute), now.Add(time.Hour)
+
+	late := draftPost(t, s, "Bob", &past)
+	early := draftPost(t, s, "Alice", &earlier)
+	pending := draftPost(t, s, "Bob", &future)
+	draftPost(t, s, "Bob", nil)
+
+	published, err := s.PublishDuePosts(ctx, now)
+	require.NoError(t, err)
+	require.Equal(t, []int{early.ID, late.ID}, ids(published, postID), "in the order they were due")
+	assert.Equal(t, model.ContentStatusPublished, published[0].Status)
+	assert.WithinDuration(t, earlier, published[0].CreatedAt, time.Millisecond)
+
+	published, err = s.PublishDuePosts(ctx, now)
+	require.NoError(t, err)
+	assert.Empty(t, published)
+
+	posts, err := s.GetPosts(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, []int{early.ID, late.ID}, ids(posts, postID))
+
+	drafts, total, err := s.GetDrafts(ctx, "Bob", 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	assert.Contains(t, ids(drafts, postID), pending.ID)
+
+	events, err := s.PendingEvents(ctx, "sink", time.Now(), 10)
+	require.NoError(t, err)
+	assert.Len(t, events, 2)
+}
+
+func testPublishDuePostsBanned(t *testing.T, s storage.Storer) {
+	ctx := context.Background()
+
+	past := time.Now().Add(-time.Minute)
+
+	banned := draftPost(t, s, "Bob", &past)
+	shadowbanned := draftPost(t, s, "Troll", &past)
+	expired := draftPost(t, s, "Alice", &past)
+
+	ban(t, s, "Bob", model.BanKindBan, nil)
+	ban(t, s, "Troll", model.BanKindShadowban, nil)
+	ban(t, s, "Alice", model.BanKindBan, &past)
+
+	published, err := s.PublishDuePosts(ctx, time.Now())
+	require.NoError(t, err)
+	require.Equal(t, []int{shadowbanned.ID, expired.ID}, ids(published, postID))
+	assert.Equal(t, model.ContentStatusShadowed, published[0].Status)
+	assert.Equal(t, model.ShadowedReason, published[0].StatusReason)
+	assert.Equal(t, model.ContentStatusPublished, published[1].Status)
+
+	post, err := s.GetPostByID(ctx, banned.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.ContentStatusScheduled, post.Status, "posts of banned authors wait for the ban to end")
+
+	posts, err := s.GetPosts(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, []int{expired.ID}, ids(posts, postID))
+
+	events, err := s.PendingEvents(ctx, "sink", time.Now(), 10)
+	require.NoError(t, err)
+	assert.Len(t, events, 1, "shadowed posts do not emit events")
+
+	_, err = s.LiftBan(ctx, "Bob", "Mod")
+	require.NoError(t, err)
+
+	published, err = s.PublishDuePosts(ctx, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, []int{banned.ID}, ids(published, postID))
+}
+
+func testPinOrder(t *testing.T, s storage.Storer) {
+	ctx := context.Background()
+
+	first := createPost(t, s, "Bob", true)
+	second := createPost(t, s, "Bob", true)
+	third := createPost(t, s, "Alice", true)
+	fourth := createPost(t, s, "Alice", true)
+
+	pinned, err := s.PinPost(ctx, second.ID, nil, "Admin")
+	require.NoError(t, err)
+	assert.Equal(t, "Admin", pinned.PinnedBy)
+	require.NotNil(t, pinned.PinnedAt)
+	assert.Nil(t, pinned.PinnedUntil)
+	assert.True(t, pinned.Pinned(time.Now()))
+
+	until := time.Now().Add(time.Hour)
+
+	_, err = s.PinPost(ctx, fourth.ID, &until, "Admin")
+	require.NoError(t, err)
+
+	posts, err := s.GetPosts(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, []int{fourth.ID, second.ID, first.ID, third.ID}, ids(posts, postID), "most recently pinned first, then oldest first")
+
+	post, err := s.GetPostByID(ctx, fourth.ID)
+	require.NoError(t, err)
+	require.NotNil(t, post.PinnedUntil)
+	assert.WithinDuration(t, until, *post.PinnedUntil, time.Millisecond)
+
+	repinned, err := s.PinPost(ctx, fourth.ID, nil, "Root")
+	require.NoError(t, err, "pinning a pinned post replaces its pin")
+	assert.Equal(t, "Root", repinned.PinnedBy)
+	assert.Nil(t, repinned.PinnedUntil)
+
+	draft := draftPost(t, s, "Bob", nil)
+
+	_, err = s.PinPost(ctx, draft.ID, nil, "Admin")
+	assert.ErrorIs(t, err, apperr.ErrPostNotFound)
+
+	_, err = s.PinPost(ctx, 42, nil, "Admin")
+	assert.ErrorIs(t, err, apperr.ErrPostNotFound)
+}
+
+func testUnpinPost(t *testing.T, s storage.Storer) {
+	ctx := context.Background()
+
+	first := createPost(t, s, "Bob", true)
+	second := createPost(t, s, "Bob", true)
+
+	_, err := s.UnpinPost(ctx, second.ID, "Admin")
+	assert.ErrorIs(t, err, apperr.ErrNotPinned)
+
+	_, err = s.PinPost(ctx, second.ID, nil, "Admin")
+	require.NoError(t, err)
+
+	unpinned, err := s.UnpinPost(ctx, second.ID, "Root")
+	require.NoError(t, err)
+	assert.Empty(t, unpinned.PinnedBy)
+	assert.Nil(t, unpinned.PinnedAt)
+	assert.False(t, unpinned.Pinned(time.Now()))
+
+	posts, err := s.GetPosts(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, []int{first.ID, second.ID}, ids(posts, postID))
+
+	entries, err := s.GetAuditLog(ctx, model.CustomAuditFilter{}, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, model.AuditActionUnpinPost, entries[0].Action)
+	assert.Equal(t, "Root", entries[0].Actor)
+	assert.Equal(t, model.AuditActionPinPost, entries[1].Action)
+	assert.Equal(t, second.ID, entries[1].TargetID)
+
+	_, err = s.UnpinPost(ctx, 42, "Admin")
+	assert.ErrorIs(t, err, apperr.ErrPostNotFound)
+}
+
+func testPinExpiry(t *testing.T, s storage.Storer) {
+	ctx := context.Background()
+
+	first := createPost(t, s, "Bob", true)
+	second := createPost(t, s, "Bob", true)
+
+	expired := time.Now().Add(-time.Minute)
+
+	_, err := s.PinPost(ctx, second.ID, &expired, "Admin")
+	require.NoError(t, err)
+
+	posts, err := s.GetPosts(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, []int{first.ID, second.ID}, ids(posts, postID), "expired pins are ignored")
+	assert.False(t, posts[1].Pinned(time.Now()))
+
+	_, err = s.UnpinPost(ctx, second.ID, "Admin")
+	assert.ErrorIs(t, err, apperr.ErrNotPinned)
+}
+
+func pollPost(t *testing.T, s storage.Storer, input model.CustomPollInput) (model.CustomPost, model.CustomPoll) {
+	t.Helper()
+
+	post, err := s.CreatePost(context.Background(), model.CustomPostInput{Title: "Which date works?", Author: "Bob", Content: "Vote", CommentsAllowed: true, Poll: &input})
+	require.NoError(t, err)
+
+	polls, err := s.GetPollsByPostIDs(context.Background(), []int{post.ID})
+	require.NoError(t, err)
+	require.Contains(t, polls, post.ID)
+
+	return post, polls[post.ID]
+}
+
+func testCreatePoll(t *testing.T, s storage.Storer) {
+	ctx := context.Background()
+
+	closesAt := time.Now().Add(time.Hour)
+	post, poll := pollPost(t, s, model.CustomPollInput{Question: "When?", Options: []string{"Monday", "Tuesday", "Friday"}, MultipleChoice: true, ClosesAt: &closesAt})
+	plain := createPost(t, s, "Bob", true)
+
+	assert.Equal(t, post.ID, poll.PostID)
+	assert.Equal(t, "When?", poll.Question)
+	assert.True(t, poll.MultipleChoice)
+	assert.False(t, poll.Anonymous)
+	require.NotNil(t, poll.ClosesAt)
+	assert.WithinDuration(t, closesAt, *poll.ClosesAt, time.Millisecond)
+	assert.Zero(t, poll.Voters)
+	require.Len(t, poll.Options, 3)
+	assert.Equal(t, []string{"Monday", "Tuesday", "Friday"}, []string{poll.Options[0].Text, poll.Options[1].Text, poll.Options[2].Text})
+
+	polls, err := s.GetPollsByPostIDs(ctx, []int{post.ID, plain.ID})
+	require.NoError(t, err)
+	assert.Len(t, polls, 1, "posts without a poll are left out")
+
+	byID, err := s.GetPoll(ctx, poll.ID)
+	require.NoError(t, err)
+	assert.Equal(t, poll.Options, byID.Options)
+
+	_, err = s.GetPoll(ctx, poll.ID+42)
+	assert.ErrorIs(t, err, apperr.ErrPollNotFound)
+}
+
+func testVote(t *testing.T, s storage.Storer) {
+	ctx := context.Background()
+
+	_, poll := pollPost(t, s, model.CustomPollInput{Question: "When?", Options: []string{"Monday", "Tuesday", "Friday"}, MultipleChoice: true})
+	monday, tuesday, friday := poll.Options[0].ID, poll.Options[1].ID, poll.Options[2].ID
+
+	result, err := s.Vote(ctx, model.CustomBallot{PollID: poll.ID, Voter: "Alice", OptionIDs: []int{monday, friday}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Voters)
+	assert.Equal(t, []int{1, 0, 1}, votes(result))
+
+	_, err = s.Vote(ctx, model.CustomBallot{PollID: poll.ID, Voter: "Carol", OptionIDs: []int{friday}})
+	require.NoError(t, err)
+
+	result, err = s.Vote(ctx, model.CustomBallot{PollID: poll.ID, Voter: "Alice", OptionIDs: []int{tuesday}})
+	require.NoError(t, err, "voting again replaces the ballot")
+	assert.Equal(t, 2, result.Voters)
+	assert.Equal(t, []int{0, 1, 1}, votes(result))
+	assert.Equal(t, []string{"Alice"}, result.Options[1].Voters)
+	assert.Equal(t, []string{"Carol"}, result.Options[2].Voters)
+
+	result, err = s.Vote(ctx, model.CustomBallot{PollID: poll.ID, Voter: "Alice"})
+	require.NoError(t, err, "an empty ballot withdraws the vote")
+	assert.Equal(t, 1, result.Voters)
+	assert.Equal(t, []int{0, 0, 1}, votes(result))
+
+	stored, err := s.GetPoll(ctx, poll.ID)
+	require.NoError(t, err)
+	assert.Equal(t, result.Options, stored.Options)
+
+	events, err := s.PendingEvents(ctx, "sink", time.Now(), 10)
+	require.NoError(t, err)
+
+	var updates []outbox.Event
+	for _, event := range events {
+		if event.Type == outbox.PollUpdated {
+			updates = append(updates, event)
+		}
+	}
+	require.Len(t, updates, 4, "every vote stores the new results")
+	assert.Equal(t, strconv.Itoa(poll.ID), updates[3].Topic)
+
+	_, anonymous := pollPost(t, s, model.CustomPollInput{Question: "Who?", Options: []string{"Me", "You"}, Anonymous: true})
+
+	result, err = s.Vote(ctx, model.CustomBallot{PollID: anonymous.ID, Voter: "Alice", OptionIDs: []int{anonymous.Options[0].ID}})
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 0}, votes(result))
+	assert.Empty(t, result.Options[0].Voters, "anonymous polls do not name voters")
+}
+
+func testVoteInvalid(t *testing.T, s storage.Storer) {
+	ctx := context.Background()
+
+	_, poll := pollPost(t, s, model.CustomPollInput{Question: "When?", Options: []string{"Monday", "Tuesday"}})
+	closesAt := time.Now().Add(-time.Minute)
+	_, closed := pollPost(t, s, model.CustomPollInput{Question: "When?", Options: []string{"Monday", "Tuesday"}, ClosesAt: &closesAt})
+
+	_, err := s.Vote(ctx, model.CustomBallot{PollID: closed.ID, Voter: "Alice", OptionIDs: []int{closed.Options[0].ID}})
+	assert.ErrorIs(t, err, apperr.ErrPollClosed)
+
+	_, err = s.Vote(ctx, model.CustomBallot{PollID: poll.ID, Voter: "Alice", OptionIDs: []int{closed.Options[0].ID}})
+	assert.ErrorIs(t, err, apperr.ErrInvalidOption)
+
+	_, err = s.Vote(ctx, model.CustomBallot{PollID: closed.ID + 42, Voter: "Alice", OptionIDs: []int{1}})
+	assert.ErrorIs(t, err, apperr.ErrPollNotFound)
+
+	stored, err := s.GetPoll(ctx, poll.ID)
+	require.NoError(t, err)
+	assert.Zero(t, stored.Voters)
+}
+
+func votes(poll model.CustomPoll) []int {
+	counts := make([]int, 0, len(poll.Options))
+	for _, option := range poll.Options {
+		counts = append(counts, option.Votes)
+	}
+	return counts
+}
+
+func testAttachments(t *testing.T, s storage.Storer) {
+	ctx := context.Background()
+
+	image := model.CustomAttachment{Key: "a.png", ThumbnailKey: "a-thumb.jpg", Filename: "cat.png", ContentType: "image/png", Size: 2048, Width: 640, Height: 480}
+	file := model.CustomAttachment{Key: "b.pdf", Filename: "rules.pdf", ContentType: "application/pdf", Size: 1 << 20}
+
+	post, err := s.CreatePost(ctx, model.CustomPostInput{Title: "Photos", Author: "Bob", Content: "See attached", CommentsAllowed: true, Attachments: []model.CustomAttachment{image, file}})
+	require.NoError(t, err)
+
+	comment, err := s.CreateComment(ctx, model.CustomCommentInput{PostID: post.ID, Author: "Alice", Content: "Nice", Attachments: []model.CustomAttachment{{Key: "c.txt", Filename: "notes.txt", ContentType: "text/plain", Size: 12}}})
+	require.NoError(t, err)
+
+	plain := createPost(t, s, "Carol", true)
+
+	byPost, err := s.GetAttachments(ctx, conv.TypePost, []int{post.ID, plain.ID})
+	require.NoError(t, err)
+	assert.NotContains(t, byPost, plain.ID, "targets without attachments are left out")
+	require.Len(t, byPost[post.ID], 2)
+
+	got := byPost[post.ID][0]
+	assert.NotZero(t, got.ID)
+	assert.Equal(t, conv.TypePost, got.TargetType)
+	assert.Equal(t, post.ID, got.TargetID)
+	assert.WithinDuration(t, post.CreatedAt, got.CreatedAt, time.Millisecond)
+
+	got.ID, got.TargetType, got.TargetID, got.CreatedAt = 0, "", 0, time.Time{}
+	assert.Equal(t, image, got)
+	assert.Equal(t, "rules.pdf", byPost[post.ID][1].Filename, "attachments keep their order")
+	assert.Zero(t, byPost[post.ID][1].Width)
+
+	byComment, err := s.GetAttachments(ctx, conv.TypeComment, []int{comment.ID})
+	require.NoError(t, err)
+	require.Len(t, byComment[comment.ID], 1, "attachments of posts are not returned for comments")
+	assert.Equal(t, "c.txt", byComment[comment.ID][0].Key)
+}
@@ -2,16 +2,107 @@ package storage
 
 import (
 	"context"
+	"time"
 
 	"github.com/erknas/forum/graph/model"
+	"github.com/erknas/forum/internal/outbox"
 )
 
+// Only published content is listed and counted, single posts and comments
+// are returned whatever their status. Listings and counts also include the
+// shadowed content of viewer, the signed-in user they are made for, and so
+// do the checks of the post and parent comment of a new comment by its
+// author. Events are stored when content is
+// published, either on creation or when its status changes to PUBLISHED.
+//
+// A user has at most one open report per target: CreateReport returns that
+// report instead of adding another, along with the number of open reports on
+// the target. The status changes that CreateReport and ResolveReports are
+// given are made to the target in the same transaction. ResolveReports audits
+// its status change as a change by resolvedBy, so rejecting reported content
+// also writes a REJECT_CONTENT entry with the rejected content.
+//
+// Changes made by a moderator or admin, the actor, are written to the
+// append-only audit log in the same transaction. Changes without an actor are
+// made by the system and are not audited.
+//
+// A user has at most one ban in force. CreateBan lifts the previous ban, even
+// an expired one, on behalf of the new ban's moderator. Bans expire by
+// themselves: a ban past ExpiresAt is neither returned by GetActiveBan nor
+// listed, and LiftBan reports ErrNoActiveBan for it.
+//
+// An edit keeps the version it replaces as a revision, numbered from 1 for
+// the original, and does not store events. GetRevisions returns the replaced
+// versions of each target, oldest first.
+//
+// Drafts and scheduled posts are only returned by id and by GetDrafts, and
+// are left alone by SetPostStatus, which reports ErrPostNotFound for them.
+// PublishPost gives a draft or scheduled post its final status, or schedules
+// it again, and reports ErrNotDraft for any other post. PublishDuePosts
+// publishes the scheduled posts that are due and returns them. The bans in
+// force at that time apply: posts of banned authors stay scheduled until the
+// ban ends, and posts of shadowbanned authors are shadowed.
+//
+// GetLatestPosts and GetLatestComments list published content newest first,
+// as anonymous viewers see it, ignoring pins. GetLatestComments includes
+// replies whose parent comments are listed as well.
+//
+// GetPosts lists pinned posts first, most recently pinned first, and the
+// others after them. A pin expires by itself past PinnedUntil: the post is
+// listed as any other and UnpinPost reports ErrNotPinned for it. Pins are
+// audited like other actions of their actor.
+//
+// CreatePost saves the poll of the post along with it. A user has a single
+// ballot per poll: Vote replaces it, or removes it if it has no options, and
+// stores a PollUpdated event with the new results. Vote reports ErrPollClosed
+// once the poll closes and ErrInvalidOption for options of another poll.
+//
+// CreatePost and CreateComment save the attachments of the content along with
+// it, whose blobs the caller has already put in the BlobStore. GetAttachments
+// returns the attachments of each target in the order they were given.
+//
 //go:generate go run github.com/vektra/mockery/v2@v2.52.2 --name=Storer
 type Storer interface {
-	CreatePost(context.Context, model.PostInput) (post model.CustomPost, err error)
-	GetPosts(context.Context) ([]model.CustomPost, error)
+	CreatePost(context.Context, model.CustomPostInput) (post model.CustomPost, err error)
+	GetPosts(ctx context.Context, viewer string) ([]model.CustomPost, error)
+	GetLatestPosts(ctx context.Context, limit int) ([]model.CustomPost, error)
+	GetLatestComments(ctx context.Context, postID int, limit int) ([]model.CustomComment, error)
 	GetPostByID(context.Context, int) (model.CustomPost, error)
+	GetPostsByIDs(context.Context, []int) ([]model.CustomPost, error)
 	CreateComment(context.Context, model.CustomCommentInput) (comment model.CustomComment, err error)
-	GetCommentsByPost(context.Context, int, int, int) ([]model.CustomComment, error)
-	GetCommentReplies(context.Context, int) ([]model.CustomComment, error)
+	GetCommentsByPost(ctx context.Context, postID int, offset int, limit int, viewer string) ([]model.CustomComment, error)
+	GetCommentsByPostIDs(ctx context.Context, postIDs []int, offset int, limit int, viewer string) ([]model.CustomComment, error)
+	GetCommentByID(context.Context, int) (model.CustomComment, error)
+	GetCommentReplies(ctx context.Context, parentID int, viewer string) ([]model.CustomComment, error)
+	GetRepliesByParentIDs(ctx context.Context, parentIDs []int, viewer string) ([]model.CustomComment, error)
+	GetAuthorByID(context.Context, int) (model.CustomAuthor, error)
+	GetAuthorByName(context.Context, string) (model.CustomAuthor, error)
+	GetAuthorStats(ctx context.Context, id int, viewer string) (model.AuthorStats, error)
+	GetAuthorStatsByIDs(ctx context.Context, ids []int, viewer string) (map[int]model.AuthorStats, error)
+	GetPostsByAuthor(ctx context.Context, authorID int, offset int, limit int, viewer string) ([]model.CustomPost, error)
+	GetCommentsByAuthor(ctx context.Context, authorID int, offset int, limit int, viewer string) ([]model.CustomComment, error)
+	SetPostStatus(ctx context.Context, id int, status model.ContentStatus, reason string, actor string) (model.CustomPost, error)
+	SetCommentStatus(ctx context.Context, id int, status model.ContentStatus, reason string, actor string) (model.CustomComment, error)
+	EditPost(ctx context.Context, edit model.CustomEdit, actor string) (model.CustomPost, error)
+	EditComment(ctx context.Context, edit model.CustomEdit, actor string) (model.CustomComment, error)
+	GetRevisions(ctx context.Context, targetType string, targetIDs []int) (map[int][]model.CustomRevision, error)
+	GetAttachments(ctx context.Context, targetType string, targetIDs []int) (map[int][]model.CustomAttachment, error)
+	GetDrafts(ctx context.Context, author string, offset int, limit int) (posts []model.CustomPost, total int, err error)
+	PublishPost(ctx context.Context, id int, status model.ContentStatus, reason string, publishAt time.Time) (model.CustomPost, error)
+	PublishDuePosts(ctx context.Context, now time.Time) ([]model.CustomPost, error)
+	PinPost(ctx context.Context, id int, until *time.Time, actor string) (model.CustomPost, error)
+	UnpinPost(ctx context.Context, id int, actor string) (model.CustomPost, error)
+	GetPoll(context.Context, int) (model.CustomPoll, error)
+	GetPollsByPostIDs(context.Context, []int) (map[int]model.CustomPoll, error)
+	Vote(context.Context, model.CustomBallot) (model.CustomPoll, error)
+	GetPendingContent(context.Context, int, int) ([]model.CustomContent, error)
+	CreateReport(context.Context, model.CustomReportInput) (report model.CustomReport, open int, err error)
+	GetReportGroups(context.Context, int, int) ([]model.CustomReportGroup, error)
+	ResolveReports(ctx context.Context, targetType string, targetID int, resolvedBy string, action model.ReportAction, change model.CustomStatusChange) ([]model.CustomReport, error)
+	GetAuditLog(context.Context, model.CustomAuditFilter, int, int) ([]model.CustomAuditEntry, error)
+	CreateBan(context.Context, model.CustomBanInput) (model.CustomBan, error)
+	LiftBan(ctx context.Context, author string, liftedBy string) (model.CustomBan, error)
+	GetActiveBan(ctx context.Context, author string) (model.CustomBan, error)
+	GetActiveBans(context.Context, int, int) ([]model.CustomBan, error)
+	outbox.Store
 }
@@ -6,6 +6,9 @@ import (
 	"github.com/erknas/forum/graph/model"
 )
 
+// PostsTopic is the topic new posts are published under.
+const PostsTopic = "posts"
+
 //go:generate go run github.com/vektra/mockery/v2@v2.52.2 --name=Subscriber
 type Subscriber interface {
 	Subscribe(string) chan *model.Comment
@@ -13,54 +16,79 @@ type Subscriber interface {
 	Publish(string, *model.Comment)
 }
 
-type Subscription struct {
+//go:generate go run github.com/vektra/mockery/v2@v2.52.2 --name=PostSubscriber
+type PostSubscriber interface {
+	Subscribe(string) chan *model.Post
+	Unsubscribe(string, chan *model.Post)
+	Publish(string, *model.Post)
+}
+
+// PollSubscriber delivers the results of a poll under its global ID.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.52.2 --name=PollSubscriber
+type PollSubscriber interface {
+	Subscribe(string) chan *model.Poll
+	Unsubscribe(string, chan *model.Poll)
+	Publish(string, *model.Poll)
+}
+
+// Hub delivers values published under a topic to its subscribers. A
+// subscriber that is not ready to receive is dropped and its channel closed.
+type Hub[T any] struct {
 	mu    sync.Mutex
-	chans map[string]map[chan *model.Comment]struct{}
+	chans map[string]map[chan T]struct{}
 }
 
+// Subscription delivers new comments under the global ID of their post.
+type Subscription = Hub[*model.Comment]
+
 func New() *Subscription {
-	return &Subscription{
-		chans: make(map[string]map[chan *model.Comment]struct{}),
+	return NewHub[*model.Comment]()
+}
+
+func NewHub[T any]() *Hub[T] {
+	return &Hub[T]{
+		chans: make(map[string]map[chan T]struct{}),
 	}
 }
 
-func (s *Subscription) Subscribe(postID string) chan *model.Comment {
+func (s *Hub[T]) Subscribe(topic string) chan T {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	ch := make(chan *model.Comment)
+	ch := make(chan T)
 
-	if _, ok := s.chans[postID]; !ok {
-		s.chans[postID] = make(map[chan *model.Comment]struct{})
+	if _, ok := s.chans[topic]; !ok {
+		s.chans[topic] = make(map[chan T]struct{})
 	}
 
-	s.chans[postID][ch] = struct{}{}
+	s.chans[topic][ch] = struct{}{}
 	return ch
 }
 
-func (s *Subscription) Unsubscribe(postID string, ch chan *model.Comment) {
+func (s *Hub[T]) Unsubscribe(topic string, ch chan T) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if channels, ok := s.chans[postID]; ok {
+	if channels, ok := s.chans[topic]; ok {
 		if _, ok := channels[ch]; ok {
 			close(ch)
 			delete(channels, ch)
 		}
 		if len(channels) == 0 {
-			delete(s.chans, postID)
+			delete(s.chans, topic)
 		}
 	}
 }
 
-func (s *Subscription) Publish(postID string, comment *model.Comment) {
+func (s *Hub[T]) Publish(topic string, value T) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if channels, ok := s.chans[postID]; ok {
+	if channels, ok := s.chans[topic]; ok {
 		for ch := range channels {
 			select {
-			case ch <- comment:
+			case ch <- value:
 			default:
 				close(ch)
 				delete(channels, ch)
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS post_drafts_idx;
+
+DROP INDEX IF EXISTS post_scheduled_idx;
+
+ALTER TABLE post DROP COLUMN IF EXISTS publish_at;
@@ -0,0 +1,5 @@
+ALTER TABLE post ADD COLUMN IF NOT EXISTS publish_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS post_scheduled_idx ON post (publish_at) WHERE status = 'SCHEDULED';
+
+CREATE INDEX IF NOT EXISTS post_drafts_idx ON post (author_id, created_at) WHERE status IN ('DRAFT', 'SCHEDULED');
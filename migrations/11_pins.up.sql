@@ -0,0 +1,7 @@
+ALTER TABLE post ADD COLUMN IF NOT EXISTS pinned_by TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE post ADD COLUMN IF NOT EXISTS pinned_at TIMESTAMPTZ;
+
+ALTER TABLE post ADD COLUMN IF NOT EXISTS pinned_until TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS post_pinned_idx ON post (pinned_at) WHERE pinned_at IS NOT NULL;
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS ballot;
+
+DROP TABLE IF EXISTS poll_option;
+
+DROP TABLE IF EXISTS poll;
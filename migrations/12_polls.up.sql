@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS poll (
+	id SERIAL PRIMARY KEY,
+	post_id INT NOT NULL UNIQUE REFERENCES post(id),
+	question VARCHAR(200) NOT NULL,
+	multiple_choice BOOLEAN NOT NULL DEFAULT FALSE,
+	anonymous BOOLEAN NOT NULL DEFAULT FALSE,
+	closes_at TIMESTAMPTZ,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS poll_option (
+	id SERIAL PRIMARY KEY,
+	poll_id INT NOT NULL REFERENCES poll(id),
+	position INT NOT NULL,
+	text VARCHAR(100) NOT NULL,
+	UNIQUE (poll_id, position)
+);
+
+CREATE TABLE IF NOT EXISTS ballot (
+	poll_id INT NOT NULL REFERENCES poll(id),
+	option_id INT NOT NULL REFERENCES poll_option(id),
+	voter TEXT NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	PRIMARY KEY (option_id, voter)
+);
+
+CREATE INDEX IF NOT EXISTS ballot_poll_idx ON ballot (poll_id, voter);
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS attachment (
+	id SERIAL PRIMARY KEY,
+	target_type VARCHAR(16) NOT NULL,
+	target_id INT NOT NULL,
+	blob_key TEXT NOT NULL UNIQUE,
+	thumbnail_key TEXT NOT NULL DEFAULT '',
+	filename TEXT NOT NULL,
+	content_type TEXT NOT NULL,
+	size BIGINT NOT NULL,
+	width INT NOT NULL DEFAULT 0,
+	height INT NOT NULL DEFAULT 0,
+	created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS attachment_target_idx ON attachment (target_type, target_id);
@@ -0,0 +1,11 @@
+-- Deliveries are kept per sink from now on. outbox.delivered_at still marks
+-- the events delivered to every sink before.
+CREATE TABLE IF NOT EXISTS outbox_delivery (
+	outbox_id BIGINT NOT NULL REFERENCES outbox (id) ON DELETE CASCADE,
+	sink VARCHAR(64) NOT NULL,
+	attempts INT NOT NULL DEFAULT 0,
+	retry_at TIMESTAMPTZ,
+	delivered_at TIMESTAMPTZ,
+	failed_at TIMESTAMPTZ,
+	PRIMARY KEY (sink, outbox_id)
+);
@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS post_author (
+	id SERIAL PRIMARY KEY,
+	author_name VARCHAR(16) NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS comment_author (
+	id SERIAL PRIMARY KEY,
+	author_name VARCHAR(16) NOT NULL UNIQUE
+);
+
+INSERT INTO post_author (id, author_name) SELECT id, name FROM author;
+
+INSERT INTO comment_author (id, author_name) SELECT id, name FROM author;
+
+SELECT setval(pg_get_serial_sequence('post_author', 'id'), COALESCE((SELECT MAX(id) FROM post_author), 0) + 1, false);
+
+SELECT setval(pg_get_serial_sequence('comment_author', 'id'), COALESCE((SELECT MAX(id) FROM comment_author), 0) + 1, false);
+
+ALTER TABLE post DROP CONSTRAINT IF EXISTS post_author_id_fkey;
+
+ALTER TABLE post ADD CONSTRAINT post_author_id_fkey FOREIGN KEY (author_id) REFERENCES post_author(id);
+
+ALTER TABLE comment DROP CONSTRAINT IF EXISTS comment_author_id_fkey;
+
+ALTER TABLE comment ADD CONSTRAINT comment_author_id_fkey FOREIGN KEY (author_id) REFERENCES comment_author(id);
+
+DROP TABLE IF EXISTS author;
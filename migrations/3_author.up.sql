@@ -0,0 +1,39 @@
+CREATE TABLE IF NOT EXISTS author (
+	id SERIAL PRIMARY KEY,
+	name VARCHAR(16) NOT NULL UNIQUE,
+	joined_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+INSERT INTO author (name, joined_at)
+SELECT names.author_name, COALESCE(MIN(names.created_at), NOW())
+FROM (
+	SELECT post_author.author_name, post.created_at 
+	FROM post_author 
+	LEFT JOIN post ON post.author_id = post_author.id
+	UNION ALL
+	SELECT comment_author.author_name, comment.created_at 
+	FROM comment_author 
+	LEFT JOIN comment ON comment.author_id = comment_author.id
+) AS names
+GROUP BY names.author_name
+ON CONFLICT (name) DO NOTHING;
+
+ALTER TABLE post DROP CONSTRAINT IF EXISTS post_author_id_fkey;
+
+UPDATE post SET author_id = author.id 
+FROM post_author, author 
+WHERE post.author_id = post_author.id AND author.name = post_author.author_name;
+
+ALTER TABLE post ADD CONSTRAINT post_author_id_fkey FOREIGN KEY (author_id) REFERENCES author(id);
+
+ALTER TABLE comment DROP CONSTRAINT IF EXISTS comment_author_id_fkey;
+
+UPDATE comment SET author_id = author.id 
+FROM comment_author, author 
+WHERE comment.author_id = comment_author.id AND author.name = comment_author.author_name;
+
+ALTER TABLE comment ADD CONSTRAINT comment_author_id_fkey FOREIGN KEY (author_id) REFERENCES author(id);
+
+DROP TABLE IF EXISTS post_author;
+
+DROP TABLE IF EXISTS comment_author;
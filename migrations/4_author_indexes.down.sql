@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS post_author_id_idx;
+
+DROP INDEX IF EXISTS comment_author_id_idx;
@@ -0,0 +1,3 @@
+CREATE INDEX IF NOT EXISTS post_author_id_idx ON post (author_id, created_at DESC);
+
+CREATE INDEX IF NOT EXISTS comment_author_id_idx ON comment (author_id, created_at DESC);
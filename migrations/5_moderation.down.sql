@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS comment_pending_idx;
+
+DROP INDEX IF EXISTS post_pending_idx;
+
+ALTER TABLE comment DROP COLUMN IF EXISTS status_reason;
+
+ALTER TABLE comment DROP COLUMN IF EXISTS status;
+
+ALTER TABLE post DROP COLUMN IF EXISTS status_reason;
+
+ALTER TABLE post DROP COLUMN IF EXISTS status;
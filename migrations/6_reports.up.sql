@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS report (
+	id SERIAL PRIMARY KEY,
+	target_type VARCHAR(16) NOT NULL,
+	target_id INT NOT NULL,
+	reporter TEXT NOT NULL,
+	reason VARCHAR(16) NOT NULL,
+	details TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	resolved_by TEXT NOT NULL DEFAULT '',
+	resolved_at TIMESTAMPTZ,
+	action VARCHAR(16) NOT NULL DEFAULT ''
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS report_open_idx ON report (target_type, target_id, reporter) WHERE resolved_at IS NULL;
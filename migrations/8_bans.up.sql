@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS ban (
+	id SERIAL PRIMARY KEY,
+	author TEXT NOT NULL,
+	kind VARCHAR(16) NOT NULL,
+	reason TEXT NOT NULL,
+	moderator TEXT NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	expires_at TIMESTAMPTZ,
+	lifted_by TEXT NOT NULL DEFAULT '',
+	lifted_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS ban_unlifted_idx ON ban (author) WHERE lifted_at IS NULL;
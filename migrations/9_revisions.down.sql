@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS revision;
+
+ALTER TABLE comment DROP COLUMN IF EXISTS edited_at;
+
+ALTER TABLE comment DROP COLUMN IF EXISTS edited_by;
+
+ALTER TABLE post DROP COLUMN IF EXISTS edited_at;
+
+ALTER TABLE post DROP COLUMN IF EXISTS edited_by;
@@ -0,0 +1,19 @@
+ALTER TABLE post ADD COLUMN IF NOT EXISTS edited_by TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE post ADD COLUMN IF NOT EXISTS edited_at TIMESTAMPTZ;
+
+ALTER TABLE comment ADD COLUMN IF NOT EXISTS edited_by TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE comment ADD COLUMN IF NOT EXISTS edited_at TIMESTAMPTZ;
+
+CREATE TABLE IF NOT EXISTS revision (
+	id SERIAL PRIMARY KEY,
+	target_type VARCHAR(16) NOT NULL,
+	target_id INT NOT NULL,
+	version INT NOT NULL,
+	title TEXT NOT NULL DEFAULT '',
+	content TEXT NOT NULL,
+	editor TEXT NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL,
+	UNIQUE (target_type, target_id, version)
+);
@@ -8,6 +8,7 @@ import (
 	"github.com/erknas/forum/internal/config"
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/database/sqlite"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 )
 
@@ -16,10 +17,19 @@ func New(cfg *config.Config) error {
 
 	time.Sleep(time.Second * 3)
 
-	m, err := migrate.New(cfg.MigrationPath, dns)
+	return up(cfg.PostgresConfig.MigrationPath, dns)
+}
+
+func NewSQLite(cfg *config.Config) error {
+	return up(cfg.SQLiteConfig.MigrationPath, "sqlite://"+cfg.SQLiteConfig.Path)
+}
+
+func up(sourceURL string, databaseURL string) error {
+	m, err := migrate.New(sourceURL, databaseURL)
 	if err != nil {
 		return err
 	}
+	defer m.Close()
 
 	if err := m.Up(); err != nil {
 		if errors.Is(err, migrate.ErrNoChange) {
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS attachment (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	target_type VARCHAR(16) NOT NULL,
+	target_id INTEGER NOT NULL,
+	blob_key TEXT NOT NULL UNIQUE,
+	thumbnail_key TEXT NOT NULL DEFAULT '',
+	filename TEXT NOT NULL,
+	content_type TEXT NOT NULL,
+	size INTEGER NOT NULL,
+	width INTEGER NOT NULL DEFAULT 0,
+	height INTEGER NOT NULL DEFAULT 0,
+	created_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS attachment_target_idx ON attachment (target_type, target_id);
@@ -0,0 +1,11 @@
+-- Deliveries are kept per sink from now on. outbox.delivered_at still marks
+-- the events delivered to every sink before.
+CREATE TABLE IF NOT EXISTS outbox_delivery (
+	outbox_id INTEGER NOT NULL REFERENCES outbox (id) ON DELETE CASCADE,
+	sink VARCHAR(64) NOT NULL,
+	attempts INTEGER NOT NULL DEFAULT 0,
+	retry_at DATETIME,
+	delivered_at DATETIME,
+	failed_at DATETIME,
+	PRIMARY KEY (sink, outbox_id)
+);
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS outbox;
+
+DROP TABLE IF EXISTS comment;
+
+DROP TABLE IF EXISTS post;
+
+DROP TABLE IF EXISTS author;
@@ -0,0 +1,43 @@
+CREATE TABLE IF NOT EXISTS author (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name VARCHAR(16) NOT NULL UNIQUE,
+	joined_at DATETIME NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS post (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	title VARCHAR(100) NOT NULL,
+	content TEXT NOT NULL,
+	created_at DATETIME NOT NULL,
+	comments_allowed BOOLEAN NOT NULL,
+	author_id INTEGER NOT NULL REFERENCES author(id)
+);
+
+CREATE TABLE IF NOT EXISTS comment (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	content VARCHAR(2000) NOT NULL CHECK (LENGTH(content) <= 2000),
+	created_at DATETIME NOT NULL,
+	post_id INTEGER NOT NULL REFERENCES post(id),
+	parent_id INTEGER REFERENCES comment(id),
+	author_id INTEGER NOT NULL REFERENCES author(id)
+);
+
+CREATE TABLE IF NOT EXISTS outbox (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	event_id TEXT NOT NULL UNIQUE,
+	event_type VARCHAR(64) NOT NULL,
+	topic VARCHAR(64) NOT NULL,
+	payload TEXT NOT NULL,
+	created_at DATETIME NOT NULL,
+	delivered_at DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS post_author_id_idx ON post (author_id, created_at DESC);
+
+CREATE INDEX IF NOT EXISTS comment_author_id_idx ON comment (author_id, created_at DESC);
+
+CREATE INDEX IF NOT EXISTS comment_post_id_idx ON comment (post_id, created_at);
+
+CREATE INDEX IF NOT EXISTS comment_parent_id_idx ON comment (parent_id);
+
+CREATE INDEX IF NOT EXISTS outbox_pending_idx ON outbox (id) WHERE delivered_at IS NULL;
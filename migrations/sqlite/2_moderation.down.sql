@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS comment_pending_idx;
+
+DROP INDEX IF EXISTS post_pending_idx;
+
+ALTER TABLE comment DROP COLUMN status_reason;
+
+ALTER TABLE comment DROP COLUMN status;
+
+ALTER TABLE post DROP COLUMN status_reason;
+
+ALTER TABLE post DROP COLUMN status;
@@ -0,0 +1,11 @@
+ALTER TABLE post ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'PUBLISHED';
+
+ALTER TABLE post ADD COLUMN status_reason TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE comment ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'PUBLISHED';
+
+ALTER TABLE comment ADD COLUMN status_reason TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS post_pending_idx ON post (created_at) WHERE status = 'PENDING';
+
+CREATE INDEX IF NOT EXISTS comment_pending_idx ON comment (created_at) WHERE status = 'PENDING';
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS report (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	target_type VARCHAR(16) NOT NULL,
+	target_id INTEGER NOT NULL,
+	reporter TEXT NOT NULL,
+	reason VARCHAR(16) NOT NULL,
+	details TEXT NOT NULL DEFAULT '',
+	created_at DATETIME NOT NULL,
+	resolved_by TEXT NOT NULL DEFAULT '',
+	resolved_at DATETIME,
+	action VARCHAR(16) NOT NULL DEFAULT ''
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS report_open_idx ON report (target_type, target_id, reporter) WHERE resolved_at IS NULL;
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS ban (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	author TEXT NOT NULL,
+	kind VARCHAR(16) NOT NULL,
+	reason TEXT NOT NULL,
+	moderator TEXT NOT NULL,
+	created_at DATETIME NOT NULL,
+	expires_at DATETIME,
+	lifted_by TEXT NOT NULL DEFAULT '',
+	lifted_at DATETIME
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS ban_unlifted_idx ON ban (author) WHERE lifted_at IS NULL;
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS revision;
+
+ALTER TABLE comment DROP COLUMN edited_at;
+
+ALTER TABLE comment DROP COLUMN edited_by;
+
+ALTER TABLE post DROP COLUMN edited_at;
+
+ALTER TABLE post DROP COLUMN edited_by;
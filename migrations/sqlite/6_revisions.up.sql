@@ -0,0 +1,19 @@
+ALTER TABLE post ADD COLUMN edited_by TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE post ADD COLUMN edited_at DATETIME;
+
+ALTER TABLE comment ADD COLUMN edited_by TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE comment ADD COLUMN edited_at DATETIME;
+
+CREATE TABLE IF NOT EXISTS revision (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	target_type VARCHAR(16) NOT NULL,
+	target_id INTEGER NOT NULL,
+	version INTEGER NOT NULL,
+	title TEXT NOT NULL DEFAULT '',
+	content TEXT NOT NULL,
+	editor TEXT NOT NULL,
+	created_at DATETIME NOT NULL,
+	UNIQUE (target_type, target_id, version)
+);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS post_pinned_idx;
+
+ALTER TABLE post DROP COLUMN pinned_until;
+
+ALTER TABLE post DROP COLUMN pinned_at;
+
+ALTER TABLE post DROP COLUMN pinned_by;
@@ -0,0 +1,7 @@
+ALTER TABLE post ADD COLUMN pinned_by TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE post ADD COLUMN pinned_at DATETIME;
+
+ALTER TABLE post ADD COLUMN pinned_until DATETIME;
+
+CREATE INDEX IF NOT EXISTS post_pinned_idx ON post (pinned_at) WHERE pinned_at IS NOT NULL;
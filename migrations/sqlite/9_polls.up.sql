@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS poll (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	post_id INTEGER NOT NULL UNIQUE REFERENCES post(id),
+	question VARCHAR(200) NOT NULL,
+	multiple_choice BOOLEAN NOT NULL DEFAULT FALSE,
+	anonymous BOOLEAN NOT NULL DEFAULT FALSE,
+	closes_at DATETIME,
+	created_at DATETIME NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS poll_option (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	poll_id INTEGER NOT NULL REFERENCES poll(id),
+	position INTEGER NOT NULL,
+	text VARCHAR(100) NOT NULL,
+	UNIQUE (poll_id, position)
+);
+
+CREATE TABLE IF NOT EXISTS ballot (
+	poll_id INTEGER NOT NULL REFERENCES poll(id),
+	option_id INTEGER NOT NULL REFERENCES poll_option(id),
+	voter TEXT NOT NULL,
+	created_at DATETIME NOT NULL,
+	PRIMARY KEY (option_id, voter)
+);
+
+CREATE INDEX IF NOT EXISTS ballot_poll_idx ON ballot (poll_id, voter);
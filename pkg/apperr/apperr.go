@@ -0,0 +1,111 @@
+// Package apperr defines the errors the API reports to clients. Each error
+// carries a stable code, so clients do not have to match messages.
+package apperr
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+type Code string
+
+const (
+	CodeNotFound         Code = "NOT_FOUND"
+	CodeForbidden        Code = "FORBIDDEN"
+	CodeValidation       Code = "VALIDATION"
+	CodeCommentsDisabled Code = "COMMENTS_DISABLED"
+	CodeRateLimited      Code = "RATE_LIMITED"
+	CodeContentRejected  Code = "CONTENT_REJECTED"
+	CodeBanned           Code = "BANNED"
+	CodeInternal         Code = "INTERNAL"
+)
+
+var (
+	ErrPostNotFound     = New(CodeNotFound, "post not found")
+	ErrCommentNotFound  = New(CodeNotFound, "comment does not exist")
+	ErrAuthorNotFound   = New(CodeNotFound, "author not found")
+	ErrCommentsDisabled = New(CodeCommentsDisabled, "comments not allowed")
+	ErrNoOpenReports    = New(CodeNotFound, "no open reports")
+	ErrNoActiveBan      = New(CodeNotFound, "no active ban")
+	ErrNotDraft         = New(CodeValidation, "post is already published")
+	ErrNotPinned        = New(CodeNotFound, "post is not pinned")
+	ErrPollNotFound     = New(CodeNotFound, "poll not found")
+	ErrPollClosed       = New(CodeValidation, "poll is closed")
+	ErrInvalidOption    = New(CodeValidation, "option does not belong to the poll")
+	ErrBlobNotFound     = New(CodeNotFound, "blob not found")
+)
+
+type Error struct {
+	Code    Code
+	Message string
+	// Fields holds details reported next to the code, e.g. per-field messages
+	// of a validation error.
+	Fields map[string]interface{}
+}
+
+func New(code Code, message string) *Error {
+	return &Error{
+		Code:    code,
+		Message: message,
+	}
+}
+
+func Validation(message string, fields map[string]interface{}) *Error {
+	return &Error{
+		Code:    CodeValidation,
+		Message: message,
+		Fields:  fields,
+	}
+}
+
+func Validationf(format string, args ...any) *Error {
+	return New(CodeValidation, fmt.Sprintf(format, args...))
+}
+
+func Forbidden(message string) *Error {
+	return New(CodeForbidden, message)
+}
+
+// RateLimited reports a request over the client's budget. retryAfter, in
+// whole seconds, tells when to try again; a zero retryAfter is not reported.
+func RateLimited(message string, retryAfter time.Duration) *Error {
+	err := New(CodeRateLimited, message)
+
+	if retryAfter > 0 {
+		err.Fields = map[string]interface{}{
+			"retryAfter": int(math.Ceil(retryAfter.Seconds())),
+		}
+	}
+
+	return err
+}
+
+// Banned reports a request by a banned user. A nil expiresAt means the ban is
+// permanent and is not reported.
+func Banned(reason string, expiresAt *time.Time) *Error {
+	err := New(CodeBanned, "user is banned")
+	err.Fields = map[string]interface{}{"reason": reason}
+
+	if expiresAt != nil {
+		err.Fields["expiresAt"] = expiresAt.UTC().Format(time.RFC3339)
+	}
+
+	return err
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// CodeOf returns the code of the first *Error in err's chain, or CodeInternal
+// if there is none.
+func CodeOf(err error) Code {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr.Code
+	}
+
+	return CodeInternal
+}
@@ -0,0 +1,68 @@
+package apperr
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCodeOf(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want Code
+	}{
+		{
+			name: "Sentinel",
+			err:  ErrPostNotFound,
+			want: CodeNotFound,
+		},
+		{
+			name: "Wrapped sentinel",
+			err:  fmt.Errorf("create comment: %w", ErrCommentsDisabled),
+			want: CodeCommentsDisabled,
+		},
+		{
+			name: "Validation",
+			err:  Validationf("invalid ID %s", "abc"),
+			want: CodeValidation,
+		},
+		{
+			name: "Forbidden",
+			err:  Forbidden("not allowed"),
+			want: CodeForbidden,
+		},
+		{
+			name: "Unknown",
+			err:  errors.New("connection refused"),
+			want: CodeInternal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, CodeOf(tt.err))
+		})
+	}
+}
+
+func TestRateLimited(t *testing.T) {
+	err := RateLimited("too many posts", 1500*time.Millisecond)
+	assert.Equal(t, CodeRateLimited, err.Code)
+	assert.Equal(t, map[string]interface{}{"retryAfter": 2}, err.Fields)
+
+	assert.Nil(t, RateLimited("too many subscriptions", 0).Fields)
+}
+
+func TestBanned(t *testing.T) {
+	expiresAt := time.Date(2024, 1, 1, 12, 0, 0, 0, time.FixedZone("MSK", 3*60*60))
+
+	err := Banned("spam", &expiresAt)
+	assert.Equal(t, CodeBanned, err.Code)
+	assert.Equal(t, map[string]interface{}{"reason": "spam", "expiresAt": "2024-01-01T09:00:00Z"}, err.Fields)
+
+	assert.Equal(t, map[string]interface{}{"reason": "spam"}, Banned("spam", nil).Fields)
+}
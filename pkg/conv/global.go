@@ -0,0 +1,66 @@
+package conv
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+
+	"github.com/erknas/forum/pkg/apperr"
+)
+
+// Node types encoded in global IDs.
+const (
+	TypePost       = "Post"
+	TypeComment    = "Comment"
+	TypeAuthor     = "Author"
+	TypeReport     = "Report"
+	TypeAuditEntry = "AuditEntry"
+	TypeBan        = "Ban"
+	TypePoll       = "Poll"
+	TypePollOption = "PollOption"
+	TypeAttachment = "Attachment"
+)
+
+// GlobalID returns an opaque ID that is unique across all node types.
+func GlobalID(typ string, id int) string {
+	return base64.StdEncoding.EncodeToString([]byte(typ + ":" + strconv.Itoa(id)))
+}
+
+// FromGlobalID decodes an ID returned by GlobalID.
+func FromGlobalID(globalID string) (typ string, id int, err error) {
+	data, err := base64.StdEncoding.DecodeString(globalID)
+	if err != nil {
+		return "", -1, apperr.Validationf("invalid ID %s", globalID)
+	}
+
+	typ, strID, ok := strings.Cut(string(data), ":")
+	if !ok || typ == "" {
+		return "", -1, apperr.Validationf("invalid ID %s", globalID)
+	}
+
+	id, err = strconv.Atoi(strID)
+	if err != nil || id <= 0 {
+		return "", -1, apperr.Validationf("invalid ID %s", globalID)
+	}
+
+	return typ, id, nil
+}
+
+// NodeID returns the ID of a node of the given type. Legacy numeric IDs are
+// accepted as well.
+func NodeID(typ string, stdID string) (int, error) {
+	if _, err := strconv.Atoi(stdID); err == nil {
+		return ID(stdID)
+	}
+
+	nodeType, id, err := FromGlobalID(stdID)
+	if err != nil {
+		return -1, err
+	}
+
+	if nodeType != typ {
+		return -1, apperr.Validationf("invalid %s ID %s", typ, stdID)
+	}
+
+	return id, nil
+}
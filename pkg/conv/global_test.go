@@ -0,0 +1,95 @@
+package conv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGlobalID(t *testing.T) {
+	postID := GlobalID(TypePost, 1)
+	commentID := GlobalID(TypeComment, 1)
+
+	assert.NotEqual(t, postID, commentID)
+
+	typ, id, err := FromGlobalID(postID)
+	require.NoError(t, err)
+	assert.Equal(t, TypePost, typ)
+	assert.Equal(t, 1, id)
+}
+
+func TestFromGlobalID_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "Not base64", input: "Post:1"},
+		{name: "No type", input: "MQ=="},
+		{name: "Empty type", input: "OjE="},
+		{name: "Not a number", input: "UG9zdDphYmM="},
+		{name: "Zero", input: "UG9zdDow"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := FromGlobalID(tt.input)
+			assert.EqualError(t, err, "invalid ID "+tt.input)
+		})
+	}
+}
+
+func TestNodeID(t *testing.T) {
+	tests := []struct {
+		name       string
+		typ        string
+		input      string
+		expectedID int
+		wantErr    string
+	}{
+		{
+			name:       "Global ID",
+			typ:        TypePost,
+			input:      GlobalID(TypePost, 42),
+			expectedID: 42,
+		},
+		{
+			name:       "Legacy numeric ID",
+			typ:        TypeComment,
+			input:      "7",
+			expectedID: 7,
+		},
+		{
+			name:    "Legacy zero ID",
+			typ:     TypeComment,
+			input:   "0",
+			wantErr: "invalid ID 0",
+		},
+		{
+			name:    "Wrong type",
+			typ:     TypeComment,
+			input:   GlobalID(TypePost, 1),
+			wantErr: "invalid Comment ID " + GlobalID(TypePost, 1),
+		},
+		{
+			name:    "Garbage",
+			typ:     TypePost,
+			input:   "abc",
+			wantErr: "invalid ID abc",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := NodeID(tt.typ, tt.input)
+
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedID, id)
+		})
+	}
+}
@@ -1,18 +1,19 @@
 package conv
 
 import (
-	"fmt"
 	"strconv"
+
+	"github.com/erknas/forum/pkg/apperr"
 )
 
 func ID(stdID string) (int, error) {
 	id, err := strconv.Atoi(stdID)
 	if err != nil {
-		return -1, fmt.Errorf("invalid ID %s", stdID)
+		return -1, apperr.Validationf("invalid ID %s", stdID)
 	}
 
 	if id <= 0 {
-		return id, fmt.Errorf("invalid ID %d", id)
+		return id, apperr.Validationf("invalid ID %d", id)
 	}
 
 	return id, nil
@@ -0,0 +1,115 @@
+// Package dataloader batches and caches lookups by key. A Loader is meant to
+// live for a single request.
+package dataloader
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// BatchFunc loads values for keys. It must return either one value and one
+// error per key, in the order of keys, or a nil slice of values and a single
+// error that applies to every key.
+type BatchFunc[K comparable, V any] func(ctx context.Context, keys []K) ([]V, []error)
+
+type Loader[K comparable, V any] struct {
+	fetch    BatchFunc[K, V]
+	wait     time.Duration
+	maxBatch int
+
+	mu    sync.Mutex
+	cache map[K]*result[V]
+	batch *batch[K, V]
+}
+
+type result[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+type batch[K comparable, V any] struct {
+	once    sync.Once
+	keys    []K
+	results []*result[V]
+}
+
+// New returns a loader that waits for wait after the first key of a batch
+// before fetching it, or until maxBatch keys are collected if maxBatch > 0.
+func New[K comparable, V any](fetch BatchFunc[K, V], wait time.Duration, maxBatch int) *Loader[K, V] {
+	return &Loader[K, V]{
+		fetch:    fetch,
+		wait:     wait,
+		maxBatch: maxBatch,
+		cache:    make(map[K]*result[V]),
+	}
+}
+
+// Load returns the value for key. Keys are fetched at most once per loader.
+func (l *Loader[K, V]) Load(ctx context.Context, key K) (V, error) {
+	l.mu.Lock()
+
+	r, ok := l.cache[key]
+	if !ok {
+		r = &result[V]{done: make(chan struct{})}
+		l.cache[key] = r
+		l.enqueue(ctx, key, r)
+	}
+
+	l.mu.Unlock()
+
+	select {
+	case <-r.done:
+		return r.value, r.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// enqueue adds the key to the pending batch. The caller must hold the lock.
+func (l *Loader[K, V]) enqueue(ctx context.Context, key K, r *result[V]) {
+	if l.batch == nil {
+		b := &batch[K, V]{}
+		l.batch = b
+		time.AfterFunc(l.wait, func() { l.dispatch(ctx, b) })
+	}
+
+	b := l.batch
+	b.keys = append(b.keys, key)
+	b.results = append(b.results, r)
+
+	if l.maxBatch > 0 && len(b.keys) >= l.maxBatch {
+		l.batch = nil
+		go l.dispatch(ctx, b)
+	}
+}
+
+func (l *Loader[K, V]) dispatch(ctx context.Context, b *batch[K, V]) {
+	l.mu.Lock()
+	if l.batch == b {
+		l.batch = nil
+	}
+	l.mu.Unlock()
+
+	b.once.Do(func() {
+		values, errs := l.fetch(ctx, b.keys)
+
+		for i, r := range b.results {
+			switch {
+			case len(values) == len(b.keys):
+				r.value = values[i]
+				if len(errs) == len(b.keys) {
+					r.err = errs[i]
+				}
+			case len(errs) > 0 && errs[0] != nil:
+				r.err = errs[0]
+			default:
+				r.err = fmt.Errorf("batch function returned %d values for %d keys", len(values), len(b.keys))
+			}
+			close(r.done)
+		}
+	})
+}
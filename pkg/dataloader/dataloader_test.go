@@ -0,0 +1,107 @@
+package dataloader
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recorder struct {
+	mu      sync.Mutex
+	batches [][]int
+}
+
+func (r *recorder) fetch(_ context.Context, keys []int) ([]string, []error) {
+	r.mu.Lock()
+	r.batches = append(r.batches, append([]int(nil), keys...))
+	r.mu.Unlock()
+
+	values := make([]string, len(keys))
+	errs := make([]error, len(keys))
+	for i, key := range keys {
+		if key < 0 {
+			errs[i] = errors.New("negative key")
+			continue
+		}
+		values[i] = strconv.Itoa(key)
+	}
+
+	return values, errs
+}
+
+func loadConcurrently(t *testing.T, l *Loader[int, string], keys []int) []string {
+	t.Helper()
+
+	var wg sync.WaitGroup
+
+	values := make([]string, len(keys))
+	for i, key := range keys {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, err := l.Load(context.Background(), key)
+			assert.NoError(t, err)
+			values[i] = value
+		}()
+	}
+
+	wg.Wait()
+
+	return values
+}
+
+func TestLoader_BatchesAndDedupes(t *testing.T) {
+	r := &recorder{}
+	l := New(r.fetch, 10*time.Millisecond, 0)
+
+	values := loadConcurrently(t, l, []int{1, 2, 1, 3, 2})
+
+	assert.Equal(t, []string{"1", "2", "1", "3", "2"}, values)
+	require.Len(t, r.batches, 1)
+	assert.ElementsMatch(t, []int{1, 2, 3}, r.batches[0])
+}
+
+func TestLoader_Caches(t *testing.T) {
+	r := &recorder{}
+	l := New(r.fetch, time.Millisecond, 0)
+
+	for i := 0; i < 3; i++ {
+		value, err := l.Load(context.Background(), 7)
+		require.NoError(t, err)
+		assert.Equal(t, "7", value)
+	}
+
+	assert.Len(t, r.batches, 1)
+}
+
+func TestLoader_MaxBatch(t *testing.T) {
+	r := &recorder{}
+	l := New(r.fetch, time.Hour, 2)
+
+	loadConcurrently(t, l, []int{1, 2, 3, 4})
+
+	require.Len(t, r.batches, 2)
+	assert.Len(t, r.batches[0], 2)
+	assert.Len(t, r.batches[1], 2)
+}
+
+func TestLoader_Errors(t *testing.T) {
+	r := &recorder{}
+	l := New(r.fetch, time.Millisecond, 0)
+
+	_, err := l.Load(context.Background(), -1)
+	assert.EqualError(t, err, "negative key")
+
+	failing := New(func(_ context.Context, keys []int) ([]string, []error) {
+		return nil, []error{errors.New("connection refused")}
+	}, time.Millisecond, 0)
+
+	_, err = failing.Load(context.Background(), 1)
+	assert.EqualError(t, err, "connection refused")
+}
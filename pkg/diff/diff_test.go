@@ -0,0 +1,80 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		name     string
+		a        string
+		b        string
+		expected []Line
+	}{
+		{
+			name:     "Equal",
+			a:        "one\ntwo",
+			b:        "one\ntwo\n",
+			expected: []Line{{Equal, "one"}, {Equal, "two"}},
+		},
+		{
+			name:     "From Empty",
+			a:        "",
+			b:        "one\ntwo",
+			expected: []Line{{Insert, "one"}, {Insert, "two"}},
+		},
+		{
+			name:     "To Empty",
+			a:        "one",
+			b:        "",
+			expected: []Line{{Delete, "one"}},
+		},
+		{
+			name:     "Changed Line",
+			a:        "one\ntwo\nthree",
+			b:        "one\n2\nthree",
+			expected: []Line{{Equal, "one"}, {Delete, "two"}, {Insert, "2"}, {Equal, "three"}},
+		},
+		{
+			name: "Moved Line",
+			a:    "a\nb\nc\nd",
+			b:    "b\nc\na\nd",
+			expected: []Line{
+				{Delete, "a"}, {Equal, "b"}, {Equal, "c"}, {Insert, "a"}, {Equal, "d"},
+			},
+		},
+		{
+			name:     "CRLF",
+			a:        "one\r\ntwo\r\n",
+			b:        "one\ntwo",
+			expected: []Line{{Equal, "one"}, {Equal, "two"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Lines(tt.a, tt.b))
+		})
+	}
+}
+
+func TestLines_Applies(t *testing.T) {
+	a := "the\nquick\nbrown\nfox\njumps\nover\nthe\nlazy\ndog"
+	b := "a\nquick\nred\nfox\njumps\nover\nthe\ndog\nagain"
+
+	var oldLines, newLines []string
+	for _, line := range Lines(a, b) {
+		if line.Op != Insert {
+			oldLines = append(oldLines, line.Text)
+		}
+		if line.Op != Delete {
+			newLines = append(newLines, line.Text)
+		}
+	}
+
+	assert.Equal(t, a, strings.Join(oldLines, "\n"))
+	assert.Equal(t, b, strings.Join(newLines, "\n"))
+}
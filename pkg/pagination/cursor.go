@@ -0,0 +1,58 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+
+	"github.com/erknas/forum/pkg/apperr"
+)
+
+const (
+	cursorPrefix = "cursor:"
+	defaultFirst = 10
+	maxFirst     = 100
+)
+
+func Cursor(offset int) string {
+	return base64.StdEncoding.EncodeToString([]byte(cursorPrefix + strconv.Itoa(offset)))
+}
+
+func Offset(cursor string) (int, error) {
+	data, err := base64.StdEncoding.DecodeString(cursor)
+	if err != nil {
+		return -1, apperr.Validationf("invalid cursor %s", cursor)
+	}
+
+	offset, err := strconv.Atoi(strings.TrimPrefix(string(data), cursorPrefix))
+	if err != nil || !strings.HasPrefix(string(data), cursorPrefix) || offset < 0 {
+		return -1, apperr.Validationf("invalid cursor %s", cursor)
+	}
+
+	return offset, nil
+}
+
+// FromCursor converts Relay style first/after arguments to limit and offset.
+// The item the after cursor points to is not included.
+func FromCursor(first *int32, after *string) (limit int, offset int, err error) {
+	limit = defaultFirst
+
+	if first != nil && *first > 0 {
+		limit = int(*first)
+	}
+
+	if limit > maxFirst {
+		limit = maxFirst
+	}
+
+	if after == nil || *after == "" {
+		return limit, 0, nil
+	}
+
+	offset, err = Offset(*after)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return limit, offset + 1, nil
+}
@@ -0,0 +1,92 @@
+package pagination
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursor(t *testing.T) {
+	for _, offset := range []int{0, 1, 42, 1000} {
+		got, err := Offset(Cursor(offset))
+		require.NoError(t, err)
+		assert.Equal(t, offset, got)
+	}
+}
+
+func TestOffset_Invalid(t *testing.T) {
+	tests := []string{"", "not base64!", "MTIz", "Y3Vyc29yOmFiYw==", "Y3Vyc29yOi0x"}
+
+	for _, cursor := range tests {
+		_, err := Offset(cursor)
+		assert.Error(t, err, cursor)
+	}
+}
+
+func TestFromCursor(t *testing.T) {
+	tests := []struct {
+		name           string
+		first          *int32
+		after          *string
+		expectedLimit  int
+		expectedOffset int
+	}{
+		{
+			name:           "Both Nil",
+			expectedLimit:  10,
+			expectedOffset: 0,
+		},
+		{
+			name:           "First Only",
+			first:          int32Ptr(5),
+			expectedLimit:  5,
+			expectedOffset: 0,
+		},
+		{
+			name:           "After Cursor",
+			first:          int32Ptr(5),
+			after:          stringPtr(Cursor(4)),
+			expectedLimit:  5,
+			expectedOffset: 5,
+		},
+		{
+			name:           "Negative First",
+			first:          int32Ptr(-3),
+			expectedLimit:  10,
+			expectedOffset: 0,
+		},
+		{
+			name:           "First Above Maximum",
+			first:          int32Ptr(1000),
+			expectedLimit:  100,
+			expectedOffset: 0,
+		},
+		{
+			name:           "Empty After",
+			first:          int32Ptr(3),
+			after:          stringPtr(""),
+			expectedLimit:  3,
+			expectedOffset: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limit, offset, err := FromCursor(tt.first, tt.after)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.expectedLimit, limit)
+			assert.Equal(t, tt.expectedOffset, offset)
+		})
+	}
+}
+
+func TestFromCursor_InvalidAfter(t *testing.T) {
+	_, _, err := FromCursor(nil, stringPtr("garbage"))
+	assert.Error(t, err)
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
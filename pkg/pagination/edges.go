@@ -0,0 +1,25 @@
+package pagination
+
+import "github.com/erknas/forum/graph/model"
+
+// Edges turns a page of items fetched with limit+1 at offset into at most
+// limit edges built by edge, which gets each item with its cursor. The extra
+// item only tells whether there is a next page. graph/model must not import
+// this package.
+func Edges[T, E any](items []T, offset, limit int, edge func(T, string) E) ([]E, *model.PageInfo) {
+	pageInfo := &model.PageInfo{HasNextPage: len(items) > limit}
+	if len(items) > limit {
+		items = items[:limit]
+	}
+
+	edges := make([]E, 0, len(items))
+
+	for i, item := range items {
+		cursor := Cursor(offset + i)
+
+		edges = append(edges, edge(item, cursor))
+		pageInfo.EndCursor = &cursor
+	}
+
+	return edges, pageInfo
+}
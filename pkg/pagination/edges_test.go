@@ -0,0 +1,44 @@
+package pagination
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testEdge struct {
+	cursor string
+	node   int
+}
+
+func TestEdges(t *testing.T) {
+	newEdge := func(item int, cursor string) testEdge { return testEdge{cursor: cursor, node: item} }
+
+	t.Run("Next Page", func(t *testing.T) {
+		edges, pageInfo := Edges([]int{10, 11, 12}, 5, 2, newEdge)
+
+		assert.Equal(t, []testEdge{{Cursor(5), 10}, {Cursor(6), 11}}, edges)
+		assert.True(t, pageInfo.HasNextPage)
+		require.NotNil(t, pageInfo.EndCursor)
+		assert.Equal(t, Cursor(6), *pageInfo.EndCursor)
+	})
+
+	t.Run("Last Page", func(t *testing.T) {
+		edges, pageInfo := Edges([]int{10}, 0, 2, newEdge)
+
+		assert.Equal(t, []testEdge{{Cursor(0), 10}}, edges)
+		assert.False(t, pageInfo.HasNextPage)
+		require.NotNil(t, pageInfo.EndCursor)
+		assert.Equal(t, Cursor(0), *pageInfo.EndCursor)
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		edges, pageInfo := Edges(nil, 0, 2, newEdge)
+
+		assert.Empty(t, edges)
+		assert.NotNil(t, edges)
+		assert.False(t, pageInfo.HasNextPage)
+		assert.Nil(t, pageInfo.EndCursor)
+	})
+}
@@ -0,0 +1,135 @@
+// Package ratelimit limits how often and how many times at once a client may
+// do something.
+package ratelimit
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Limiter is a token bucket per key. A bucket holds up to burst tokens and
+// gets a new token every interval.
+type Limiter struct {
+	interval time.Duration
+	burst    int
+	now      func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastPrune time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func New(interval time.Duration, burst int) *Limiter {
+	return &Limiter{
+		interval: interval,
+		burst:    burst,
+		now:      time.Now,
+		buckets:  make(map[string]*bucket),
+	}
+}
+
+// Allow takes a token from the bucket of key. If the bucket is empty it
+// returns false and the time until the next token.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.prune(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.burst), last: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens = min(float64(l.burst), b.tokens+float64(now.Sub(b.last))/float64(l.interval))
+	b.last = now
+
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) * float64(l.interval))
+	}
+
+	b.tokens--
+
+	return true, 0
+}
+
+// prune forgets buckets that are full again, they are the same as new ones.
+func (l *Limiter) prune(now time.Time) {
+	refill := l.interval * time.Duration(l.burst)
+	if now.Sub(l.lastPrune) < refill {
+		return
+	}
+
+	for key, b := range l.buckets {
+		if now.Sub(b.last) >= refill {
+			delete(l.buckets, key)
+		}
+	}
+
+	l.lastPrune = now
+}
+
+// Middleware answers requests over the limit of their client, whom key
+// identifies, with 429 Too Many Requests and a Retry-After header.
+func Middleware(l *Limiter, key func(*http.Request) string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ok, retryAfter := l.Allow(key(r)); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Concurrency limits the number of slots a key holds at once.
+type Concurrency struct {
+	max int
+
+	mu     sync.Mutex
+	active map[string]int
+}
+
+func NewConcurrency(max int) *Concurrency {
+	return &Concurrency{
+		max:    max,
+		active: make(map[string]int),
+	}
+}
+
+// Acquire reserves a slot for key. If ok, release must be called once the
+// slot is no longer used; calling it more than once has no effect.
+func (c *Concurrency) Acquire(key string) (release func(), ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.active[key] >= c.max {
+		return nil, false
+	}
+
+	c.active[key]++
+
+	var once sync.Once
+
+	return func() {
+		once.Do(func() {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+
+			if c.active[key]--; c.active[key] == 0 {
+				delete(c.active, key)
+			}
+		})
+	}, true
+}
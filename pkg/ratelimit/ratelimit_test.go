@@ -0,0 +1,133 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func newLimiter(interval time.Duration, burst int) (*Limiter, *clock) {
+	c := &clock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	l := New(interval, burst)
+	l.now = c.Now
+
+	return l, c
+}
+
+func TestLimiter(t *testing.T) {
+	l, c := newLimiter(time.Minute, 2)
+
+	for range 2 {
+		ok, _ := l.Allow("a")
+		require.True(t, ok)
+	}
+
+	ok, retryAfter := l.Allow("a")
+	assert.False(t, ok)
+	assert.Equal(t, time.Minute, retryAfter)
+
+	ok, _ = l.Allow("b")
+	assert.True(t, ok, "keys have separate buckets")
+
+	c.now = c.now.Add(45 * time.Second)
+	ok, retryAfter = l.Allow("a")
+	assert.False(t, ok)
+	assert.Equal(t, 15*time.Second, retryAfter)
+
+	c.now = c.now.Add(15 * time.Second)
+	ok, _ = l.Allow("a")
+	assert.True(t, ok)
+}
+
+func TestLimiter_RefillsUpToBurst(t *testing.T) {
+	l, c := newLimiter(time.Second, 3)
+
+	ok, _ := l.Allow("a")
+	require.True(t, ok)
+
+	c.now = c.now.Add(time.Hour)
+
+	for range 3 {
+		ok, _ := l.Allow("a")
+		require.True(t, ok)
+	}
+
+	ok, _ = l.Allow("a")
+	assert.False(t, ok)
+}
+
+func TestLimiter_PrunesFullBuckets(t *testing.T) {
+	l, c := newLimiter(time.Second, 2)
+
+	l.Allow("a")
+	l.Allow("b")
+	require.Len(t, l.buckets, 2)
+
+	c.now = c.now.Add(2 * time.Second)
+	l.Allow("c")
+
+	assert.Len(t, l.buckets, 1)
+}
+
+func TestMiddleware(t *testing.T) {
+	l, _ := newLimiter(1500*time.Millisecond, 1)
+
+	h := Middleware(l, func(r *http.Request) string { return r.RemoteAddr }, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	serve := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remoteAddr
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	assert.Equal(t, http.StatusNoContent, serve("10.0.0.1:1").Code)
+
+	rec := serve("10.0.0.1:1")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get("Retry-After"))
+
+	assert.Equal(t, http.StatusNoContent, serve("10.0.0.2:1").Code, "clients have their own budgets")
+}
+
+func TestConcurrency(t *testing.T) {
+	c := NewConcurrency(2)
+
+	release1, ok := c.Acquire("a")
+	require.True(t, ok)
+	_, ok = c.Acquire("a")
+	require.True(t, ok)
+
+	_, ok = c.Acquire("a")
+	assert.False(t, ok)
+
+	_, ok = c.Acquire("b")
+	assert.True(t, ok, "keys have separate slots")
+
+	release1()
+	release1()
+
+	_, ok = c.Acquire("a")
+	assert.True(t, ok)
+
+	_, ok = c.Acquire("a")
+	assert.False(t, ok, "a second release has no effect")
+}
@@ -0,0 +1,59 @@
+package thumbnail
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScale(t *testing.T) {
+	tests := []struct {
+		name          string
+		width, height int
+		size          int
+		want          image.Point
+	}{
+		{name: "landscape", width: 800, height: 400, size: 100, want: image.Pt(100, 50)},
+		{name: "portrait", width: 300, height: 600, size: 100, want: image.Pt(50, 100)},
+		{name: "thin", width: 1000, height: 2, size: 100, want: image.Pt(100, 1)},
+		{name: "small", width: 40, height: 30, size: 100, want: image.Pt(40, 30)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img := Scale(image.NewRGBA(image.Rect(0, 0, tt.width, tt.height)), tt.size)
+			assert.Equal(t, tt.want, img.Bounds().Size())
+		})
+	}
+}
+
+func TestScale_Averages(t *testing.T) {
+	img := image.NewRGBA(image.Rect(10, 10, 14, 12))
+	for x := 10; x < 14; x++ {
+		img.Set(x, 10, color.White)
+		img.Set(x, 11, color.Black)
+	}
+
+	scaled := Scale(img, 2)
+
+	require.Equal(t, image.Pt(2, 1), scaled.Bounds().Size())
+	assert.Equal(t, color.RGBA{R: 127, G: 127, B: 127, A: 255}, scaled.At(0, 0))
+}
+
+func TestEncode(t *testing.T) {
+	var buf bytes.Buffer
+
+	require.NoError(t, Encode(&buf, image.NewNRGBA(image.Rect(0, 0, 8, 4))))
+
+	img, err := jpeg.Decode(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, image.Pt(8, 4), img.Bounds().Size())
+
+	r, g, b, _ := img.At(0, 0).RGBA()
+	assert.Greater(t, r+g+b, uint32(3*0xf000), "transparent pixels are drawn over white")
+}
@@ -0,0 +1,255 @@
+package wal
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+type SyncPolicy string
+
+const (
+	// SyncAlways fsyncs the log after every record.
+	SyncAlways SyncPolicy = "always"
+	// SyncInterval fsyncs the log periodically; a crash may lose the records
+	// written since the last sync.
+	SyncInterval SyncPolicy = "interval"
+	// SyncNever leaves flushing to the operating system.
+	SyncNever SyncPolicy = "never"
+)
+
+const (
+	logFile      = "wal.log"
+	snapshotFile = "snapshot.json"
+)
+
+// Log is an append-only log of JSON records paired with a snapshot file. A
+// snapshot replaces the log: once it is written the log is truncated.
+type Log struct {
+	mu     sync.Mutex
+	dir    string
+	file   *os.File
+	policy SyncPolicy
+	dirty  bool
+	done   chan struct{}
+}
+
+func ParseSyncPolicy(policy string) (SyncPolicy, error) {
+	switch SyncPolicy(policy) {
+	case SyncAlways, SyncInterval, SyncNever:
+		return SyncPolicy(policy), nil
+	default:
+		return "", fmt.Errorf("unknown fsync policy %q", policy)
+	}
+}
+
+func Open(dir string, policy SyncPolicy, interval time.Duration) (*Log, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(filepath.Join(dir, logFile), os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &Log{
+		dir:    dir,
+		file:   file,
+		policy: policy,
+		done:   make(chan struct{}),
+	}
+
+	if policy == SyncInterval {
+		go l.syncLoop(interval)
+	}
+
+	return l, nil
+}
+
+// Replay calls fn for every record in the log, in order. A partially written
+// last record, left behind by a crash in the middle of Append, is discarded.
+func (l *Log) Replay(fn func(json.RawMessage) error) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, err := l.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	var (
+		reader = bufio.NewReader(l.file)
+		offset int64
+	)
+
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(bytes.TrimSpace(line)) > 0 {
+				return l.truncate(offset)
+			}
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := fn(json.RawMessage(line)); err != nil {
+			return fmt.Errorf("replay record at offset %d: %w", offset, err)
+		}
+
+		offset += int64(len(line))
+	}
+
+	_, err := l.file.Seek(0, io.SeekEnd)
+	return err
+}
+
+func (l *Log) Append(record any) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, err := l.file.Write(append(data, '\n')); err != nil {
+		return err
+	}
+
+	if l.policy == SyncAlways {
+		return l.file.Sync()
+	}
+
+	l.dirty = true
+
+	return nil
+}
+
+// LoadSnapshot decodes the latest snapshot into state. It reports false when
+// no snapshot has been written yet.
+func (l *Log) LoadSnapshot(state any) (bool, error) {
+	data, err := os.ReadFile(filepath.Join(l.dir, snapshotFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if err := json.Unmarshal(data, state); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Snapshot atomically replaces the snapshot with state and truncates the log.
+// The caller must make sure no records are appended concurrently, otherwise
+// they would be lost with the truncated log.
+func (l *Log) Snapshot(state any) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	tmp := filepath.Join(l.dir, snapshotFile+".tmp")
+
+	if err := writeFileSync(tmp, data); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp, filepath.Join(l.dir, snapshotFile)); err != nil {
+		return err
+	}
+
+	if err := syncDir(l.dir); err != nil {
+		return err
+	}
+
+	return l.truncate(0)
+}
+
+func (l *Log) Close() error {
+	close(l.done)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := l.file.Sync(); err != nil {
+		return err
+	}
+
+	return l.file.Close()
+}
+
+func (l *Log) truncate(offset int64) error {
+	if err := l.file.Truncate(offset); err != nil {
+		return err
+	}
+
+	if _, err := l.file.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	return l.file.Sync()
+}
+
+func (l *Log) syncLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.done:
+			return
+		case <-ticker.C:
+			l.mu.Lock()
+			if l.dirty {
+				_ = l.file.Sync()
+				l.dirty = false
+			}
+			l.mu.Unlock()
+		}
+	}
+}
+
+func writeFileSync(path string, data []byte) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	return d.Sync()
+}
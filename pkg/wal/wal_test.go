@@ -0,0 +1,115 @@
+package wal
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type record struct {
+	N int `json:"n"`
+}
+
+func replayAll(t *testing.T, l *Log) []int {
+	t.Helper()
+
+	var got []int
+
+	err := l.Replay(func(data json.RawMessage) error {
+		r := record{}
+		if err := json.Unmarshal(data, &r); err != nil {
+			return err
+		}
+		got = append(got, r.N)
+		return nil
+	})
+	require.NoError(t, err)
+
+	return got
+}
+
+func TestAppendAndReplay(t *testing.T) {
+	dir := t.TempDir()
+
+	l, err := Open(dir, SyncAlways, 0)
+	require.NoError(t, err)
+
+	for i := 1; i <= 3; i++ {
+		require.NoError(t, l.Append(record{N: i}))
+	}
+	require.NoError(t, l.Close())
+
+	l, err = Open(dir, SyncAlways, 0)
+	require.NoError(t, err)
+	defer l.Close()
+
+	assert.Equal(t, []int{1, 2, 3}, replayAll(t, l))
+
+	require.NoError(t, l.Append(record{N: 4}))
+	assert.Equal(t, []int{1, 2, 3, 4}, replayAll(t, l))
+}
+
+func TestReplay_DiscardsTornRecord(t *testing.T) {
+	dir := t.TempDir()
+
+	l, err := Open(dir, SyncNever, 0)
+	require.NoError(t, err)
+	require.NoError(t, l.Append(record{N: 1}))
+	require.NoError(t, l.Close())
+
+	file, err := os.OpenFile(filepath.Join(dir, logFile), os.O_APPEND|os.O_WRONLY, 0o644)
+	require.NoError(t, err)
+	_, err = file.WriteString(`{"n":`)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	l, err = Open(dir, SyncNever, 0)
+	require.NoError(t, err)
+	defer l.Close()
+
+	assert.Equal(t, []int{1}, replayAll(t, l))
+
+	require.NoError(t, l.Append(record{N: 2}))
+	assert.Equal(t, []int{1, 2}, replayAll(t, l))
+}
+
+func TestSnapshot(t *testing.T) {
+	dir := t.TempDir()
+
+	l, err := Open(dir, SyncInterval, time.Millisecond)
+	require.NoError(t, err)
+	defer l.Close()
+
+	state := map[string]int{}
+
+	ok, err := l.LoadSnapshot(&state)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, l.Append(record{N: 1}))
+	require.NoError(t, l.Snapshot(map[string]int{"n": 1}))
+	require.NoError(t, l.Append(record{N: 2}))
+
+	ok, err = l.LoadSnapshot(&state)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, map[string]int{"n": 1}, state)
+
+	assert.Equal(t, []int{2}, replayAll(t, l))
+}
+
+func TestParseSyncPolicy(t *testing.T) {
+	for _, policy := range []string{"always", "interval", "never"} {
+		got, err := ParseSyncPolicy(policy)
+		require.NoError(t, err)
+		assert.Equal(t, SyncPolicy(policy), got)
+	}
+
+	_, err := ParseSyncPolicy("sometimes")
+	assert.Error(t, err)
+}